@@ -1,117 +1,50 @@
 package app
 
 import (
-	"fmt"
-	"net/http"
-	"net/http/httptest"
-	"net/url"
-	"os"
+	"context"
+	"errors"
 	"path/filepath"
 	"testing"
 	"time"
 
-	"github.com/gin-gonic/gin"
-	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
-	"github.com/johannes-kuhfuss/mairlist-feeder/config"
 	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupHelperTest(t *testing.T) {
-	t.Helper()
-	testApp = Application{}
-	testApp.state = appstate.New()
-	config.InitConfig(config.EnvFile, &testApp.cfg)
-	testApp.cfg.Export.ExportFolder = t.TempDir()
-	testApp.state.Runtime.ListenAddr = fmt.Sprintf("%s:%s", testApp.cfg.Server.Host, testApp.cfg.Server.Port)
-}
-
-var testApp Application
-
-func TestExportDayEventsNoConfigReturnsError(t *testing.T) {
-	testApp = Application{}
-	file, err := testApp.exportState(eventUrl, "events")
-	assert.NotNil(t, err)
-	assert.EqualValues(t, "", file)
-	assert.EqualValues(t, "Get \"http:///events\": http: no Host in request URL", err.Error())
+type fakeArchive struct {
+	dates []time.Time
+	err   error
 }
 
-func TestExportDayEventsGetErrorReturnsError(t *testing.T) {
-	setupHelperTest(t)
-	file, err := testApp.exportState(eventUrl, "events")
-	assert.NotNil(t, err)
-	assert.EqualValues(t, "", file)
+func (f *fakeArchive) ArchiveDayContext(ctx context.Context, date time.Time) (int, error) {
+	f.dates = append(f.dates, date)
+	return 3, f.err
 }
 
-func TestExportDayEventsNoErrorReturnsFileName(t *testing.T) {
-	setupHelperTest(t)
-	htmlResp := "<!DOCTYPE html><html><body></body></html>"
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(htmlResp))
-	}))
-	defer srv.Close()
-	u, _ := url.Parse(srv.URL)
-	testApp.state.Runtime.ListenAddr = u.Hostname() + ":" + u.Port()
-	fileName, err := testApp.exportState(eventUrl, "events")
-	_, noFile := os.Stat(fileName)
-	assert.Nil(t, err)
-	assert.Nil(t, noFile)
-}
-
-func TestExportDayEventsNonOkStatusReturnsError(t *testing.T) {
-	setupHelperTest(t)
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusInternalServerError)
-	}))
-	defer srv.Close()
-	u, _ := url.Parse(srv.URL)
-	testApp.state.Runtime.ListenAddr = u.Hostname() + ":" + u.Port()
+var testApp Application
 
-	fileName, err := testApp.exportState(eventUrl, "events")
+func TestArchiveDayWithoutArchiveDoesNothing(t *testing.T) {
+	testApp = Application{}
 
-	assert.EqualValues(t, "", fileName)
-	assert.NotNil(t, err)
-	assert.Contains(t, err.Error(), "500 Internal Server Error")
+	assert.NotPanics(t, func() { testApp.archiveDay(t.Context(), time.Now()) })
 }
 
-func TestExportDayEventsUsesTimeoutClient(t *testing.T) {
-	setupHelperTest(t)
-	oldClient := exportStateHTTPClient
-	exportStateHTTPClient = &http.Client{Timeout: time.Nanosecond}
-	defer func() {
-		exportStateHTTPClient = oldClient
-	}()
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		time.Sleep(50 * time.Millisecond)
-		w.WriteHeader(http.StatusOK)
-	}))
-	defer srv.Close()
-	u, _ := url.Parse(srv.URL)
-	testApp.state.Runtime.ListenAddr = u.Hostname() + ":" + u.Port()
+func TestArchiveDayArchivesDate(t *testing.T) {
+	archive := &fakeArchive{}
+	testApp = Application{archiveService: archive}
+	date := time.Date(2026, 10, 18, 23, 15, 0, 0, time.Local)
 
-	fileName, err := testApp.exportState(eventUrl, "events")
+	testApp.archiveDay(t.Context(), date)
 
-	assert.EqualValues(t, "", fileName)
-	assert.NotNil(t, err)
+	assert.EqualValues(t, []time.Time{date}, archive.dates)
 }
 
-func TestExportDayEventsUsesRouterWhenAvailableWithTlsEnabled(t *testing.T) {
-	setupHelperTest(t)
-	gin.SetMode(gin.TestMode)
-	router := gin.New()
-	router.GET(eventUrl, func(c *gin.Context) {
-		c.String(http.StatusOK, "<!DOCTYPE html><html><body>ok</body></html>")
-	})
-	testApp.state.Runtime.Router = router
-	testApp.cfg.Server.UseTLS = true
-
-	fileName, err := testApp.exportState(eventUrl, "events")
-	data, readErr := os.ReadFile(fileName)
+func TestArchiveDayErrorIsLogged(t *testing.T) {
+	archive := &fakeArchive{err: errors.New("disk full")}
+	testApp = Application{archiveService: archive}
 
-	assert.Nil(t, err)
-	assert.Nil(t, readErr)
-	assert.Contains(t, string(data), "ok")
+	assert.NotPanics(t, func() { testApp.archiveDay(t.Context(), time.Now()) })
+	assert.Len(t, archive.dates, 1)
 }
 
 func TestIsPathWithinRejectsSiblingDirectory(t *testing.T) {
@@ -21,6 +21,7 @@ import (
 	"github.com/robfig/cron/v3"
 
 	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/calcms"
 	"github.com/johannes-kuhfuss/mairlist-feeder/config"
 	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
 	"github.com/johannes-kuhfuss/mairlist-feeder/handlers"
@@ -32,17 +33,28 @@ import (
 )
 
 type Application struct {
-	cfg            config.AppConfig
-	state          *appstate.AppState
-	server         http.Server
-	appCtx         context.Context
-	appCancel      context.CancelFunc
-	statsUiHandler handlers.StatsUiHandler
-	fileRepo       repositories.FileRepository
-	crawlService   applicationCrawler
-	cleanService   applicationCleaner
-	exportService  applicationExporter
-	calCmsService  applicationCalCms
+	cfg             config.AppConfig
+	state           *appstate.AppState
+	server          http.Server
+	appCtx          context.Context
+	appCancel       context.CancelFunc
+	statsUiHandler  handlers.StatsUiHandler
+	historyHandler  handlers.ExportHistoryHandler
+	overrideHandler handlers.OverrideHandler
+	asRunHandler    handlers.AsRunHandler
+	musicHandler    handlers.MusicReportHandler
+	alarmHandler    handlers.AlarmHandler
+	changeHandler   handlers.ScheduleChangeHandler
+	matchHandler    handlers.TitleMatchHandler
+	eventsHandler   handlers.EventHistoryHandler
+	fileRepo        repositories.FileRepository
+	crawlService    applicationCrawler
+	cleanService    applicationCleaner
+	exportService   applicationExporter
+	calCmsService   applicationCalCms
+	alarmService    applicationAlarms
+	reminderService applicationReminders
+	archiveService  applicationArchive
 }
 
 type applicationCrawler interface {
@@ -57,14 +69,36 @@ type applicationExporter interface {
 	service.Exporter
 	ExportAllHoursContext(context.Context) error
 	ExportForHourContext(context.Context, string) error
+	ExportForDateAndHourContext(context.Context, time.Time, string) error
+	ExportDayPlaylistContext(context.Context) error
+	ExportDayPlaylistForDateContext(context.Context, time.Time) (string, error)
+	PreviewForDateAndHoursContext(context.Context, time.Time, int, int) ([]dto.HourPreview, error)
+	ReconcileContext(context.Context) (dto.ReconcileReport, error)
+	LastReconcile() dto.ReconcileReport
+	RetryOutboxContext(context.Context) error
+	RefreshFillerPoolsContext(context.Context)
 	QueryStatus(context.Context)
 }
 
+type applicationReminders interface {
+	RemindContext(context.Context) error
+}
+
+type applicationArchive interface {
+	ArchiveDayContext(context.Context, time.Time) (int, error)
+}
+
+type applicationAlarms interface {
+	EvaluateContext(context.Context) error
+}
+
 type applicationCalCms interface {
 	service.CalCmsQuerier
 	RefreshTodayEventsContext(context.Context) ([]dto.Event, error)
 	GetTodayEvents() ([]dto.Event, error)
 	GetYesterdaysEvents() []dto.Event
+	GetEventsForDatesContext(context.Context, []time.Time) ([]dto.Event, error)
+	GetWeekOverviewContext(context.Context, time.Time, int) (dto.WeekOverview, error)
 	SaveYesterdaysEvents()
 	CountRunContext(context.Context)
 }
@@ -111,6 +145,7 @@ func (a *Application) Start() error {
 	if err := a.crawlService.CrawlContext(a.appCtx); err != nil {
 		logger.Error("Error running initial crawl", err)
 	}
+	a.exportService.RefreshFillerPoolsContext(a.appCtx)
 	if _, err := a.calCmsService.RefreshTodayEventsContext(a.appCtx); err != nil {
 		logger.Error("Error refreshing today's events", err)
 	}
@@ -188,15 +223,100 @@ func (a *Application) initServer() {
 func (a *Application) wireApp() {
 	fileRepo := repositories.NewFileRepository(&a.cfg)
 	calCmsService := service.NewCalCmsServiceWithState(&a.cfg, a.state, &fileRepo)
+	eventFileRepo := repositories.NewEventFileRepository(&a.cfg)
+	if err := eventFileRepo.LoadFromDisk(); err != nil {
+		logger.Error("Error reading event files from disk", err)
+	}
+	calCmsService.EventFiles = &eventFileRepo
+	titleMatchRepo := repositories.NewTitleMatchRepository(&a.cfg)
+	if err := titleMatchRepo.LoadFromDisk(); err != nil {
+		logger.Error("Error reading title matches from disk", err)
+	}
+	calCmsService.TitleMatches = &titleMatchRepo
+	if a.cfg.CalCms.CacheDays > 0 {
+		calCmsCacheRepo := repositories.NewCalCmsCacheRepository(&a.cfg)
+		calCmsService.Cache = &calCmsCacheRepo
+	}
 	crawlService := service.NewCrawlServiceWithState(&a.cfg, a.state, &fileRepo, &calCmsService)
 	cleanService := service.NewCleanServiceWithState(&a.cfg, a.state, &fileRepo)
 	exportService := service.NewExportServiceWithState(&a.cfg, a.state, &fileRepo)
+	if len(a.cfg.Export.MairListTargets) > 0 {
+		targets, err := config.MairListTargets(&a.cfg)
+		if err != nil {
+			logger.Error("Invalid mAirList targets, using MAIRLIST_URL only", err)
+		}
+		exportService.Targets = targets
+	}
+	historyRepo := repositories.NewExportHistoryRepository(&a.cfg)
+	if err := historyRepo.LoadFromDisk(); err != nil {
+		logger.Error("Error reading export history from disk", err)
+	}
+	// without versions the history stays empty, so reconciliation falls back to the export plan
+	if historyRepo.Enabled() {
+		exportService.History = &historyRepo
+	}
+	overrideRepo := repositories.NewOverrideRepository(&a.cfg)
+	if err := overrideRepo.LoadFromDisk(); err != nil {
+		logger.Error("Error reading overrides from disk", err)
+	}
+	exportService.Overrides = &overrideRepo
+	nowPlayingService := service.NewNowPlayingService(&a.cfg, &calCmsService)
+	go nowPlayingService.Run(a.appCtx)
+	exportService.NowPlaying = &nowPlayingService
+	asRunRepo := repositories.NewAsRunRepository(&a.cfg)
+	exportService.AsRun = &asRunRepo
+	outboxRepo := repositories.NewOutboxRepository(&a.cfg)
+	if err := outboxRepo.LoadFromDisk(); err != nil {
+		logger.Error("Error reading outbox from disk", err)
+	}
+	exportService.Outbox = &outboxRepo
+	calCmsService.Exports = &exportService
+	exportService.Reruns = &calCmsService
+	if a.cfg.CalCms.WriteBack {
+		calCmsClient := calcms.NewClient(&a.cfg, service.InitHttpCalClient())
+		calCmsService.UploadStatus = calCmsClient
+		go calCmsService.RunUploadStatus(a.appCtx)
+		exportService.Uploads = &calCmsService
+	}
+	notifier := service.NewNotifier(&a.cfg)
+	if len(a.cfg.Notify.Webhooks) > 0 {
+		go notifier.Run(a.appCtx)
+		calCmsService.Notifier = &notifier
+		crawlService.Notifier = &notifier
+		exportService.Notifier = &notifier
+	}
 	a.fileRepo = &fileRepo
 	a.calCmsService = &calCmsService
 	a.crawlService = &crawlService
 	a.cleanService = &cleanService
 	a.exportService = &exportService
 	a.statsUiHandler = handlers.NewStatsUiHandlerWithContext(a.appCtx, &a.cfg, a.state, a.fileRepo, a.crawlService, a.exportService, a.cleanService, a.calCmsService)
+	a.statsUiHandler.Overrides = &overrideRepo
+	a.statsUiHandler.Outbox = &outboxRepo
+	a.historyHandler = handlers.NewExportHistoryHandler(&historyRepo)
+	a.overrideHandler = handlers.NewOverrideHandler(&overrideRepo)
+	a.asRunHandler = handlers.NewAsRunHandler(&asRunRepo)
+	musicReportService := service.NewMusicReportService(&a.cfg, &fileRepo, &asRunRepo)
+	a.musicHandler = handlers.NewMusicReportHandler(&musicReportService)
+	reminderRepo := repositories.NewReminderRepository(&a.cfg)
+	if err := reminderRepo.LoadFromDisk(); err != nil {
+		logger.Error("Error reading sent reminders from disk", err)
+	}
+	reminderService := service.NewReminderServiceWithState(&a.cfg, a.state, &calCmsService, &fileRepo, &reminderRepo)
+	reminderService.Reruns = &calCmsService
+	a.reminderService = &reminderService
+	alarmService := service.NewAlarmServiceWithState(&a.cfg, a.state, &calCmsService)
+	if len(a.cfg.Notify.Webhooks) > 0 {
+		alarmService.Notifier = &notifier
+	}
+	a.alarmService = &alarmService
+	a.alarmHandler = handlers.NewAlarmHandler(&alarmService)
+	a.changeHandler = handlers.NewScheduleChangeHandler(&calCmsService)
+	a.matchHandler = handlers.NewTitleMatchHandler(&calCmsService)
+	archiveRepo := repositories.NewEventArchiveRepository(&a.cfg)
+	archiveService := service.NewArchiveServiceWithState(&a.cfg, a.state, &calCmsService, &archiveRepo)
+	a.archiveService = &archiveService
+	a.eventsHandler = handlers.NewEventHistoryHandler(&archiveService)
 }
 
 // mapUrls defines the handlers for the available URLs
@@ -211,9 +331,37 @@ func (a *Application) mapUrls() error {
 	a.state.Runtime.Router.GET(fileUrl, a.statsUiHandler.FileListPage)
 	a.state.Runtime.Router.GET(eventUrl, a.statsUiHandler.EventListPage)
 	a.state.Runtime.Router.GET("/yesterday", a.statsUiHandler.YesterdaysEvents)
+	a.state.Runtime.Router.GET("/week", a.statsUiHandler.WeekPage)
+	a.state.Runtime.Router.GET("/history", a.eventsHandler.HistoryPage)
+	a.state.Runtime.Router.GET("/history.json", a.eventsHandler.HistoryJson)
+	a.state.Runtime.Router.GET("/history.csv", a.eventsHandler.HistoryCsv)
+	a.state.Runtime.Router.GET("/history/stats", a.eventsHandler.HistoryStatsPage)
+	a.state.Runtime.Router.GET("/history/punctuality", a.eventsHandler.PunctualityPage)
+	a.state.Runtime.Router.GET("/history/punctuality/json", a.eventsHandler.PunctualityJson)
 	a.state.Runtime.Router.GET(actionUrl, a.statsUiHandler.ActionPage)
 	a.state.Runtime.Router.POST(actionUrl, a.statsUiHandler.ExecAction)
 	a.state.Runtime.Router.GET(actionUrl+"/:id", a.statsUiHandler.ActionStatus)
+	a.state.Runtime.Router.GET("/preview", a.statsUiHandler.PreviewPage)
+	a.state.Runtime.Router.GET("/preview/json", a.statsUiHandler.PreviewJson)
+	a.state.Runtime.Router.GET("/reconcile", a.statsUiHandler.ReconcilePage)
+	a.state.Runtime.Router.GET("/reconcile/json", a.statsUiHandler.ReconcileJson)
+	a.state.Runtime.Router.GET("/exports", a.historyHandler.ExportsPage)
+	a.state.Runtime.Router.GET("/exports/view", a.historyHandler.ExportVersionView)
+	a.state.Runtime.Router.GET("/exports/diff", a.historyHandler.ExportDiffPage)
+	a.state.Runtime.Router.GET("/overrides", a.overrideHandler.OverridesPage)
+	a.state.Runtime.Router.POST("/overrides", a.overrideHandler.CreateOverride)
+	a.state.Runtime.Router.POST("/overrides/:id/delete", a.overrideHandler.DeleteOverride)
+	a.state.Runtime.Router.GET("/asrun", a.asRunHandler.AsRunPage)
+	a.state.Runtime.Router.GET("/asrun.csv", a.asRunHandler.AsRunCsv)
+	a.state.Runtime.Router.GET("/musicreport", a.musicHandler.MusicReportPage)
+	a.state.Runtime.Router.GET("/musicreport.csv", a.musicHandler.MusicReportCsv)
+	a.state.Runtime.Router.GET("/alarms", a.alarmHandler.AlarmsPage)
+	a.state.Runtime.Router.GET("/alarms/json", a.alarmHandler.AlarmsJson)
+	a.state.Runtime.Router.GET("/changes", a.changeHandler.ChangesPage)
+	a.state.Runtime.Router.GET("/changes/json", a.changeHandler.ChangesJson)
+	a.state.Runtime.Router.GET("/matches", a.matchHandler.MatchesPage)
+	a.state.Runtime.Router.POST("/matches/confirm", a.matchHandler.ConfirmMatch)
+	a.state.Runtime.Router.POST("/matches/reject", a.matchHandler.RejectMatch)
 	a.state.Runtime.Router.GET("/logs", a.statsUiHandler.LogsPage)
 	a.state.Runtime.Router.GET("/about", a.statsUiHandler.AboutPage)
 	a.state.Runtime.Router.GET("/healthz", a.healthz)
@@ -260,6 +408,7 @@ func (a *Application) scheduleBgJobs() {
 		if err := a.crawlService.CrawlContext(a.appCtx); err != nil {
 			logger.Error("Error running scheduled crawl", err)
 		}
+		a.exportService.RefreshFillerPoolsContext(a.appCtx)
 	})
 	// Clean 00:30 local time
 	cleanID, cleanErr := bgJobs.AddFunc("30 0 * * *", func() {
@@ -302,6 +451,76 @@ func (a *Application) scheduleBgJobs() {
 			logger.Infof("Recording Day's Events Job: %v", bgJobs.Entry(eventID).Job)
 		}
 	}
+	// Export tomorrow's day playlist, if scheduled
+	if a.cfg.Export.DayPlaylistCron != "" {
+		dayExportID, dayExportErr := bgJobs.AddFunc(a.cfg.Export.DayPlaylistCron, func() {
+			if err := a.exportService.ExportDayPlaylistContext(a.appCtx); err != nil {
+				logger.Error("Error running scheduled day playlist export", err)
+			}
+		})
+		if dayExportErr != nil {
+			logger.Errorf("Error when scheduling job %v for exporting the day playlist. %v", dayExportID, dayExportErr)
+		} else {
+			a.state.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.DayExportJobID = dayExportID })
+			logger.Infof("Day Playlist Export Job: %v", bgJobs.Entry(dayExportID).Job)
+		}
+	}
+	// Retry failed mAirList appends, nothing is queued if playlists aren't appended
+	if a.cfg.Export.AppendPlaylist && a.cfg.Export.MairListRetry {
+		outboxID, outboxErr := bgJobs.AddFunc("@every 5s", func() {
+			if err := a.exportService.RetryOutboxContext(a.appCtx); err != nil {
+				logger.Error("Error retrying mAirList appends", err)
+			}
+		})
+		if outboxErr != nil {
+			logger.Errorf("Error when scheduling job %v for retrying mAirList appends. %v", outboxID, outboxErr)
+		} else {
+			a.state.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.OutboxJobID = outboxID })
+			logger.Infof("mAirList Retry Job: %v", bgJobs.Entry(outboxID).Job)
+		}
+	}
+	// Evaluate alarms whenever the mAirList status is queried
+	if a.cfg.Export.QueryMairListStatus && a.cfg.CalCms.QueryCalCms {
+		alarmID, alarmErr := bgJobs.AddFunc(fmt.Sprintf("@every %ds", a.cfg.Export.StatusQueryCycleSec), func() {
+			if err := a.alarmService.EvaluateContext(a.appCtx); err != nil {
+				logger.Error("Error evaluating alarms", err)
+			}
+		})
+		if alarmErr != nil {
+			logger.Errorf("Error when scheduling job %v for evaluating alarms. %v", alarmID, alarmErr)
+		} else {
+			a.state.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.AlarmJobID = alarmID })
+			logger.Infof("Alarm Evaluation Job: %v", bgJobs.Entry(alarmID).Job)
+		}
+	}
+	// Compare mAirList's playlist with the export every x minutes
+	if a.cfg.Export.Reconcile {
+		reconcileID, reconcileErr := bgJobs.AddFunc(fmt.Sprintf("@every %dm", a.cfg.Export.ReconcileCycleMin), func() {
+			if _, err := a.exportService.ReconcileContext(a.appCtx); err != nil {
+				logger.Error("Error reconciling mAirList playlist", err)
+			}
+		})
+		if reconcileErr != nil {
+			logger.Errorf("Error when scheduling job %v for reconciling the mAirList playlist. %v", reconcileID, reconcileErr)
+		} else {
+			a.state.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.ReconcileJobID = reconcileID })
+			logger.Infof("Reconcile Job: %v", bgJobs.Entry(reconcileID).Job)
+		}
+	}
+	// Remind producers of missing files
+	if a.cfg.Reminder.SmtpHost != "" && a.cfg.CalCms.QueryCalCms {
+		reminderID, reminderErr := bgJobs.AddFunc(fmt.Sprintf("@every %dm", a.cfg.Reminder.CycleMin), func() {
+			if err := a.reminderService.RemindContext(a.appCtx); err != nil {
+				logger.Error("Error sending reminders", err)
+			}
+		})
+		if reminderErr != nil {
+			logger.Errorf("Error when scheduling job %v for sending reminders. %v", reminderID, reminderErr)
+		} else {
+			a.state.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.ReminderJobID = reminderID })
+			logger.Infof("Reminder Job: %v", bgJobs.Entry(reminderID).Job)
+		}
+	}
 	if a.cfg.CalCms.QueryCalCms {
 		calCmsID, calCmsErr := bgJobs.AddFunc("@every 1m", func() { a.calCmsService.CountRunContext(a.appCtx) })
 		if calCmsErr != nil {
@@ -2,6 +2,7 @@
 package appstate
 
 import (
+	"maps"
 	"sync"
 	"time"
 
@@ -19,6 +20,11 @@ type Metrics struct {
 	RunResults         *prometheus.CounterVec
 	RunDurations       *prometheus.HistogramVec
 	FastEventDurations *prometheus.HistogramVec
+	ReconcileItems     *prometheus.GaugeVec
+	OutboxEntries      *prometheus.GaugeVec
+	AlarmActive        *prometheus.GaugeVec
+	CalCmsDataAge      *prometheus.GaugeVec
+	LateUploads        *prometheus.CounterVec
 }
 
 type RuntimeState struct {
@@ -32,6 +38,8 @@ type RuntimeState struct {
 	LastExportRunDate     time.Time
 	LastExportedFileDate  time.Time
 	LastExportFileName    string
+	LastDayExportDate     time.Time
+	LastDayExportFileName string
 	CrawlRunning          bool
 	ExportRunning         bool
 	CleanRunning          bool
@@ -42,11 +50,21 @@ type RuntimeState struct {
 	CleanJobID            cron.EntryID
 	EventJobID            cron.EntryID
 	CalCmsJobID           cron.EntryID
+	DayExportJobID        cron.EntryID
+	OutboxJobID           cron.EntryID
+	AlarmJobID            cron.EntryID
+	ReconcileJobID        cron.EntryID
+	ReminderJobID         cron.EntryID
 	LastCalCmsState       string
 	LastCalCmsRefreshDate time.Time
 	LastCalCmsRefreshErr  string
+	CalCmsDataFetched     time.Time // time the calCMS program in use was received
+	CalCmsDataCached      bool      // calCMS is unreachable, the program is served from the cache
 	LastMairListCommState string
 	MairListPlaying       bool
+	LastMairListPlaying   time.Time         // last time mAirList was seen playing
+	MairListTargetStates  map[string]string // last communication state per mAirList instance
+	MairListActiveTarget  string            // instance used for playlists and status, differs from the primary after a failover
 }
 
 // RuntimeSnapshot is an immutable copy of runtime values safe for concurrent readers.
@@ -60,6 +78,8 @@ type RuntimeSnapshot struct {
 	LastExportRunDate     time.Time
 	LastExportedFileDate  time.Time
 	LastExportFileName    string
+	LastDayExportDate     time.Time
+	LastDayExportFileName string
 	CrawlRunning          bool
 	ExportRunning         bool
 	CleanRunning          bool
@@ -70,11 +90,21 @@ type RuntimeSnapshot struct {
 	CleanJobID            cron.EntryID
 	EventJobID            cron.EntryID
 	CalCmsJobID           cron.EntryID
+	DayExportJobID        cron.EntryID
+	OutboxJobID           cron.EntryID
+	AlarmJobID            cron.EntryID
+	ReconcileJobID        cron.EntryID
+	ReminderJobID         cron.EntryID
 	LastCalCmsState       string
 	LastCalCmsRefreshDate time.Time
 	LastCalCmsRefreshErr  string
+	CalCmsDataFetched     time.Time // time the calCMS program in use was received
+	CalCmsDataCached      bool      // calCMS is unreachable, the program is served from the cache
 	LastMairListCommState string
 	MairListPlaying       bool
+	LastMairListPlaying   time.Time         // last time mAirList was seen playing
+	MairListTargetStates  map[string]string // last communication state per mAirList instance
+	MairListActiveTarget  string            // instance used for playlists and status, differs from the primary after a failover
 }
 
 func (r *RuntimeState) Update(update func(*RuntimeState)) {
@@ -96,6 +126,8 @@ func (r *RuntimeState) Snapshot() RuntimeSnapshot {
 		LastExportRunDate:     r.LastExportRunDate,
 		LastExportedFileDate:  r.LastExportedFileDate,
 		LastExportFileName:    r.LastExportFileName,
+		LastDayExportDate:     r.LastDayExportDate,
+		LastDayExportFileName: r.LastDayExportFileName,
 		CrawlRunning:          r.CrawlRunning,
 		ExportRunning:         r.ExportRunning,
 		CleanRunning:          r.CleanRunning,
@@ -106,11 +138,21 @@ func (r *RuntimeState) Snapshot() RuntimeSnapshot {
 		CleanJobID:            r.CleanJobID,
 		EventJobID:            r.EventJobID,
 		CalCmsJobID:           r.CalCmsJobID,
+		DayExportJobID:        r.DayExportJobID,
+		OutboxJobID:           r.OutboxJobID,
+		AlarmJobID:            r.AlarmJobID,
+		ReconcileJobID:        r.ReconcileJobID,
+		ReminderJobID:         r.ReminderJobID,
 		LastCalCmsState:       r.LastCalCmsState,
 		LastCalCmsRefreshDate: r.LastCalCmsRefreshDate,
 		LastCalCmsRefreshErr:  r.LastCalCmsRefreshErr,
+		CalCmsDataFetched:     r.CalCmsDataFetched,
+		CalCmsDataCached:      r.CalCmsDataCached,
 		LastMairListCommState: r.LastMairListCommState,
 		MairListPlaying:       r.MairListPlaying,
+		LastMairListPlaying:   r.LastMairListPlaying,
+		MairListTargetStates:  maps.Clone(r.MairListTargetStates),
+		MairListActiveTarget:  r.MairListActiveTarget,
 	}
 }
 
@@ -164,3 +206,36 @@ func (m *Metrics) ObserveFastEvent(name string, value float64) {
 		m.FastEventDurations.WithLabelValues(name).Observe(value)
 	}
 }
+
+func (m *Metrics) SetReconcileItems(kind string, value float64) {
+	if m.ReconcileItems != nil {
+		m.ReconcileItems.WithLabelValues(kind).Set(value)
+	}
+}
+
+func (m *Metrics) SetOutboxEntries(status string, value float64) {
+	if m.OutboxEntries != nil {
+		m.OutboxEntries.WithLabelValues(status).Set(value)
+	}
+}
+
+func (m *Metrics) SetAlarmActive(kind string, value float64) {
+	if m.AlarmActive != nil {
+		m.AlarmActive.WithLabelValues(kind).Set(value)
+	}
+}
+
+// SetCalCmsDataAge sets the age of the calCMS program in use, labelled with its source. The other source is removed
+func (m *Metrics) SetCalCmsDataAge(source string, value float64) {
+	if m.CalCmsDataAge != nil {
+		m.CalCmsDataAge.Reset()
+		m.CalCmsDataAge.WithLabelValues(source).Set(value)
+	}
+}
+
+// AddLateUpload counts a preproduced event of a series whose file was uploaded late or is missing
+func (m *Metrics) AddLateUpload(series string, status string) {
+	if m.LateUploads != nil {
+		m.LateUploads.WithLabelValues(series, status).Inc()
+	}
+}
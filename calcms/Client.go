@@ -0,0 +1,85 @@
+// package calcms implements a client writing the upload status of events back into calCMS
+package calcms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+)
+
+var (
+	ErrUrlEmpty = errors.New("url cannot be empty")
+)
+
+// Client updates event metadata via an HTTP endpoint of calCMS. The event id and the upload status are posted as
+// form values event_id and upload_status, any 2xx answer counts as success
+type Client struct {
+	Url        string
+	User       string // leave empty to send without basic authentication
+	Password   string
+	Token      string // sent as bearer token if set
+	HttpClient *http.Client
+}
+
+// StatusError is returned when calCMS answers with a status other than 2xx
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("calCMS answered with status %v", e.StatusCode)
+	}
+	return fmt.Sprintf("calCMS answered with status %v: %v", e.StatusCode, e.Body)
+}
+
+// NewClient creates a client for the write-back endpoint given in the configuration
+func NewClient(cfg *config.AppConfig, httpClient *http.Client) Client {
+	return Client{
+		Url:        cfg.CalCms.WriteBackUrl,
+		User:       cfg.CalCms.WriteBackUser,
+		Password:   cfg.CalCms.WriteBackPassword,
+		Token:      cfg.CalCms.WriteBackToken,
+		HttpClient: httpClient,
+	}
+}
+
+// SetUploadStatus sets the upload status of an event, e.g. "File received, 58 min, OK"
+func (c Client) SetUploadStatus(ctx context.Context, eventId int, status string) error {
+	if c.Url == "" {
+		return ErrUrlEmpty
+	}
+	form := url.Values{}
+	form.Set("event_id", strconv.Itoa(eventId))
+	form.Set("upload_status", status)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Password)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
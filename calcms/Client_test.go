@@ -0,0 +1,80 @@
+package calcms
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/calcms/calcmstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCalCms(t *testing.T) (Client, *calcmstest.Server) {
+	t.Helper()
+	srv := calcmstest.NewServer()
+	t.Cleanup(srv.Close)
+	return Client{Url: srv.Url(), HttpClient: &http.Client{Timeout: 5 * time.Second}}, srv
+}
+
+func TestSetUploadStatusUpdatesEvent(t *testing.T) {
+	client, srv := setupCalCms(t)
+
+	err := client.SetUploadStatus(context.Background(), 4711, "File received, 58 min, OK")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []calcmstest.Update{{EventId: 4711, Status: "File received, 58 min, OK"}}, srv.Updates())
+}
+
+func TestSetUploadStatusWithUserAuthenticates(t *testing.T) {
+	client, srv := setupCalCms(t)
+	srv.User, srv.Password = "feeder", "secret"
+	client.User, client.Password = "feeder", "secret"
+
+	err := client.SetUploadStatus(context.Background(), 4711, "No file received")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "No file received", srv.Status()[4711])
+}
+
+func TestSetUploadStatusWithTokenAuthenticates(t *testing.T) {
+	client, srv := setupCalCms(t)
+	srv.Token = "t0ken"
+	client.Token = "t0ken"
+
+	err := client.SetUploadStatus(context.Background(), 4711, "No file received")
+
+	require.NoError(t, err)
+	assert.Len(t, srv.Updates(), 1)
+}
+
+func TestSetUploadStatusUnauthorizedReturnsError(t *testing.T) {
+	client, srv := setupCalCms(t)
+	srv.Token = "t0ken"
+
+	err := client.SetUploadStatus(context.Background(), 4711, "No file received")
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.EqualValues(t, http.StatusUnauthorized, statusErr.StatusCode)
+	assert.Empty(t, srv.Updates())
+}
+
+func TestSetUploadStatusServerErrorReturnsError(t *testing.T) {
+	client, srv := setupCalCms(t)
+	srv.FailWith(http.StatusInternalServerError)
+
+	err := client.SetUploadStatus(context.Background(), 4711, "No file received")
+
+	require.Error(t, err)
+	assert.EqualValues(t, "calCMS answered with status 500", err.Error())
+}
+
+func TestSetUploadStatusNoUrlReturnsError(t *testing.T) {
+	client := Client{HttpClient: http.DefaultClient}
+
+	err := client.SetUploadStatus(context.Background(), 4711, "No file received")
+
+	assert.ErrorIs(t, err, ErrUrlEmpty)
+}
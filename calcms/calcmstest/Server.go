@@ -0,0 +1,98 @@
+// package calcmstest provides a fake calCMS write-back endpoint for tests
+package calcmstest
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Update is an upload status received by the fake server
+type Update struct {
+	EventId int
+	Status  string
+}
+
+// Server is a fake calCMS accepting upload status updates posted to /upload_status. If User or Token are set,
+// requests must authenticate with them
+type Server struct {
+	*httptest.Server
+	User     string
+	Password string
+	Token    string
+	mu       sync.Mutex
+	updates  []Update
+	status   map[int]string
+	failWith int
+}
+
+// NewServer starts a fake calCMS server. Close it after use
+func NewServer() *Server {
+	s := &Server{status: make(map[int]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /upload_status", s.uploadStatus)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Url returns the URL of the write-back endpoint
+func (s *Server) Url() string {
+	return s.URL + "/upload_status"
+}
+
+// Updates returns all updates received so far
+func (s *Server) Updates() []Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.updates)
+}
+
+// Status returns the current upload status of all updated events
+func (s *Server) Status() map[int]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.status)
+}
+
+// FailWith makes the server answer all following updates with the given status code. Pass 0 to reset
+func (s *Server) FailWith(statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failWith = statusCode
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.Token != "" {
+		return r.Header.Get("Authorization") == "Bearer "+s.Token
+	}
+	if s.User == "" {
+		return true
+	}
+	user, pass, ok := r.BasicAuth()
+	return ok && user == s.User && pass == s.Password
+}
+
+func (s *Server) uploadStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failWith != 0 {
+		w.WriteHeader(s.failWith)
+		return
+	}
+	eventId, err := strconv.Atoi(r.PostFormValue("event_id"))
+	if err != nil {
+		http.Error(w, "invalid event id", http.StatusBadRequest)
+		return
+	}
+	status := r.PostFormValue("upload_status")
+	s.updates = append(s.updates, Update{EventId: eventId, Status: status})
+	s.status[eventId] = status
+	w.Write([]byte("ok"))
+}
@@ -4,11 +4,17 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/mail"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/robfig/cron/v3"
 )
 
 // Configuration with subsections
@@ -29,9 +35,14 @@ type AppConfig struct {
 		LogToLogger  bool   `envconfig:"LOG_TO_LOGGER" default:"false"`
 	}
 	Misc struct {
-		TestCrawl    bool   `envconfig:"TEST_CRAWL" default:"false"`
-		TestDate     string `envconfig:"TEST_DATE" default:"2024/01/15"`
-		FileSaveFile string `envconfig:"FILE_SAVE_FILE" default:"files.dta"`
+		TestCrawl          bool   `envconfig:"TEST_CRAWL" default:"false"`
+		TestDate           string `envconfig:"TEST_DATE" default:"2024/01/15"`
+		FileSaveFile       string `envconfig:"FILE_SAVE_FILE" default:"files.dta"`
+		OverrideSaveFile   string `envconfig:"OVERRIDE_SAVE_FILE" default:"overrides.dta"`
+		OutboxSaveFile     string `envconfig:"OUTBOX_SAVE_FILE" default:"outbox.dta"`
+		ReminderSaveFile   string `envconfig:"REMINDER_SAVE_FILE" default:"reminders.dta"`
+		EventFileSaveFile  string `envconfig:"EVENT_FILE_SAVE_FILE" default:"eventfiles.dta"`
+		TitleMatchSaveFile string `envconfig:"TITLE_MATCH_SAVE_FILE" default:"titlematches.dta"`
 	}
 	Crawl struct {
 		RootFolder              string         `envconfig:"ROOT_FOLDER"`
@@ -46,33 +57,126 @@ type AppConfig struct {
 		AddNonCalCmsFiles       bool           `envconfig:"ADD_NON_CALCMS_FILES" default:"true"`
 	}
 	Export struct {
-		ExportFolder           string  `envconfig:"EXPORT_FOLDER" default:"C:\\TEMP"`
-		ShortDeltaAllowance    float64 `envconfig:"SHORT_DELTA_ALLOWANCE" default:"8.0"`
-		LongDeltaAllowance     float64 `envconfig:"LONG_DELTA_ALLOWANCE" default:"12.0"`
-		MairListUrl            string  `envconfig:"MAIRLIST_URL" default:"http://localhost:9300/"`
-		MairListUser           string  `envconfig:"MAIRLIST_USER"`
-		MairListPassword       string  `envconfig:"MAIRLIST_PASS"`
-		MairListVersion        int     `envconfig:"MAIRLIST_VERSION" default:"6"`
-		AppendPlaylist         bool    `envconfig:"APPEND_PLAYLIST" default:"false"`
-		TerminateAfterDuration bool    `envconfig:"TERM_AFTER_DUR" default:"true"`
-		QueryMairListStatus    bool    `envconfig:"QUERY_MAIRLIST_STATUS" default:"false"`
-		StatusQueryCycleSec    int     `envconfig:"QUERY_STATUS_CYCLE_SEC" default:"5"`
-		ExportLiveItems        bool    `envconfig:"EXPORT_LIVE_ITEMS" default:"false"`
-		ExportMinute           int     `envconfig:"EXPORT_MINUTE" default:"59"`
+		ExportFolder           string   `envconfig:"EXPORT_FOLDER" default:"C:\\TEMP"`
+		ShortDeltaAllowance    float64  `envconfig:"SHORT_DELTA_ALLOWANCE" default:"8.0"`
+		LongDeltaAllowance     float64  `envconfig:"LONG_DELTA_ALLOWANCE" default:"12.0"`
+		MairListUrl            string   `envconfig:"MAIRLIST_URL" default:"http://localhost:9300/"`
+		MairListUser           string   `envconfig:"MAIRLIST_USER"`
+		MairListPassword       string   `envconfig:"MAIRLIST_PASS"`
+		MairListVersion        int      `envconfig:"MAIRLIST_VERSION" default:"6"`
+		MairListPlaylist       int      `envconfig:"MAIRLIST_PLAYLIST" default:"1"` // playlist index used for commands, starting at 1; values below 1 select the first playlist
+		MairListTargets        []string `envconfig:"MAIRLIST_TARGETS"`              // name|role|url|user|pass|version, e.g. main|primary|http://studio1:9300/|||6; leave empty to use MAIRLIST_URL only
+		MairListFailover       bool     `envconfig:"MAIRLIST_FAILOVER" default:"false"`
+		MairListRetry          bool     `envconfig:"MAIRLIST_RETRY" default:"true"` // retry failed appends from the outbox
+		RetryMinSec            int      `envconfig:"MAIRLIST_RETRY_MIN_SEC" default:"15"`
+		RetryMaxSec            int      `envconfig:"MAIRLIST_RETRY_MAX_SEC" default:"300"`
+		RetryDeadlineMin       int      `envconfig:"MAIRLIST_RETRY_DEADLINE_MIN" default:"10"` // minutes after the slot start to give up
+		AppendPlaylist         bool     `envconfig:"APPEND_PLAYLIST" default:"false"`
+		TerminateAfterDuration bool     `envconfig:"TERM_AFTER_DUR" default:"true"`
+		QueryMairListStatus    bool     `envconfig:"QUERY_MAIRLIST_STATUS" default:"false"`
+		StatusQueryCycleSec    int      `envconfig:"QUERY_STATUS_CYCLE_SEC" default:"5"`
+		ExportLiveItems        bool     `envconfig:"EXPORT_LIVE_ITEMS" default:"false"`
+		ExportMinute           int      `envconfig:"EXPORT_MINUTE" default:"59"`
+		DayPlaylistFolder      string   `envconfig:"DAY_PLAYLIST_FOLDER"`                  // leave empty to use the export folder
+		DayPlaylistCron        string   `envconfig:"DAY_PLAYLIST_CRON"`                    // leave empty to disable the scheduled day playlist export
+		HistoryFolder          string   `envconfig:"EXPORT_HISTORY_FOLDER"`                // leave empty to use the "history" folder below the export folder
+		HistoryVersions        int      `envconfig:"EXPORT_HISTORY_VERSIONS" default:"20"` // versions kept per slot, 0 disables the history
+		HistoryDays            int      `envconfig:"EXPORT_HISTORY_DAYS" default:"30"`     // days a slot is kept after its latest version, 0 keeps slots forever
+		AsRunFolder            string   `envconfig:"AS_RUN_FOLDER"`                        // leave empty to use the "asrun" folder below the export folder
+		Reconcile              bool     `envconfig:"RECONCILE_PLAYLIST" default:"false"`   // compare mAirList's playlist with the export every reconcile cycle
+		ReconcileCycleMin      int      `envconfig:"RECONCILE_CYCLE_MIN" default:"1"`
+		ReconcileHours         int      `envconfig:"RECONCILE_HOURS" default:"3"`
+		ReconcileReappend      bool     `envconfig:"RECONCILE_REAPPEND" default:"false"`
+	}
+	Filler struct {
+		FillGaps    bool     `envconfig:"FILL_GAPS" default:"false"`
+		Pools       []string `envconfig:"FILLER_POOLS"` // name=folder, e.g. ids=/audio/ids,beds=/audio/beds
+		MinGapSec   int      `envconfig:"FILLER_MIN_GAP_SEC" default:"10"`
+		RepeatBlock int      `envconfig:"FILLER_REPEAT_BLOCK" default:"5"`
 	}
 	CalCms struct {
 		QueryCalCms        bool     `envconfig:"QUERY_CALCMS" default:"false"`
+		Provider           string   `envconfig:"SCHEDULE_PROVIDER" default:"calcms"` // calcms or ical
+		ICalSource         string   `envconfig:"ICAL_SOURCE"`                        // URL or path of the iCalendar feed
+		ICalLiveCategory   string   `envconfig:"ICAL_LIVE_CATEGORY" default:"live"`  // events with this category are live
 		CmsUrl             string   `envconfig:"CALCMS_URL" default:"https://programm.coloradio.org/agenda/events.cgi"`
 		Template           string   `envconfig:"CALCMS_TEMPLATE" default:"event.json-p"`
 		EventExclusion     []string `envconfig:"EVENT_EXCLUSION"`
+		EventFilters       []string `envconfig:"EVENT_FILTERS"` // events matching one of the filters are ignored, e.g. draft=yes,location=Außenstudio
 		ExportDayEvents    bool     `envconfig:"EXPORT_DAY_EVENTS" default:"false"`
 		ShowNonCalCmsFiles bool     `envconfig:"SHOW_NON_CALCMS_FILES" default:"true"`
 		FutureEventsDays   int      `envconfig:"FUTURE_EVENTS_DAYS" default:"5"`
+		CacheFolder        string   `envconfig:"CALCMS_CACHE_FOLDER"`                 // leave empty to use the "calcms" folder below the export folder
+		CacheDays          int      `envconfig:"CALCMS_CACHE_DAYS" default:"7"`       // days the program is kept after its date, 0 disables the cache
+		TitleMatchAccept   float64  `envconfig:"TITLE_MATCH_ACCEPT" default:"0.8"`    // link files without event id to events from this confidence on, 0 disables title matching
+		TitleMatchSuggest  float64  `envconfig:"TITLE_MATCH_SUGGEST" default:"0.5"`   // suggest matches from this confidence on for confirmation in the web UI
+		TitleMatchWindow   int      `envconfig:"TITLE_MATCH_WINDOW_MIN" default:"30"` // maximum difference in minutes between the start of file and event
+		WriteBack          bool     `envconfig:"CALCMS_WRITE_BACK" default:"false"`   // write the upload status of events back into calCMS
+		WriteBackUrl       string   `envconfig:"CALCMS_WRITE_BACK_URL"`
+		WriteBackUser      string   `envconfig:"CALCMS_WRITE_BACK_USER"`
+		WriteBackPassword  string   `envconfig:"CALCMS_WRITE_BACK_PASS"`
+		WriteBackToken     string   `envconfig:"CALCMS_WRITE_BACK_TOKEN"`
+	}
+	Archive struct {
+		Folder              string `envconfig:"ARCHIVE_FOLDER"`                     // leave empty to use the "archive" folder below the export folder
+		Days                int    `envconfig:"ARCHIVE_DAYS" default:"0"`           // days the event status is kept, 0 keeps it forever
+		UploadDeadlineHours int    `envconfig:"UPLOAD_DEADLINE_HOURS" default:"24"` // files of preproduced events are due this many hours before air
+	}
+	Rerun struct {
+		ScheduleReruns bool   `envconfig:"SCHEDULE_RERUNS" default:"true"`   // schedule the file of the original broadcast for reruns without own file
+		ArchiveFolder  string `envconfig:"RERUN_ARCHIVE_FOLDER"`             // searched for files named with the original event id, leave empty to disable
+		HistoryDays    int    `envconfig:"RERUN_HISTORY_DAYS" default:"365"` // days the file broadcast for an event is remembered
+	}
+	Alarm struct {
+		DeadAirSec   int `envconfig:"ALARM_DEAD_AIR_SEC" default:"30"`    // silence during a preproduced event before alarming, 0 disables the alarm
+		LiveGraceSec int `envconfig:"ALARM_LIVE_GRACE_SEC" default:"120"` // time after the start of a live event mAirList may still play
+	}
+	Notify struct {
+		Webhooks   []string `envconfig:"NOTIFY_WEBHOOKS"` // url|secret|types, types separated by semicolons, e.g. https://chat/hook|s3cret|export_failed;file_missing
+		Retries    int      `envconfig:"NOTIFY_RETRIES" default:"3"`
+		RetrySec   int      `envconfig:"NOTIFY_RETRY_SEC" default:"5"` // delay before the first retry, doubled for every further retry
+		QueueSize  int      `envconfig:"NOTIFY_QUEUE_SIZE" default:"100"`
+		MissingMin int      `envconfig:"NOTIFY_MISSING_MIN" default:"60"` // notify about missing files of events starting within this many minutes
+	}
+	Reminder struct {
+		SmtpHost     string `envconfig:"SMTP_HOST"` // leave empty to disable reminders
+		SmtpPort     int    `envconfig:"SMTP_PORT" default:"25"`
+		SmtpUser     string `envconfig:"SMTP_USER"` // leave empty to send without authentication
+		SmtpPassword string `envconfig:"SMTP_PASS"`
+		From         string `envconfig:"REMINDER_FROM"`
+		LeadHours    []int  `envconfig:"REMINDER_LEAD_HOURS" default:"48,24,3"`
+		CycleMin     int    `envconfig:"REMINDER_CYCLE_MIN" default:"15"`
+		AddressFile  string `envconfig:"REMINDER_ADDRESS_FILE"`                  // lines of key=address, key being an event id, series name or event title
+		ProjectEmail bool   `envconfig:"REMINDER_PROJECT_EMAIL" default:"false"` // fall back to the project email from calCMS
+		Subject      string `envconfig:"REMINDER_SUBJECT" default:"No file for {{ .Title }} on {{ .Date }} {{ .Start }}"`
+		TemplateFile string `envconfig:"REMINDER_TEMPLATE_FILE"` // leave empty to use the built-in text
+	}
+	NowPlaying struct {
+		Template        string `envconfig:"NOW_PLAYING_TEMPLATE" default:"{{ if .Artist }}{{ .Artist }} - {{ end }}{{ .Title }}"`
+		IcecastUrl      string `envconfig:"NOW_PLAYING_ICECAST_URL"` // e.g. http://icecast:8000, leave empty to disable
+		IcecastMount    string `envconfig:"NOW_PLAYING_ICECAST_MOUNT" default:"/stream"`
+		IcecastUser     string `envconfig:"NOW_PLAYING_ICECAST_USER" default:"admin"`
+		IcecastPassword string `envconfig:"NOW_PLAYING_ICECAST_PASS"`
+		LineAddress     string `envconfig:"NOW_PLAYING_LINE_ADDRESS"` // tcp://host:port or udp://host:port, leave empty to disable
+		LineTemplate    string `envconfig:"NOW_PLAYING_LINE_TEMPLATE" default:"RT={{ .Text }}"`
+		JsonFile        string `envconfig:"NOW_PLAYING_JSON_FILE"` // leave empty to disable
+	}
+	Report struct {
+		MusicColumns        []string `envconfig:"MUSIC_REPORT_COLUMNS" default:"artist,title,album,genre,year,plays,duration"`
+		MusicSeparator      string   `envconfig:"MUSIC_REPORT_SEPARATOR" default:";"`
+		MusicExcludeFolders []string `envconfig:"MUSIC_REPORT_EXCLUDE_FOLDERS"` // folders with show files as seen by mAirList, in addition to ROOT_FOLDER
 	}
 }
 
+const (
+	ScheduleProviderCalCms = "calcms" // racalmas events.cgi JSON API
+	ScheduleProviderICal   = "ical"   // iCalendar feed from a file or URL
+)
+
 var (
 	EnvFile = ".env"
+	// MusicReportColumns lists the columns available in the music report
+	MusicReportColumns = []string{"artist", "title", "album", "genre", "year", "plays", "duration", "seconds", "first", "last", "file"}
 )
 
 // InitConfig initializes the configuration and sets the defaults
@@ -105,12 +209,118 @@ func validateConfig(config *AppConfig) error {
 	if config.Export.StatusQueryCycleSec <= 0 {
 		return fmt.Errorf("status query cycle must be greater than 0")
 	}
-	if config.Export.AppendPlaylist || config.Export.QueryMairListStatus {
-		if config.Export.MairListUser == "" {
-			return fmt.Errorf("mAirList user must be configured when mAirList integration is enabled")
+	if config.Export.Reconcile && config.Export.ReconcileCycleMin <= 0 {
+		return fmt.Errorf("reconcile cycle must be greater than 0")
+	}
+	if config.Export.DayPlaylistCron != "" {
+		if _, err := cron.ParseStandard(config.Export.DayPlaylistCron); err != nil {
+			return fmt.Errorf("day playlist cron %q is invalid: %w", config.Export.DayPlaylistCron, err)
+		}
+	}
+	if config.Export.HistoryVersions < 0 {
+		return fmt.Errorf("export history versions must not be negative")
+	}
+	if config.Export.HistoryDays < 0 {
+		return fmt.Errorf("export history days must not be negative")
+	}
+	if config.CalCms.Provider != "" && config.CalCms.Provider != ScheduleProviderCalCms && config.CalCms.Provider != ScheduleProviderICal {
+		return fmt.Errorf("schedule provider must be %v or %v", ScheduleProviderCalCms, ScheduleProviderICal)
+	}
+	if config.CalCms.Provider == ScheduleProviderICal && config.CalCms.QueryCalCms && config.CalCms.ICalSource == "" {
+		return fmt.Errorf("iCalendar source must be set for the %v schedule provider", ScheduleProviderICal)
+	}
+	if _, err := EventFilters(config); err != nil {
+		return err
+	}
+	if config.CalCms.CacheDays < 0 {
+		return fmt.Errorf("calCMS cache days must not be negative")
+	}
+	if config.CalCms.TitleMatchAccept < 0 || config.CalCms.TitleMatchAccept > 1 || config.CalCms.TitleMatchSuggest < 0 || config.CalCms.TitleMatchSuggest > 1 {
+		return fmt.Errorf("title match confidences must be between 0 and 1")
+	}
+	if config.CalCms.TitleMatchSuggest > config.CalCms.TitleMatchAccept {
+		return fmt.Errorf("title match suggest confidence must not be greater than the accept confidence")
+	}
+	if config.CalCms.WriteBack && config.CalCms.WriteBackUrl == "" {
+		return fmt.Errorf("calCMS write-back url must be set to write back the upload status")
+	}
+	if config.CalCms.TitleMatchWindow < 0 {
+		return fmt.Errorf("title match window must not be negative")
+	}
+	if config.Rerun.HistoryDays < 0 {
+		return fmt.Errorf("rerun history days must not be negative")
+	}
+	if config.Archive.Days < 0 {
+		return fmt.Errorf("archive days must not be negative")
+	}
+	if config.Archive.UploadDeadlineHours < 0 {
+		return fmt.Errorf("upload deadline hours must not be negative")
+	}
+	if config.Alarm.DeadAirSec < 0 || config.Alarm.LiveGraceSec < 0 {
+		return fmt.Errorf("alarm times must not be negative")
+	}
+	if config.Filler.MinGapSec < 0 {
+		return fmt.Errorf("filler minimum gap must not be negative")
+	}
+	if config.Filler.RepeatBlock < 0 {
+		return fmt.Errorf("filler repeat block must not be negative")
+	}
+	if config.Filler.FillGaps {
+		if len(config.Filler.Pools) == 0 {
+			return fmt.Errorf("filler pools must be configured when gap filling is enabled")
+		}
+		for _, pool := range config.Filler.Pools {
+			name, folder, found := strings.Cut(pool, "=")
+			if !found || strings.TrimSpace(name) == "" || strings.TrimSpace(folder) == "" {
+				return fmt.Errorf("filler pool %q must have the form name=folder", pool)
+			}
+		}
+	}
+	for _, column := range config.Report.MusicColumns {
+		if !slices.Contains(MusicReportColumns, column) {
+			return fmt.Errorf("unknown music report column %q", column)
+		}
+	}
+	if utf8.RuneCountInString(config.Report.MusicSeparator) > 1 {
+		return fmt.Errorf("music report separator must be a single character")
+	}
+	if config.Export.MairListRetry {
+		if config.Export.RetryMinSec <= 0 || config.Export.RetryMaxSec < config.Export.RetryMinSec {
+			return fmt.Errorf("mAirList retry interval must be greater than 0 and the maximum not below the minimum")
 		}
-		if config.Export.MairListPassword == "" {
-			return fmt.Errorf("mAirList password must be configured when mAirList integration is enabled")
+		if config.Export.RetryDeadlineMin < 0 {
+			return fmt.Errorf("mAirList retry deadline must not be negative")
+		}
+	}
+	if len(config.Notify.Webhooks) > 0 {
+		if config.Notify.Retries < 0 || config.Notify.MissingMin < 0 {
+			return fmt.Errorf("notification retries and missing file lead time must not be negative")
+		}
+		if config.Notify.RetrySec <= 0 || config.Notify.QueueSize <= 0 {
+			return fmt.Errorf("notification retry interval and queue size must be greater than 0")
+		}
+		if _, err := NotificationWebhooks(config); err != nil {
+			return err
+		}
+	}
+	if err := validateReminder(config); err != nil {
+		return err
+	}
+	if err := validateNowPlaying(config); err != nil {
+		return err
+	}
+	targets, err := MairListTargets(config)
+	if err != nil {
+		return err
+	}
+	if config.Export.AppendPlaylist || config.Export.QueryMairListStatus {
+		for _, target := range targets {
+			if target.User == "" {
+				return fmt.Errorf("mAirList user must be configured when mAirList integration is enabled")
+			}
+			if target.Password == "" {
+				return fmt.Errorf("mAirList password must be configured when mAirList integration is enabled")
+			}
 		}
 	}
 	if config.Server.UseTLS {
@@ -149,6 +359,56 @@ func validateConfig(config *AppConfig) error {
 			return fmt.Errorf("export folder must be a directory")
 		}
 	}
+	if config.Export.DayPlaylistFolder != "" {
+		info, err := os.Stat(config.Export.DayPlaylistFolder)
+		if err != nil {
+			return fmt.Errorf("day playlist folder is not accessible: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("day playlist folder must be a directory")
+		}
+	}
+	return nil
+}
+
+// validateNowPlaying checks the now-playing templates and the address of the line protocol sink
+func validateNowPlaying(config *AppConfig) error {
+	for name, text := range map[string]string{"now-playing": config.NowPlaying.Template, "now-playing line": config.NowPlaying.LineTemplate} {
+		if _, err := template.New(name).Parse(text); err != nil {
+			return fmt.Errorf("%v template is invalid: %w", name, err)
+		}
+	}
+	if config.NowPlaying.LineAddress != "" {
+		scheme, address, found := strings.Cut(config.NowPlaying.LineAddress, "://")
+		if !found || (scheme != "tcp" && scheme != "udp") || address == "" {
+			return fmt.Errorf("now-playing line address must have the form tcp://host:port or udp://host:port")
+		}
+	}
+	return nil
+}
+
+// validateReminder checks the reminder settings, if reminders are enabled
+func validateReminder(config *AppConfig) error {
+	if config.Reminder.SmtpHost == "" {
+		return nil
+	}
+	if _, err := mail.ParseAddress(config.Reminder.From); err != nil {
+		return fmt.Errorf("reminder sender address is invalid: %w", err)
+	}
+	if config.Reminder.SmtpPort <= 0 || config.Reminder.CycleMin <= 0 {
+		return fmt.Errorf("SMTP port and reminder cycle must be greater than 0")
+	}
+	if len(config.Reminder.LeadHours) == 0 || slices.ContainsFunc(config.Reminder.LeadHours, func(h int) bool { return h <= 0 }) {
+		return fmt.Errorf("reminder lead times must be given and greater than 0")
+	}
+	if _, err := template.New("subject").Parse(config.Reminder.Subject); err != nil {
+		return fmt.Errorf("reminder subject template is invalid: %w", err)
+	}
+	if config.Reminder.TemplateFile != "" {
+		if _, err := template.ParseFiles(config.Reminder.TemplateFile); err != nil {
+			return fmt.Errorf("reminder template is invalid: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -175,9 +435,23 @@ func setDefaults(config *AppConfig) {
 	checkFilePath(&config.Server.KeyFile)
 	checkFilePath(&config.Server.LogFile)
 	checkFilePath(&config.Misc.FileSaveFile)
+	checkFilePath(&config.Misc.OverrideSaveFile)
+	checkFilePath(&config.Misc.OutboxSaveFile)
+	checkFilePath(&config.Misc.ReminderSaveFile)
+	checkFilePath(&config.Misc.EventFileSaveFile)
+	checkFilePath(&config.Misc.TitleMatchSaveFile)
+	checkFilePath(&config.Reminder.AddressFile)
+	checkFilePath(&config.Reminder.TemplateFile)
 	checkFilePath(&config.Crawl.RootFolder)
 	checkFilePath(&config.Crawl.FFprobePath)
 	checkFilePath(&config.Export.ExportFolder)
+	checkFilePath(&config.Export.DayPlaylistFolder)
+	checkFilePath(&config.Export.HistoryFolder)
+	checkFilePath(&config.Export.AsRunFolder)
+	checkFilePath(&config.CalCms.CacheFolder)
+	checkFilePath(&config.Rerun.ArchiveFolder)
+	checkFilePath(&config.Archive.Folder)
+	checkFilePath(&config.NowPlaying.JsonFile)
 }
 
 // loadConfig loads the configuration from file. Returns an error if loading fails
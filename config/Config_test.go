@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -135,6 +136,89 @@ func TestValidateConfigInvalidStatusQueryCycleReturnsError(t *testing.T) {
 	assert.EqualValues(t, "status query cycle must be greater than 0", err.Error())
 }
 
+func TestValidateConfigNegativeArchiveDaysReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Archive.Days = -1
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "archive days must not be negative", err.Error())
+}
+
+func TestValidateConfigNegativeExportHistoryDaysReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Export.HistoryDays = -1
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "export history days must not be negative", err.Error())
+}
+
+func TestValidateConfigReconcileWithoutCycleReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Export.Reconcile = true
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "reconcile cycle must be greater than 0", err.Error())
+}
+
+func TestValidateConfigInvalidDayPlaylistCronReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Export.DayPlaylistCron = "0 22 * *"
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "day playlist cron \"0 22 * *\" is invalid")
+}
+
+func TestValidateConfigValidDayPlaylistCronReturnsNoError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Export.DayPlaylistCron = "0 22 * * *"
+
+	err := validateConfig(&cfg)
+
+	assert.Nil(t, err)
+}
+
+func TestValidateConfigNegativeUploadDeadlineReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Archive.UploadDeadlineHours = -1
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "upload deadline hours must not be negative", err.Error())
+}
+
 func TestValidateConfigAppendPlaylistMissingPasswordReturnsError(t *testing.T) {
 	var cfg AppConfig
 	cfg.Server.GracefulShutdownTime = 10
@@ -305,3 +389,170 @@ func TestValidateConfigMissingFfprobeReturnsError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "ffprobe executable is not accessible")
 }
+
+func TestValidateConfigFillGapsInvalidPoolReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Filler.FillGaps = true
+	cfg.Filler.Pools = []string{"jingles"}
+
+	err := validateConfig(&cfg)
+
+	assert.EqualError(t, err, "filler pool \"jingles\" must have the form name=folder")
+}
+
+func TestValidateConfigFillGapsValidPoolReturnsNoError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Filler.FillGaps = true
+	cfg.Filler.Pools = []string{"jingles=" + t.TempDir()}
+
+	err := validateConfig(&cfg)
+
+	assert.Nil(t, err)
+}
+
+func TestValidateConfigUnknownMusicReportColumnReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Report.MusicColumns = []string{"artist", "composer"}
+
+	err := validateConfig(&cfg)
+
+	assert.EqualError(t, err, "unknown music report column \"composer\"")
+}
+
+func TestValidateConfigInvalidRetryIntervalReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Export.MairListRetry = true
+	cfg.Export.RetryMinSec = 60
+	cfg.Export.RetryMaxSec = 30
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "mAirList retry interval must be greater than 0 and the maximum not below the minimum", err.Error())
+}
+
+func TestValidateConfigInvalidNowPlayingLineAddressReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.NowPlaying.LineAddress = "rds-encoder:5000"
+
+	err := validateConfig(&cfg)
+
+	assert.EqualError(t, err, "now-playing line address must have the form tcp://host:port or udp://host:port")
+}
+
+func TestValidateConfigInvalidNowPlayingTemplateReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.NowPlaying.Template = "{{ .Title "
+
+	err := validateConfig(&cfg)
+
+	assert.ErrorContains(t, err, "now-playing template is invalid")
+}
+
+func TestValidateConfigInvalidReminderReturnsError(t *testing.T) {
+	tests := map[string]func(*AppConfig){
+		"reminder sender address is invalid: mail: no address": func(c *AppConfig) { c.Reminder.From = "" },
+		"reminder lead times must be given and greater than 0": func(c *AppConfig) { c.Reminder.LeadHours = []int{24, 0} },
+		"SMTP port and reminder cycle must be greater than 0":  func(c *AppConfig) { c.Reminder.CycleMin = 0 },
+	}
+	for expected, change := range tests {
+		var cfg AppConfig
+		cfg.Server.GracefulShutdownTime = 10
+		cfg.Crawl.CrawlCycleMin = 10
+		cfg.Export.ExportMinute = 59
+		cfg.Export.StatusQueryCycleSec = 5
+		cfg.Reminder.SmtpHost = "localhost"
+		cfg.Reminder.SmtpPort = 25
+		cfg.Reminder.CycleMin = 15
+		cfg.Reminder.From = "feeder@coloradio.org"
+		cfg.Reminder.LeadHours = []int{48, 24, 3}
+		change(&cfg)
+
+		err := validateConfig(&cfg)
+
+		require.Error(t, err, expected)
+		assert.EqualValues(t, expected, err.Error())
+	}
+}
+
+func TestValidateConfigNegativeCalCmsCacheDaysReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.CalCms.CacheDays = -1
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "calCMS cache days must not be negative", err.Error())
+}
+
+func TestValidateConfigUnknownScheduleProviderReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.CalCms.Provider = "google"
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "schedule provider must be calcms or ical", err.Error())
+}
+
+func TestValidateConfigICalProviderWithoutSourceReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.CalCms.QueryCalCms = true
+	cfg.CalCms.Provider = ScheduleProviderICal
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "iCalendar source must be set for the ical schedule provider", err.Error())
+}
+
+func TestValidateConfigTitleMatchSuggestAboveAcceptReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.CalCms.TitleMatchAccept = 0.5
+	cfg.CalCms.TitleMatchSuggest = 0.8
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "title match suggest confidence must not be greater than the accept confidence", err.Error())
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// EventFilterFields lists the event fields a filter condition can refer to
+	EventFilterFields = []string{"project", "location", "studio", "series", "skey", "title", "live", "draft", "published", "playout"}
+	// eventFilterFlags are the fields holding a yes/no flag
+	eventFilterFlags = []string{"live", "draft", "published", "playout"}
+	// eventFilterOperators are the comparison operators, two-character operators first
+	eventFilterOperators = []string{"!=", "!~", "=", "~"}
+)
+
+// FilterCondition compares one event field with a value. Text is compared case-insensitively, ~ tests whether the field contains the value
+type FilterCondition struct {
+	Field    string
+	Operator string // =, !=, ~ or !~
+	Value    string // "1" or "0" for flags
+}
+
+// EventFilter excludes the events matching all of its conditions
+type EventFilter struct {
+	Expression string
+	Conditions []FilterCondition
+}
+
+// Matches checks the conditions of the filter against the event field values returned by value
+func (f EventFilter) Matches(value func(field string) string) bool {
+	for _, condition := range f.Conditions {
+		actual := strings.ToLower(value(condition.Field))
+		expected := strings.ToLower(condition.Value)
+		var match bool
+		switch condition.Operator {
+		case "=":
+			match = actual == expected
+		case "!=":
+			match = actual != expected
+		case "~":
+			match = strings.Contains(actual, expected)
+		case "!~":
+			match = !strings.Contains(actual, expected)
+		}
+		if !match {
+			return false
+		}
+	}
+	return len(f.Conditions) > 0
+}
+
+// EventFilters returns the configured event filters. Each filter consists of conditions of the form field=value,
+// field!=value, field~value (contains) or field!~value joined by &, e.g. series~Test&live=no
+func EventFilters(config *AppConfig) ([]EventFilter, error) {
+	var filters []EventFilter
+	for _, expression := range config.CalCms.EventFilters {
+		filter, err := ParseEventFilter(expression)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// ParseEventFilter parses a single filter expression
+func ParseEventFilter(expression string) (EventFilter, error) {
+	filter := EventFilter{Expression: strings.TrimSpace(expression)}
+	for part := range strings.SplitSeq(expression, "&") {
+		condition, err := parseFilterCondition(part)
+		if err != nil {
+			return EventFilter{}, fmt.Errorf("event filter %q: %w", filter.Expression, err)
+		}
+		filter.Conditions = append(filter.Conditions, condition)
+	}
+	return filter, nil
+}
+
+func parseFilterCondition(part string) (FilterCondition, error) {
+	var condition FilterCondition
+	index := strings.IndexAny(part, "!=~")
+	if index < 0 {
+		return condition, fmt.Errorf("condition %q must have the form field=value, field!=value, field~value or field!~value", strings.TrimSpace(part))
+	}
+	for _, operator := range eventFilterOperators {
+		if strings.HasPrefix(part[index:], operator) {
+			condition.Operator = operator
+			break
+		}
+	}
+	if condition.Operator == "" {
+		return condition, fmt.Errorf("condition %q has an unknown operator", strings.TrimSpace(part))
+	}
+	condition.Field = strings.ToLower(strings.TrimSpace(part[:index]))
+	condition.Value = strings.TrimSpace(part[index+len(condition.Operator):])
+	if !slices.Contains(EventFilterFields, condition.Field) {
+		return condition, fmt.Errorf("unknown field %q", condition.Field)
+	}
+	if slices.Contains(eventFilterFlags, condition.Field) {
+		if condition.Operator == "~" || condition.Operator == "!~" {
+			return condition, fmt.Errorf("flag %q can only be compared with = or !=", condition.Field)
+		}
+		switch strings.ToLower(condition.Value) {
+		case "1", "true", "yes":
+			condition.Value = "1"
+		case "0", "false", "no":
+			condition.Value = "0"
+		default:
+			return condition, fmt.Errorf("flag %q must be compared with yes or no", condition.Field)
+		}
+	}
+	return condition, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventValues(values map[string]string) func(string) string {
+	return func(field string) string {
+		return values[field]
+	}
+}
+
+func TestParseEventFilterReturnsConditions(t *testing.T) {
+	filter, err := ParseEventFilter(" series~Test & live=no ")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "series~Test & live=no", filter.Expression)
+	assert.EqualValues(t, []FilterCondition{{Field: "series", Operator: "~", Value: "Test"}, {Field: "live", Operator: "=", Value: "0"}}, filter.Conditions)
+}
+
+func TestParseEventFilterInvalidReturnsError(t *testing.T) {
+	tests := map[string]string{
+		"location":      "event filter \"location\": condition \"location\" must have the form field=value, field!=value, field~value or field!~value",
+		"room=A":        "event filter \"room=A\": unknown field \"room\"",
+		"draft~1":       "event filter \"draft~1\": flag \"draft\" can only be compared with = or !=",
+		"draft=maybe":   "event filter \"draft=maybe\": flag \"draft\" must be compared with yes or no",
+		"location!Test": "event filter \"location!Test\": condition \"location!Test\" has an unknown operator",
+	}
+	for expression, expected := range tests {
+		_, err := ParseEventFilter(expression)
+
+		require.Error(t, err, expression)
+		assert.EqualValues(t, expected, err.Error())
+	}
+}
+
+func TestEventFilterMatchesAllConditions(t *testing.T) {
+	filter, _ := ParseEventFilter("location=außenstudio&live!=1")
+
+	assert.True(t, filter.Matches(eventValues(map[string]string{"location": "Außenstudio", "live": "0"})))
+	assert.False(t, filter.Matches(eventValues(map[string]string{"location": "Außenstudio", "live": "1"})))
+	assert.False(t, filter.Matches(eventValues(map[string]string{"location": "Studio", "live": "0"})))
+}
+
+func TestEventFilterContainsIgnoresCase(t *testing.T) {
+	filter, _ := ParseEventFilter("series!~news")
+
+	assert.False(t, filter.Matches(eventValues(map[string]string{"series": "Morning News"})))
+	assert.True(t, filter.Matches(eventValues(map[string]string{"series": "Jazz"})))
+}
+
+func TestValidateConfigInvalidEventFilterReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.CalCms.EventFilters = []string{"draft=1", "room=A"}
+
+	err := validateConfig(&cfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "event filter \"room=A\": unknown field \"room\"", err.Error())
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	MairListRolePrimary = "primary" // receives all playlists, its playlist is used for status and as-run log
+	MairListRoleMirror  = "mirror"  // receives all playlists in addition to the primary
+	MairListRoleBackup  = "backup"  // receives playlists only if the primary is unreachable and failover is enabled
+)
+
+// MairListTarget describes one mAirList instance the feeder talks to
+type MairListTarget struct {
+	Name     string
+	Role     string
+	Url      string
+	User     string
+	Password string
+	Version  int
+}
+
+// MairListTargets returns the configured mAirList instances. Entries have the form name|role|url|user|pass|version,
+// empty user, password and version fall back to MAIRLIST_USER, MAIRLIST_PASS and MAIRLIST_VERSION.
+// Without MAIRLIST_TARGETS the instance given by MAIRLIST_URL is the only, primary target named "main"
+func MairListTargets(config *AppConfig) ([]MairListTarget, error) {
+	if len(config.Export.MairListTargets) == 0 {
+		return []MairListTarget{DefaultMairListTarget(config)}, nil
+	}
+	var (
+		targets   []MairListTarget
+		primaries int
+	)
+	for _, entry := range config.Export.MairListTargets {
+		fields := strings.Split(entry, "|")
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("mAirList target %q must have the form name|role|url|user|pass|version", entry)
+		}
+		target := MairListTarget{
+			Name:     strings.TrimSpace(fields[0]),
+			Role:     strings.ToLower(strings.TrimSpace(fields[1])),
+			Url:      strings.TrimSpace(fields[2]),
+			User:     cmp.Or(fields[3], config.Export.MairListUser),
+			Password: cmp.Or(fields[4], config.Export.MairListPassword),
+			Version:  config.Export.MairListVersion,
+		}
+		if version := strings.TrimSpace(fields[5]); version != "" {
+			v, err := strconv.Atoi(version)
+			if err != nil {
+				return nil, fmt.Errorf("mAirList target %q has an invalid version", target.Name)
+			}
+			target.Version = v
+		}
+		if target.Name == "" || target.Url == "" {
+			return nil, fmt.Errorf("mAirList target %q must have a name and an url", entry)
+		}
+		if slices.ContainsFunc(targets, func(t MairListTarget) bool { return t.Name == target.Name }) {
+			return nil, fmt.Errorf("mAirList target name %q is used twice", target.Name)
+		}
+		switch target.Role {
+		case MairListRolePrimary:
+			primaries++
+		case MairListRoleMirror, MairListRoleBackup:
+		default:
+			return nil, fmt.Errorf("mAirList target %q has unknown role %q", target.Name, target.Role)
+		}
+		targets = append(targets, target)
+	}
+	if primaries != 1 {
+		return nil, fmt.Errorf("exactly one mAirList target must have the role primary")
+	}
+	return targets, nil
+}
+
+// DefaultMairListTarget returns the primary target named "main" given by MAIRLIST_URL
+func DefaultMairListTarget(config *AppConfig) MairListTarget {
+	return MairListTarget{
+		Name:     "main",
+		Role:     MairListRolePrimary,
+		Url:      config.Export.MairListUrl,
+		User:     config.Export.MairListUser,
+		Password: config.Export.MairListPassword,
+		Version:  config.Export.MairListVersion,
+	}
+}
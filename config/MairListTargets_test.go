@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMairListTargetsWithoutTargetsUsesMairListUrl(t *testing.T) {
+	var cfg AppConfig
+	cfg.Export.MairListUrl = "http://studio:9300/"
+	cfg.Export.MairListUser = "user"
+	cfg.Export.MairListVersion = 6
+
+	targets, err := MairListTargets(&cfg)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []MairListTarget{{Name: "main", Role: MairListRolePrimary, Url: "http://studio:9300/", User: "user", Version: 6}}, targets)
+}
+
+func TestMairListTargetsParsesEntriesWithDefaults(t *testing.T) {
+	var cfg AppConfig
+	cfg.Export.MairListUser = "user"
+	cfg.Export.MairListPassword = "pass"
+	cfg.Export.MairListVersion = 6
+	cfg.Export.MairListTargets = []string{"main|primary|http://studio1:9300/|||", "backup|Backup|http://studio2:9300/|other|secret|5"}
+
+	targets, err := MairListTargets(&cfg)
+
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	assert.EqualValues(t, MairListTarget{Name: "main", Role: MairListRolePrimary, Url: "http://studio1:9300/", User: "user", Password: "pass", Version: 6}, targets[0])
+	assert.EqualValues(t, MairListTarget{Name: "backup", Role: MairListRoleBackup, Url: "http://studio2:9300/", User: "other", Password: "secret", Version: 5}, targets[1])
+}
+
+func TestMairListTargetsInvalidEntriesReturnError(t *testing.T) {
+	tests := map[string][]string{
+		"mAirList target \"main|primary\" must have the form name|role|url|user|pass|version": {"main|primary"},
+		"mAirList target \"main\" has unknown role \"standby\"":                               {"main|standby|http://studio1:9300/|||"},
+		"mAirList target \"main\" has an invalid version":                                     {"main|primary|http://studio1:9300/|||six"},
+		"mAirList target name \"main\" is used twice":                                         {"main|primary|http://studio1:9300/|||", "main|mirror|http://studio2:9300/|||"},
+		"exactly one mAirList target must have the role primary":                              {"a|mirror|http://studio1:9300/|||", "b|backup|http://studio2:9300/|||"},
+	}
+	for expected, entries := range tests {
+		var cfg AppConfig
+		cfg.Export.MairListTargets = entries
+
+		_, err := MairListTargets(&cfg)
+
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func TestValidateConfigMairListTargetMissingUserReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Export.AppendPlaylist = true
+	cfg.Export.MairListTargets = []string{"main|primary|http://studio1:9300/|user|pass|6", "backup|backup|http://studio2:9300/|||6"}
+
+	err := validateConfig(&cfg)
+
+	assert.EqualError(t, err, "mAirList user must be configured when mAirList integration is enabled")
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	// NotificationTypes lists the event types a webhook can subscribe to
+	NotificationTypes = []string{"file_missing", "export_failed", "calcms_unreachable", "ffprobe_failed", "file_rejected", "alarm_raised", "alarm_cleared", "schedule_changed"}
+)
+
+// Webhook describes one receiver of feeder notifications
+type Webhook struct {
+	Url    string
+	Secret string   // key for the HMAC-SHA256 signature of the body, leave empty to send unsigned requests
+	Types  []string // event types delivered to this webhook, empty for all types
+}
+
+// Accepts checks whether the webhook subscribed to the given event type
+func (w Webhook) Accepts(eventType string) bool {
+	return len(w.Types) == 0 || slices.Contains(w.Types, eventType)
+}
+
+// NotificationWebhooks returns the configured webhooks. Entries have the form url|secret|types,
+// where types is a semicolon separated list of event types, e.g. https://chat/hook|s3cret|export_failed;file_missing.
+// Secret and types may be left empty
+func NotificationWebhooks(config *AppConfig) ([]Webhook, error) {
+	var webhooks []Webhook
+	for _, entry := range config.Notify.Webhooks {
+		fields := strings.Split(entry, "|")
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("webhook %q must have the form url|secret|types", entry)
+		}
+		fields = append(fields, "", "")
+		webhook := Webhook{
+			Url:    strings.TrimSpace(fields[0]),
+			Secret: fields[1],
+		}
+		if u, err := url.Parse(webhook.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook %q must have an http or https url", webhook.Url)
+		}
+		for eventType := range strings.SplitSeq(fields[2], ";") {
+			eventType = strings.ToLower(strings.TrimSpace(eventType))
+			if eventType == "" {
+				continue
+			}
+			if !slices.Contains(NotificationTypes, eventType) {
+				return nil, fmt.Errorf("webhook %q has unknown event type %q", webhook.Url, eventType)
+			}
+			webhook.Types = append(webhook.Types, eventType)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationWebhooksParsesEntries(t *testing.T) {
+	var cfg AppConfig
+	cfg.Notify.Webhooks = []string{"https://chat.example.org/hook|s3cret|Export_Failed; file_missing", "http://monitor:8080/events"}
+
+	webhooks, err := NotificationWebhooks(&cfg)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []Webhook{
+		{Url: "https://chat.example.org/hook", Secret: "s3cret", Types: []string{"export_failed", "file_missing"}},
+		{Url: "http://monitor:8080/events"},
+	}, webhooks)
+	assert.True(t, webhooks[0].Accepts("file_missing"))
+	assert.False(t, webhooks[0].Accepts("ffprobe_failed"))
+	assert.True(t, webhooks[1].Accepts("ffprobe_failed"))
+}
+
+func TestNotificationWebhooksInvalidEntriesReturnError(t *testing.T) {
+	tests := map[string]string{
+		"webhook \"a|b|c|d\" must have the form url|secret|types":            "a|b|c|d",
+		"webhook \"chat/hook\" must have an http or https url":               "chat/hook|s3cret|",
+		"webhook \"https://chat/hook\" has unknown event type \"disk_full\"": "https://chat/hook||disk_full",
+		"webhook \"ftp://chat/hook\" must have an http or https url":         "ftp://chat/hook",
+	}
+	for expected, entry := range tests {
+		var cfg AppConfig
+		cfg.Notify.Webhooks = []string{entry}
+
+		_, err := NotificationWebhooks(&cfg)
+
+		require.Error(t, err, entry)
+		assert.EqualValues(t, expected, err.Error())
+	}
+}
+
+func TestValidateConfigInvalidNotificationRetryReturnsError(t *testing.T) {
+	var cfg AppConfig
+	cfg.Server.GracefulShutdownTime = 10
+	cfg.Crawl.CrawlCycleMin = 10
+	cfg.Export.ExportMinute = 59
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Notify.Webhooks = []string{"https://chat/hook"}
+	cfg.Notify.QueueSize = 100
+
+	err := validateConfig(&cfg)
+
+	require.Error(t, err)
+	assert.EqualValues(t, "notification retry interval and queue size must be greater than 0", err.Error())
+}
@@ -0,0 +1,27 @@
+// package domain defines the core data structures
+package domain
+
+import "time"
+
+type AlarmKind string
+
+const (
+	AlarmDeadAir      AlarmKind = "dead_air"      // mAirList is silent during a preproduced event
+	AlarmLiveConflict AlarmKind = "live_conflict" // mAirList is still playing during a live event
+)
+
+// Alarm is a condition raised by the alarm evaluator. Cleared is zero while the alarm is active
+type Alarm struct {
+	Kind       AlarmKind `json:"kind"`
+	Message    string    `json:"message"`
+	EventId    string    `json:"event_id"`
+	EventTitle string    `json:"event_title"`
+	Since      time.Time `json:"since"`
+	Raised     time.Time `json:"raised"`
+	Cleared    time.Time `json:"cleared,omitzero"`
+}
+
+// Active checks whether the alarm hasn't been cleared yet
+func (a Alarm) Active() bool {
+	return a.Cleared.IsZero()
+}
@@ -0,0 +1,42 @@
+// package domain defines the core data structures
+package domain
+
+import "time"
+
+type AsRunStatus string
+
+const (
+	AsRunPlaying   AsRunStatus = "playing"
+	AsRunCompleted AsRunStatus = "completed" // played to the end
+	AsRunSkipped   AsRunStatus = "skipped"   // stopped before the end
+	AsRunUnknown   AsRunStatus = "unknown"   // the feeder wasn't running when the item stopped
+)
+
+// AsRunEntry records the playback of one item in mAirList
+type AsRunEntry struct {
+	Id       string
+	ItemId   string // mAirList's id of the playlist item, may be empty
+	Filename string
+	Title    string
+	Artist   string
+	Album    string
+	Genre    string
+	Year     string
+	Duration time.Duration
+	Started  time.Time
+	Stopped  time.Time
+	Status   AsRunStatus
+}
+
+// Day returns the date the entry is logged under, i.e. the date playback started
+func (e AsRunEntry) Day() string {
+	return e.Started.Format(FolderDateLayout)
+}
+
+// Played returns how long the item was played, zero while it is still playing
+func (e AsRunEntry) Played() time.Duration {
+	if e.Stopped.IsZero() {
+		return 0
+	}
+	return e.Stopped.Sub(e.Started).Round(time.Second)
+}
@@ -1,6 +1,8 @@
 // package domain defines the core data structures
 package domain
 
+import "time"
+
 // CalCmsPgmData is the data structure returned from calCms
 type CalCmsPgmData struct {
 	Archive     string `json:"archive"`
@@ -190,6 +192,7 @@ type CalCmsEvent struct {
 	StaticFilesURL       string `json:"static_files_url"`
 	Status               any    `json:"status"`
 	Stkey                string `json:"stkey"`
+	StudioName           string `json:"studio_name"` // filled from the response for events without own studio
 	ThumbURL             string `json:"thumb_url"`
 	TimeZone             string `json:"time_zone"`
 	Title                string `json:"title"`
@@ -219,3 +222,10 @@ type CalCmsEvent struct {
 	Last                 int    `json:"__last__,omitempty"`
 	Counter12            int    `json:"counter_12,omitempty"`
 }
+
+// CalCmsCacheEntry is the program of one day as last received from calCms, used while calCms is unreachable
+type CalCmsCacheEntry struct {
+	Date    string // YYYY-MM-DD
+	Fetched time.Time
+	Data    CalCmsPgmData // only the events starting on that day
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// EventRecord is the status of an event at the end of its day, kept in the event archive
+type EventRecord struct {
+	Date            string        `json:"date"` // YYYY-MM-DD
+	EventId         int           `json:"event_id"`
+	Title           string        `json:"title"`
+	Series          string        `json:"series"`
+	Start           time.Time     `json:"start"`
+	End             time.Time     `json:"end"`
+	Live            bool          `json:"live"`
+	Rerun           string        `json:"rerun,omitempty"`
+	PlannedDuration time.Duration `json:"planned_duration"`
+	ActualDuration  time.Duration `json:"actual_duration"`
+	FileStatus      string        `json:"file_status"`
+	FileSource      string        `json:"file_source"`
+	Path            string        `json:"path,omitempty"`
+	FileModified    time.Time     `json:"file_modified,omitzero"`
+	FileScanned     time.Time     `json:"file_scanned,omitzero"`
+	Exported        bool          `json:"exported"`
+	Producer        []string      `json:"producer,omitempty"` // addresses reminders for the event go to, resolved when archiving
+	Recorded        time.Time     `json:"recorded"`
+}
+
+const (
+	PunctualityOnTime  = "on-time"
+	PunctualityLate    = "late"
+	PunctualityMissing = "missing"
+	PunctualityUnknown = "unknown"
+)
+
+// HasFile returns true, if a file was present for the event
+func (r EventRecord) HasFile() bool {
+	return r.FileStatus != "Missing" && r.FileStatus != "N/A" && r.FileStatus != ""
+}
+
+// Uploaded returns the time the event's file was uploaded: its last modification or, if unknown, the time the crawler first saw it
+func (r EventRecord) Uploaded() time.Time {
+	if !r.FileModified.IsZero() {
+		return r.FileModified
+	}
+	return r.FileScanned
+}
+
+// UploadLead returns the time between the upload of the event's file and the start of the event.
+// It is negative for files uploaded after the start and unknown without file
+func (r EventRecord) UploadLead() (time.Duration, bool) {
+	uploaded := r.Uploaded()
+	if uploaded.IsZero() {
+		return 0, false
+	}
+	return r.Start.Sub(uploaded), true
+}
+
+// Punctuality classifies the upload of a preproduced event's file as on-time, if it was uploaded at least deadline before
+// the start of the event, late or missing. It is unknown for files without upload time and empty for live events
+func (r EventRecord) Punctuality(deadline time.Duration) string {
+	switch {
+	case r.Live:
+		return ""
+	case !r.HasFile():
+		return PunctualityMissing
+	}
+	lead, known := r.UploadLead()
+	switch {
+	case !known:
+		return PunctualityUnknown
+	case lead < deadline:
+		return PunctualityLate
+	default:
+		return PunctualityOnTime
+	}
+}
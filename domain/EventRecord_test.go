@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var recordStart = time.Date(2026, 10, 17, 20, 0, 0, 0, time.Local)
+
+func TestPunctualityClassifiesUploads(t *testing.T) {
+	tests := []struct {
+		name   string
+		record EventRecord
+		want   string
+	}{
+		{"on time", EventRecord{Start: recordStart, FileStatus: "Present", FileModified: recordStart.Add(-30 * time.Hour)}, PunctualityOnTime},
+		{"at the deadline", EventRecord{Start: recordStart, FileStatus: "Present", FileModified: recordStart.Add(-24 * time.Hour)}, PunctualityOnTime},
+		{"late", EventRecord{Start: recordStart, FileStatus: "Present", FileModified: recordStart.Add(-2 * time.Hour)}, PunctualityLate},
+		{"after air", EventRecord{Start: recordStart, FileStatus: "Present", FileModified: recordStart.Add(time.Hour)}, PunctualityLate},
+		{"scan time only", EventRecord{Start: recordStart, FileStatus: "Present", FileScanned: recordStart.Add(-25 * time.Hour)}, PunctualityOnTime},
+		{"missing", EventRecord{Start: recordStart, FileStatus: "Missing"}, PunctualityMissing},
+		{"unknown", EventRecord{Start: recordStart, FileStatus: "Present", FileSource: "History"}, PunctualityUnknown},
+		{"live", EventRecord{Start: recordStart, Live: true, FileStatus: "N/A"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualValues(t, tt.want, tt.record.Punctuality(24*time.Hour))
+		})
+	}
+}
+
+func TestUploadLeadPrefersModificationTime(t *testing.T) {
+	record := EventRecord{Start: recordStart, FileModified: recordStart.Add(-26 * time.Hour), FileScanned: recordStart.Add(-25 * time.Hour)}
+
+	lead, known := record.UploadLead()
+
+	assert.True(t, known)
+	assert.EqualValues(t, 26*time.Hour, lead)
+}
+
+func TestPunctualityOfShowAfterMidnightUsesModificationTime(t *testing.T) {
+	// the crawler picks up a day's folder late, so a file uploaded a week early may be seen for the first time shortly before air
+	start := time.Date(2026, 10, 18, 0, 30, 0, 0, time.Local)
+	record := EventRecord{Start: start, FileStatus: "Present", FileModified: start.AddDate(0, 0, -7), FileScanned: start.Add(-30 * time.Minute)}
+
+	assert.EqualValues(t, PunctualityOnTime, record.Punctuality(24*time.Hour))
+}
@@ -0,0 +1,17 @@
+// package domain defines the core data structures
+package domain
+
+import "time"
+
+// ExportVersion describes one stored version of a generated playlist
+type ExportVersion struct {
+	Slot       string // playlist file name without extension, e.g. 2026-10-19-20
+	Version    int
+	ExportPath string
+	CopyPath   string
+	Hash       string
+	Lines      int
+	Created    time.Time
+	Appended   bool
+	AppendedAt time.Time
+}
@@ -39,6 +39,7 @@ type FileInfo struct {
 	StreamName          string
 	Checksum            string
 	EventIsLive         bool
+	MatchConfidence     float64 // confidence of the title match that linked the file to its event, 0 for files named with the event id
 }
 
 type FileList []FileInfo
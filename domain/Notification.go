@@ -0,0 +1,26 @@
+// package domain defines the core data structures
+package domain
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationFileMissing       NotificationType = "file_missing"       // no file for a preproduced event starting within the next hour
+	NotificationExportFailed      NotificationType = "export_failed"      // writing or appending a playlist failed
+	NotificationCalCmsUnreachable NotificationType = "calcms_unreachable" // calCMS query failed after the previous one succeeded
+	NotificationFfprobeFailed     NotificationType = "ffprobe_failed"     // ffprobe could not analyze an audio file
+	NotificationFileRejected      NotificationType = "file_rejected"      // the length of a file doesn't match its slot
+	NotificationAlarmRaised       NotificationType = "alarm_raised"
+	NotificationAlarmCleared      NotificationType = "alarm_cleared"
+	NotificationScheduleChanged   NotificationType = "schedule_changed" // the schedule changed for an hour that was already exported
+)
+
+// Notification is an event emitted by the services and delivered to the configured webhooks
+type Notification struct {
+	Id      string            `json:"id"`
+	Type    NotificationType  `json:"type"`
+	Time    time.Time         `json:"time"`
+	Message string            `json:"message"`
+	Data    map[string]string `json:"data,omitempty"`
+}
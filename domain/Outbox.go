@@ -0,0 +1,32 @@
+// package domain defines the core data structures
+package domain
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending" // waiting for the next attempt
+	OutboxFailed  OutboxStatus = "failed"  // deadline passed without a successful delivery
+)
+
+// OutboxEntry is a playlist append to mAirList that failed and is retried until its deadline
+type OutboxEntry struct {
+	Id          string
+	Slot        string // playlist file name without extension, e.g. 2026-10-19-20; there is at most one entry per slot
+	FileName    string
+	SlotStart   time.Time
+	Deadline    time.Time
+	Created     time.Time
+	Attempts    int
+	LastAttempt time.Time
+	NextAttempt time.Time
+	LastError   string
+	Delivered   []string // mAirList instances the playlist has been appended to, they are skipped on retries
+	Status      OutboxStatus
+}
+
+// Due checks whether the entry should be retried at the given time
+func (e OutboxEntry) Due(now time.Time) bool {
+	return e.Status == OutboxPending && !now.Before(e.NextAttempt)
+}
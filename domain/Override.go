@@ -0,0 +1,90 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type OverrideType string
+
+const (
+	OverridePin     OverrideType = "pin"     // always export a file in a given slot
+	OverrideExclude OverrideType = "exclude" // never export a file
+	OverrideReplace OverrideType = "replace" // export a different file for an event
+	OverrideAccept  OverrideType = "accept"  // export a file even if it failed the length check
+)
+
+// Override is a manual decision by an operator that takes precedence over the automatic selection of files for export
+type Override struct {
+	Id         string
+	Type       OverrideType
+	Path       string    // file pinned, excluded, accepted or used as replacement
+	FolderDate time.Time // date the override applies to, zero for all dates (exclude and accept only)
+	StartTime  string    // slot in HH:MM format (pin only)
+	EventId    int       // event whose file is replaced (replace only)
+	Note       string
+	Created    time.Time
+}
+
+// OverrideAudit records the creation or deletion of an override
+type OverrideAudit struct {
+	Time     time.Time
+	Action   string
+	Note     string
+	Override Override
+}
+
+// Validate checks whether an override contains all information required for its type
+func (o Override) Validate() error {
+	if strings.TrimSpace(o.Path) == "" {
+		return errors.New("path must be given")
+	}
+	if strings.TrimSpace(o.Note) == "" {
+		return errors.New("note must be given")
+	}
+	switch o.Type {
+	case OverrideExclude, OverrideAccept:
+		return nil
+	case OverridePin:
+		if o.FolderDate.IsZero() {
+			return errors.New("date must be given to pin a file")
+		}
+		if _, err := time.Parse("15:04", o.StartTime); err != nil || len(o.StartTime) != 5 {
+			return errors.New("start time must have the format HH:MM")
+		}
+		return nil
+	case OverrideReplace:
+		if o.FolderDate.IsZero() {
+			return errors.New("date must be given to replace a file")
+		}
+		if o.EventId <= 0 {
+			return errors.New("event id must be given to replace a file")
+		}
+		return nil
+	default:
+		return errors.New("unknown override type")
+	}
+}
+
+// AppliesToDate returns true, if the override is valid for the given folder date
+func (o Override) AppliesToDate(folderDate time.Time) bool {
+	return o.FolderDate.IsZero() || NormalizeDate(o.FolderDate).Equal(NormalizeDate(folderDate))
+}
+
+// Describe returns a short description of the override for display purposes
+func (o Override) Describe() string {
+	var desc string
+	switch o.Type {
+	case OverridePin:
+		desc = "Pinned to " + FormatFolderDate(o.FolderDate) + " " + o.StartTime
+	case OverrideExclude:
+		desc = "Excluded"
+	case OverrideReplace:
+		desc = "Replaces file of event"
+	case OverrideAccept:
+		desc = "Length accepted"
+	}
+	return desc + " (" + o.Note + ")"
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideValidate(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		override Override
+		err      string
+	}{
+		{"missing path", Override{Type: OverrideExclude, Note: "note"}, "path must be given"},
+		{"missing note", Override{Type: OverrideExclude, Path: "A.mp3"}, "note must be given"},
+		{"pin without date", Override{Type: OverridePin, Path: "A.mp3", StartTime: "20:00", Note: "note"}, "date must be given to pin a file"},
+		{"pin with invalid time", Override{Type: OverridePin, Path: "A.mp3", FolderDate: date, StartTime: "8:00", Note: "note"}, "start time must have the format HH:MM"},
+		{"replace without event", Override{Type: OverrideReplace, Path: "A.mp3", FolderDate: date, Note: "note"}, "event id must be given to replace a file"},
+		{"unknown type", Override{Type: "move", Path: "A.mp3", Note: "note"}, "unknown override type"},
+		{"valid pin", Override{Type: OverridePin, Path: "A.mp3", FolderDate: date, StartTime: "20:00", Note: "note"}, ""},
+		{"valid accept", Override{Type: OverrideAccept, Path: "A.mp3", Note: "note"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.override.Validate()
+			if tt.err == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualValues(t, tt.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestOverrideAppliesToDate(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	assert.True(t, Override{}.AppliesToDate(date))
+	assert.True(t, Override{FolderDate: date}.AppliesToDate(date.Add(5*time.Hour)))
+	assert.False(t, Override{FolderDate: date}.AppliesToDate(date.AddDate(0, 0, 1)))
+}
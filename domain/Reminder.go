@@ -0,0 +1,28 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reminder is an email sent to the producers of an event whose file wasn't uploaded in time
+type Reminder struct {
+	EventId   int
+	Title     string
+	Date      string // event date, YYYY-MM-DD
+	Start     string // event start time, HH:MM
+	LeadHours int    // lead time before air that triggered the reminder
+	To        []string
+	Sent      time.Time
+}
+
+// Key identifies a reminder, an event gets each lead time's reminder only once
+func (r Reminder) Key() string {
+	return ReminderKey(r.EventId, r.Date, r.LeadHours)
+}
+
+// ReminderKey builds the key of the reminder for an event on a date at a lead time
+func ReminderKey(eventId int, date string, leadHours int) string {
+	return fmt.Sprintf("%d@%v@%dh", eventId, date, leadHours)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventFile records the audio file broadcast for an event. Reruns of the event are scheduled with this file
+type EventFile struct {
+	EventId  int
+	Path     string
+	Duration time.Duration
+	Date     string // date of the broadcast, YYYY-MM-DD
+	Title    string
+	Recorded time.Time
+}
+
+// RerunOf returns the id of the original event, if the event is a rerun. calCms flags reruns with rerun and
+// refers to the original event with recurrence, older versions with reference
+func (e CalCmsEvent) RerunOf() (int, bool) {
+	if flag, ok := anyInt(e.Rerun); !ok || flag == 0 {
+		return 0, false
+	}
+	if e.Recurrence > 0 && e.Recurrence != e.EventID {
+		return e.Recurrence, true
+	}
+	if id, ok := anyInt(e.Reference); ok && id > 0 && id != e.EventID {
+		return id, true
+	}
+	return 0, false
+}
+
+// anyInt converts the loosely typed values calCms delivers (numbers, numeric strings or booleans) into an int
+func anyInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
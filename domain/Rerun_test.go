@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRerunOfReturnsRecurrence(t *testing.T) {
+	event := CalCmsEvent{EventID: 20, Rerun: float64(1), Recurrence: 10}
+
+	id, ok := event.RerunOf()
+
+	assert.True(t, ok)
+	assert.EqualValues(t, 10, id)
+}
+
+func TestRerunOfFallsBackToReference(t *testing.T) {
+	event := CalCmsEvent{EventID: 20, Rerun: "1", Reference: "10"}
+
+	id, ok := event.RerunOf()
+
+	assert.True(t, ok)
+	assert.EqualValues(t, 10, id)
+}
+
+func TestRerunOfNoRerunReturnsFalse(t *testing.T) {
+	for _, rerun := range []any{nil, "", "0", float64(0), false} {
+		event := CalCmsEvent{EventID: 20, Rerun: rerun, Recurrence: 10}
+
+		_, ok := event.RerunOf()
+
+		assert.False(t, ok)
+	}
+}
+
+func TestRerunOfWithoutOriginalReturnsFalse(t *testing.T) {
+	event := CalCmsEvent{EventID: 20, Rerun: true, Reference: nil}
+
+	_, ok := event.RerunOf()
+
+	assert.False(t, ok)
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// ScheduleEvent is an event of the broadcast schedule with the information every schedule provider delivers
+type ScheduleEvent struct {
+	Id    int
+	Title string
+	Start time.Time
+	End   time.Time
+	Live  bool
+	// CalCms holds the full event for events read from calCms, it is nil for all other providers
+	CalCms *CalCmsEvent
+}
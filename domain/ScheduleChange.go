@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+type ScheduleChangeType string
+
+const (
+	ScheduleChangeAdded       ScheduleChangeType = "added"
+	ScheduleChangeRemoved     ScheduleChangeType = "removed"
+	ScheduleChangeMoved       ScheduleChangeType = "moved" // start or end time changed
+	ScheduleChangeRetitled    ScheduleChangeType = "retitled"
+	ScheduleChangeLiveChanged ScheduleChangeType = "live_changed"
+)
+
+// ScheduleChange is a difference between two consecutive schedules received from calCms
+type ScheduleChange struct {
+	Id       int                `json:"id"`
+	Detected time.Time          `json:"detected"`
+	Type     ScheduleChangeType `json:"type"`
+	EventId  int                `json:"event_id"`
+	Title    string             `json:"title"`
+	Old      string             `json:"old,omitempty"` // previous time, title or live flag
+	New      string             `json:"new,omitempty"`
+	Slots    []ScheduleSlot     `json:"slots"` // hours affected by the change
+}
+
+// ScheduleSlot is an hour of a day affected by a schedule change
+type ScheduleSlot struct {
+	Date     string `json:"date"` // YYYY-MM-DD
+	Hour     string `json:"hour"` // HH
+	Exported bool   `json:"exported"`
+}
+
+// Exported checks whether the change affects an hour that was already exported
+func (c ScheduleChange) Exported() bool {
+	for _, slot := range c.Slots {
+		if slot.Exported {
+			return true
+		}
+	}
+	return false
+}
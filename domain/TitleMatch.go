@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+type TitleMatchStatus string
+
+const (
+	TitleMatchAccepted  TitleMatchStatus = "accepted"  // confidence high enough to link the file automatically
+	TitleMatchSuggested TitleMatchStatus = "suggested" // waiting for confirmation in the web UI
+	TitleMatchConfirmed TitleMatchStatus = "confirmed" // confirmed by an operator
+	TitleMatchRejected  TitleMatchStatus = "rejected"  // rejected by an operator, not suggested again
+)
+
+// TitleMatch links a file without an event id in its name to the event whose start time and title fit the file best
+type TitleMatch struct {
+	Path       string
+	FolderDate time.Time
+	FileStart  time.Time
+	EventId    int
+	EventTitle string
+	EventStart time.Time
+	Confidence float64 // 0..1, combining title and time similarity
+	Status     TitleMatchStatus
+	Updated    time.Time
+}
+
+// ConfidencePercent returns the confidence rounded to whole percent for display
+func (m TitleMatch) ConfidencePercent() int {
+	return int(math.Round(m.Confidence * 100))
+}
@@ -2,6 +2,7 @@
 package dto
 
 import (
+	"fmt"
 	"sort"
 	"strconv"
 	"strings"
@@ -50,14 +51,24 @@ type ConfigResp struct {
 	LastCalCmsState            string
 	LastCalCmsRefreshDate      string
 	LastCalCmsRefreshError     string
+	CalCmsDataSource           string
+	CalCmsDataAge              string
 	LastMairListCommState      string
 	ExportDayEvents            string
 	MairListPlayingState       string
+	MairListTargets            string
 	LogFile                    string
 	QueryMairListStatus        string
 	ExportLiveItems            string
 	AddNonCalCmsFiles          string
 	ExportMinute               string
+	FillGaps                   string
+	FillerPools                string
+	DayPlaylistFolder          string
+	DayPlaylistCron            string
+	LastDayExportDate          string
+	LastDayExportFileName      string
+	NextDayExportDate          string
 }
 
 // setStartDate sets the service start date and adds the run duration
@@ -67,6 +78,25 @@ func setStartDate(date time.Time) string {
 }
 
 // convertDate converts a date to its display format
+// formatCalCmsDataSource tells whether the calCms program in use was received from calCms or read from the cache
+func formatCalCmsDataSource(runtime appstate.RuntimeSnapshot) string {
+	if runtime.CalCmsDataFetched.IsZero() {
+		return "N/A"
+	}
+	if runtime.CalCmsDataCached {
+		return "Cache (calCMS unreachable)"
+	}
+	return "calCMS"
+}
+
+// formatCalCmsDataAge returns the age of the calCms program in use, rounded to minutes
+func formatCalCmsDataAge(fetched time.Time, now time.Time) string {
+	if fetched.IsZero() {
+		return "N/A"
+	}
+	return now.Sub(fetched).Round(time.Minute).String()
+}
+
 func convertDate(date time.Time) string {
 	return convertDateInLocation(date, time.Local)
 }
@@ -107,6 +137,35 @@ func formatLogFile(logFile string) string {
 	return logFile
 }
 
+// formatDayPlaylistFolder returns the folder day playlists are written to, falling back to the export folder
+func formatDayPlaylistFolder(cfg *config.AppConfig) string {
+	if cfg.Export.DayPlaylistFolder == "" {
+		return cfg.Export.ExportFolder
+	}
+	return cfg.Export.DayPlaylistFolder
+}
+
+// formatMairListTargets lists the mAirList instances with their role and the outcome of the last request, marking the active instance
+func formatMairListTargets(cfg *config.AppConfig, runtime appstate.RuntimeSnapshot) string {
+	targets, err := config.MairListTargets(cfg)
+	if err != nil {
+		return err.Error()
+	}
+	var formatted []string
+	for _, target := range targets {
+		role := target.Role
+		if target.Name == runtime.MairListActiveTarget {
+			role += ", active"
+		}
+		state, ok := runtime.MairListTargetStates[target.Name]
+		if !ok {
+			state = "N/A"
+		}
+		formatted = append(formatted, fmt.Sprintf("%v (%v): %v", target.Name, role, state))
+	}
+	return strings.Join(formatted, "; ")
+}
+
 // GetConfig converts the configuration to its display format
 func GetConfig(cfg *config.AppConfig, state *appstate.AppState) (resp ConfigResp) {
 	runtime := state.Runtime.Snapshot()
@@ -137,14 +196,22 @@ func GetConfig(cfg *config.AppConfig, state *appstate.AppState) (resp ConfigResp
 		LastCalCmsState:            runtime.LastCalCmsState,
 		LastCalCmsRefreshDate:      convertDate(runtime.LastCalCmsRefreshDate),
 		LastCalCmsRefreshError:     formatEmpty(runtime.LastCalCmsRefreshErr),
+		CalCmsDataSource:           formatCalCmsDataSource(runtime),
+		CalCmsDataAge:              formatCalCmsDataAge(runtime.CalCmsDataFetched, time.Now()),
 		LastMairListCommState:      runtime.LastMairListCommState,
 		ExportDayEvents:            strconv.FormatBool(cfg.CalCms.ExportDayEvents),
 		MairListPlayingState:       strconv.FormatBool(runtime.MairListPlaying),
+		MairListTargets:            formatMairListTargets(cfg, runtime),
 		LogFile:                    formatLogFile(cfg.Server.LogFile),
 		QueryMairListStatus:        strconv.FormatBool(cfg.Export.QueryMairListStatus),
 		ExportLiveItems:            strconv.FormatBool(cfg.Export.ExportLiveItems),
 		AddNonCalCmsFiles:          strconv.FormatBool(cfg.Crawl.AddNonCalCmsFiles),
 		ExportMinute:               strconv.Itoa(cfg.Export.ExportMinute),
+		FillGaps:                   strconv.FormatBool(cfg.Filler.FillGaps),
+		FillerPools:                strings.Join(cfg.Filler.Pools, ", "),
+		DayPlaylistFolder:          formatDayPlaylistFolder(cfg),
+		DayPlaylistCron:            formatEmpty(cfg.Export.DayPlaylistCron),
+		LastDayExportFileName:      formatEmpty(runtime.LastDayExportFileName),
 	}
 	resp.LastCrawlDate = convertDate(runtime.LastCrawlDate)
 	resp.LastExportDate = convertDate(runtime.LastExportRunDate)
@@ -162,6 +229,8 @@ func GetConfig(cfg *config.AppConfig, state *appstate.AppState) (resp ConfigResp
 	resp.NextCrawlDate = getNextJobDate(runtime.BgJobs, runtime.CrawlJobID)
 	resp.NextCleanDate = getNextJobDate(runtime.BgJobs, runtime.CleanJobID)
 	resp.NextExportDate = getNextJobDate(runtime.BgJobs, runtime.ExportJobID)
+	resp.NextDayExportDate = getNextJobDate(runtime.BgJobs, runtime.DayExportJobID)
+	resp.LastDayExportDate = convertDate(runtime.LastDayExportDate)
 	resp.StreamFileMapping = getStreamMappings(cfg.Crawl.StreamMap)
 	return
 }
@@ -15,4 +15,6 @@ type Event struct {
 	FileStatus      string `json:"file_status"`
 	FileSource      string `json:"file_source"`
 	FileAvail       string `json:"file_avail"`
+	Override        string `json:"override"`
+	Rerun           string `json:"rerun"`
 }
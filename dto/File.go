@@ -29,6 +29,7 @@ type FileResp struct {
 	EventLinkAvail bool
 	CalCmsInfo     string
 	TechMd         string
+	Override       string
 }
 
 // FileCounts structure to list counts of file types
@@ -73,6 +74,14 @@ func GetFilesForDate(repo repositories.FileRepository, CmsUrl string, folderDate
 	return
 }
 
+// GetFilesForDates returns the files of the folder dates, ordered by date and start time
+func GetFilesForDates(repo repositories.FileRepository, CmsUrl string, folderDates []time.Time) (fileDta []FileResp) {
+	for _, folderDate := range folderDates {
+		fileDta = append(fileDta, GetFilesForDate(repo, CmsUrl, folderDate)...)
+	}
+	return
+}
+
 func formatFiles(files domain.FileList, CmsUrl string) (fileDta []FileResp) {
 	for _, file := range files {
 		dta := FileResp{
@@ -123,6 +132,9 @@ func buildCalCmsInfo(file domain.FileInfo) (info string) {
 	} else {
 		info = info + "None"
 	}
+	if file.MatchConfidence > 0 {
+		info = info + fmt.Sprintf(" (title match %v%%)", math.Round(file.MatchConfidence*100))
+	}
 	return
 }
 
@@ -146,8 +158,11 @@ func buildTechMd(file domain.FileInfo) (info string) {
 	return
 }
 
-// buildEventIdLink returns a link to a calCms event
+// buildEventIdLink returns a link to a calCms event, or an empty string if there is no calCms to link to
 func buildEventIdLink(CmsUrl string, eventId int) string {
+	if CmsUrl == "" {
+		return ""
+	}
 	// https://programm.coloradio.org/agenda/events.cgi?event_id=xxxxx
 	idStr := strconv.Itoa(eventId)
 	return CmsUrl + "?event_id=" + idStr
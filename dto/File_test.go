@@ -157,6 +157,17 @@ func TestBuildCalCmsInfoReturnsInfo2(t *testing.T) {
 	assert.EqualValues(t, "No, No, None", s)
 }
 
+func TestBuildCalCmsInfoTitleMatchReturnsConfidence(t *testing.T) {
+	fi1 := domain.FileInfo{
+		FromCalCMS:          true,
+		CalCmsInfoExtracted: true,
+		CalCmsTitle:         "myTitle",
+		MatchConfidence:     0.85,
+	}
+	s := buildCalCmsInfo(fi1)
+	assert.EqualValues(t, "Yes, Yes, \"myTitle\" (title match 85%)", s)
+}
+
 func TestBuildTechMdDefault(t *testing.T) {
 	info := buildTechMd(fis)
 	assert.EqualValues(t, "N/A", info)
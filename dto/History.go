@@ -0,0 +1,139 @@
+package dto
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+)
+
+// HistoryEvent is an archived event shown in the history view
+type HistoryEvent struct {
+	Date            string `json:"date"`
+	EventId         string `json:"event_id"`
+	Title           string `json:"title"`
+	Series          string `json:"series"`
+	StartTime       string `json:"start_time"`
+	EndTime         string `json:"end_time"`
+	EventType       string `json:"event_type"`
+	PlannedDuration string `json:"planned_duration"`
+	ActualDuration  string `json:"actual_duration"`
+	FileStatus      string `json:"file_status"`
+	FileSource      string `json:"file_source"`
+	Path            string `json:"path"`
+	Uploaded        string `json:"uploaded"`
+	UploadLead      string `json:"upload_lead"`
+	Punctuality     string `json:"punctuality"`
+	Exported        string `json:"exported"`
+	Rerun           string `json:"rerun"`
+}
+
+// SeriesStats sums up the archived events of a series. AvgLeadHours is the average time between upload and air
+type SeriesStats struct {
+	Series       string `json:"series"`
+	Events       int    `json:"events"`
+	Live         int    `json:"live"`
+	Preproduced  int    `json:"preproduced"`
+	WithFile     int    `json:"with_file"`
+	Missing      int    `json:"missing"`
+	Exported     int    `json:"exported"`
+	AvgLeadHours string `json:"avg_lead_hours"`
+}
+
+// PunctualityCount counts the preproduced events by the punctuality of their upload
+type PunctualityCount struct {
+	Events  int `json:"events"`
+	OnTime  int `json:"on_time"`
+	Late    int `json:"late"`
+	Missing int `json:"missing"`
+	Unknown int `json:"unknown"`
+}
+
+// PunctualityRow sums up the punctuality of a series or producer, in total and per week of the report
+type PunctualityRow struct {
+	Name         string             `json:"name"`
+	Total        PunctualityCount   `json:"total"`
+	AvgLeadHours string             `json:"avg_lead_hours"`
+	Weeks        []PunctualityCount `json:"weeks"`
+}
+
+// PunctualityReport shows the punctuality of the uploads of a date range by series or producer.
+// Late lists the late and missing uploads for the follow-up
+type PunctualityReport struct {
+	From          string           `json:"from"`
+	To            string           `json:"to"`
+	By            string           `json:"by"`
+	DeadlineHours int              `json:"deadline_hours"`
+	Weeks         []string         `json:"weeks"`
+	Rows          []PunctualityRow `json:"rows"`
+	Total         PunctualityRow   `json:"total"`
+	Late          []HistoryEvent   `json:"late"`
+}
+
+// Add counts an event classified with the punctuality
+func (c *PunctualityCount) Add(punctuality string) {
+	c.Events++
+	switch punctuality {
+	case domain.PunctualityOnTime:
+		c.OnTime++
+	case domain.PunctualityLate:
+		c.Late++
+	case domain.PunctualityMissing:
+		c.Missing++
+	default:
+		c.Unknown++
+	}
+}
+
+// OnTimeRate returns the share of on-time uploads among the classified events in percent, "N/A" without events
+func (c PunctualityCount) OnTimeRate() string {
+	classified := c.OnTime + c.Late + c.Missing
+	if classified == 0 {
+		return "N/A"
+	}
+	return strconv.FormatFloat(math.Round(float64(c.OnTime)*1000/float64(classified))/10, 'f', 1, 64) + " %"
+}
+
+// NewHistoryEvent converts an archived event for display. Durations are shown in minutes, the upload lead in hours.
+// The upload is classified with the given deadline before air
+func NewHistoryEvent(record domain.EventRecord, deadline time.Duration) HistoryEvent {
+	event := HistoryEvent{
+		Date:            record.Date,
+		EventId:         strconv.Itoa(record.EventId),
+		Title:           record.Title,
+		Series:          record.Series,
+		StartTime:       record.Start.Format("15:04"),
+		EndTime:         record.End.Format("15:04"),
+		EventType:       "Preproduction",
+		PlannedDuration: formatMinutes(record.PlannedDuration),
+		ActualDuration:  "N/A",
+		FileStatus:      record.FileStatus,
+		FileSource:      record.FileSource,
+		Path:            record.Path,
+		UploadLead:      "N/A",
+		Exported:        "No",
+		Rerun:           record.Rerun,
+		Punctuality:     record.Punctuality(deadline),
+	}
+	if record.Live {
+		event.EventType = "Live"
+	}
+	if record.ActualDuration > 0 {
+		event.ActualDuration = formatMinutes(record.ActualDuration)
+	}
+	if uploaded := record.Uploaded(); !uploaded.IsZero() {
+		event.Uploaded = uploaded.Format("2006-01-02 15:04")
+	}
+	if lead, known := record.UploadLead(); known {
+		event.UploadLead = strconv.FormatFloat(math.Round(lead.Hours()*10)/10, 'f', 1, 64)
+	}
+	if record.Exported {
+		event.Exported = "Yes"
+	}
+	return event
+}
+
+func formatMinutes(d time.Duration) string {
+	return strconv.FormatFloat(math.Round(d.Minutes()), 'f', 1, 64)
+}
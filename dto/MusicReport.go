@@ -0,0 +1,18 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import "time"
+
+// MusicReportRow aggregates the plays of one music title over the reporting period
+type MusicReportRow struct {
+	Artist      string        `json:"artist"`
+	Title       string        `json:"title"`
+	Album       string        `json:"album"`
+	Genre       string        `json:"genre"`
+	Year        string        `json:"year"`
+	File        string        `json:"file"`
+	Plays       int           `json:"plays"`
+	Duration    time.Duration `json:"duration"`
+	FirstPlayed time.Time     `json:"first_played"`
+	LastPlayed  time.Time     `json:"last_played"`
+}
@@ -0,0 +1,18 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import "time"
+
+// NowPlaying is what is on air right now, as published to the now-playing sinks and available in the text templates
+type NowPlaying struct {
+	Text        string    `json:"text"` // rendered from NOW_PLAYING_TEMPLATE
+	Title       string    `json:"title"`
+	Artist      string    `json:"artist"`
+	Album       string    `json:"album"`
+	Show        string    `json:"show"`
+	ShowId      string    `json:"show_id"`
+	File        string    `json:"file"`
+	Started     time.Time `json:"started"`
+	DurationSec int       `json:"duration_sec"`
+	Updated     time.Time `json:"updated"`
+}
@@ -0,0 +1,21 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+// PreviewFile defines the data displayed per file in an export preview
+type PreviewFile struct {
+	StartTime  string `json:"start_time"`
+	Path       string `json:"path"`
+	Duration   string `json:"duration"`
+	SlotLength string `json:"slot_length"`
+	EventId    string `json:"event_id"`
+	Info       string `json:"info"`
+}
+
+// HourPreview is the result of a dry-run export for one hour
+type HourPreview struct {
+	Date     string        `json:"date"`
+	Hour     string        `json:"hour"`
+	Planned  []PreviewFile `json:"planned"`
+	Rejected []PreviewFile `json:"rejected"`
+	Playlist string        `json:"playlist"`
+}
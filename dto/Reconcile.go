@@ -0,0 +1,23 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+// ReconcileItem is an exported item that doesn't match mAirList's playlist
+type ReconcileItem struct {
+	Slot      string `json:"slot"`
+	StartTime string `json:"start_time"`
+	Path      string `json:"path"`
+	Position  int    `json:"position"` // position in mAirList's playlist, -1 if not present
+}
+
+// ReconcileReport is the result of comparing mAirList's playlist with the exported playlists of the coming hours
+type ReconcileReport struct {
+	Checked    string          `json:"checked"`
+	Slots      []string        `json:"slots"`
+	Expected   int             `json:"expected"`
+	Matched    int             `json:"matched"`
+	Missing    []ReconcileItem `json:"missing"`
+	Extra      []ReconcileItem `json:"extra"`
+	OutOfOrder []ReconcileItem `json:"out_of_order"`
+	Reappended []ReconcileItem `json:"reappended"`
+	Error      string          `json:"error,omitempty"`
+}
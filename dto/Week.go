@@ -0,0 +1,34 @@
+package dto
+
+// WeekEvent is an event shown in the week overview. Status is ok, missing, warning or live and determines its colour.
+// Continued is set in the hours after the one the event starts in
+type WeekEvent struct {
+	EventId    string `json:"event_id"`
+	Title      string `json:"title"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	FileStatus string `json:"file_status"`
+	Status     string `json:"status"`
+	Continued  bool   `json:"continued"`
+}
+
+// WeekDay is a column of the week overview
+type WeekDay struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// WeekRow is an hour of the week overview with the events on air in it, one cell per day
+type WeekRow struct {
+	Hour  string        `json:"hour"`
+	Cells [][]WeekEvent `json:"cells"`
+}
+
+// WeekOverview shows the events of consecutive days by hour
+type WeekOverview struct {
+	From     string    `json:"from"`
+	Previous string    `json:"previous"`
+	Next     string    `json:"next"`
+	Days     []WeekDay `json:"days"`
+	Rows     []WeekRow `json:"rows"`
+}
@@ -2,17 +2,27 @@
 package dto
 
 // MairListRequest describes the request sent to mAirList
-// There are 3 requests: Get On-Air Status, Append Playlist, Get Current Playlist
-// ReqType = onair, appendpl, getpl
-// For Append Playlist, a file name needs to be passed
+// ReqType = appendpl, getpl, insert, clearafter, playerstart, playerstop, automationon, automationoff, command
+// For Append Playlist and Insert, a file name needs to be passed. Insert and Clear After need a position,
+// player commands the player ("A", "B", ...) and Command the command to execute
 type MairListRequestType string
 
 const (
 	MairListRequestAppendPlaylist MairListRequestType = "appendpl"
 	MairListRequestGetPlaylist    MairListRequestType = "getpl"
+	MairListRequestInsert         MairListRequestType = "insert"
+	MairListRequestClearAfter     MairListRequestType = "clearafter"
+	MairListRequestPlayerStart    MairListRequestType = "playerstart"
+	MairListRequestPlayerStop     MairListRequestType = "playerstop"
+	MairListRequestAutomationOn   MairListRequestType = "automationon"
+	MairListRequestAutomationOff  MairListRequestType = "automationoff"
+	MairListRequestCommand        MairListRequestType = "command"
 )
 
 type MairListRequest struct {
 	ReqType  MairListRequestType
 	FileName string
+	Position int
+	Player   string
+	Command  string
 }
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+)
+
+type AlarmHandler struct {
+	Alarms alarmReporter
+}
+
+type alarmReporter interface {
+	ActiveAlarms() []domain.Alarm
+	RecentAlarms() []domain.Alarm
+}
+
+// NewAlarmHandler creates a new handler for the alarm pages and injects its dependencies
+func NewAlarmHandler(alarms alarmReporter) AlarmHandler {
+	return AlarmHandler{
+		Alarms: alarms,
+	}
+}
+
+// AlarmsPage is the handler for the page showing the active and the recently cleared alarms
+func (ah *AlarmHandler) AlarmsPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "alarms.page.tmpl", gin.H{
+		"title":  "Alarms",
+		"active": ah.Alarms.ActiveAlarms(),
+		"recent": ah.Alarms.RecentAlarms(),
+	})
+}
+
+// AlarmsJson is the handler returning the active alarms as JSON, used by the alarm banner shown on every page
+func (ah *AlarmHandler) AlarmsJson(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"active": ah.Alarms.ActiveAlarms(),
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAlarms struct {
+	active []domain.Alarm
+	recent []domain.Alarm
+}
+
+func (f fakeAlarms) ActiveAlarms() []domain.Alarm {
+	return f.active
+}
+
+func (f fakeAlarms) RecentAlarms() []domain.Alarm {
+	return f.recent
+}
+
+func setupAlarmUiTest() {
+	since := time.Date(2026, 10, 19, 20, 1, 0, 0, time.Local)
+	alarmHandler := NewAlarmHandler(fakeAlarms{
+		active: []domain.Alarm{{Kind: domain.AlarmDeadAir, Message: "mAirList is not playing during Evening Show", EventId: "1", EventTitle: "Evening Show", Since: since, Raised: since.Add(30 * time.Second)}},
+		recent: []domain.Alarm{{Kind: domain.AlarmLiveConflict, Message: "mAirList is still playing during live event Live Talk", EventId: "2", EventTitle: "Live Talk", Since: since, Raised: since, Cleared: since.Add(time.Minute)}},
+	})
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/alarms", alarmHandler.AlarmsPage)
+	router.GET("/alarms/json", alarmHandler.AlarmsJson)
+	recorder = httptest.NewRecorder()
+}
+
+func TestAlarmsPageShowsActiveAndRecentAlarms(t *testing.T) {
+	setupAlarmUiTest()
+
+	data, res := getAsRun("/alarms")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "<title>Alarms</title>")
+	assert.Contains(t, data, "mAirList is not playing during Evening Show")
+	assert.Contains(t, data, "mAirList is still playing during live event Live Talk")
+	assert.Contains(t, data, "alarm-banner")
+}
+
+func TestAlarmsJsonReturnsActiveAlarms(t *testing.T) {
+	setupAlarmUiTest()
+
+	data, res := getAsRun("/alarms/json")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, `"kind":"dead_air"`)
+	assert.NotContains(t, data, "live_conflict")
+	assert.NotContains(t, data, `"cleared"`)
+}
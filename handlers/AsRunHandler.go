@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type AsRunHandler struct {
+	AsRun repositories.AsRunRepository
+	Now   func() time.Time
+}
+
+const (
+	asRunTimeFormat = "2006-01-02 15:04:05"
+)
+
+// NewAsRunHandler creates a new handler for the as-run log pages and injects its dependencies
+func NewAsRunHandler(asRun repositories.AsRunRepository) AsRunHandler {
+	return AsRunHandler{
+		AsRun: asRun,
+		Now:   time.Now,
+	}
+}
+
+// AsRunPage is the handler for the page showing the as-run log of a day, today if no date is given
+func (ah *AsRunHandler) AsRunPage(c *gin.Context) {
+	date, entries, ok := ah.selectedDay(c)
+	if !ok {
+		return
+	}
+	c.HTML(http.StatusOK, "asrun.page.tmpl", gin.H{
+		"title":   "As-Run Log",
+		"date":    domain.FormatFolderDate(date),
+		"dates":   ah.AsRun.GetDates(),
+		"entries": entries,
+	})
+}
+
+// AsRunCsv is the handler returning the as-run log of a day as CSV file
+func (ah *AsRunHandler) AsRunCsv(c *gin.Context) {
+	date, entries, ok := ah.selectedDay(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"asrun-%v.csv\"", domain.FormatFolderDate(date)))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"Started", "Stopped", "Played", "Duration", "Status", "Title", "Artist", "File", "Item Id"})
+	for _, entry := range entries {
+		stopped := ""
+		if !entry.Stopped.IsZero() {
+			stopped = entry.Stopped.Format(asRunTimeFormat)
+		}
+		w.Write([]string{
+			entry.Started.Format(asRunTimeFormat),
+			stopped,
+			entry.Played().String(),
+			entry.Duration.Round(time.Second).String(),
+			string(entry.Status),
+			entry.Title,
+			entry.Artist,
+			entry.Filename,
+			entry.ItemId,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logger.Error("Error writing as-run log as CSV", err)
+	}
+}
+
+// selectedDay reads the day selected by the user and its entries. Writes the error response and returns false on failure
+func (ah *AsRunHandler) selectedDay(c *gin.Context) (time.Time, []domain.AsRunEntry, bool) {
+	date := domain.NormalizeDate(ah.Now())
+	if value := c.Query("date"); value != "" {
+		parsed, err := domain.ParseFolderDate(value)
+		if err != nil {
+			apiErr := api_error.NewBadRequestError("invalid date, use YYYY-MM-DD")
+			c.JSON(apiErr.StatusCode(), apiErr)
+			return time.Time{}, nil, false
+		}
+		date = parsed
+	}
+	entries, err := ah.AsRun.GetByDate(date)
+	if err != nil {
+		logger.Error("Error reading as-run log", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return time.Time{}, nil, false
+	}
+	return date, entries, true
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	asRunRepo    repositories.DefaultAsRunRepository
+	asRunHandler AsRunHandler
+)
+
+func setupAsRunUiTest(t *testing.T) {
+	var asRunCfg config.AppConfig
+	asRunCfg.Export.AsRunFolder = t.TempDir()
+	asRunRepo = repositories.NewAsRunRepository(&asRunCfg)
+	asRunHandler = NewAsRunHandler(&asRunRepo)
+	asRunHandler.Now = func() time.Time { return time.Date(2026, 10, 19, 21, 0, 0, 0, time.Local) }
+	started := time.Date(2026, 10, 19, 20, 0, 0, 0, time.Local)
+	entry, err := asRunRepo.Start(domain.AsRunEntry{Filename: "/audio/show.mp3", Title: "Show, Part 1", Duration: time.Hour, Started: started, Status: domain.AsRunPlaying})
+	require.NoError(t, err)
+	entry.Stopped = started.Add(59*time.Minute + 58*time.Second)
+	entry.Status = domain.AsRunCompleted
+	require.NoError(t, asRunRepo.Update(entry))
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/asrun", asRunHandler.AsRunPage)
+	router.GET("/asrun.csv", asRunHandler.AsRunCsv)
+	recorder = httptest.NewRecorder()
+}
+
+func getAsRun(target string) (string, *http.Response) {
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	return string(data), res
+}
+
+func TestAsRunPageShowsToday(t *testing.T) {
+	setupAsRunUiTest(t)
+
+	data, res := getAsRun("/asrun")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "<title>As-Run Log</title>")
+	assert.Contains(t, data, "Show, Part 1")
+	assert.Contains(t, data, "59m58s")
+}
+
+func TestAsRunPageOtherDayWithoutEntries(t *testing.T) {
+	setupAsRunUiTest(t)
+
+	data, res := getAsRun("/asrun?date=2026-10-18")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "No items logged for this day.")
+}
+
+func TestAsRunCsvReturnsEntries(t *testing.T) {
+	setupAsRunUiTest(t)
+
+	data, res := getAsRun("/asrun.csv?date=2026-10-19")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, "attachment; filename=\"asrun-2026-10-19.csv\"", res.Header.Get("Content-Disposition"))
+	assert.EqualValues(t, "Started,Stopped,Played,Duration,Status,Title,Artist,File,Item Id\n"+
+		"2026-10-19 20:00:00,2026-10-19 20:59:58,59m58s,1h0m0s,completed,\"Show, Part 1\",,/audio/show.mp3,\n", data)
+}
+
+func TestAsRunCsvInvalidDateReturnsBadRequest(t *testing.T) {
+	setupAsRunUiTest(t)
+
+	_, res := getAsRun("/asrun.csv?date=yesterday")
+
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type EventHistoryHandler struct {
+	Archive eventArchive
+	Now     func() time.Time
+}
+
+type eventArchive interface {
+	Records(time.Time, time.Time) ([]domain.EventRecord, error)
+	MonthlyStats(time.Time) ([]dto.SeriesStats, error)
+	PunctualityReport(time.Time, time.Time, string) (dto.PunctualityReport, error)
+	UploadDeadline() time.Duration
+}
+
+const (
+	historyDefaultDays      = 7
+	historyMaxDays          = 366
+	punctualityDefaultWeeks = 4
+)
+
+// NewEventHistoryHandler creates a new handler for the event history pages and injects its dependencies
+func NewEventHistoryHandler(archive eventArchive) EventHistoryHandler {
+	return EventHistoryHandler{
+		Archive: archive,
+		Now:     time.Now,
+	}
+}
+
+// HistoryPage is the handler for the page showing the archived events, the last seven days if no range is given
+func (hh *EventHistoryHandler) HistoryPage(c *gin.Context) {
+	filter, records, ok := hh.selectedRecords(c)
+	if !ok {
+		return
+	}
+	events := make([]dto.HistoryEvent, 0, len(records))
+	for _, record := range records {
+		events = append(events, dto.NewHistoryEvent(record, hh.Archive.UploadDeadline()))
+	}
+	c.HTML(http.StatusOK, "history.page.tmpl", gin.H{
+		"title":  "Event History",
+		"from":   filter.From,
+		"to":     filter.To,
+		"series": filter.Series,
+		"events": events,
+	})
+}
+
+// HistoryJson is the handler returning the archived events as JSON
+func (hh *EventHistoryHandler) HistoryJson(c *gin.Context) {
+	_, records, ok := hh.selectedRecords(c)
+	if !ok {
+		return
+	}
+	if records == nil {
+		records = []domain.EventRecord{}
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// HistoryCsv is the handler returning the archived events as CSV file
+func (hh *EventHistoryHandler) HistoryCsv(c *gin.Context) {
+	filter, records, ok := hh.selectedRecords(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"history-%v-%v.csv\"", filter.From, filter.To))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"Date", "Start", "End", "Event Id", "Title", "Series", "Type", "Planned Duration", "Actual Duration",
+		"File Status", "File Source", "File", "Uploaded", "Upload Lead (h)", "Punctuality", "Exported", "Rerun"})
+	for _, record := range records {
+		event := dto.NewHistoryEvent(record, hh.Archive.UploadDeadline())
+		w.Write([]string{
+			event.Date,
+			event.StartTime,
+			event.EndTime,
+			event.EventId,
+			event.Title,
+			event.Series,
+			event.EventType,
+			event.PlannedDuration,
+			event.ActualDuration,
+			event.FileStatus,
+			event.FileSource,
+			event.Path,
+			event.Uploaded,
+			event.UploadLead,
+			event.Punctuality,
+			event.Exported,
+			event.Rerun,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logger.Error("Error writing event history as CSV", err)
+	}
+}
+
+// HistoryStatsPage is the handler for the page showing the statistics per series of a month, the current month if none is given
+func (hh *EventHistoryHandler) HistoryStatsPage(c *gin.Context) {
+	month := time.Date(hh.Now().Year(), hh.Now().Month(), 1, 0, 0, 0, 0, time.Local)
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, time.Local)
+		if err != nil {
+			apiErr := api_error.NewBadRequestError("month must be a month (YYYY-MM)")
+			c.JSON(apiErr.StatusCode(), apiErr)
+			return
+		}
+		month = parsed
+	}
+	stats, err := hh.Archive.MonthlyStats(month)
+	if err != nil {
+		logger.Error("Error reading event archive", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.HTML(http.StatusOK, "historystats.page.tmpl", gin.H{
+		"title":    "Event Statistics",
+		"month":    month.Format("2006-01"),
+		"previous": month.AddDate(0, -1, 0).Format("2006-01"),
+		"next":     month.AddDate(0, 1, 0).Format("2006-01"),
+		"stats":    stats,
+	})
+}
+
+// PunctualityPage is the handler for the page showing the punctuality of the uploads per series or producer and week,
+// the last four weeks if no range is given
+func (hh *EventHistoryHandler) PunctualityPage(c *gin.Context) {
+	report, ok := hh.punctualityReport(c)
+	if !ok {
+		return
+	}
+	c.HTML(http.StatusOK, "punctuality.page.tmpl", gin.H{
+		"title":  "Upload Punctuality",
+		"report": report,
+	})
+}
+
+// PunctualityJson is the handler returning the punctuality report as JSON
+func (hh *EventHistoryHandler) PunctualityJson(c *gin.Context) {
+	report, ok := hh.punctualityReport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// punctualityReport reads the date range and grouping selected by the user and creates the punctuality report.
+// Writes the error response and returns false on failure
+func (hh *EventHistoryHandler) punctualityReport(c *gin.Context) (dto.PunctualityReport, bool) {
+	today := domain.NormalizeDate(hh.Now())
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	from, to, message := hh.selectedRange(c, monday.AddDate(0, 0, -7*(punctualityDefaultWeeks-1)))
+	if message != "" {
+		hh.badRequest(c, message)
+		return dto.PunctualityReport{}, false
+	}
+	by := c.DefaultQuery("by", service.PunctualityBySeries)
+	if by != service.PunctualityBySeries && by != service.PunctualityByProducer {
+		hh.badRequest(c, "by must be series or producer")
+		return dto.PunctualityReport{}, false
+	}
+	report, err := hh.Archive.PunctualityReport(from, to, by)
+	if err != nil {
+		logger.Error("Error creating punctuality report", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return dto.PunctualityReport{}, false
+	}
+	return report, true
+}
+
+type historyFilter struct {
+	From   string
+	To     string
+	Series string
+}
+
+// selectedRange reads the date range selected by the user, from the given default up to today if none is given.
+// Returns the error message for an invalid range
+func (hh *EventHistoryHandler) selectedRange(c *gin.Context, defaultFrom time.Time) (time.Time, time.Time, string) {
+	to := domain.NormalizeDate(hh.Now())
+	from := defaultFrom
+	if value := c.Query("from"); value != "" {
+		parsed, err := domain.ParseFolderDate(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, "from must be a date (YYYY-MM-DD)"
+		}
+		from = parsed
+		if c.Query("to") == "" {
+			to = from
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := domain.ParseFolderDate(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, "to must be a date (YYYY-MM-DD)"
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, "to must not be before from"
+	}
+	if to.Sub(from) >= historyMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Sprintf("date range must not exceed %v days", historyMaxDays)
+	}
+	return from, to, ""
+}
+
+// selectedRecords reads the date range and series selected by the user and the matching archived events.
+// Writes the error response and returns false on failure
+func (hh *EventHistoryHandler) selectedRecords(c *gin.Context) (historyFilter, []domain.EventRecord, bool) {
+	from, to, message := hh.selectedRange(c, domain.NormalizeDate(hh.Now()).AddDate(0, 0, 1-historyDefaultDays))
+	if message != "" {
+		return hh.badRequest(c, message)
+	}
+	filter := historyFilter{
+		From:   domain.FormatFolderDate(from),
+		To:     domain.FormatFolderDate(to),
+		Series: strings.TrimSpace(c.Query("series")),
+	}
+	records, err := hh.Archive.Records(from, to)
+	if err != nil {
+		logger.Error("Error reading event archive", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return historyFilter{}, nil, false
+	}
+	if filter.Series != "" {
+		var matching []domain.EventRecord
+		for _, record := range records {
+			if containsFold(record.Series, filter.Series) || containsFold(record.Title, filter.Series) {
+				matching = append(matching, record)
+			}
+		}
+		records = matching
+	}
+	return filter, records, true
+}
+
+func (hh *EventHistoryHandler) badRequest(c *gin.Context, message string) (historyFilter, []domain.EventRecord, bool) {
+	apiErr := api_error.NewBadRequestError(message)
+	c.JSON(apiErr.StatusCode(), apiErr)
+	return historyFilter{}, nil, false
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
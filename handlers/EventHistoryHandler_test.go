@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventArchive struct {
+	records  []domain.EventRecord
+	from, to time.Time
+	month    time.Time
+	by       string
+}
+
+func (f *fakeEventArchive) Records(from time.Time, to time.Time) ([]domain.EventRecord, error) {
+	f.from, f.to = from, to
+	return f.records, nil
+}
+
+func (f *fakeEventArchive) MonthlyStats(month time.Time) ([]dto.SeriesStats, error) {
+	f.month = month
+	return []dto.SeriesStats{{Series: "Jazz Night", Events: 4, Preproduced: 4, WithFile: 3, Missing: 1, AvgLeadHours: "26.5"}}, nil
+}
+
+func (f *fakeEventArchive) PunctualityReport(from time.Time, to time.Time, by string) (dto.PunctualityReport, error) {
+	f.from, f.to, f.by = from, to, by
+	row := dto.PunctualityRow{Name: "Jazz Night", Total: dto.PunctualityCount{Events: 4, OnTime: 3, Late: 1}, AvgLeadHours: "30.0", Weeks: []dto.PunctualityCount{{Events: 4, OnTime: 3, Late: 1}}}
+	return dto.PunctualityReport{
+		From:          domain.FormatFolderDate(from),
+		To:            domain.FormatFolderDate(to),
+		By:            by,
+		DeadlineHours: 24,
+		Weeks:         []string{"2026-10-12"},
+		Rows:          []dto.PunctualityRow{row},
+		Total:         row,
+		Late:          []dto.HistoryEvent{{Date: "2026-10-15", EventId: "9", Title: "Jazz Night, Part 0", UploadLead: "2.0", Punctuality: domain.PunctualityLate}},
+	}, nil
+}
+
+func (f *fakeEventArchive) UploadDeadline() time.Duration {
+	return 24 * time.Hour
+}
+
+var historyArchive *fakeEventArchive
+
+func setupEventHistoryTest() {
+	start := time.Date(2026, 10, 17, 20, 0, 0, 0, time.Local)
+	historyArchive = &fakeEventArchive{records: []domain.EventRecord{
+		{Date: "2026-10-17", EventId: 7, Title: "Jazz Night, Part 1", Series: "Jazz Night", Start: start, End: start.Add(time.Hour), PlannedDuration: time.Hour, FileStatus: "Present", FileSource: "calCMS", Path: "/audio/jazz.mp3", FileModified: start.Add(-26 * time.Hour), Exported: true},
+		{Date: "2026-10-17", EventId: 8, Title: "Morning Talk", Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Live: true, FileStatus: "N/A"},
+	}}
+	handler := NewEventHistoryHandler(historyArchive)
+	handler.Now = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local) }
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/history", handler.HistoryPage)
+	router.GET("/history.json", handler.HistoryJson)
+	router.GET("/history.csv", handler.HistoryCsv)
+	router.GET("/history/stats", handler.HistoryStatsPage)
+	router.GET("/history/punctuality", handler.PunctualityPage)
+	router.GET("/history/punctuality/json", handler.PunctualityJson)
+	recorder = httptest.NewRecorder()
+}
+
+func TestHistoryPageDefaultsToLastSevenDays(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "<title>Event History</title>")
+	assert.Contains(t, data, "Jazz Night, Part 1")
+	assert.Contains(t, data, "26.0")
+	assert.EqualValues(t, "2026-10-12", domain.FormatFolderDate(historyArchive.from))
+	assert.EqualValues(t, "2026-10-18", domain.FormatFolderDate(historyArchive.to))
+}
+
+func TestHistoryPageFiltersBySeries(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history?from=2026-10-17&series=talk")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "Morning Talk")
+	assert.NotContains(t, data, "Jazz Night, Part 1")
+	assert.EqualValues(t, "2026-10-17", domain.FormatFolderDate(historyArchive.to))
+}
+
+func TestHistoryPageInvalidRangeReturnsBadRequest(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history?from=2026-10-17&to=2026-10-01")
+
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+	assert.Contains(t, data, "to must not be before from")
+}
+
+func TestHistoryJsonReturnsRecords(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history.json?from=2026-10-17")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "\"event_id\":7")
+	assert.Contains(t, data, "\"exported\":true")
+}
+
+func TestHistoryCsvReturnsRecords(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history.csv?from=2026-10-17&series=jazz")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, "attachment; filename=\"history-2026-10-17-2026-10-17.csv\"", res.Header.Get("Content-Disposition"))
+	assert.EqualValues(t, "Date,Start,End,Event Id,Title,Series,Type,Planned Duration,Actual Duration,File Status,File Source,File,Uploaded,Upload Lead (h),Punctuality,Exported,Rerun\n"+
+		"2026-10-17,20:00,21:00,7,\"Jazz Night, Part 1\",Jazz Night,Preproduction,60.0,N/A,Present,calCMS,/audio/jazz.mp3,2026-10-16 18:00,26.0,on-time,Yes,\n", data)
+}
+
+func TestHistoryStatsPageShowsMonth(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history/stats?month=2026-09")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "Event Statistics 2026-09")
+	assert.Contains(t, data, "Jazz Night")
+	assert.Contains(t, data, "26.5")
+	assert.EqualValues(t, time.September, historyArchive.month.Month())
+}
+
+func TestHistoryStatsPageInvalidMonthReturnsBadRequest(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history/stats?month=September")
+
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+	assert.Contains(t, data, "month must be a month (YYYY-MM)")
+}
+
+func TestPunctualityPageDefaultsToLastFourWeeksBySeries(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history/punctuality")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "<title>Upload Punctuality</title>")
+	assert.Contains(t, data, "75.0 %")
+	assert.Contains(t, data, "3 / 1 / 0")
+	assert.Contains(t, data, "Jazz Night, Part 0")
+	assert.EqualValues(t, "2026-09-21", domain.FormatFolderDate(historyArchive.from))
+	assert.EqualValues(t, "2026-10-18", domain.FormatFolderDate(historyArchive.to))
+	assert.EqualValues(t, "series", historyArchive.by)
+}
+
+func TestPunctualityJsonByProducer(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history/punctuality/json?from=2026-10-01&to=2026-10-18&by=producer")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "\"by\":\"producer\"")
+	assert.Contains(t, data, "\"late\":1")
+	assert.EqualValues(t, "producer", historyArchive.by)
+}
+
+func TestPunctualityPageInvalidGroupingReturnsBadRequest(t *testing.T) {
+	setupEventHistoryTest()
+
+	data, res := getAsRun("/history/punctuality?by=studio")
+
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+	assert.Contains(t, data, "by must be series or producer")
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type ExportHistoryHandler struct {
+	History repositories.ExportHistoryRepository
+}
+
+// exportSlot groups the versions of one playlist slot for display, newest version first
+type exportSlot struct {
+	Slot     string
+	Versions []domain.ExportVersion
+}
+
+// NewExportHistoryHandler creates a new handler for the export history pages and injects its dependencies
+func NewExportHistoryHandler(history repositories.ExportHistoryRepository) ExportHistoryHandler {
+	return ExportHistoryHandler{
+		History: history,
+	}
+}
+
+// ExportsPage is the handler for the page listing all recorded versions of the exported playlists
+func (eh *ExportHistoryHandler) ExportsPage(c *gin.Context) {
+	var slots []exportSlot
+	for _, slot := range eh.History.GetSlots() {
+		versions := eh.History.GetVersions(slot)
+		for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+			versions[i], versions[j] = versions[j], versions[i]
+		}
+		slots = append(slots, exportSlot{Slot: slot, Versions: versions})
+	}
+	c.HTML(http.StatusOK, "exports.page.tmpl", gin.H{
+		"title": "Exports",
+		"slots": slots,
+	})
+}
+
+// ExportVersionView is the handler returning the content of one recorded playlist version
+func (eh *ExportHistoryHandler) ExportVersionView(c *gin.Context) {
+	version, ok := eh.selectedVersion(c, "version")
+	if !ok {
+		return
+	}
+	content, err := eh.History.ReadVersion(*version)
+	if err != nil {
+		logger.Error("Error reading playlist version", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", content)
+}
+
+// ExportDiffPage is the handler for the page comparing a playlist version with the previous version or a given base version
+func (eh *ExportHistoryHandler) ExportDiffPage(c *gin.Context) {
+	version, ok := eh.selectedVersion(c, "version")
+	if !ok {
+		return
+	}
+	var baseLines []string
+	var base *domain.ExportVersion
+	if c.Query("base") != "" {
+		if base, ok = eh.selectedVersion(c, "base"); !ok {
+			return
+		}
+	} else {
+		base = eh.History.GetVersion(version.Slot, version.Version-1)
+	}
+	newLines, err := eh.readLines(*version)
+	if err == nil && base != nil {
+		baseLines, err = eh.readLines(*base)
+	}
+	if err != nil {
+		logger.Error("Error reading playlist version", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	c.HTML(http.StatusOK, "exportdiff.page.tmpl", gin.H{
+		"title":   "Export Diff",
+		"version": version,
+		"base":    base,
+		"diff":    helper.DiffLines(baseLines, newLines),
+	})
+}
+
+// selectedVersion looks up the version given by the slot and the named query parameter. Writes an error response if not found
+func (eh *ExportHistoryHandler) selectedVersion(c *gin.Context, param string) (*domain.ExportVersion, bool) {
+	number, err := strconv.Atoi(c.Query(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": param + " must be a number"})
+		return nil, false
+	}
+	version := eh.History.GetVersion(c.Query("slot"), number)
+	if version == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "playlist version not found"})
+		return nil, false
+	}
+	return version, true
+}
+
+func (eh *ExportHistoryHandler) readLines(version domain.ExportVersion) ([]string, error) {
+	content, err := eh.History.ReadVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n"), nil
+}
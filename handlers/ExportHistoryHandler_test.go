@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	historyRepo    repositories.DefaultExportHistoryRepository
+	historyHandler ExportHistoryHandler
+)
+
+func setupHistoryUiTest(t *testing.T) {
+	var historyCfg config.AppConfig
+	historyCfg.Export.ExportFolder = t.TempDir()
+	historyCfg.Export.HistoryVersions = 5
+	historyRepo = repositories.NewExportHistoryRepository(&historyCfg)
+	exportPath := filepath.Join(historyCfg.Export.ExportFolder, "2026-10-19-20.tpi")
+	historyRepo.Record(exportPath, []byte("20:00:00\tH\tF\tA.mp3\n21:00:00\tH\tD\tEnd of block\n"), time.Now())
+	historyRepo.Record(exportPath, []byte("20:00:00\tH\tF\tB.mp3\n21:00:00\tH\tD\tEnd of block\n"), time.Now())
+	historyRepo.MarkAppended(exportPath, time.Now())
+	historyHandler = NewExportHistoryHandler(&historyRepo)
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/exports", historyHandler.ExportsPage)
+	router.GET("/exports/view", historyHandler.ExportVersionView)
+	router.GET("/exports/diff", historyHandler.ExportDiffPage)
+	recorder = httptest.NewRecorder()
+}
+
+func getHistoryPage(target string) (string, int) {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	return string(data), res.StatusCode
+}
+
+func TestExportsPageListsVersions(t *testing.T) {
+	setupHistoryUiTest(t)
+
+	data, statusCode := getHistoryPage("/exports")
+
+	assert.EqualValues(t, http.StatusOK, statusCode)
+	assert.Contains(t, data, "<title>Exports</title>")
+	assert.Contains(t, data, "2026-10-19-20")
+	assert.Contains(t, data, "/exports/diff?slot=2026-10-19-20&version=2")
+	assert.Equal(t, 1, strings.Count(data, "badge text-bg-success"))
+}
+
+func TestExportVersionViewReturnsContent(t *testing.T) {
+	setupHistoryUiTest(t)
+
+	data, statusCode := getHistoryPage("/exports/view?slot=2026-10-19-20&version=1")
+
+	assert.EqualValues(t, http.StatusOK, statusCode)
+	assert.EqualValues(t, "20:00:00\tH\tF\tA.mp3\n21:00:00\tH\tD\tEnd of block\n", data)
+}
+
+func TestExportVersionViewUnknownVersionReturnsNotFound(t *testing.T) {
+	setupHistoryUiTest(t)
+
+	_, statusCode := getHistoryPage("/exports/view?slot=../../etc&version=1")
+
+	assert.EqualValues(t, http.StatusNotFound, statusCode)
+}
+
+func TestExportDiffPageShowsChangesToPreviousVersion(t *testing.T) {
+	setupHistoryUiTest(t)
+
+	data, statusCode := getHistoryPage("/exports/diff?slot=2026-10-19-20&version=2")
+
+	assert.EqualValues(t, http.StatusOK, statusCode)
+	assert.Contains(t, data, "- 20:00:00\tH\tF\tA.mp3")
+	assert.Contains(t, data, "+ 20:00:00\tH\tF\tB.mp3")
+	assert.Contains(t, data, "  21:00:00\tH\tD\tEnd of block")
+}
+
+func TestExportDiffPageInvalidVersionReturnsBadRequest(t *testing.T) {
+	setupHistoryUiTest(t)
+
+	_, statusCode := getHistoryPage("/exports/diff?slot=2026-10-19-20&version=x")
+
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type MusicReportHandler struct {
+	Reports musicReporter
+	Now     func() time.Time
+}
+
+type musicReporter interface {
+	MusicReport(time.Time, time.Time) ([]dto.MusicReportRow, error)
+	WriteMusicReportCsv(io.Writer, []dto.MusicReportRow) error
+}
+
+// NewMusicReportHandler creates a new handler for the music report pages and injects its dependencies
+func NewMusicReportHandler(reports musicReporter) MusicReportHandler {
+	return MusicReportHandler{
+		Reports: reports,
+		Now:     time.Now,
+	}
+}
+
+// MusicReportPage is the handler for the page showing the music played in a period, the previous quarter if no period is given
+func (mh *MusicReportHandler) MusicReportPage(c *gin.Context) {
+	from, to, report, ok := mh.selectedReport(c)
+	if !ok {
+		return
+	}
+	var plays int
+	var duration time.Duration
+	for _, row := range report {
+		plays += row.Plays
+		duration += row.Duration
+	}
+	c.HTML(http.StatusOK, "musicreport.page.tmpl", gin.H{
+		"title":    "Music Report",
+		"from":     domain.FormatFolderDate(from),
+		"to":       domain.FormatFolderDate(to),
+		"report":   report,
+		"plays":    plays,
+		"duration": duration,
+	})
+}
+
+// MusicReportCsv is the handler returning the music report of a period as CSV file in the configured layout
+func (mh *MusicReportHandler) MusicReportCsv(c *gin.Context) {
+	from, to, report, ok := mh.selectedReport(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"music-%v-%v.csv\"", domain.FormatFolderDate(from), domain.FormatFolderDate(to)))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := mh.Reports.WriteMusicReportCsv(c.Writer, report); err != nil {
+		logger.Error("Error writing music report as CSV", err)
+	}
+}
+
+// selectedReport reads the period selected by the user and creates its report. Writes the error response and returns false on failure
+func (mh *MusicReportHandler) selectedReport(c *gin.Context) (time.Time, time.Time, []dto.MusicReportRow, bool) {
+	from, to := previousQuarter(mh.Now())
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if value := c.Query(param.name); value != "" {
+			parsed, err := domain.ParseFolderDate(value)
+			if err != nil {
+				apiErr := api_error.NewBadRequestError(fmt.Sprintf("invalid %v date, use YYYY-MM-DD", param.name))
+				c.JSON(apiErr.StatusCode(), apiErr)
+				return time.Time{}, time.Time{}, nil, false
+			}
+			*param.value = parsed
+		}
+	}
+	report, err := mh.Reports.MusicReport(from, to)
+	if err != nil {
+		apiErr := api_error.NewBadRequestError(err.Error())
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return time.Time{}, time.Time{}, nil, false
+	}
+	return from, to, report, true
+}
+
+// previousQuarter is a helper function returning the first and the last day of the quarter before the given date
+func previousQuarter(now time.Time) (time.Time, time.Time) {
+	quarterStart := time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, now.Location())
+	return quarterStart.AddDate(0, -3, 0), quarterStart.AddDate(0, 0, -1)
+}
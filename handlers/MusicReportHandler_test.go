@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/mairlist-feeder/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMusicReportUiTest(t *testing.T) {
+	var reportCfg config.AppConfig
+	reportCfg.Export.AsRunFolder = t.TempDir()
+	reportCfg.Report.MusicColumns = []string{"artist", "title", "plays"}
+	reportCfg.Report.MusicSeparator = ","
+	asRunRepo = repositories.NewAsRunRepository(&reportCfg)
+	started := time.Date(2026, 8, 3, 10, 0, 0, 0, time.Local)
+	_, err := asRunRepo.Start(domain.AsRunEntry{Filename: "/music/song.mp3", Artist: "Band", Title: "Song", Started: started, Stopped: started.Add(3 * time.Minute), Status: domain.AsRunCompleted})
+	require.NoError(t, err)
+	reportSvc := service.NewMusicReportService(&reportCfg, nil, &asRunRepo)
+	musicHandler := NewMusicReportHandler(&reportSvc)
+	musicHandler.Now = func() time.Time { return time.Date(2026, 10, 19, 21, 0, 0, 0, time.Local) }
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/musicreport", musicHandler.MusicReportPage)
+	router.GET("/musicreport.csv", musicHandler.MusicReportCsv)
+	recorder = httptest.NewRecorder()
+}
+
+func TestMusicReportPageDefaultsToPreviousQuarter(t *testing.T) {
+	setupMusicReportUiTest(t)
+
+	data, res := getAsRun("/musicreport")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "<title>Music Report</title>")
+	assert.Contains(t, data, "Music 2026-07-01 to 2026-09-30")
+	assert.Contains(t, data, "1 titles, 1 plays, 3m0s total.")
+}
+
+func TestMusicReportCsvReturnsReport(t *testing.T) {
+	setupMusicReportUiTest(t)
+
+	data, res := getAsRun("/musicreport.csv?from=2026-08-01&to=2026-08-31")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, "attachment; filename=\"music-2026-08-01-2026-08-31.csv\"", res.Header.Get("Content-Disposition"))
+	assert.EqualValues(t, "\ufeffArtist,Title,Plays\nBand,Song,1\n", data)
+}
+
+func TestMusicReportInvalidDateReturnsBadRequest(t *testing.T) {
+	setupMusicReportUiTest(t)
+
+	_, resDate := getAsRun("/musicreport?from=july")
+	recorder = httptest.NewRecorder()
+	_, resOrder := getAsRun("/musicreport?from=2026-08-31&to=2026-08-01")
+
+	assert.EqualValues(t, http.StatusBadRequest, resDate.StatusCode)
+	assert.EqualValues(t, http.StatusBadRequest, resOrder.StatusCode)
+}
+
+func TestPreviousQuarterReturnsQuarterBounds(t *testing.T) {
+	from, to := previousQuarter(time.Date(2026, 1, 15, 0, 0, 0, 0, time.Local))
+
+	assert.EqualValues(t, time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local), from)
+	assert.EqualValues(t, time.Date(2025, 12, 31, 0, 0, 0, 0, time.Local), to)
+}
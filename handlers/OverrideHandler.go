@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type OverrideHandler struct {
+	Overrides repositories.OverrideRepository
+}
+
+// NewOverrideHandler creates a new handler for editing the manual schedule overrides and injects its dependencies
+func NewOverrideHandler(overrides repositories.OverrideRepository) OverrideHandler {
+	return OverrideHandler{
+		Overrides: overrides,
+	}
+}
+
+// OverridesPage is the handler for the page listing all overrides and their audit log
+func (oh *OverrideHandler) OverridesPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "overrides.page.tmpl", gin.H{
+		"title":     "Overrides",
+		"overrides": oh.Overrides.GetAll(),
+		"audit":     oh.Overrides.GetAudit(),
+	})
+}
+
+// CreateOverride is the handler invoked when the user creates an override
+func (oh *OverrideHandler) CreateOverride(c *gin.Context) {
+	override, err := overrideFromForm(c)
+	if err != nil {
+		logger.Error("Error validating override", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	if _, err := oh.Overrides.Store(override); err != nil {
+		logger.Error("Error storing override", err)
+		apiErr := api_error.NewBadRequestError(err.Error())
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/overrides")
+}
+
+// DeleteOverride is the handler invoked when the user deletes an override. A note stating the reason is required
+func (oh *OverrideHandler) DeleteOverride(c *gin.Context) {
+	id := c.Param("id")
+	if oh.Overrides.Get(id) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "override not found"})
+		return
+	}
+	if err := oh.Overrides.Delete(id, strings.TrimSpace(c.PostForm("note"))); err != nil {
+		logger.Error("Error deleting override", err)
+		apiErr := api_error.NewBadRequestError(err.Error())
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/overrides")
+}
+
+// overrideFromForm builds an override from the form values posted by the user
+func overrideFromForm(c *gin.Context) (domain.Override, api_error.ApiErr) {
+	override := domain.Override{
+		Type:      domain.OverrideType(c.PostForm("type")),
+		Path:      strings.TrimSpace(c.PostForm("path")),
+		StartTime: strings.TrimSpace(c.PostForm("time")),
+		Note:      strings.TrimSpace(c.PostForm("note")),
+	}
+	if dayDate := c.PostForm("date"); dayDate != "" {
+		if err := validateDate(dayDate); err != nil {
+			return override, err
+		}
+		override.FolderDate, _ = time.ParseInLocation(domain.FolderDateLayout, dayDate, time.Local)
+	}
+	if eventId := c.PostForm("eventid"); eventId != "" {
+		id, err := strconv.Atoi(eventId)
+		if err != nil {
+			return override, api_error.NewBadRequestError("event id must be a number")
+		}
+		override.EventId = id
+	}
+	if err := override.Validate(); err != nil {
+		return override, api_error.NewBadRequestError(err.Error())
+	}
+	return override, nil
+}
+
+// annotateFiles adds the descriptions of the overrides concerning each file to the file list
+func annotateFiles(overrides repositories.OverrideRepository, files []dto.FileResp) {
+	if overrides == nil {
+		return
+	}
+	for i := range files {
+		files[i].Override = describeOverrides(overrides.ForPath(files[i].Path))
+	}
+}
+
+// annotateEvents adds the descriptions of the overrides concerning each event to the event list.
+// These are the overrides replacing the event's file and the overrides for the files of the event
+func annotateEvents(overrides repositories.OverrideRepository, repo repositories.FileRepository, events []dto.Event) {
+	if overrides == nil {
+		return
+	}
+	for i := range events {
+		eventId, err := strconv.Atoi(events[i].EventId)
+		if err != nil {
+			continue
+		}
+		eventDate, err := domain.ParseFolderDate(events[i].StartDate)
+		if err != nil {
+			continue
+		}
+		concerned := overrides.ForEvent(eventId, eventDate)
+		for _, file := range repo.GetByEventIdAndDate(eventId, eventDate) {
+			concerned = append(concerned, overrides.ForPath(file.Path)...)
+		}
+		events[i].Override = describeOverrides(concerned)
+	}
+}
+
+func describeOverrides(overrides []domain.Override) string {
+	descriptions := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		descriptions = append(descriptions, o.Describe())
+	}
+	return strings.Join(descriptions, "; ")
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	overrideRepo    repositories.DefaultOverrideRepository
+	overrideHandler OverrideHandler
+)
+
+func setupOverrideUiTest(t *testing.T) {
+	var overrideCfg config.AppConfig
+	overrideCfg.Misc.OverrideSaveFile = filepath.Join(t.TempDir(), "overrides.dta")
+	overrideRepo = repositories.NewOverrideRepository(&overrideCfg)
+	overrideHandler = NewOverrideHandler(&overrideRepo)
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/overrides", overrideHandler.OverridesPage)
+	router.POST("/overrides", overrideHandler.CreateOverride)
+	router.POST("/overrides/:id/delete", overrideHandler.DeleteOverride)
+	recorder = httptest.NewRecorder()
+}
+
+func postOverrideForm(target string, form url.Values) (string, int) {
+	request := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	return string(data), res.StatusCode
+}
+
+func TestCreateOverrideStoresOverrideAndRedirects(t *testing.T) {
+	setupOverrideUiTest(t)
+	form := url.Values{"type": {"pin"}, "path": {"A.mp3"}, "date": {"2026-10-19"}, "time": {"20:00"}, "note": {"late delivery"}}
+
+	_, statusCode := postOverrideForm("/overrides", form)
+
+	assert.EqualValues(t, http.StatusSeeOther, statusCode)
+	require.EqualValues(t, 1, len(overrideRepo.GetAll()))
+	assert.EqualValues(t, domain.OverridePin, overrideRepo.GetAll()[0].Type)
+	assert.EqualValues(t, "20:00", overrideRepo.GetAll()[0].StartTime)
+}
+
+func TestCreateOverrideWithoutNoteReturnsBadRequest(t *testing.T) {
+	setupOverrideUiTest(t)
+	form := url.Values{"type": {"exclude"}, "path": {"A.mp3"}}
+
+	data, statusCode := postOverrideForm("/overrides", form)
+
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.Contains(t, data, "note must be given")
+	assert.Empty(t, overrideRepo.GetAll())
+}
+
+func TestCreateOverrideWithInvalidDateReturnsBadRequest(t *testing.T) {
+	setupOverrideUiTest(t)
+	form := url.Values{"type": {"replace"}, "path": {"A.mp3"}, "date": {"19.10.2026"}, "eventid": {"42"}, "note": {"note"}}
+
+	data, statusCode := postOverrideForm("/overrides", form)
+
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.Contains(t, data, "date must have the format YYYY-MM-DD")
+}
+
+func TestDeleteOverrideWithoutNoteReturnsBadRequest(t *testing.T) {
+	setupOverrideUiTest(t)
+	o, _ := overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "note"})
+
+	_, statusCode := postOverrideForm("/overrides/"+o.Id+"/delete", url.Values{})
+
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.NotNil(t, overrideRepo.Get(o.Id))
+}
+
+func TestDeleteUnknownOverrideReturnsNotFound(t *testing.T) {
+	setupOverrideUiTest(t)
+
+	_, statusCode := postOverrideForm("/overrides/7/delete", url.Values{"note": {"note"}})
+
+	assert.EqualValues(t, http.StatusNotFound, statusCode)
+}
+
+func TestDeleteOverrideRemovesOverride(t *testing.T) {
+	setupOverrideUiTest(t)
+	o, _ := overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "note"})
+
+	_, statusCode := postOverrideForm("/overrides/"+o.Id+"/delete", url.Values{"note": {"file fixed"}})
+
+	assert.EqualValues(t, http.StatusSeeOther, statusCode)
+	assert.Nil(t, overrideRepo.Get(o.Id))
+}
+
+func TestOverridesPageListsOverridesAndAudit(t *testing.T) {
+	setupOverrideUiTest(t)
+	overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "broken file"})
+	overrideRepo.Store(domain.Override{Type: domain.OverrideAccept, Path: "B.mp3", Note: "short show"})
+	overrideRepo.Delete("2", "not needed")
+	request := httptest.NewRequest(http.MethodGet, "/overrides", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	body := string(data)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, body, "<title>Overrides</title>")
+	assert.Contains(t, body, `action="/overrides/1/delete"`)
+	assert.NotContains(t, body, `action="/overrides/2/delete"`)
+	assert.Contains(t, body, "not needed")
+}
+
+func TestFileListPageShowsOverrides(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	setupOverrideUiTest(t)
+	cfg.Misc.TestCrawl = true
+	cfg.Misc.TestDate = "2024/09/17"
+	repo.Store(domain.FileInfo{Path: "today-file", FolderDate: domain.MustParseFolderDate("2024-09-17")})
+	overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "today-file", Note: "broken file"})
+	uh.Overrides = &overrideRepo
+	router.GET("/filelist", uh.FileListPage)
+	request := httptest.NewRequest(http.MethodGet, "/filelist", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.Contains(t, string(data), "Excluded (broken file)")
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+)
+
+type ScheduleChangeHandler struct {
+	Changes scheduleChangeReporter
+}
+
+type scheduleChangeReporter interface {
+	GetScheduleChanges() []domain.ScheduleChange
+}
+
+// NewScheduleChangeHandler creates a new handler for the schedule change feed and injects its dependencies
+func NewScheduleChangeHandler(changes scheduleChangeReporter) ScheduleChangeHandler {
+	return ScheduleChangeHandler{
+		Changes: changes,
+	}
+}
+
+// ChangesPage is the handler for the page listing the schedule changes detected since the start of the service
+func (sh *ScheduleChangeHandler) ChangesPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "changes.page.tmpl", gin.H{
+		"title":   "Schedule Changes",
+		"changes": sh.Changes.GetScheduleChanges(),
+	})
+}
+
+// ChangesJson is the handler returning the schedule changes as JSON, newest first
+func (sh *ScheduleChangeHandler) ChangesJson(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"changes": sh.Changes.GetScheduleChanges(),
+	})
+}
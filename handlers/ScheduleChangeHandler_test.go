@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeScheduleChanges []domain.ScheduleChange
+
+func (f fakeScheduleChanges) GetScheduleChanges() []domain.ScheduleChange {
+	return f
+}
+
+func setupChangeUiTest(changes fakeScheduleChanges) {
+	changeHandler := NewScheduleChangeHandler(changes)
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/changes", changeHandler.ChangesPage)
+	router.GET("/changes/json", changeHandler.ChangesJson)
+	recorder = httptest.NewRecorder()
+}
+
+func TestChangesPageOffersReexportOfExportedHours(t *testing.T) {
+	setupChangeUiTest(fakeScheduleChanges{{
+		Id:       1,
+		Detected: time.Date(2026, 10, 19, 18, 0, 0, 0, time.Local),
+		Type:     domain.ScheduleChangeMoved,
+		EventId:  4711,
+		Title:    "Evening Show",
+		Old:      "2026-10-19 20:00-21:00",
+		New:      "2026-10-19 21:00-22:00",
+		Slots:    []domain.ScheduleSlot{{Date: "2026-10-19", Hour: "20", Exported: true}, {Date: "2026-10-19", Hour: "21"}},
+	}})
+
+	data, res := getAsRun("/changes")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "<title>Schedule Changes</title>")
+	assert.Contains(t, data, "Evening Show (4711)")
+	assert.Contains(t, data, "2026-10-19 21:00-22:00")
+	assert.Contains(t, data, "reexport('2026-10-19', '20')")
+	assert.NotContains(t, data, "reexport('2026-10-19', '21')")
+}
+
+func TestChangesPageWithoutChangesShowsHint(t *testing.T) {
+	setupChangeUiTest(nil)
+
+	data, res := getAsRun("/changes")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "No schedule changes detected")
+}
+
+func TestChangesJsonReturnsChanges(t *testing.T) {
+	setupChangeUiTest(fakeScheduleChanges{{Id: 1, Type: domain.ScheduleChangeRemoved, EventId: 4711, Title: "Evening Show"}})
+
+	data, res := getAsRun("/changes/json")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, `"type":"removed"`)
+	assert.Contains(t, data, `"event_id":4711`)
+}
@@ -4,6 +4,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"slices"
 	"strconv"
@@ -29,17 +30,38 @@ type StatsUiHandler struct {
 	ExportSvc uiExporter
 	CleanSvc  service.Cleaner
 	CalCmsSvc uiCalCmsService
+	Overrides repositories.OverrideRepository
+	Outbox    repositories.OutboxRepository
 	jobs      *actionJobs
 }
 
 type uiExporter interface {
 	ExportAllHoursContext(context.Context) error
 	ExportForHourContext(context.Context, string) error
+	ExportForDateAndHourContext(context.Context, time.Time, string) error
+	ExportDayPlaylistForDateContext(context.Context, time.Time) (string, error)
+	PreviewForDateAndHoursContext(context.Context, time.Time, int, int) ([]dto.HourPreview, error)
+	ReconcileContext(context.Context) (dto.ReconcileReport, error)
+	LastReconcile() dto.ReconcileReport
 }
 
 type uiCalCmsService interface {
 	GetTodayEvents() ([]dto.Event, error)
 	GetYesterdaysEvents() []dto.Event
+	GetEventsForDatesContext(context.Context, []time.Time) ([]dto.Event, error)
+	GetWeekOverviewContext(context.Context, time.Time, int) (dto.WeekOverview, error)
+}
+
+const (
+	maxFilterDays = 31
+	weekDays      = 7
+)
+
+// dateFilter is the selection of the file and event lists, either a day or a range of dates
+type dateFilter struct {
+	Day  string // today, tomorrow or yesterday, empty for a date or a range
+	From string // YYYY-MM-DD
+	To   string // YYYY-MM-DD
 }
 
 // NewStatsUiHandler creates a new web UI handler and injects its dependencies
@@ -71,54 +93,83 @@ func (uh *StatsUiHandler) Close() {
 // StatusPage is the handler for the status page
 func (uh *StatsUiHandler) StatusPage(c *gin.Context) {
 	configData := dto.GetConfig(uh.Cfg, uh.State)
+	var outbox []domain.OutboxEntry
+	if uh.Outbox != nil {
+		outbox = uh.Outbox.GetAll()
+	}
 	c.HTML(http.StatusOK, "status.page.tmpl", gin.H{
 		"title":      "Status",
 		"configdata": configData,
+		"outbox":     outbox,
 	})
 }
 
 // FileListPage is the handler for the file list page
 func (uh *StatsUiHandler) FileListPage(c *gin.Context) {
-	filterDate, filterDay, err := uh.selectedFilterDate(c)
+	filterDates, filter, err := uh.selectedFilterDates(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
-	files := dto.GetFilesForDate(uh.Repo, uh.Cfg.CalCms.CmsUrl, filterDate)
+	cmsUrl := uh.Cfg.CalCms.CmsUrl
+	if uh.Cfg.CalCms.Provider == config.ScheduleProviderICal {
+		cmsUrl = ""
+	}
+	files := dto.GetFilesForDates(uh.Repo, cmsUrl, filterDates)
+	annotateFiles(uh.Overrides, files)
 	c.HTML(http.StatusOK, "filelist.page.tmpl", gin.H{
 		"title":       "File List",
 		"files":       files,
-		"filterDay":   filterDay,
+		"filterDay":   filter.Day,
+		"filterFrom":  filter.From,
+		"filterTo":    filter.To,
 		"filterRoute": "/filelist",
 	})
 }
 
 // EventListPage is the handler for the event list page
 func (uh *StatsUiHandler) EventListPage(c *gin.Context) {
-	filterDate, filterDay, dateErr := uh.selectedFilterDate(c)
+	filterDates, filter, dateErr := uh.selectedFilterDates(c)
 	if dateErr != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": dateErr.Error()})
 		return
 	}
-	events, err := uh.CalCmsSvc.GetTodayEvents()
+	events, err := uh.CalCmsSvc.GetEventsForDatesContext(c.Request.Context(), filterDates)
+	loadError := ""
 	if err != nil {
-		logger.Error("Error getting today's events", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
-		return
+		logger.Error("Error getting events", err)
+		loadError = err.Error()
+	}
+	annotateEvents(uh.Overrides, uh.Repo, events)
+	cachedSince := ""
+	if runtime := uh.State.Runtime.Snapshot(); runtime.CalCmsDataCached {
+		cachedSince = runtime.CalCmsDataFetched.Format("2006-01-02 15:04")
 	}
-	events = filterEventsByDate(events, filterDate)
 	c.HTML(http.StatusOK, "eventlist.page.tmpl", gin.H{
 		"title":       "Event List",
 		"events":      events,
-		"filterDay":   filterDay,
+		"filterDay":   filter.Day,
+		"filterFrom":  filter.From,
+		"filterTo":    filter.To,
 		"filterRoute": "/events",
 		"showFilter":  true,
+		"cachedSince": cachedSince,
+		"loadError":   loadError,
 	})
 }
 
-// YesterdaysEvents is the handler for the yesterday's event list page
+// YesterdaysEvents is the handler for the yesterday's event list page. Without a snapshot taken at midnight,
+// e.g. after a restart, yesterday's events are loaded like the events of any other date
 func (uh *StatsUiHandler) YesterdaysEvents(c *gin.Context) {
 	events := uh.CalCmsSvc.GetYesterdaysEvents()
+	if len(events) == 0 {
+		var err error
+		yesterday := helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, -1)
+		if events, err = uh.CalCmsSvc.GetEventsForDatesContext(c.Request.Context(), []time.Time{yesterday}); err != nil {
+			logger.Error("Error getting yesterday's events", err)
+		}
+	}
+	annotateEvents(uh.Overrides, uh.Repo, events)
 	c.HTML(http.StatusOK, "eventlist.page.tmpl", gin.H{
 		"title":      "Yesterday's Event List",
 		"events":     events,
@@ -126,27 +177,167 @@ func (uh *StatsUiHandler) YesterdaysEvents(c *gin.Context) {
 	})
 }
 
-func (uh *StatsUiHandler) selectedFilterDate(c *gin.Context) (time.Time, string, error) {
+// WeekPage is the handler for the page showing the events of a week by day and hour, colour-coded by file status
+func (uh *StatsUiHandler) WeekPage(c *gin.Context) {
+	today := helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, 0)
+	from := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	if value := c.Query("from"); value != "" {
+		date, err := domain.ParseFolderDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		from = date
+	}
+	week, err := uh.CalCmsSvc.GetWeekOverviewContext(c.Request.Context(), from, weekDays)
+	loadError := ""
+	if err != nil {
+		logger.Error("Error getting events of the week", err)
+		loadError = err.Error()
+	}
+	c.HTML(http.StatusOK, "week.page.tmpl", gin.H{
+		"title":     "Week",
+		"week":      week,
+		"today":     domain.FormatFolderDate(today),
+		"loadError": loadError,
+	})
+}
+
+// selectedFilterDates returns the dates selected for the file and event lists. day selects today, tomorrow, yesterday
+// or a date (YYYY-MM-DD), from and to a range of up to 31 days. Without parameters today is selected
+func (uh *StatsUiHandler) selectedFilterDates(c *gin.Context) ([]time.Time, dateFilter, error) {
+	if from := c.Query("from"); from != "" {
+		to := c.DefaultQuery("to", from)
+		fromDate, err := domain.ParseFolderDate(from)
+		if err != nil {
+			return nil, dateFilter{}, errors.New("from must be a date (YYYY-MM-DD)")
+		}
+		toDate, err := domain.ParseFolderDate(to)
+		if err != nil {
+			return nil, dateFilter{}, errors.New("to must be a date (YYYY-MM-DD)")
+		}
+		if toDate.Before(fromDate) {
+			return nil, dateFilter{}, errors.New("to must not be before from")
+		}
+		dates := dateRange(fromDate, toDate)
+		if len(dates) > maxFilterDays {
+			return nil, dateFilter{}, fmt.Errorf("date range must not exceed %v days", maxFilterDays)
+		}
+		return dates, dateFilter{From: from, To: to}, nil
+	}
 	selectedDay := c.DefaultQuery("day", "today")
+	var date time.Time
 	switch selectedDay {
 	case "today":
-		return helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, 0), selectedDay, nil
+		date = helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, 0)
 	case "tomorrow":
-		return helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, 1), selectedDay, nil
+		date = helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, 1)
+	case "yesterday":
+		date = helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, -1)
 	default:
-		return time.Time{}, "", errors.New("day must be today or tomorrow")
+		parsed, err := domain.ParseFolderDate(selectedDay)
+		if err != nil {
+			return nil, dateFilter{}, errors.New("day must be today, tomorrow, yesterday or a date (YYYY-MM-DD)")
+		}
+		date, selectedDay = parsed, ""
+	}
+	day := domain.FormatFolderDate(date)
+	return []time.Time{date}, dateFilter{Day: selectedDay, From: day, To: day}, nil
+}
+
+// dateRange returns the dates from first to last
+func dateRange(first time.Time, last time.Time) (dates []time.Time) {
+	for date := domain.NormalizeDate(first); !date.After(domain.NormalizeDate(last)); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// PreviewPage is the handler for the page showing a dry-run export for a date and range of hours
+func (uh *StatsUiHandler) PreviewPage(c *gin.Context) {
+	previewDate, fromHour, toHour, err := uh.selectedPreviewRange(c)
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	previews, previewErr := uh.ExportSvc.PreviewForDateAndHoursContext(c.Request.Context(), previewDate, fromHour, toHour)
+	if previewErr != nil {
+		logger.Error("Error creating export preview", previewErr)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": previewErr.Error()})
+		return
 	}
+	c.HTML(http.StatusOK, "preview.page.tmpl", gin.H{
+		"title":    "Export Preview",
+		"previews": previews,
+		"date":     domain.FormatFolderDate(previewDate),
+		"from":     fmt.Sprintf("%02d", fromHour),
+		"to":       fmt.Sprintf("%02d", toHour),
+	})
+}
+
+// PreviewJson is the handler returning a dry-run export for a date and range of hours as JSON
+func (uh *StatsUiHandler) PreviewJson(c *gin.Context) {
+	previewDate, fromHour, toHour, err := uh.selectedPreviewRange(c)
+	if err != nil {
+		c.JSON(err.StatusCode(), err)
+		return
+	}
+	previews, previewErr := uh.ExportSvc.PreviewForDateAndHoursContext(c.Request.Context(), previewDate, fromHour, toHour)
+	if previewErr != nil {
+		logger.Error("Error creating export preview", previewErr)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": previewErr.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, previews)
 }
 
-func filterEventsByDate(events []dto.Event, filterDate time.Time) []dto.Event {
-	filterValue := domain.FormatFolderDate(filterDate)
-	filteredEvents := make([]dto.Event, 0, len(events))
-	for _, event := range events {
-		if event.StartDate == filterValue {
-			filteredEvents = append(filteredEvents, event)
+// ReconcilePage is the handler for the page comparing mAirList's playlist with the export
+func (uh *StatsUiHandler) ReconcilePage(c *gin.Context) {
+	c.HTML(http.StatusOK, "reconcile.page.tmpl", gin.H{
+		"title":   "Reconcile",
+		"report":  uh.ExportSvc.LastReconcile(),
+		"enabled": uh.Cfg.Export.Reconcile,
+	})
+}
+
+// ReconcileJson is the handler returning the last comparison of mAirList's playlist with the export as JSON
+func (uh *StatsUiHandler) ReconcileJson(c *gin.Context) {
+	c.JSON(http.StatusOK, uh.ExportSvc.LastReconcile())
+}
+
+// selectedPreviewRange reads the date and hour range of a preview from the query.
+// Defaults to today, starting with the next hour and covering three hours
+func (uh *StatsUiHandler) selectedPreviewRange(c *gin.Context) (previewDate time.Time, fromHour int, toHour int, e api_error.ApiErr) {
+	previewDate, nextHour := getPreviewStart(uh.Cfg, time.Now())
+	if dayDate := c.Query("date"); dayDate != "" {
+		if err := validateDate(dayDate); err != nil {
+			return time.Time{}, 0, 0, err
 		}
+		previewDate, _ = time.ParseInLocation(domain.FolderDateLayout, dayDate, time.Local)
+	}
+	from := c.DefaultQuery("from", fmt.Sprintf("%02d", nextHour))
+	if err := validateHour(from); err != nil {
+		return time.Time{}, 0, 0, err
+	}
+	fromHour, _ = strconv.Atoi(from)
+	to := c.DefaultQuery("to", fmt.Sprintf("%02d", min(fromHour+2, 23)))
+	if err := validateHour(to); err != nil {
+		return time.Time{}, 0, 0, err
+	}
+	toHour, _ = strconv.Atoi(to)
+	if toHour < fromHour {
+		return time.Time{}, 0, 0, api_error.NewBadRequestError("to hour must not be before from hour")
+	}
+	return previewDate, fromHour, toHour, nil
+}
+
+// getPreviewStart returns the date and hour a preview starts with by default, i.e. the next hour
+func getPreviewStart(cfg *config.AppConfig, now time.Time) (time.Time, int) {
+	next := now.Add(time.Hour)
+	if cfg.Misc.TestCrawl {
+		return helper.DateForFolder(true, cfg.Misc.TestDate, 0), next.Hour()
 	}
-	return filteredEvents
+	return domain.NormalizeDate(next), next.Hour()
 }
 
 // ActionPage is the handler for the page where the user can invoke actions
@@ -180,6 +371,7 @@ func (uh *StatsUiHandler) ExecAction(c *gin.Context) {
 	note := c.PostForm("note")
 	logger.Infof("Execute Action %s with note %v", action, note)
 	hour := c.PostForm("hour")
+	dayDate := c.PostForm("date")
 	if err := validateAction(action); err != nil {
 		logger.Error("Error validating action", err)
 		c.JSON(err.StatusCode(), err)
@@ -190,8 +382,13 @@ func (uh *StatsUiHandler) ExecAction(c *gin.Context) {
 		c.JSON(err.StatusCode(), err)
 		return
 	}
+	if err := validateDate(dayDate); err != nil {
+		logger.Error("Error validating date", err)
+		c.JSON(err.StatusCode(), err)
+		return
+	}
 	job, err := uh.jobs.submit(action, func(ctx context.Context) (string, error) {
-		message, err := uh.executeAction(ctx, action, hour)
+		message, err := uh.executeAction(ctx, action, hour, dayDate)
 		if err != nil {
 			logger.Errorf("Error executing %s action: %v", action, err)
 		}
@@ -213,7 +410,7 @@ func (uh *StatsUiHandler) ActionStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, job)
 }
 
-func (uh *StatsUiHandler) executeAction(ctx context.Context, action, hour string) (string, error) {
+func (uh *StatsUiHandler) executeAction(ctx context.Context, action, hour, dayDate string) (string, error) {
 	switch action {
 	case "crawl":
 		if err := uh.CrawlSvc.CrawlContext(ctx); err != nil {
@@ -225,7 +422,30 @@ func (uh *StatsUiHandler) executeAction(ctx context.Context, action, hour string
 		if hour == "" {
 			return "Export completed for all hours.", uh.ExportSvc.ExportAllHoursContext(ctx)
 		}
+		if dayDate != "" {
+			exportDate, _ := time.ParseInLocation(domain.FolderDateLayout, dayDate, time.Local)
+			return "Export completed for " + dayDate + " hour " + hour + ".", uh.ExportSvc.ExportForDateAndHourContext(ctx, exportDate, hour)
+		}
 		return "Export completed for hour " + hour + ".", uh.ExportSvc.ExportForHourContext(ctx, hour)
+	case "exportday":
+		exportDate := helper.DateForFolder(uh.Cfg.Misc.TestCrawl, uh.Cfg.Misc.TestDate, 1)
+		if dayDate != "" {
+			exportDate, _ = time.ParseInLocation(domain.FolderDateLayout, dayDate, time.Local)
+		}
+		exportedFile, err := uh.ExportSvc.ExportDayPlaylistForDateContext(ctx, exportDate)
+		if err != nil {
+			return "", err
+		}
+		if exportedFile == "" {
+			return "No elements to export for day playlist " + domain.FormatFolderDate(exportDate) + ".", nil
+		}
+		return "Day playlist exported to " + exportedFile + ".", nil
+	case "reconcile":
+		report, err := uh.ExportSvc.ReconcileContext(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Reconciliation completed: %v missing, %v extra, %v out of order.", len(report.Missing), len(report.Extra), len(report.OutOfOrder)), nil
 	case "exporttodisk":
 		return "File list saved to disk.", uh.Repo.SaveToDisk(uh.Cfg.Misc.FileSaveFile)
 	case "clean":
@@ -237,7 +457,7 @@ func (uh *StatsUiHandler) executeAction(ctx context.Context, action, hour string
 
 // validateAction filters the actions tring and only allows valid actions
 func validateAction(action string) api_error.ApiErr {
-	actions := []string{"crawl", "export", "clean", "exporttodisk"}
+	actions := []string{"crawl", "export", "exportday", "clean", "exporttodisk", "reconcile"}
 	if slices.Contains(actions, action) {
 		return nil
 	} else {
@@ -260,6 +480,17 @@ func validateHour(hour string) api_error.ApiErr {
 	return nil
 }
 
+// validateDate validates the date input by the user and only allows dates in the format YYYY-MM-DD
+func validateDate(dayDate string) api_error.ApiErr {
+	if dayDate == "" {
+		return nil
+	}
+	if _, err := time.ParseInLocation(domain.FolderDateLayout, dayDate, time.Local); err != nil {
+		return api_error.NewBadRequestError("date must have the format YYYY-MM-DD")
+	}
+	return nil
+}
+
 func (uh *StatsUiHandler) resetCrawl() {
 	runtime := uh.State.Runtime.Snapshot()
 	if runtime.BgJobs == nil {
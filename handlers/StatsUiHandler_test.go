@@ -16,6 +16,8 @@ import (
 	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
 	"github.com/johannes-kuhfuss/mairlist-feeder/config"
 	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
 	metrics "github.com/johannes-kuhfuss/mairlist-feeder/metrics"
 	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
 	"github.com/johannes-kuhfuss/mairlist-feeder/service"
@@ -178,7 +180,7 @@ func TestFileListPageRejectsUnknownDayFilter(t *testing.T) {
 
 	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
 	assert.Nil(t, err)
-	assert.EqualValues(t, "{\"message\":\"day must be today or tomorrow\"}", string(data))
+	assert.EqualValues(t, "{\"message\":\"day must be today, tomorrow, yesterday or a date (YYYY-MM-DD)\"}", string(data))
 }
 
 func TestActionPageReturnsAction(t *testing.T) {
@@ -273,7 +275,7 @@ func TestValidateActionUnkownActionReturnsError(t *testing.T) {
 func TestValidateActionCorrectActionReturnsNoError(t *testing.T) {
 	teardown := setupUiTest()
 	defer teardown()
-	actions := []string{"crawl", "export", "clean", "exporttodisk"}
+	actions := []string{"crawl", "export", "exportday", "clean", "exporttodisk", "reconcile"}
 	for _, action := range actions {
 		err := validateAction(action)
 		assert.Nil(t, err)
@@ -401,6 +403,51 @@ func TestActionExecExportReturnsOk(t *testing.T) {
 	assert.Equal(t, "Export completed for hour 13.", job.Message)
 }
 
+func TestValidateDateInvalidDateReturnsError(t *testing.T) {
+	err := validateDate("19.10.2026")
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "date must have the format YYYY-MM-DD", err.Message())
+}
+
+func TestValidateDateValidOrEmptyDateReturnsNoError(t *testing.T) {
+	assert.Nil(t, validateDate(""))
+	assert.Nil(t, validateDate("2026-10-19"))
+}
+
+func TestActionExecExportDayReturnsOk(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Export.ExportFolder = t.TempDir()
+	router.POST(actionUrl, uh.ExecAction)
+	repo.Store(domain.FileInfo{
+		Path:       "morning.mp3",
+		Duration:   time.Hour,
+		StartTime:  helper.TimeFromHourAndMinute(8, 0),
+		FolderDate: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		FileType:   domain.FileTypeAudio,
+	})
+	form := url.Values{"action": {"exportday"}, "date": {"2026-10-19"}}
+
+	data, statusCode := runRequest(form)
+
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+	job := waitForActionJob(t, data)
+	assert.Equal(t, "succeeded", job.Status)
+	assert.Equal(t, "Day playlist exported to "+filepath.Join(cfg.Export.ExportFolder, "2026-10-19-day.tpi")+".", job.Message)
+}
+
+func TestActionExecExportDayInvalidDateReturnsError(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	router.POST(actionUrl, uh.ExecAction)
+	form := url.Values{"action": {"exportday"}, "date": {"tomorrow"}}
+
+	data, statusCode := runRequest(form)
+
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.EqualValues(t, "{\"message\":\"date must have the format YYYY-MM-DD\",\"statuscode\":400,\"causes\":null}", string(data))
+}
+
 func TestActionStatusReturnsCompletedJob(t *testing.T) {
 	teardown := setupUiTest()
 	defer teardown()
@@ -543,7 +590,97 @@ func TestEventsPageRejectsUnknownDayFilter(t *testing.T) {
 
 	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
 	assert.Nil(t, err)
-	assert.EqualValues(t, "{\"message\":\"day must be today or tomorrow\"}", string(data))
+	assert.EqualValues(t, "{\"message\":\"day must be today, tomorrow, yesterday or a date (YYYY-MM-DD)\"}", string(data))
+}
+
+func TestEventsPageShowsDateRange(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Misc.TestCrawl = true
+	cfg.Misc.TestDate = "2024/09/17"
+	seedUiEvents(t)
+	router.GET("/events", uh.EventListPage)
+	request := httptest.NewRequest(http.MethodGet, "/events?from=2024-09-17&to=2024-09-18", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	body := string(data)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.Contains(t, body, `name="from" value="2024-09-17"`)
+	assert.Contains(t, body, `name="to" value="2024-09-18"`)
+	assert.Contains(t, body, "Today Event")
+	assert.Contains(t, body, "Tomorrow Event")
+}
+
+func TestEventsPageShowsDate(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Misc.TestCrawl = true
+	cfg.Misc.TestDate = "2024/09/17"
+	seedUiEvents(t)
+	router.GET("/events", uh.EventListPage)
+	request := httptest.NewRequest(http.MethodGet, "/events?day=2024-09-18", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	body := string(data)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.NotContains(t, body, "Today Event")
+	assert.Contains(t, body, "Tomorrow Event")
+}
+
+func TestEventsPageRejectsInvalidRanges(t *testing.T) {
+	tests := map[string]string{
+		"/events?from=2024-09-18&to=2024-09-17": "to must not be before from",
+		"/events?from=2024-09-01&to=2024-10-31": "date range must not exceed 31 days",
+		"/events?from=yesterday":                "from must be a date (YYYY-MM-DD)",
+	}
+	for target, message := range tests {
+		teardown := setupUiTest()
+		router.GET("/events", uh.EventListPage)
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+
+		router.ServeHTTP(recorder, request)
+		res := recorder.Result()
+		data, err := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		assert.EqualValues(t, http.StatusBadRequest, res.StatusCode, target)
+		assert.Nil(t, err)
+		assert.EqualValues(t, "{\"message\":\""+message+"\"}", string(data))
+		teardown()
+	}
+}
+
+func TestWeekPageShowsEventsByDayAndHour(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Misc.TestCrawl = true
+	cfg.Misc.TestDate = "2024/09/17"
+	seedUiEvents(t)
+	router.GET("/week", uh.WeekPage)
+	request := httptest.NewRequest(http.MethodGet, "/week", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	body := string(data)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.Contains(t, body, "<title>Week</title>")
+	assert.Contains(t, body, "Week from 2024-09-16")
+	assert.Contains(t, body, `href="/week?from=2024-09-23"`)
+	assert.Contains(t, body, "text-bg-danger\" title=\"1: Missing\">1100 Today Event")
 }
 
 func seedUiEvents(t *testing.T) {
@@ -589,6 +726,11 @@ func seedUiEvents(t *testing.T) {
 func TestYesterdayPageReturnsYesterdaysEvents(t *testing.T) {
 	teardown := setupUiTest()
 	defer teardown()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	cfg.CalCms.CmsUrl = srv.URL
 	cfg.CalCms.QueryCalCms = true
 	router.GET("/yesterday", uh.YesterdaysEvents)
 	request := httptest.NewRequest(http.MethodGet, "/yesterday", nil)
@@ -603,3 +745,137 @@ func TestYesterdayPageReturnsYesterdaysEvents(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, containsTitle)
 }
+
+func TestPreviewPageReturnsPreview(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	router.GET("/preview", uh.PreviewPage)
+	repo.Store(domain.FileInfo{
+		Path:       "evening.mp3",
+		Duration:   time.Hour,
+		StartTime:  helper.TimeFromHourAndMinute(20, 0),
+		FolderDate: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		FileType:   domain.FileTypeAudio,
+	})
+	request := httptest.NewRequest(http.MethodGet, "/preview?date=2026-10-19&from=19&to=20", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, string(data), "<title>Export Preview</title>")
+	assert.Contains(t, string(data), "2026-10-19 19:00")
+	assert.Contains(t, string(data), "20:00:00\tH\tF\tevening.mp3")
+}
+
+func TestPreviewJsonReturnsHours(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	router.GET("/preview/json", uh.PreviewJson)
+	request := httptest.NewRequest(http.MethodGet, "/preview/json?date=2026-10-19&from=05&to=07", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	var previews []map[string]any
+	err := json.NewDecoder(res.Body).Decode(&previews)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.Len(t, previews, 3)
+	assert.EqualValues(t, "05", previews[0]["hour"])
+}
+
+func TestPreviewJsonInvalidRangeReturnsError(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	router.GET("/preview/json", uh.PreviewJson)
+	request := httptest.NewRequest(http.MethodGet, "/preview/json?from=10&to=09", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+	assert.EqualValues(t, "{\"message\":\"to hour must not be before from hour\",\"statuscode\":400,\"causes\":null}", string(data))
+}
+
+func TestReconcilePageWithoutRunReturnsHint(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	router.GET("/reconcile", uh.ReconcilePage)
+	request := httptest.NewRequest(http.MethodGet, "/reconcile", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, string(data), "<title>Reconcile</title>")
+	assert.Contains(t, string(data), "No reconciliation run yet.")
+}
+
+func TestActionExecReconcileReportsDifferences(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	srv := mairlisttest.NewServer(6)
+	defer srv.Close()
+	srv.SetItems(mairlisttest.Item{Filename: "/audio/jingle.mp3", Class: "File", State: "none"})
+	cfg.Export.MairListUrl = srv.URL
+	cfg.Export.MairListVersion = 6
+	router.POST(actionUrl, uh.ExecAction)
+	router.GET("/reconcile/json", uh.ReconcileJson)
+	form := url.Values{"action": {"reconcile"}}
+
+	data, statusCode := runRequest(form)
+	job := waitForActionJob(t, data)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/reconcile/json", nil))
+	var report map[string]any
+	err := json.NewDecoder(recorder.Result().Body).Decode(&report)
+
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+	assert.Equal(t, "succeeded", job.Status)
+	assert.Equal(t, "Reconciliation completed: 0 missing, 1 extra, 0 out of order.", job.Message)
+	assert.Nil(t, err)
+	assert.Len(t, report["extra"], 1)
+}
+
+func TestStatusPageShowsPendingDeliveries(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Misc.OutboxSaveFile = ""
+	outboxRepo := repositories.NewOutboxRepository(&cfg)
+	outboxRepo.Enqueue(domain.OutboxEntry{Slot: "2026-10-19-20", Attempts: 3, LastError: "connection refused", Status: domain.OutboxPending})
+	uh.Outbox = &outboxRepo
+	router.GET("/", uh.StatusPage)
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "Pending mAirList Deliveries")
+	assert.Contains(t, string(data), "2026-10-19-20")
+	assert.Contains(t, string(data), "connection refused")
+}
+
+func TestActionExecExportForDateReturnsOk(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	router.POST(actionUrl, uh.ExecAction)
+	form := url.Values{"action": {"export"}, "hour": {"13"}, "date": {"2026-10-19"}}
+
+	data, statusCode := runRequest(form)
+
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+	job := waitForActionJob(t, data)
+	assert.Equal(t, "succeeded", job.Status)
+	assert.Equal(t, "Export completed for 2026-10-19 hour 13.", job.Message)
+}
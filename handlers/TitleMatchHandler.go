@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type TitleMatchHandler struct {
+	Matches titleMatcher
+}
+
+type titleMatcher interface {
+	GetTitleMatches() []domain.TitleMatch
+	ConfirmTitleMatch(string) error
+	RejectTitleMatch(string) error
+}
+
+// NewTitleMatchHandler creates a new handler for reviewing the title matches of files without event id and injects its dependencies
+func NewTitleMatchHandler(matches titleMatcher) TitleMatchHandler {
+	return TitleMatchHandler{
+		Matches: matches,
+	}
+}
+
+// MatchesPage is the handler for the page listing the files linked to events by title and the suggestions awaiting confirmation
+func (th *TitleMatchHandler) MatchesPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "matches.page.tmpl", gin.H{
+		"title":   "Title Matches",
+		"matches": th.Matches.GetTitleMatches(),
+	})
+}
+
+// ConfirmMatch is the handler invoked when the user confirms a suggested match
+func (th *TitleMatchHandler) ConfirmMatch(c *gin.Context) {
+	th.decide(c, th.Matches.ConfirmTitleMatch)
+}
+
+// RejectMatch is the handler invoked when the user rejects a match
+func (th *TitleMatchHandler) RejectMatch(c *gin.Context) {
+	th.decide(c, th.Matches.RejectTitleMatch)
+}
+
+func (th *TitleMatchHandler) decide(c *gin.Context, decision func(string) error) {
+	path := strings.TrimSpace(c.PostForm("path"))
+	if path == "" {
+		apiErr := api_error.NewBadRequestError("path must be given")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	if err := decision(path); err != nil {
+		logger.Error("Error deciding on title match", err)
+		apiErr := api_error.NewBadRequestError(err.Error())
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/matches")
+}
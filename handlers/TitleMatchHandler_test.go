@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTitleMatcher struct {
+	matches   []domain.TitleMatch
+	confirmed []string
+	rejected  []string
+}
+
+func (f *fakeTitleMatcher) GetTitleMatches() []domain.TitleMatch {
+	return f.matches
+}
+
+func (f *fakeTitleMatcher) ConfirmTitleMatch(path string) error {
+	f.confirmed = append(f.confirmed, path)
+	return nil
+}
+
+func (f *fakeTitleMatcher) RejectTitleMatch(path string) error {
+	if len(f.matches) == 0 {
+		return errors.New("no match for this file")
+	}
+	f.rejected = append(f.rejected, path)
+	return nil
+}
+
+func setupMatchUiTest(matcher *fakeTitleMatcher) {
+	matchHandler := NewTitleMatchHandler(matcher)
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/matches", matchHandler.MatchesPage)
+	router.POST("/matches/confirm", matchHandler.ConfirmMatch)
+	router.POST("/matches/reject", matchHandler.RejectMatch)
+	recorder = httptest.NewRecorder()
+}
+
+func TestMatchesPageOffersConfirmationOfSuggestions(t *testing.T) {
+	setupMatchUiTest(&fakeTitleMatcher{matches: []domain.TitleMatch{{
+		Path:       "/root/2026/10/19/2000-2100_quatsch.mp3",
+		FolderDate: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		FileStart:  time.Date(2026, 10, 19, 20, 0, 0, 0, time.Local),
+		EventId:    4711,
+		EventTitle: "Quatschbrötchen",
+		EventStart: time.Date(2026, 10, 19, 20, 0, 0, 0, time.Local),
+		Confidence: 0.61,
+		Status:     domain.TitleMatchSuggested,
+	}}})
+
+	data, res := getAsRun("/matches")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, data, "<title>Title Matches</title>")
+	assert.Contains(t, data, "Quatschbrötchen (4711)")
+	assert.Contains(t, data, "61%")
+	assert.Contains(t, data, "/matches/confirm")
+}
+
+func TestConfirmMatchRedirectsToMatches(t *testing.T) {
+	matcher := &fakeTitleMatcher{}
+	setupMatchUiTest(matcher)
+
+	_, statusCode := postOverrideForm("/matches/confirm", url.Values{"path": {"A.mp3"}})
+
+	assert.EqualValues(t, http.StatusSeeOther, statusCode)
+	assert.EqualValues(t, []string{"A.mp3"}, matcher.confirmed)
+}
+
+func TestRejectMatchWithoutMatchReturnsBadRequest(t *testing.T) {
+	setupMatchUiTest(&fakeTitleMatcher{})
+
+	_, statusCode := postOverrideForm("/matches/reject", url.Values{"path": {"A.mp3"}})
+
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+}
@@ -0,0 +1,47 @@
+package helper
+
+// DiffLine is one line of a line-based diff. Op is "+" for added, "-" for removed and " " for unchanged lines
+type DiffLine struct {
+	Op   string
+	Text string
+}
+
+// DiffLines compares two texts line by line using their longest common subsequence
+func DiffLines(oldLines, newLines []string) []DiffLine {
+	var diff []DiffLine
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			diff = append(diff, DiffLine{Op: " ", Text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: "-", Text: oldLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: "+", Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		diff = append(diff, DiffLine{Op: "-", Text: oldLines[i]})
+	}
+	for ; j < len(newLines); j++ {
+		diff = append(diff, DiffLine{Op: "+", Text: newLines[j]})
+	}
+	return diff
+}
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLinesEqualTextsReturnsUnchangedLines(t *testing.T) {
+	diff := DiffLines([]string{"a", "b"}, []string{"a", "b"})
+
+	assert.EqualValues(t, []DiffLine{{Op: " ", Text: "a"}, {Op: " ", Text: "b"}}, diff)
+}
+
+func TestDiffLinesReturnsChanges(t *testing.T) {
+	diff := DiffLines([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+
+	assert.EqualValues(t, []DiffLine{
+		{Op: " ", Text: "a"},
+		{Op: "-", Text: "b"},
+		{Op: "+", Text: "x"},
+		{Op: " ", Text: "c"},
+		{Op: "+", Text: "d"},
+	}, diff)
+}
+
+func TestDiffLinesEmptyOldReturnsAllAdded(t *testing.T) {
+	diff := DiffLines(nil, []string{"a"})
+
+	assert.EqualValues(t, []DiffLine{{Op: "+", Text: "a"}}, diff)
+}
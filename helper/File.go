@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to a temporary file next to the destination and installs it, so readers never see a partial file
+func WriteFileAtomic(fileName string, data []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	closeWithError := func(currentErr error) error {
+		if closeErr := tmpFile.Close(); currentErr == nil {
+			return closeErr
+		}
+		return currentErr
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		return closeWithError(err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		return closeWithError(err)
+	}
+	if err := tmpFile.Chmod(perm); err != nil {
+		return closeWithError(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return replaceFile(tmpPath, fileName)
+}
+
+// replaceFile installs a fully-written temporary file. On platforms where rename
+// cannot replace an existing file, the old file is restored if installation fails.
+func replaceFile(tmpPath, destination string) error {
+	if err := os.Rename(tmpPath, destination); err == nil {
+		return nil
+	}
+	backupFile, err := os.CreateTemp(filepath.Dir(destination), filepath.Base(destination)+".*.bak")
+	if err != nil {
+		return err
+	}
+	backupPath := backupFile.Name()
+	if err := backupFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(backupPath); err != nil {
+		return err
+	}
+	destinationMoved := false
+	if err := os.Rename(destination, backupPath); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	} else {
+		destinationMoved = true
+	}
+	if err := os.Rename(tmpPath, destination); err != nil {
+		if destinationMoved {
+			_ = os.Rename(backupPath, destination)
+		}
+		return err
+	}
+	if destinationMoved {
+		_ = os.Remove(backupPath)
+	}
+	return nil
+}
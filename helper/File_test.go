@@ -0,0 +1,41 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomicReplacesExistingFile(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "playlist.tpi")
+	require.NoError(t, os.WriteFile(destination, []byte("stale data"), 0644))
+
+	err := WriteFileAtomic(destination, []byte("new data"), 0644)
+
+	require.NoError(t, err)
+	data, readErr := os.ReadFile(destination)
+	require.NoError(t, readErr)
+	assert.Equal(t, "new data", string(data))
+	leftovers, globErr := filepath.Glob(filepath.Join(filepath.Dir(destination), "*.tmp"))
+	require.NoError(t, globErr)
+	assert.Empty(t, leftovers)
+}
+
+func TestReplaceFileRestoresDestinationWhenInstallFails(t *testing.T) {
+	dir := t.TempDir()
+	destination := filepath.Join(dir, "playlist.tpi")
+	require.NoError(t, os.WriteFile(destination, []byte("existing playlist"), 0644))
+
+	err := replaceFile(filepath.Join(dir, "missing.tmp"), destination)
+
+	require.Error(t, err)
+	data, readErr := os.ReadFile(destination)
+	require.NoError(t, readErr)
+	assert.Equal(t, "existing playlist", string(data))
+	backups, globErr := filepath.Glob(filepath.Join(dir, "*.bak"))
+	require.NoError(t, globErr)
+	assert.Empty(t, backups)
+}
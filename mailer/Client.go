@@ -0,0 +1,136 @@
+// package mailer implements a client sending plain text emails via SMTP
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+)
+
+var (
+	ErrNoRecipients = errors.New("message has no recipients")
+)
+
+// Client delivers messages to one SMTP server. STARTTLS is used when the server offers it
+type Client struct {
+	Host     string
+	Port     int
+	User     string // leave empty to send without authentication
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// Message is a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// NewClient creates a client for the SMTP server given in the configuration
+func NewClient(cfg *config.AppConfig) Client {
+	return Client{
+		Host:     cfg.Reminder.SmtpHost,
+		Port:     cfg.Reminder.SmtpPort,
+		User:     cfg.Reminder.SmtpUser,
+		Password: cfg.Reminder.SmtpPassword,
+		From:     cfg.Reminder.From,
+		Timeout:  30 * time.Second,
+	}
+}
+
+// Send delivers a message to all its recipients
+func (c Client) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	from, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address %q: %w", c.From, err)
+	}
+	var recipients []string
+	for _, to := range msg.To {
+		address, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("invalid recipient address %q: %w", to, err)
+		}
+		recipients = append(recipients, address.Address)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.Host, strconv.Itoa(c.Port)))
+	if err != nil {
+		return fmt.Errorf("could not connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("could not connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.Host}); err != nil {
+			return fmt.Errorf("could not start TLS: %w", err)
+		}
+	}
+	if c.User != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.User, c.Password, c.Host)); err != nil {
+			return fmt.Errorf("could not authenticate with SMTP server: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %v was rejected: %w", recipient, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(c.compose(msg, time.Now())); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose renders the headers and the body of a message with CRLF line endings
+func (c Client) compose(msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", c.From)
+	fmt.Fprintf(&b, "To: %v\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %v\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	if !strings.HasSuffix(body, "\n") {
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/mailer/mailertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMailer(t *testing.T) (Client, *mailertest.Server) {
+	t.Helper()
+	srv := mailertest.NewServer()
+	t.Cleanup(srv.Close)
+	return Client{Host: srv.Host(), Port: srv.Port(), From: "Feeder <feeder@coloradio.org>", Timeout: 5 * time.Second}, srv
+}
+
+func TestSendDeliversMessage(t *testing.T) {
+	client, srv := setupMailer(t)
+
+	err := client.Send(context.Background(), Message{To: []string{"producer@example.org", "Other <other@example.org>"}, Subject: "Fehlende Datei für Abendshow", Body: "Hello,\nplease upload.\n"})
+
+	require.NoError(t, err)
+	messages := srv.Messages()
+	require.Len(t, messages, 1)
+	assert.EqualValues(t, "feeder@coloradio.org", messages[0].From)
+	assert.EqualValues(t, []string{"producer@example.org", "other@example.org"}, messages[0].To)
+	assert.EqualValues(t, "=?utf-8?q?Fehlende_Datei_f=C3=BCr_Abendshow?=", messages[0].Header("Subject"))
+	assert.EqualValues(t, "producer@example.org, Other <other@example.org>", messages[0].Header("To"))
+	assert.EqualValues(t, "Hello,\nplease upload.\n", messages[0].Body())
+	assert.Empty(t, srv.Auths())
+}
+
+func TestSendWithUserAuthenticates(t *testing.T) {
+	client, srv := setupMailer(t)
+	client.User = "feeder"
+	client.Password = "secret"
+
+	err := client.Send(context.Background(), Message{To: []string{"producer@example.org"}, Subject: "Reminder", Body: "Hello"})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"feeder"}, srv.Auths())
+	require.Len(t, srv.Messages(), 1)
+	assert.EqualValues(t, "Hello\n", srv.Messages()[0].Body())
+}
+
+func TestSendRejectedRecipientReturnsError(t *testing.T) {
+	client, srv := setupMailer(t)
+	srv.Reject("gone@example.org")
+
+	err := client.Send(context.Background(), Message{To: []string{"gone@example.org"}, Subject: "Reminder", Body: "Hello"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "recipient gone@example.org was rejected")
+	assert.Empty(t, srv.Messages())
+}
+
+func TestSendWithoutRecipientsReturnsError(t *testing.T) {
+	client, _ := setupMailer(t)
+
+	err := client.Send(context.Background(), Message{Subject: "Reminder"})
+
+	assert.ErrorIs(t, err, ErrNoRecipients)
+}
+
+func TestSendUnreachableServerReturnsError(t *testing.T) {
+	client, srv := setupMailer(t)
+	srv.Close()
+
+	err := client.Send(context.Background(), Message{To: []string{"producer@example.org"}, Subject: "Reminder"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not connect to SMTP server")
+}
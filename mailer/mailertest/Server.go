@@ -0,0 +1,196 @@
+// package mailertest provides a fake SMTP server for tests
+package mailertest
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Message is an email received by the fake server
+type Message struct {
+	From string
+	To   []string
+	Data string // headers and body as sent by the client, with CRLF line endings
+}
+
+// Server is a fake SMTP server accepting all messages. It understands EHLO, HELO, AUTH PLAIN, MAIL, RCPT, DATA, RSET,
+// NOOP and QUIT and offers neither STARTTLS nor any other extension
+type Server struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	auths    []string
+	reject   map[string]bool
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a fake SMTP server on a random local port. Close it after use
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("mailertest: could not listen: " + err.Error())
+	}
+	s := &Server{listener: listener, reject: make(map[string]bool)}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Host returns the host the server listens on
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Messages returns all messages received so far
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// Auths returns the user names of all successful logins
+func (s *Server) Auths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.auths)
+}
+
+// Reject makes the server refuse the given recipient address
+func (s *Server) Reject(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject[strings.ToLower(address)] = true
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle speaks SMTP on one connection until the client quits
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var msg Message
+	tp.PrintfLine("220 mailertest ESMTP ready")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			tp.PrintfLine("250-mailertest")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "HELO":
+			tp.PrintfLine("250 mailertest")
+		case "AUTH":
+			s.auth(tp, arg)
+		case "MAIL":
+			msg = Message{From: address(arg)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			to := address(arg)
+			s.mu.Lock()
+			rejected := s.reject[strings.ToLower(to)]
+			s.mu.Unlock()
+			if rejected {
+				tp.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			msg.To = append(msg.To, to)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = strings.ReplaceAll(string(data), "\n", "\r\n")
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = Message{}
+			tp.PrintfLine("250 OK queued")
+		case "RSET":
+			msg = Message{}
+			tp.PrintfLine("250 OK")
+		case "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// auth accepts any AUTH PLAIN login and remembers the user name
+func (s *Server) auth(tp *textproto.Conn, arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mechanism, "PLAIN") || initial == "" {
+		tp.PrintfLine("504 unrecognized authentication type")
+		return
+	}
+	user := ""
+	if decoded, err := base64.StdEncoding.DecodeString(initial); err == nil {
+		if parts := strings.Split(string(decoded), "\x00"); len(parts) == 3 {
+			user = parts[1]
+		}
+	}
+	s.mu.Lock()
+	s.auths = append(s.auths, user)
+	s.mu.Unlock()
+	tp.PrintfLine("235 authentication successful")
+}
+
+// address extracts the mail address from the argument of MAIL FROM or RCPT TO
+func address(arg string) string {
+	_, value, _ := strings.Cut(arg, ":")
+	value, _, _ = strings.Cut(strings.TrimSpace(value), " ")
+	return strings.Trim(value, "<>")
+}
+
+// Header returns the value of a header of a received message, or "" if it is missing
+func (m Message) Header(name string) string {
+	tp := textproto.NewReader(bufio.NewReader(strings.NewReader(m.Data)))
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && len(header) == 0 {
+		return ""
+	}
+	return header.Get(name)
+}
+
+// Body returns the body of a received message with LF line endings
+func (m Message) Body() string {
+	_, body, _ := strings.Cut(m.Data, "\r\n\r\n")
+	return strings.ReplaceAll(body, "\r\n", "\n")
+}
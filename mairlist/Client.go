@@ -0,0 +1,246 @@
+// package mairlist implements a client for the mAirList remote control API
+package mairlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+)
+
+var (
+	ErrUrlEmpty = errors.New("url cannot be empty")
+	ErrNotFound = errors.New("url not found")
+)
+
+// Client sends commands to and reads the playlist from one mAirList instance
+type Client struct {
+	BaseUrl    string
+	User       string
+	Password   string
+	Version    int // mAirList major version, determines the response formats
+	Playlist   int // playlist index as used in commands, starting at 1
+	HttpClient *http.Client
+	OnResult   func(success bool) // called after each request with the outcome of the communication, optional
+}
+
+// NewClient creates a client for the mAirList instance given in the configuration
+func NewClient(cfg *config.AppConfig, httpClient *http.Client) Client {
+	return Client{
+		BaseUrl:    cfg.Export.MairListUrl,
+		User:       cfg.Export.MairListUser,
+		Password:   cfg.Export.MairListPassword,
+		Version:    cfg.Export.MairListVersion,
+		Playlist:   cfg.Export.MairListPlaylist,
+		HttpClient: httpClient,
+	}
+}
+
+// NewTargetClient creates a client for one of the configured mAirList instances
+func NewTargetClient(cfg *config.AppConfig, target config.MairListTarget, httpClient *http.Client) Client {
+	return Client{
+		BaseUrl:    target.Url,
+		User:       target.User,
+		Password:   target.Password,
+		Version:    target.Version,
+		Playlist:   cfg.Export.MairListPlaylist,
+		HttpClient: httpClient,
+	}
+}
+
+// IsUnreachable checks whether an error means the mAirList instance could not be reached,
+// as opposed to mAirList refusing a command
+func IsUnreachable(err error) bool {
+	var (
+		urlErr    *url.Error
+		statusErr *StatusError
+	)
+	return errors.As(err, &urlErr) || errors.As(err, &statusErr) || errors.Is(err, ErrNotFound)
+}
+
+// playlistIndex returns the playlist index used in commands, defaulting to the first playlist
+func (c Client) playlistIndex() int {
+	return max(c.Playlist, 1)
+}
+
+// Execute runs an arbitrary command, e.g. "PLAYLIST 1 APPEND <file>", and checks that mAirList acknowledged it
+func (c Client) Execute(ctx context.Context, command string) error {
+	req, err := c.BuildCommandRequest(ctx, command)
+	if err != nil {
+		return err
+	}
+	data, statusCode, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	if statusCode == http.StatusOK && IsOkReply(c.Version, data) {
+		return nil
+	}
+	return errors.New(string(data))
+}
+
+// Append appends a playlist file or a single audio file to the end of the playlist
+func (c Client) Append(ctx context.Context, fileName string) error {
+	return c.Execute(ctx, c.AppendCommand(fileName))
+}
+
+// AppendCommand returns the command appending a file to the end of the playlist
+func (c Client) AppendCommand(fileName string) string {
+	return fmt.Sprintf("PLAYLIST %d APPEND %v", c.playlistIndex(), fileName)
+}
+
+// Insert inserts a playlist file or a single audio file at the given position of the playlist, starting at 0
+func (c Client) Insert(ctx context.Context, position int, fileName string) error {
+	if position < 0 {
+		return errors.New("position must not be negative")
+	}
+	return c.Execute(ctx, fmt.Sprintf("PLAYLIST %d INSERT %d %v", c.playlistIndex(), position, fileName))
+}
+
+// ClearAfter removes all items after the given position of the playlist
+func (c Client) ClearAfter(ctx context.Context, position int) error {
+	if position < 0 {
+		return errors.New("position must not be negative")
+	}
+	return c.Execute(ctx, fmt.Sprintf("PLAYLIST %d CLEAR AFTER %d", c.playlistIndex(), position))
+}
+
+// PlayerStart starts a player of the playlist, players are given as "A", "B", ... or "1", "2", ...
+func (c Client) PlayerStart(ctx context.Context, player string) error {
+	return c.playerCommand(ctx, player, "START")
+}
+
+// PlayerStop stops a player of the playlist
+func (c Client) PlayerStop(ctx context.Context, player string) error {
+	return c.playerCommand(ctx, player, "STOP")
+}
+
+func (c Client) playerCommand(ctx context.Context, player string, action string) error {
+	if strings.TrimSpace(player) == "" || strings.ContainsAny(player, " \t\r\n") {
+		return errors.New("invalid player")
+	}
+	return c.Execute(ctx, fmt.Sprintf("PLAYER %d-%v %v", c.playlistIndex(), player, action))
+}
+
+// SetAutomation switches the automation of the playlist on or off
+func (c Client) SetAutomation(ctx context.Context, on bool) error {
+	state := "OFF"
+	if on {
+		state = "ON"
+	}
+	return c.Execute(ctx, fmt.Sprintf("AUTOMATION %d %v", c.playlistIndex(), state))
+}
+
+// GetPlaylist reads the content of the playlist. The response format depends on the mAirList version
+func (c Client) GetPlaylist(ctx context.Context) (Playlist, error) {
+	req, err := c.BuildGetPlaylistRequest(ctx)
+	if err != nil {
+		return Playlist{}, err
+	}
+	data, statusCode, err := c.do(req)
+	if err != nil {
+		return Playlist{}, err
+	}
+	if statusCode != http.StatusOK {
+		return Playlist{}, &StatusError{StatusCode: statusCode}
+	}
+	return ParsePlaylist(c.Version, data)
+}
+
+// StatusError is returned when mAirList answers a request with an unexpected HTTP status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("mAirList playlist request failed: HTTP %d", e.StatusCode)
+}
+
+// IsOkReply checks the acknowledgement of a command. mAirList 5 answers with ok, mAirList 6 and later with "ok" as JSON string
+func IsOkReply(version int, data []byte) bool {
+	if version >= 6 {
+		return string(data) == "\"ok\""
+	}
+	return string(data) == "ok"
+}
+
+// BuildCommandRequest constructs the API request executing a command
+func (c Client) BuildCommandRequest(ctx context.Context, command string) (*http.Request, error) {
+	apiUrl, err := c.apiUrl("/execute")
+	if err != nil {
+		return nil, err
+	}
+	cmd := url.Values{}
+	cmd.Set("command", command)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiUrl, strings.NewReader(cmd.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.User, c.Password)
+	return req, nil
+}
+
+// BuildGetPlaylistRequest constructs the API request reading the playlist. The API counts playlists starting at 0
+func (c Client) BuildGetPlaylistRequest(ctx context.Context) (*http.Request, error) {
+	apiUrl, err := c.apiUrl("/playlist/" + strconv.Itoa(c.playlistIndex()-1) + "/content")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.User, c.Password)
+	return req, nil
+}
+
+func (c Client) apiUrl(apiPath string) (string, error) {
+	if c.BaseUrl == "" {
+		return "", ErrUrlEmpty
+	}
+	mairListUrl, err := url.Parse(c.BaseUrl)
+	if err != nil {
+		return "", err
+	}
+	mairListUrl.Path = path.Join(mairListUrl.Path, apiPath)
+	return mairListUrl.String(), nil
+}
+
+// do executes the request against mAirList and returns the data
+func (c Client) do(req *http.Request) (respData []byte, respStatus int, err error) {
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		c.report(false)
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		c.report(false)
+		return nil, resp.StatusCode, ErrNotFound
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.report(false)
+		return nil, resp.StatusCode, err
+	}
+	c.report(true)
+	return b, resp.StatusCode, nil
+}
+
+func (c Client) report(success bool) {
+	if c.OnResult != nil {
+		c.OnResult(success)
+	}
+}
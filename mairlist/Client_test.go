@@ -0,0 +1,226 @@
+package mairlist
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupClientTest(t *testing.T, version int) (*mairlisttest.Server, Client) {
+	t.Helper()
+	srv := mairlisttest.NewServer(version)
+	srv.User = "user"
+	srv.Password = "pass"
+	t.Cleanup(srv.Close)
+	var clientCfg config.AppConfig
+	clientCfg.Export.MairListUrl = srv.URL
+	clientCfg.Export.MairListUser = "user"
+	clientCfg.Export.MairListPassword = "pass"
+	clientCfg.Export.MairListVersion = version
+	clientCfg.Export.MairListPlaylist = 2
+	return srv, NewClient(&clientCfg, srv.Client())
+}
+
+func TestBuildCommandRequestEmptyUrlReturnsError(t *testing.T) {
+	client := Client{}
+
+	req, err := client.BuildCommandRequest(context.Background(), "AUTOMATION 1 ON")
+
+	assert.Nil(t, req)
+	assert.ErrorIs(t, err, ErrUrlEmpty)
+}
+
+func TestBuildGetPlaylistRequestUsesZeroBasedIndex(t *testing.T) {
+	client := Client{BaseUrl: "http://localhost:9300/", Playlist: 2}
+
+	req, err := client.BuildGetPlaylistRequest(context.Background())
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "http://localhost:9300/playlist/1/content", req.URL.String())
+}
+
+func TestBuildCommandRequestDefaultsToFirstPlaylist(t *testing.T) {
+	client := Client{BaseUrl: "http://localhost:9300/"}
+
+	req, err := client.BuildCommandRequest(context.Background(), client.AppendCommand("test"))
+	b, _ := io.ReadAll(req.Body)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "command=PLAYLIST+1+APPEND+test", string(b))
+}
+
+func TestIsOkReplyDependsOnVersion(t *testing.T) {
+	assert.True(t, IsOkReply(5, []byte("ok")))
+	assert.False(t, IsOkReply(5, []byte("\"ok\"")))
+	assert.True(t, IsOkReply(6, []byte("\"ok\"")))
+	assert.False(t, IsOkReply(6, []byte("ok")))
+}
+
+func TestClientCommandsAreSentToConfiguredPlaylist(t *testing.T) {
+	for _, version := range []int{5, 6} {
+		srv, client := setupClientTest(t, version)
+		ctx := context.Background()
+
+		require.NoError(t, client.Append(ctx, "b.tpi"))
+		require.NoError(t, client.Insert(ctx, 0, "a.tpi"))
+		require.NoError(t, client.Append(ctx, "c.tpi"))
+		require.NoError(t, client.ClearAfter(ctx, 1))
+		require.NoError(t, client.PlayerStart(ctx, "A"))
+		require.NoError(t, client.PlayerStop(ctx, "A"))
+		require.NoError(t, client.SetAutomation(ctx, true))
+
+		assert.EqualValues(t, []string{
+			"PLAYLIST 2 APPEND b.tpi",
+			"PLAYLIST 2 INSERT 0 a.tpi",
+			"PLAYLIST 2 APPEND c.tpi",
+			"PLAYLIST 2 CLEAR AFTER 1",
+			"PLAYER 2-A START",
+			"PLAYER 2-A STOP",
+			"AUTOMATION 2 ON",
+		}, srv.Commands())
+		require.Len(t, srv.Items(), 2)
+		assert.EqualValues(t, "a.tpi", srv.Items()[0].Filename)
+		assert.EqualValues(t, "b.tpi", srv.Items()[1].Filename)
+		assert.True(t, srv.Automation())
+	}
+}
+
+func TestExecuteNotAcknowledgedReturnsReply(t *testing.T) {
+	srv, client := setupClientTest(t, 6)
+	srv.FailWith("\"error\"")
+
+	err := client.Execute(context.Background(), "AUTOMATION 1 OFF")
+
+	assert.EqualValues(t, "\"error\"", err.Error())
+}
+
+func TestExecuteWrongCredentialsReturnsError(t *testing.T) {
+	_, client := setupClientTest(t, 6)
+	client.Password = "wrong"
+
+	err := client.SetAutomation(context.Background(), false)
+
+	assert.NotNil(t, err)
+}
+
+func TestExecuteReportsCommunicationResult(t *testing.T) {
+	_, client := setupClientTest(t, 6)
+	var results []bool
+	client.OnResult = func(success bool) { results = append(results, success) }
+
+	client.SetAutomation(context.Background(), true)
+	client.BaseUrl = client.BaseUrl + "/missing"
+	err := client.SetAutomation(context.Background(), true)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.EqualValues(t, []bool{true, false}, results)
+}
+
+func TestPlayerCommandInvalidPlayerReturnsError(t *testing.T) {
+	srv, client := setupClientTest(t, 6)
+
+	err := client.PlayerStart(context.Background(), "A STOP")
+
+	assert.EqualValues(t, "invalid player", err.Error())
+	assert.Empty(t, srv.Commands())
+}
+
+func TestInsertNegativePositionReturnsError(t *testing.T) {
+	_, client := setupClientTest(t, 6)
+
+	err := client.Insert(context.Background(), -1, "a.tpi")
+
+	assert.EqualValues(t, "position must not be negative", err.Error())
+}
+
+func TestGetPlaylistParsesVersionSpecificFormat(t *testing.T) {
+	for _, version := range []int{5, 6} {
+		srv, client := setupClientTest(t, version)
+		srv.SetItems(
+			mairlisttest.Item{Filename: "played.mp3", Class: "File", State: "played", Duration: 60},
+			mairlisttest.Item{Filename: "current.mp3", Class: "File", State: "playing", Duration: 3600.5},
+		)
+
+		playlist, err := client.GetPlaylist(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, playlist.Items, 2)
+		assert.True(t, playlist.Playing())
+		assert.EqualValues(t, "current.mp3", playlist.Current().Filename)
+		assert.EqualValues(t, time.Hour+500*time.Millisecond, playlist.Current().Duration)
+	}
+}
+
+func TestGetPlaylistSilenceIsNotPlaying(t *testing.T) {
+	srv, client := setupClientTest(t, 6)
+	srv.SetItems(mairlisttest.Item{Class: "InfiniteSilence", State: "playing"})
+
+	playlist, err := client.GetPlaylist(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, playlist.Playing())
+}
+
+func TestParsePlaylistSamples(t *testing.T) {
+	xmlData, _ := os.ReadFile("../samples/mairlist_playlist_playing.xml")
+	jsonData, _ := os.ReadFile("../samples/mairlist_playlist_playing.json")
+
+	xmlPlaylist, xmlErr := ParsePlaylist(5, xmlData)
+	jsonPlaylist, jsonErr := ParsePlaylist(6, jsonData)
+
+	require.NoError(t, xmlErr)
+	require.NoError(t, jsonErr)
+	assert.EqualValues(t, 20460*time.Millisecond, xmlPlaylist.Current().Duration)
+	assert.EqualValues(t, "2030-2130_quatschbrötchen 121", jsonPlaylist.Current().Title)
+}
+
+func TestGetPlaylistParsesMusicAttributes(t *testing.T) {
+	for _, version := range []int{5, 6} {
+		srv, client := setupClientTest(t, version)
+		srv.SetItems(mairlisttest.Item{ID: "42", Filename: "song.mp3", Class: "File", State: "playing", Artist: "Band", Album: "Album", Genre: "Pop", Year: "1999", Duration: 180, PlaybackPosition: 12.5})
+
+		playlist, err := client.GetPlaylist(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, playlist.Items, 1)
+		assert.EqualValues(t, PlaylistItem{
+			ID:               "42",
+			Class:            "File",
+			State:            "playing",
+			Filename:         "song.mp3",
+			Artist:           "Band",
+			Album:            "Album",
+			Genre:            "Pop",
+			Year:             "1999",
+			Duration:         3 * time.Minute,
+			PlaybackPosition: 12500 * time.Millisecond,
+		}, playlist.Items[0])
+	}
+}
+
+func TestParsePositionAcceptsSecondsAndTime(t *testing.T) {
+	assert.EqualValues(t, 90500*time.Millisecond, parsePosition("90.5"))
+	assert.EqualValues(t, 61*time.Minute+2*time.Second, parsePosition("01:01:02"))
+	assert.EqualValues(t, 0, parsePosition("soon"))
+	assert.EqualValues(t, 0, parsePosition(""))
+}
+
+func TestIsUnreachableOnlyForConnectionFailures(t *testing.T) {
+	srv, client := setupClientTest(t, 6)
+	srv.FailWith("\"error\"")
+
+	refused := client.Execute(context.Background(), "AUTOMATION 1 OFF")
+	srv.Close()
+	down := client.Execute(context.Background(), "AUTOMATION 1 OFF")
+
+	assert.False(t, IsUnreachable(refused))
+	assert.True(t, IsUnreachable(down))
+	assert.True(t, IsUnreachable(&StatusError{StatusCode: 503}))
+}
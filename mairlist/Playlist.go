@@ -0,0 +1,137 @@
+package mairlist
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+)
+
+// Playlist is the version-independent content of a mAirList playlist
+type Playlist struct {
+	Items []PlaylistItem
+}
+
+// PlaylistItem is one entry of a mAirList playlist
+type PlaylistItem struct {
+	ID               string
+	Class            string
+	State            string
+	Time             string
+	Filename         string
+	Title            string
+	Artist           string
+	Album            string
+	Genre            string
+	Year             string
+	Duration         time.Duration
+	PlaybackPosition time.Duration // position of the playing item
+}
+
+// Playing returns true, if an item other than silence is currently playing
+func (p Playlist) Playing() bool {
+	return p.Current() != nil
+}
+
+// Current returns the item currently playing, nil if nothing but silence is playing
+func (p Playlist) Current() *PlaylistItem {
+	for i, item := range p.Items {
+		if item.State == "playing" && item.Class != "InfiniteSilence" {
+			return &p.Items[i]
+		}
+	}
+	return nil
+}
+
+// ParsePlaylist converts the playlist returned by mAirList. mAirList 5 returns XML, mAirList 6 and later return JSON
+func ParsePlaylist(version int, data []byte) (Playlist, error) {
+	if version >= 6 {
+		return ParsePlaylistJson(data)
+	}
+	return ParsePlaylistXml(data)
+}
+
+// ParsePlaylistXml converts a playlist in the XML format of mAirList 5
+func ParsePlaylistXml(data []byte) (Playlist, error) {
+	var playList domain.MairListPlaylistXml
+	if err := xml.Unmarshal(data, &playList); err != nil {
+		return Playlist{}, err
+	}
+	items := make([]PlaylistItem, 0, len(playList.PlaylistItem))
+	for _, item := range playList.PlaylistItem {
+		seconds, _ := strconv.ParseFloat(item.Duration, 64)
+		attributes := make(map[string]string)
+		for _, attribute := range item.Attributes.Item {
+			attributes[strings.ToLower(attribute.Name)] = attribute.Value
+		}
+		items = append(items, PlaylistItem{
+			ID:               item.DatabaseID,
+			Class:            item.Class,
+			State:            item.State,
+			Time:             item.Time,
+			Filename:         item.Filename,
+			Title:            item.Title,
+			Artist:           item.Artist,
+			Album:            attributes["album"],
+			Genre:            attributes["genre"],
+			Year:             cmp.Or(attributes["year"], attributes["jahr"]),
+			Duration:         secondsToDuration(seconds),
+			PlaybackPosition: parsePosition(item.PlaybackPosition),
+		})
+	}
+	return Playlist{Items: items}, nil
+}
+
+// ParsePlaylistJson converts a playlist in the JSON format of mAirList 6 and later
+func ParsePlaylistJson(data []byte) (Playlist, error) {
+	var playList domain.MairListPlaylistJson
+	if err := json.Unmarshal(data, &playList); err != nil {
+		return Playlist{}, err
+	}
+	items := make([]PlaylistItem, 0, len(playList.Items))
+	for _, item := range playList.Items {
+		items = append(items, PlaylistItem{
+			ID:               item.ID,
+			Class:            item.Class,
+			State:            item.State,
+			Time:             item.Time,
+			Filename:         item.Filename,
+			Title:            item.Title,
+			Artist:           item.Artist,
+			Album:            item.Attributes.Album,
+			Genre:            item.Attributes.Genre,
+			Year:             item.Attributes.Jahr,
+			Duration:         secondsToDuration(item.Duration),
+			PlaybackPosition: parsePosition(item.PlaybackPosition),
+		})
+	}
+	return Playlist{Items: items}, nil
+}
+
+// parsePosition converts a playback position given in seconds or as "hh:mm:ss.zzz". Invalid positions are returned as zero
+func parsePosition(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return secondsToDuration(seconds)
+	}
+	var position float64
+	for part := range strings.SplitSeq(value, ":") {
+		unit, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		position = position*60 + unit
+	}
+	return secondsToDuration(position)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+}
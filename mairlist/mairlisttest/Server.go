@@ -0,0 +1,262 @@
+// package mairlisttest provides a fake mAirList server for tests
+package mairlisttest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Item is an entry of the fake playlist
+type Item struct {
+	ID               string
+	Filename         string
+	Title            string
+	State            string
+	Class            string
+	Artist           string
+	Album            string
+	Genre            string
+	Year             string
+	Duration         float64 // seconds
+	PlaybackPosition float64 // seconds
+}
+
+// Server is a fake mAirList instance keeping a single playlist in memory. It understands the commands
+// APPEND, INSERT, CLEAR AFTER, PLAYER START / STOP and AUTOMATION ON / OFF and answers in the format of the given version
+type Server struct {
+	*httptest.Server
+	Version    int
+	User       string
+	Password   string
+	mu         sync.Mutex
+	commands   []string
+	items      []Item
+	automation bool
+	failWith   string
+}
+
+// NewServer starts a fake mAirList server answering like the given mAirList version. Close it after use
+func NewServer(version int) *Server {
+	s := &Server{Version: version}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /execute", s.execute)
+	mux.HandleFunc("GET /playlist/{index}/content", s.content)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Commands returns all commands received so far
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.commands)
+}
+
+// Items returns the current content of the playlist
+func (s *Server) Items() []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.items)
+}
+
+// SetItems replaces the content of the playlist
+func (s *Server) SetItems(items ...Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = slices.Clone(items)
+}
+
+// Automation returns whether the automation was switched on
+func (s *Server) Automation() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.automation
+}
+
+// FailWith makes the server answer all following commands with the given reply instead of ok. Pass "" to reset
+func (s *Server) FailWith(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failWith = reply
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.User == "" {
+		return true
+	}
+	user, pass, ok := r.BasicAuth()
+	return ok && user == s.User && pass == s.Password
+}
+
+func (s *Server) execute(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	command := r.PostFormValue("command")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, command)
+	if s.failWith != "" {
+		w.Write([]byte(s.failWith))
+		return
+	}
+	if err := s.apply(strings.Fields(command)); err != nil {
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if s.Version >= 6 {
+		w.Write([]byte("\"ok\""))
+	} else {
+		w.Write([]byte("ok"))
+	}
+}
+
+// apply changes the playlist according to the command. The caller must hold the lock
+func (s *Server) apply(fields []string) error {
+	switch {
+	case len(fields) >= 4 && fields[0] == "PLAYLIST" && fields[2] == "APPEND":
+		s.items = append(s.items, Item{Filename: strings.Join(fields[3:], " "), Class: "File", State: "none"})
+	case len(fields) >= 5 && fields[0] == "PLAYLIST" && fields[2] == "INSERT":
+		pos, err := strconv.Atoi(fields[3])
+		if err != nil || pos < 0 || pos > len(s.items) {
+			return fmt.Errorf("invalid position %v", fields[3])
+		}
+		s.items = slices.Insert(s.items, pos, Item{Filename: strings.Join(fields[4:], " "), Class: "File", State: "none"})
+	case len(fields) == 5 && fields[0] == "PLAYLIST" && fields[2] == "CLEAR" && fields[3] == "AFTER":
+		pos, err := strconv.Atoi(fields[4])
+		if err != nil || pos < 0 {
+			return fmt.Errorf("invalid position %v", fields[4])
+		}
+		if pos+1 < len(s.items) {
+			s.items = s.items[:pos+1]
+		}
+	case len(fields) == 3 && fields[0] == "PLAYER":
+		return s.player(fields[2])
+	case len(fields) == 3 && fields[0] == "AUTOMATION":
+		s.automation = fields[2] == "ON"
+	default:
+		return fmt.Errorf("unknown command %v", strings.Join(fields, " "))
+	}
+	return nil
+}
+
+// player starts the first item not yet played or stops the playing item. The caller must hold the lock
+func (s *Server) player(action string) error {
+	for i, item := range s.items {
+		switch {
+		case action == "START" && item.State != "played":
+			s.items[i].State = "playing"
+			return nil
+		case action == "STOP" && item.State == "playing":
+			s.items[i].State = "played"
+			return nil
+		}
+	}
+	return nil
+}
+
+type xmlPlaylist struct {
+	XMLName xml.Name  `xml:"Playlist"`
+	Items   []xmlItem `xml:"PlaylistItem"`
+}
+
+type xmlItem struct {
+	Class            string         `xml:"Class,attr"`
+	State            string         `xml:"State,attr"`
+	PlaybackPosition string         `xml:"PlaybackPosition,attr,omitempty"`
+	Filename         string         `xml:"Filename"`
+	Title            string         `xml:"Title"`
+	Artist           string         `xml:"Artist,omitempty"`
+	Duration         string         `xml:"Duration"`
+	DatabaseID       string         `xml:"DatabaseID,omitempty"`
+	Attributes       []xmlAttribute `xml:"Attributes>Item"`
+}
+
+type xmlAttribute struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+type jsonItem struct {
+	ID               string  `json:"ID"`
+	Filename         string  `json:"Filename"`
+	Title            string  `json:"Title"`
+	State            string  `json:"State"`
+	Class            string  `json:"Class"`
+	Duration         float64 `json:"Duration"`
+	PlaybackPosition string  `json:"PlaybackPosition,omitempty"`
+	Artist           string  `json:"Artist,omitempty"`
+	Attributes       struct {
+		Album string `json:"Album"`
+		Genre string `json:"Genre"`
+		Jahr  string `json:"Jahr"`
+	} `json:"Attributes"`
+}
+
+func (s *Server) content(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Version >= 6 {
+		items := make([]jsonItem, 0, len(s.items))
+		for _, item := range s.items {
+			converted := jsonItem{
+				ID:               item.ID,
+				Filename:         item.Filename,
+				Title:            item.Title,
+				State:            item.State,
+				Class:            item.Class,
+				Duration:         item.Duration,
+				PlaybackPosition: position(item),
+				Artist:           item.Artist,
+			}
+			converted.Attributes.Album = item.Album
+			converted.Attributes.Genre = item.Genre
+			converted.Attributes.Jahr = item.Year
+			items = append(items, converted)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string][]jsonItem{"Items": items})
+		return
+	}
+	var playlist xmlPlaylist
+	for _, item := range s.items {
+		playlist.Items = append(playlist.Items, xmlItem{
+			Class:            item.Class,
+			State:            item.State,
+			PlaybackPosition: position(item),
+			Filename:         item.Filename,
+			Title:            item.Title,
+			Artist:           item.Artist,
+			Duration:         strconv.FormatFloat(item.Duration, 'f', 3, 64),
+			DatabaseID:       item.ID,
+			Attributes: []xmlAttribute{
+				{Name: "Album", Value: item.Album},
+				{Name: "Genre", Value: item.Genre},
+				{Name: "Year", Value: item.Year},
+			},
+		})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(playlist)
+}
+
+// position returns the playback position of the playing item in seconds, empty for all other items
+func position(item Item) string {
+	if item.State != "playing" {
+		return ""
+	}
+	return strconv.FormatFloat(item.PlaybackPosition, 'f', 3, 64)
+}
@@ -50,6 +50,38 @@ func InitMetrics(state *appstate.AppState, registry prometheus.Registerer) {
 	}, []string{
 		"eventname",
 	}))
+	state.Metrics.ReconcileItems = registerGaugeVec(registry, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coloradio",
+		Subsystem: "mairlistfeeder",
+		Name:      "reconcile_items",
+		Help:      "Number of exported items matched, missing, extra or out of order in the mAirList playlist",
+	}, []string{
+		"kind",
+	}))
+	state.Metrics.OutboxEntries = registerGaugeVec(registry, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coloradio",
+		Subsystem: "mairlistfeeder",
+		Name:      "outbox_entries",
+		Help:      "Number of playlist appends to mAirList waiting for a retry or failed",
+	}, []string{
+		"status",
+	}))
+	state.Metrics.AlarmActive = registerGaugeVec(registry, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coloradio",
+		Subsystem: "mairlistfeeder",
+		Name:      "alarm_active",
+		Help:      "Whether an alarm of the kind is currently raised",
+	}, []string{
+		"kind",
+	}))
+	state.Metrics.CalCmsDataAge = registerGaugeVec(registry, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coloradio",
+		Subsystem: "mairlistfeeder",
+		Name:      "calcms_data_age_seconds",
+		Help:      "Age of the calCMS program in use, source is calcms or cache",
+	}, []string{
+		"source",
+	}))
 	state.Metrics.RunResults = registerCounterVec(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "coloradio",
 		Subsystem: "mairlistfeeder",
@@ -59,6 +91,15 @@ func InitMetrics(state *appstate.AppState, registry prometheus.Registerer) {
 		"service",
 		"result",
 	}))
+	state.Metrics.LateUploads = registerCounterVec(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "coloradio",
+		Subsystem: "mairlistfeeder",
+		Name:      "late_uploads_total",
+		Help:      "Number of archived preproduced events whose file was uploaded after the deadline or missing, by series",
+	}, []string{
+		"series",
+		"status",
+	}))
 	state.Metrics.RunDurations = registerHistogramVec(registry, prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "coloradio",
@@ -100,7 +141,12 @@ func UnregisterMetrics(state *appstate.AppState, registry prometheus.Registerer)
 	unregister(registry, state.Metrics.Connected)
 	unregister(registry, state.Metrics.EventCounters)
 	unregister(registry, state.Metrics.CrawlIntervals)
+	unregister(registry, state.Metrics.ReconcileItems)
+	unregister(registry, state.Metrics.OutboxEntries)
+	unregister(registry, state.Metrics.AlarmActive)
+	unregister(registry, state.Metrics.CalCmsDataAge)
 	unregister(registry, state.Metrics.RunResults)
+	unregister(registry, state.Metrics.LateUploads)
 	unregister(registry, state.Metrics.RunDurations)
 	unregister(registry, state.Metrics.FastEventDurations)
 }
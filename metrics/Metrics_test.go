@@ -20,6 +20,7 @@ func TestInitMetricsRegistersUsableCollectors(t *testing.T) {
 	state.Metrics.SetMairListPlaying("studio", 1)
 	state.Metrics.SetCrawlInterval("sincelastcrawl", 60)
 	state.Metrics.ObserveFastEvent("crawl", 0.5)
+	state.Metrics.AddLateUpload("Jazz Night", "late")
 
 	families, err := registry.Gather()
 	require.NoError(t, err)
@@ -30,6 +31,7 @@ func TestInitMetricsRegistersUsableCollectors(t *testing.T) {
 	assert.Contains(t, names, "coloradio_mairlistfeeder_file_count")
 	assert.Contains(t, names, "coloradio_mairlistfeeder_subsystem_connection")
 	assert.Contains(t, names, "coloradio_mairlistfeeder_fast_event_duration_seconds")
+	assert.Contains(t, names, "coloradio_mairlistfeeder_late_uploads_total")
 }
 
 func TestUnregisterMetricsRemovesCollectors(t *testing.T) {
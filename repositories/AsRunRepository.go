@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+)
+
+type AsRunRepository interface {
+	Start(domain.AsRunEntry) (domain.AsRunEntry, error)
+	Update(domain.AsRunEntry) error
+	LastOpen(time.Time) *domain.AsRunEntry
+	GetByDate(time.Time) ([]domain.AsRunEntry, error)
+	GetDates() []string
+}
+
+// DefaultAsRunRepository keeps the as-run log in one file per day. Only the day last written to is kept in memory,
+// other days are read from disk when accessed
+type DefaultAsRunRepository struct {
+	Cfg  *config.AppConfig
+	mu   *sync.Mutex
+	days map[string][]domain.AsRunEntry
+}
+
+const (
+	asRunFilePrefix = "asrun-"
+	asRunFileSuffix = ".json"
+)
+
+// NewAsRunRepository creates a new repository for the as-run log
+func NewAsRunRepository(cfg *config.AppConfig) DefaultAsRunRepository {
+	return DefaultAsRunRepository{
+		Cfg:  cfg,
+		mu:   &sync.Mutex{},
+		days: make(map[string][]domain.AsRunEntry),
+	}
+}
+
+// Folder returns the folder the as-run log is stored in
+func (ar DefaultAsRunRepository) Folder() string {
+	if ar.Cfg.Export.AsRunFolder != "" {
+		return ar.Cfg.Export.AsRunFolder
+	}
+	return filepath.Join(ar.Cfg.Export.ExportFolder, "asrun")
+}
+
+func (ar DefaultAsRunRepository) dayFile(day string) string {
+	return filepath.Join(ar.Folder(), asRunFilePrefix+day+asRunFileSuffix)
+}
+
+// Start adds a new entry to the log of the day playback started and assigns its id.
+// Entries of that day still playing are closed with unknown status, since only one item can be on air
+func (ar DefaultAsRunRepository) Start(entry domain.AsRunEntry) (domain.AsRunEntry, error) {
+	day := entry.Day()
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	entries, err := ar.loadDayLocked(day)
+	if err != nil {
+		return domain.AsRunEntry{}, err
+	}
+	for i := range entries {
+		if entries[i].Status == domain.AsRunPlaying {
+			entries[i].Status = domain.AsRunUnknown
+			entries[i].Stopped = entry.Started
+		}
+	}
+	entry.Id = fmt.Sprintf("%v-%04d", day, len(entries)+1)
+	ar.days[day] = append(entries, entry)
+	return entry, ar.saveDayLocked(day)
+}
+
+// Update replaces an entry, e.g. when playback stopped
+func (ar DefaultAsRunRepository) Update(entry domain.AsRunEntry) error {
+	day := entry.Day()
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	entries, err := ar.loadDayLocked(day)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(entries, func(e domain.AsRunEntry) bool { return e.Id == entry.Id })
+	if i < 0 {
+		return fmt.Errorf("as-run entry %v not found", entry.Id)
+	}
+	entries[i] = entry
+	return ar.saveDayLocked(day)
+}
+
+// LastOpen returns the last entry still playing on the given or the previous day, nil if there is none.
+// Used to continue an entry after a restart
+func (ar DefaultAsRunRepository) LastOpen(now time.Time) *domain.AsRunEntry {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	for _, date := range []time.Time{now, now.AddDate(0, 0, -1)} {
+		entries, err := ar.readDayLocked(date.Format(domain.FolderDateLayout))
+		if err != nil || len(entries) == 0 {
+			continue
+		}
+		if last := entries[len(entries)-1]; last.Status == domain.AsRunPlaying {
+			return &last
+		}
+		return nil
+	}
+	return nil
+}
+
+// GetByDate returns the entries of a day in order of playback
+func (ar DefaultAsRunRepository) GetByDate(date time.Time) ([]domain.AsRunEntry, error) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	entries, err := ar.readDayLocked(date.Format(domain.FolderDateLayout))
+	return slices.Clone(entries), err
+}
+
+// GetDates returns all days with a log, newest first
+func (ar DefaultAsRunRepository) GetDates() []string {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	var dates []string
+	for day, entries := range ar.days {
+		if len(entries) > 0 {
+			dates = append(dates, day)
+		}
+	}
+	files, _ := os.ReadDir(ar.Folder())
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasPrefix(name, asRunFilePrefix) && strings.HasSuffix(name, asRunFileSuffix) {
+			day := strings.TrimSuffix(strings.TrimPrefix(name, asRunFilePrefix), asRunFileSuffix)
+			if !slices.Contains(dates, day) {
+				dates = append(dates, day)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+	return dates
+}
+
+// loadDayLocked returns the entries of a day to be changed, reading them from disk if necessary.
+// The day replaces the day kept in memory so far. The caller must hold the lock
+func (ar DefaultAsRunRepository) loadDayLocked(day string) ([]domain.AsRunEntry, error) {
+	if entries, ok := ar.days[day]; ok {
+		return entries, nil
+	}
+	entries, err := ar.readDayLocked(day)
+	if err != nil {
+		return nil, err
+	}
+	clear(ar.days)
+	ar.days[day] = entries
+	return entries, nil
+}
+
+// readDayLocked returns the entries of a day from memory if kept there, from disk otherwise without keeping them.
+// The caller must hold the lock
+func (ar DefaultAsRunRepository) readDayLocked(day string) ([]domain.AsRunEntry, error) {
+	if entries, ok := ar.days[day]; ok {
+		return entries, nil
+	}
+	var entries []domain.AsRunEntry
+	b, err := os.ReadFile(ar.dayFile(day))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// saveDayLocked writes the entries of a day to disk. The caller must hold the lock
+func (ar DefaultAsRunRepository) saveDayLocked(day string) error {
+	b, err := json.Marshal(ar.days[day])
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ar.Folder(), 0755); err != nil {
+		return err
+	}
+	return helper.WriteFileAtomic(ar.dayFile(day), b, 0644)
+}
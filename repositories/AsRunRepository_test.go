@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var asRunStart = time.Date(2026, 10, 19, 20, 0, 0, 0, time.Local)
+
+func setupAsRunTest(t *testing.T) (DefaultAsRunRepository, *config.AppConfig) {
+	var asRunCfg config.AppConfig
+	asRunCfg.Export.AsRunFolder = t.TempDir()
+	return NewAsRunRepository(&asRunCfg), &asRunCfg
+}
+
+func TestAsRunStartAndUpdatePersistEntries(t *testing.T) {
+	asRunRepo, asRunCfg := setupAsRunTest(t)
+
+	entry, err := asRunRepo.Start(domain.AsRunEntry{Filename: "A.mp3", Started: asRunStart, Status: domain.AsRunPlaying})
+	require.NoError(t, err)
+	entry.Stopped = asRunStart.Add(time.Hour)
+	entry.Status = domain.AsRunCompleted
+	require.NoError(t, asRunRepo.Update(entry))
+	reloaded := NewAsRunRepository(asRunCfg)
+	entries, readErr := reloaded.GetByDate(asRunStart)
+
+	require.NoError(t, readErr)
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, "2026-10-19-0001", entries[0].Id)
+	assert.EqualValues(t, domain.AsRunCompleted, entries[0].Status)
+	assert.EqualValues(t, time.Hour, entries[0].Played())
+	assert.EqualValues(t, []string{"2026-10-19"}, reloaded.GetDates())
+}
+
+func TestAsRunStartClosesEntriesStillPlaying(t *testing.T) {
+	asRunRepo, _ := setupAsRunTest(t)
+
+	_, err1 := asRunRepo.Start(domain.AsRunEntry{Filename: "A.mp3", Started: asRunStart, Status: domain.AsRunPlaying})
+	_, err2 := asRunRepo.Start(domain.AsRunEntry{Filename: "B.mp3", Started: asRunStart.Add(time.Hour), Status: domain.AsRunPlaying})
+	entries, _ := asRunRepo.GetByDate(asRunStart)
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.Len(t, entries, 2)
+	assert.EqualValues(t, domain.AsRunUnknown, entries[0].Status)
+	assert.EqualValues(t, asRunStart.Add(time.Hour), entries[0].Stopped)
+	assert.EqualValues(t, "B.mp3", asRunRepo.LastOpen(asRunStart.Add(2*time.Hour)).Filename)
+}
+
+func TestAsRunLastOpenChecksPreviousDay(t *testing.T) {
+	asRunRepo, _ := setupAsRunTest(t)
+	_, err := asRunRepo.Start(domain.AsRunEntry{Filename: "A.mp3", Started: asRunStart.Add(3*time.Hour + 30*time.Minute), Status: domain.AsRunPlaying})
+
+	open := asRunRepo.LastOpen(asRunStart.Add(4 * time.Hour))
+
+	require.NoError(t, err)
+	require.NotNil(t, open)
+	assert.EqualValues(t, "A.mp3", open.Filename)
+	assert.EqualValues(t, "2026-10-19", open.Day())
+}
+
+func TestAsRunKeepsOnlyDayLastWrittenInMemory(t *testing.T) {
+	asRunRepo, _ := setupAsRunTest(t)
+	_, err1 := asRunRepo.Start(domain.AsRunEntry{Filename: "A.mp3", Started: asRunStart.AddDate(0, 0, -1), Status: domain.AsRunPlaying})
+	_, err2 := asRunRepo.Start(domain.AsRunEntry{Filename: "B.mp3", Started: asRunStart, Status: domain.AsRunPlaying})
+
+	previous, readErr := asRunRepo.GetByDate(asRunStart.AddDate(0, 0, -1))
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, readErr)
+	require.Len(t, previous, 1)
+	assert.EqualValues(t, "A.mp3", previous[0].Filename)
+	assert.Len(t, asRunRepo.days, 1)
+	assert.Contains(t, asRunRepo.days, "2026-10-19")
+	assert.EqualValues(t, []string{"2026-10-19", "2026-10-18"}, asRunRepo.GetDates())
+}
+
+func TestAsRunUpdateUnknownEntryReturnsError(t *testing.T) {
+	asRunRepo, _ := setupAsRunTest(t)
+
+	err := asRunRepo.Update(domain.AsRunEntry{Id: "2026-10-19-0001", Started: asRunStart})
+
+	assert.EqualError(t, err, "as-run entry 2026-10-19-0001 not found")
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+)
+
+type CalCmsCacheRepository interface {
+	Store(domain.CalCmsCacheEntry) error
+	Load(string) (domain.CalCmsCacheEntry, error)
+	Prune(string) error
+}
+
+// DefaultCalCmsCacheRepository keeps the last program received from calCms in one file per day
+type DefaultCalCmsCacheRepository struct {
+	Cfg *config.AppConfig
+	mu  *sync.Mutex
+}
+
+const (
+	calCmsCacheFilePrefix = "calcms-"
+	calCmsCacheFileSuffix = ".json"
+)
+
+// NewCalCmsCacheRepository creates a new repository for the cached calCms program
+func NewCalCmsCacheRepository(cfg *config.AppConfig) DefaultCalCmsCacheRepository {
+	return DefaultCalCmsCacheRepository{
+		Cfg: cfg,
+		mu:  &sync.Mutex{},
+	}
+}
+
+// Folder returns the folder the cached program is stored in
+func (cr DefaultCalCmsCacheRepository) Folder() string {
+	if cr.Cfg.CalCms.CacheFolder != "" {
+		return cr.Cfg.CalCms.CacheFolder
+	}
+	return filepath.Join(cr.Cfg.Export.ExportFolder, "calcms")
+}
+
+func (cr DefaultCalCmsCacheRepository) dayFile(day string) string {
+	return filepath.Join(cr.Folder(), calCmsCacheFilePrefix+day+calCmsCacheFileSuffix)
+}
+
+// Store replaces the cached program of a day
+func (cr DefaultCalCmsCacheRepository) Store(entry domain.CalCmsCacheEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if err := os.MkdirAll(cr.Folder(), 0755); err != nil {
+		return err
+	}
+	return helper.WriteFileAtomic(cr.dayFile(entry.Date), b, 0644)
+}
+
+// Load returns the cached program of a day. The error wraps os.ErrNotExist if the day isn't cached
+func (cr DefaultCalCmsCacheRepository) Load(day string) (domain.CalCmsCacheEntry, error) {
+	var entry domain.CalCmsCacheEntry
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	b, err := os.ReadFile(cr.dayFile(day))
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(b, &entry)
+	return entry, err
+}
+
+// Prune removes the cached programs of the days before the given day
+func (cr DefaultCalCmsCacheRepository) Prune(before string) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	files, err := os.ReadDir(cr.Folder())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, calCmsCacheFilePrefix) || !strings.HasSuffix(name, calCmsCacheFileSuffix) {
+			continue
+		}
+		if day := strings.TrimSuffix(strings.TrimPrefix(name, calCmsCacheFilePrefix), calCmsCacheFileSuffix); day < before {
+			if err := os.Remove(filepath.Join(cr.Folder(), name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCalCmsCacheTest(t *testing.T) DefaultCalCmsCacheRepository {
+	var cacheCfg config.AppConfig
+	cacheCfg.CalCms.CacheFolder = t.TempDir()
+	return NewCalCmsCacheRepository(&cacheCfg)
+}
+
+func TestCalCmsCacheStoreAndLoadReturnsEntry(t *testing.T) {
+	cacheRepo := setupCalCmsCacheTest(t)
+	fetched := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	entry := domain.CalCmsCacheEntry{Date: "2026-10-19", Fetched: fetched}
+	entry.Data.Events = append(entry.Data.Events, domain.CalCmsEvent{EventID: 1, FullTitle: "Evening Show", StartDate: "2026-10-19"})
+
+	require.NoError(t, cacheRepo.Store(entry))
+	loaded, err := cacheRepo.Load("2026-10-19")
+
+	require.NoError(t, err)
+	assert.True(t, fetched.Equal(loaded.Fetched))
+	require.Len(t, loaded.Data.Events, 1)
+	assert.EqualValues(t, "Evening Show", loaded.Data.Events[0].FullTitle)
+}
+
+func TestCalCmsCacheLoadMissingDayReturnsNotExist(t *testing.T) {
+	cacheRepo := setupCalCmsCacheTest(t)
+
+	_, err := cacheRepo.Load("2026-10-19")
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCalCmsCachePruneRemovesOlderDays(t *testing.T) {
+	cacheRepo := setupCalCmsCacheTest(t)
+	for _, day := range []string{"2026-10-17", "2026-10-18", "2026-10-19"} {
+		require.NoError(t, cacheRepo.Store(domain.CalCmsCacheEntry{Date: day}))
+	}
+
+	err := cacheRepo.Prune("2026-10-18")
+
+	require.NoError(t, err)
+	_, err1 := cacheRepo.Load("2026-10-17")
+	_, err2 := cacheRepo.Load("2026-10-18")
+	_, err3 := cacheRepo.Load("2026-10-19")
+	assert.ErrorIs(t, err1, os.ErrNotExist)
+	assert.NoError(t, err2)
+	assert.NoError(t, err3)
+}
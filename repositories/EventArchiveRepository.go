@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+)
+
+type EventArchiveRepository interface {
+	Store(string, []domain.EventRecord) error
+	Load(string) ([]domain.EventRecord, error)
+	GetDates() []string
+	Prune(string) error
+}
+
+// DefaultEventArchiveRepository keeps the end-of-day status of the events in one file per day
+type DefaultEventArchiveRepository struct {
+	Cfg *config.AppConfig
+	mu  *sync.Mutex
+}
+
+const (
+	eventArchiveFilePrefix = "events-"
+	eventArchiveFileSuffix = ".json"
+)
+
+// NewEventArchiveRepository creates a new repository for the archived event status
+func NewEventArchiveRepository(cfg *config.AppConfig) DefaultEventArchiveRepository {
+	return DefaultEventArchiveRepository{
+		Cfg: cfg,
+		mu:  &sync.Mutex{},
+	}
+}
+
+// Folder returns the folder the archive is stored in
+func (ar DefaultEventArchiveRepository) Folder() string {
+	if ar.Cfg.Archive.Folder != "" {
+		return ar.Cfg.Archive.Folder
+	}
+	return filepath.Join(ar.Cfg.Export.ExportFolder, "archive")
+}
+
+func (ar DefaultEventArchiveRepository) dayFile(day string) string {
+	return filepath.Join(ar.Folder(), eventArchiveFilePrefix+day+eventArchiveFileSuffix)
+}
+
+// Store replaces the archived event status of a day
+func (ar DefaultEventArchiveRepository) Store(day string, records []domain.EventRecord) error {
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if err := os.MkdirAll(ar.Folder(), 0755); err != nil {
+		return err
+	}
+	return helper.WriteFileAtomic(ar.dayFile(day), b, 0644)
+}
+
+// Load returns the archived event status of a day. The error wraps os.ErrNotExist if the day isn't archived
+func (ar DefaultEventArchiveRepository) Load(day string) ([]domain.EventRecord, error) {
+	var records []domain.EventRecord
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	b, err := os.ReadFile(ar.dayFile(day))
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &records)
+	return records, err
+}
+
+// GetDates returns the archived days, oldest first
+func (ar DefaultEventArchiveRepository) GetDates() []string {
+	var days []string
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	files, err := os.ReadDir(ar.Folder())
+	if err != nil {
+		return nil
+	}
+	for _, file := range files {
+		if day, ok := ar.archiveDay(file.Name()); ok {
+			days = append(days, day)
+		}
+	}
+	slices.Sort(days)
+	return days
+}
+
+// Prune removes the archived event status of the days before the given day
+func (ar DefaultEventArchiveRepository) Prune(before string) error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	files, err := os.ReadDir(ar.Folder())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		if day, ok := ar.archiveDay(file.Name()); ok && day < before {
+			if err := os.Remove(filepath.Join(ar.Folder(), file.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// archiveDay returns the day of an archive file name
+func (ar DefaultEventArchiveRepository) archiveDay(name string) (string, bool) {
+	if !strings.HasPrefix(name, eventArchiveFilePrefix) || !strings.HasSuffix(name, eventArchiveFileSuffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, eventArchiveFilePrefix), eventArchiveFileSuffix), true
+}
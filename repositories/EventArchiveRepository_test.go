@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupEventArchiveTest(t *testing.T) DefaultEventArchiveRepository {
+	var archiveCfg config.AppConfig
+	archiveCfg.Archive.Folder = t.TempDir()
+	return NewEventArchiveRepository(&archiveCfg)
+}
+
+func TestEventArchiveStoreAndLoadReturnsRecords(t *testing.T) {
+	archiveRepo := setupEventArchiveTest(t)
+	start := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	records := []domain.EventRecord{{Date: "2026-10-19", EventId: 1, Title: "Evening Show", Series: "Evening", Start: start, FileStatus: "Present", Exported: true}}
+
+	require.NoError(t, archiveRepo.Store("2026-10-19", records))
+	loaded, err := archiveRepo.Load("2026-10-19")
+
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	assert.EqualValues(t, "Evening Show", loaded[0].Title)
+	assert.True(t, start.Equal(loaded[0].Start))
+	assert.True(t, loaded[0].Exported)
+}
+
+func TestEventArchiveLoadMissingDayReturnsNotExist(t *testing.T) {
+	archiveRepo := setupEventArchiveTest(t)
+
+	_, err := archiveRepo.Load("2026-10-19")
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestEventArchivePruneRemovesOlderDays(t *testing.T) {
+	archiveRepo := setupEventArchiveTest(t)
+	for _, day := range []string{"2026-10-19", "2026-10-17", "2026-10-18"} {
+		require.NoError(t, archiveRepo.Store(day, nil))
+	}
+
+	require.NoError(t, archiveRepo.Prune("2026-10-18"))
+
+	assert.EqualValues(t, []string{"2026-10-18", "2026-10-19"}, archiveRepo.GetDates())
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type EventFileRepository interface {
+	Store(domain.EventFile) error
+	Get(int) *domain.EventFile
+	Prune(time.Time) error
+	LoadFromDisk() error
+}
+
+// DefaultEventFileRepository remembers the file broadcast for each event beyond the days kept in the file list
+type DefaultEventFileRepository struct {
+	Cfg  *config.AppConfig
+	data *eventFileData
+}
+
+// eventFileData is the persisted state of the event file repository
+type eventFileData struct {
+	mu    sync.RWMutex
+	Files map[int]domain.EventFile
+}
+
+// NewEventFileRepository creates a new repository for the files broadcast per event. You need to pass in the configuration
+func NewEventFileRepository(cfg *config.AppConfig) DefaultEventFileRepository {
+	return DefaultEventFileRepository{
+		Cfg:  cfg,
+		data: &eventFileData{Files: make(map[int]domain.EventFile)},
+	}
+}
+
+// Store records the file of an event and persists the repository. An unchanged file is not written again
+func (er DefaultEventFileRepository) Store(file domain.EventFile) error {
+	er.data.mu.Lock()
+	defer er.data.mu.Unlock()
+	if old, found := er.data.Files[file.EventId]; found && old.Path == file.Path && old.Duration == file.Duration && old.Date == file.Date {
+		return nil
+	}
+	er.data.Files[file.EventId] = file
+	return er.saveLocked()
+}
+
+// Get returns the file recorded for an event, nil if there is none
+func (er DefaultEventFileRepository) Get(eventId int) *domain.EventFile {
+	er.data.mu.RLock()
+	defer er.data.mu.RUnlock()
+	if file, found := er.data.Files[eventId]; found {
+		return &file
+	}
+	return nil
+}
+
+// Prune removes the files recorded before the given time and persists the repository
+func (er DefaultEventFileRepository) Prune(before time.Time) error {
+	er.data.mu.Lock()
+	defer er.data.mu.Unlock()
+	size := len(er.data.Files)
+	maps.DeleteFunc(er.data.Files, func(_ int, file domain.EventFile) bool {
+		return file.Recorded.Before(before)
+	})
+	if len(er.data.Files) == size {
+		return nil
+	}
+	return er.saveLocked()
+}
+
+// saveLocked writes all event files to the configured file. The caller must hold the lock
+func (er DefaultEventFileRepository) saveLocked() error {
+	if er.Cfg.Misc.EventFileSaveFile == "" {
+		return nil
+	}
+	b, err := json.Marshal(er.data)
+	if err != nil {
+		return err
+	}
+	if err := helper.WriteFileAtomic(er.Cfg.Misc.EventFileSaveFile, b, 0644); err != nil {
+		logger.Error("Error while writing event files to disk", err)
+		return err
+	}
+	return nil
+}
+
+// LoadFromDisk loads the event files from the configured file. A missing file is not an error
+func (er DefaultEventFileRepository) LoadFromDisk() error {
+	var loaded eventFileData
+	if er.Cfg.Misc.EventFileSaveFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(er.Cfg.Misc.EventFileSaveFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		return err
+	}
+	er.data.mu.Lock()
+	defer er.data.mu.Unlock()
+	clear(er.data.Files)
+	maps.Copy(er.data.Files, loaded.Files)
+	logger.Infof("Read event files from disk (%v items)", len(loaded.Files))
+	return nil
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var eventFileRecorded = time.Date(2026, 10, 18, 14, 0, 0, 0, time.Local)
+
+func setupEventFileTest(t *testing.T) DefaultEventFileRepository {
+	var eventFileCfg config.AppConfig
+	eventFileCfg.Misc.EventFileSaveFile = filepath.Join(t.TempDir(), "eventfiles.dta")
+	return NewEventFileRepository(&eventFileCfg)
+}
+
+func TestEventFileStoreSurvivesRestart(t *testing.T) {
+	eventFileRepo := setupEventFileTest(t)
+
+	err := eventFileRepo.Store(domain.EventFile{EventId: 10, Path: "/archive/show-id10-.mp3", Duration: time.Hour, Date: "2026-10-18", Recorded: eventFileRecorded})
+	loaded := NewEventFileRepository(eventFileRepo.Cfg)
+	loadErr := loaded.LoadFromDisk()
+
+	require.NoError(t, err)
+	require.NoError(t, loadErr)
+	require.NotNil(t, loaded.Get(10))
+	assert.EqualValues(t, "/archive/show-id10-.mp3", loaded.Get(10).Path)
+	assert.EqualValues(t, time.Hour, loaded.Get(10).Duration)
+	assert.Nil(t, loaded.Get(11))
+}
+
+func TestEventFilePruneRemovesOldFiles(t *testing.T) {
+	eventFileRepo := setupEventFileTest(t)
+	eventFileRepo.Store(domain.EventFile{EventId: 10, Path: "A.mp3", Recorded: eventFileRecorded.AddDate(-1, 0, -1)})
+	eventFileRepo.Store(domain.EventFile{EventId: 11, Path: "B.mp3", Recorded: eventFileRecorded})
+
+	err := eventFileRepo.Prune(eventFileRecorded.AddDate(-1, 0, 0))
+
+	require.NoError(t, err)
+	assert.Nil(t, eventFileRepo.Get(10))
+	assert.NotNil(t, eventFileRepo.Get(11))
+}
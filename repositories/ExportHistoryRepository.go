@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type ExportHistoryRepository interface {
+	Record(string, []byte, time.Time) (domain.ExportVersion, bool, error)
+	MarkAppended(string, time.Time) error
+	GetSlots() []string
+	GetVersions(string) []domain.ExportVersion
+	GetVersion(string, int) *domain.ExportVersion
+	ReadVersion(domain.ExportVersion) ([]byte, error)
+	LoadFromDisk() error
+}
+
+type DefaultExportHistoryRepository struct {
+	Cfg      *config.AppConfig
+	mu       *sync.RWMutex
+	versions map[string][]domain.ExportVersion
+}
+
+const (
+	exportHistoryIndex = "index.json"
+)
+
+// NewExportHistoryRepository creates a new repository keeping versioned copies of the exported playlists
+func NewExportHistoryRepository(cfg *config.AppConfig) DefaultExportHistoryRepository {
+	return DefaultExportHistoryRepository{
+		Cfg:      cfg,
+		mu:       &sync.RWMutex{},
+		versions: make(map[string][]domain.ExportVersion),
+	}
+}
+
+// Enabled returns true, if versions of exported playlists should be kept
+func (hr DefaultExportHistoryRepository) Enabled() bool {
+	return hr.Cfg.Export.HistoryVersions > 0
+}
+
+// Folder returns the folder the versioned copies and the index are stored in
+func (hr DefaultExportHistoryRepository) Folder() string {
+	if hr.Cfg.Export.HistoryFolder != "" {
+		return hr.Cfg.Export.HistoryFolder
+	}
+	return filepath.Join(hr.Cfg.Export.ExportFolder, "history")
+}
+
+// SlotFromPath returns the slot name of an exported playlist, i.e. its file name without extension
+func SlotFromPath(exportPath string) string {
+	base := filepath.Base(exportPath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// PlaylistHash calculates the hash of a playlist's content. Comment lines are ignored, since they contain the export time
+func PlaylistHash(content []byte) (hash string, lines int) {
+	h := sha256.New()
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if line == "" || strings.HasPrefix(line, "\t\tR\t") {
+			continue
+		}
+		h.Write([]byte(line + "\n"))
+		lines++
+	}
+	return hex.EncodeToString(h.Sum(nil)), lines
+}
+
+// Record stores a copy of an exported playlist as a new version of its slot.
+// If the content is identical to the latest version of the slot, no new version is created and the latest version is returned
+func (hr DefaultExportHistoryRepository) Record(exportPath string, content []byte, created time.Time) (domain.ExportVersion, bool, error) {
+	if !hr.Enabled() {
+		return domain.ExportVersion{}, false, nil
+	}
+	slot := SlotFromPath(exportPath)
+	hash, lines := PlaylistHash(content)
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	versions := hr.versions[slot]
+	if size := len(versions); size > 0 && versions[size-1].Hash == hash {
+		return versions[size-1], false, nil
+	}
+	version := domain.ExportVersion{
+		Slot:       slot,
+		Version:    1,
+		ExportPath: exportPath,
+		Hash:       hash,
+		Lines:      lines,
+		Created:    created,
+	}
+	if size := len(versions); size > 0 {
+		version.Version = versions[size-1].Version + 1
+	}
+	slotFolder := filepath.Join(hr.Folder(), slot)
+	if err := os.MkdirAll(slotFolder, 0755); err != nil {
+		return domain.ExportVersion{}, false, err
+	}
+	version.CopyPath = filepath.Join(slotFolder, fmt.Sprintf("%v-v%03d.tpi", slot, version.Version))
+	if err := helper.WriteFileAtomic(version.CopyPath, content, 0644); err != nil {
+		return domain.ExportVersion{}, false, err
+	}
+	versions = append(versions, version)
+	hr.versions[slot] = hr.prune(versions)
+	hr.pruneSlotsLocked(created)
+	return version, true, hr.saveLocked()
+}
+
+// pruneSlotsLocked removes the slots whose latest version is older than the configured number of days, together with
+// their copies. The caller must hold the lock
+func (hr DefaultExportHistoryRepository) pruneSlotsLocked(now time.Time) {
+	if hr.Cfg.Export.HistoryDays <= 0 {
+		return
+	}
+	before := now.AddDate(0, 0, -hr.Cfg.Export.HistoryDays)
+	for slot, versions := range hr.versions {
+		if len(versions) > 0 && !versions[len(versions)-1].Created.Before(before) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(hr.Folder(), slot)); err != nil {
+			logger.Errorf("Error removing playlist versions of slot %v: %v", slot, err)
+			continue
+		}
+		delete(hr.versions, slot)
+	}
+}
+
+// prune removes the oldest versions of a slot exceeding the configured number of versions to keep
+func (hr DefaultExportHistoryRepository) prune(versions []domain.ExportVersion) []domain.ExportVersion {
+	excess := len(versions) - hr.Cfg.Export.HistoryVersions
+	if excess <= 0 {
+		return versions
+	}
+	for _, version := range versions[:excess] {
+		if err := os.Remove(version.CopyPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Errorf("Error removing playlist version %v: %v", version.CopyPath, err)
+		}
+	}
+	return versions[excess:]
+}
+
+// MarkAppended records that the latest version of a playlist was appended to mAirList
+func (hr DefaultExportHistoryRepository) MarkAppended(exportPath string, appendedAt time.Time) error {
+	if !hr.Enabled() {
+		return nil
+	}
+	slot := SlotFromPath(exportPath)
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	versions := hr.versions[slot]
+	if len(versions) == 0 {
+		return fmt.Errorf("no versions recorded for slot %v", slot)
+	}
+	versions[len(versions)-1].Appended = true
+	versions[len(versions)-1].AppendedAt = appendedAt
+	return hr.saveLocked()
+}
+
+// GetSlots returns all slots with recorded versions, newest first
+func (hr DefaultExportHistoryRepository) GetSlots() []string {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	slots := make([]string, 0, len(hr.versions))
+	for slot := range hr.versions {
+		slots = append(slots, slot)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(slots)))
+	return slots
+}
+
+// GetVersions returns all versions of a slot, oldest first
+func (hr DefaultExportHistoryRepository) GetVersions(slot string) []domain.ExportVersion {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	versions := make([]domain.ExportVersion, len(hr.versions[slot]))
+	copy(versions, hr.versions[slot])
+	return versions
+}
+
+// GetVersion returns a specific version of a slot, nil if it doesn't exist
+func (hr DefaultExportHistoryRepository) GetVersion(slot string, version int) *domain.ExportVersion {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	for _, v := range hr.versions[slot] {
+		if v.Version == version {
+			return &v
+		}
+	}
+	return nil
+}
+
+// ReadVersion returns the stored content of a version
+func (hr DefaultExportHistoryRepository) ReadVersion(version domain.ExportVersion) ([]byte, error) {
+	return os.ReadFile(version.CopyPath)
+}
+
+// saveLocked writes the index of all versions to disk. The caller must hold the lock
+func (hr DefaultExportHistoryRepository) saveLocked() error {
+	b, err := json.Marshal(hr.versions)
+	if err != nil {
+		return err
+	}
+	return helper.WriteFileAtomic(filepath.Join(hr.Folder(), exportHistoryIndex), b, 0644)
+}
+
+// LoadFromDisk loads the index of all versions from disk. A missing index is not an error
+func (hr DefaultExportHistoryRepository) LoadFromDisk() error {
+	versions := make(map[string][]domain.ExportVersion)
+	b, err := os.ReadFile(filepath.Join(hr.Folder(), exportHistoryIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &versions); err != nil {
+		return err
+	}
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	clear(hr.versions)
+	maps.Copy(hr.versions, versions)
+	logger.Infof("Read export history from disk (%v slots)", len(versions))
+	return nil
+}
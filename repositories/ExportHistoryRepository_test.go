@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	playlistV1 = "\t\tR\tPlaylist auto-generated by mAirList Feeder at 2026-10-19 19:59:00\n20:00:00\tH\tF\tA.mp3\n"
+	playlistV2 = "\t\tR\tPlaylist auto-generated by mAirList Feeder at 2026-10-19 20:10:00\n20:00:00\tH\tF\tB.mp3\n"
+)
+
+func setupHistoryTest(t *testing.T, versions int) (DefaultExportHistoryRepository, string) {
+	var historyCfg config.AppConfig
+	historyCfg.Export.ExportFolder = t.TempDir()
+	historyCfg.Export.HistoryVersions = versions
+	exportPath := filepath.Join(historyCfg.Export.ExportFolder, "2026-10-19-20.tpi")
+	return NewExportHistoryRepository(&historyCfg), exportPath
+}
+
+func TestPlaylistHashIgnoresComments(t *testing.T) {
+	hash1, lines := PlaylistHash([]byte(playlistV1))
+	hash2, _ := PlaylistHash([]byte("\t\tR\tother comment\n20:00:00\tH\tF\tA.mp3\n"))
+
+	assert.EqualValues(t, hash1, hash2)
+	assert.EqualValues(t, 1, lines)
+}
+
+func TestRecordStoresVersionedCopies(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 5)
+
+	v1, created1, err1 := historyRepo.Record(exportPath, []byte(playlistV1), time.Now())
+	v2, created2, err2 := historyRepo.Record(exportPath, []byte(playlistV2), time.Now())
+	content, readErr := historyRepo.ReadVersion(v1)
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, readErr)
+	assert.True(t, created1)
+	assert.True(t, created2)
+	assert.EqualValues(t, "2026-10-19-20", v1.Slot)
+	assert.EqualValues(t, 1, v1.Version)
+	assert.EqualValues(t, 2, v2.Version)
+	assert.EqualValues(t, playlistV1, string(content))
+	assert.EqualValues(t, filepath.Join(historyRepo.Folder(), "2026-10-19-20", "2026-10-19-20-v002.tpi"), v2.CopyPath)
+}
+
+func TestRecordIdenticalContentReturnsLatestVersion(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 5)
+	historyRepo.Record(exportPath, []byte(playlistV1), time.Now())
+
+	version, created, err := historyRepo.Record(exportPath, []byte(playlistV1+"\t\tR\tEnd\n"), time.Now())
+
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.EqualValues(t, 1, version.Version)
+	assert.Len(t, historyRepo.GetVersions("2026-10-19-20"), 1)
+}
+
+func TestRecordDisabledStoresNothing(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 0)
+
+	_, created, err := historyRepo.Record(exportPath, []byte(playlistV1), time.Now())
+
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Empty(t, historyRepo.GetSlots())
+}
+
+func TestRecordPrunesOldestVersions(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 1)
+	v1, _, _ := historyRepo.Record(exportPath, []byte(playlistV1), time.Now())
+
+	historyRepo.Record(exportPath, []byte(playlistV2), time.Now())
+	versions := historyRepo.GetVersions("2026-10-19-20")
+	_, statErr := os.Stat(v1.CopyPath)
+
+	require.Len(t, versions, 1)
+	assert.EqualValues(t, 2, versions[0].Version)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestRecordRemovesSlotsOlderThanHistoryDays(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 5)
+	historyRepo.Cfg.Export.HistoryDays = 30
+	now := time.Date(2026, 10, 19, 20, 0, 0, 0, time.Local)
+	oldPath := filepath.Join(historyRepo.Cfg.Export.ExportFolder, "2026-09-01-20.tpi")
+	old, _, _ := historyRepo.Record(oldPath, []byte(playlistV1), now.AddDate(0, 0, -31))
+
+	_, _, err := historyRepo.Record(exportPath, []byte(playlistV1), now)
+	_, statErr := os.Stat(old.CopyPath)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"2026-10-19-20"}, historyRepo.GetSlots())
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestMarkAppendedMarksLatestVersion(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 5)
+	historyRepo.Record(exportPath, []byte(playlistV1), time.Now())
+	historyRepo.Record(exportPath, []byte(playlistV2), time.Now())
+
+	err := historyRepo.MarkAppended(exportPath, time.Now())
+
+	assert.Nil(t, err)
+	assert.False(t, historyRepo.GetVersion("2026-10-19-20", 1).Appended)
+	assert.True(t, historyRepo.GetVersion("2026-10-19-20", 2).Appended)
+}
+
+func TestMarkAppendedUnknownSlotReturnsError(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 5)
+
+	err := historyRepo.MarkAppended(exportPath, time.Now())
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "no versions recorded for slot 2026-10-19-20", err.Error())
+}
+
+func TestExportHistoryLoadFromDiskRestoresIndex(t *testing.T) {
+	historyRepo, exportPath := setupHistoryTest(t, 5)
+	historyRepo.Record(exportPath, []byte(playlistV1), time.Now())
+	loaded := NewExportHistoryRepository(historyRepo.Cfg)
+
+	err := loaded.LoadFromDisk()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"2026-10-19-20"}, loaded.GetSlots())
+}
+
+func TestExportHistoryLoadFromDiskMissingIndexReturnsNoError(t *testing.T) {
+	historyRepo, _ := setupHistoryTest(t, 5)
+
+	err := historyRepo.LoadFromDisk()
+
+	assert.Nil(t, err)
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
@@ -275,7 +274,7 @@ func (fr DefaultFileRepository) SaveToDisk(fileName string) error {
 		logger.Error("Error while converting file list to JSON", err)
 		return err
 	}
-	if err := writeFileAtomic(fileName, b, 0644); err != nil {
+	if err := helper.WriteFileAtomic(fileName, b, 0644); err != nil {
 		logger.Error("Error while writing files data to disk", err)
 		return err
 	}
@@ -283,41 +282,6 @@ func (fr DefaultFileRepository) SaveToDisk(fileName string) error {
 	return nil
 }
 
-func writeFileAtomic(fileName string, data []byte, perm os.FileMode) error {
-	dir := filepath.Dir(fileName)
-	base := filepath.Base(fileName)
-	tmpFile, err := os.CreateTemp(dir, base+".*.tmp")
-	if err != nil {
-		return err
-	}
-	tmpName := tmpFile.Name()
-	defer os.Remove(tmpName)
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		return err
-	}
-	if err := tmpFile.Chmod(perm); err != nil {
-		tmpFile.Close()
-		return err
-	}
-	if err := tmpFile.Sync(); err != nil {
-		tmpFile.Close()
-		return err
-	}
-	if err := tmpFile.Close(); err != nil {
-		return err
-	}
-	if err := os.Rename(tmpName, fileName); err != nil {
-		if removeErr := os.Remove(fileName); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
-			return removeErr
-		}
-		if renameErr := os.Rename(tmpName, fileName); renameErr != nil {
-			return renameErr
-		}
-	}
-	return nil
-}
-
 // LoadFromDisk loads file information stored on disk into memory
 func (fr DefaultFileRepository) LoadFromDisk(fileName string) error {
 	logger.Info("Reading files data from disk...")
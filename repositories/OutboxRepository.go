@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type OutboxRepository interface {
+	Enqueue(domain.OutboxEntry) (domain.OutboxEntry, error)
+	Update(domain.OutboxEntry) error
+	Remove(string) error
+	Due(time.Time) []domain.OutboxEntry
+	GetAll() []domain.OutboxEntry
+	Prune(time.Time) error
+	LoadFromDisk() error
+}
+
+type DefaultOutboxRepository struct {
+	Cfg  *config.AppConfig
+	data *outboxData
+}
+
+// outboxData is the persisted state of the outbox repository
+type outboxData struct {
+	mu      sync.RWMutex
+	Entries []domain.OutboxEntry
+	NextId  int
+}
+
+// NewOutboxRepository creates a new repository for mAirList appends waiting to be retried. You need to pass in the configuration
+func NewOutboxRepository(cfg *config.AppConfig) DefaultOutboxRepository {
+	return DefaultOutboxRepository{
+		Cfg:  cfg,
+		data: &outboxData{NextId: 1},
+	}
+}
+
+// Enqueue adds an entry for a slot and persists the outbox. An existing entry for the same slot is replaced
+// keeping its id, so a slot is never delivered twice
+func (or DefaultOutboxRepository) Enqueue(e domain.OutboxEntry) (domain.OutboxEntry, error) {
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	if idx := or.indexLocked(e.Slot); idx >= 0 {
+		existing := or.data.Entries[idx]
+		e.Id = existing.Id
+		e.Created = existing.Created
+		or.data.Entries[idx] = e
+		return e, or.saveLocked()
+	}
+	e.Id = strconv.Itoa(or.data.NextId)
+	or.data.NextId++
+	or.data.Entries = append(or.data.Entries, e)
+	return e, or.saveLocked()
+}
+
+// Update replaces the entry of the same slot and persists the outbox. Updating an entry that was removed in the meantime is not an error
+func (or DefaultOutboxRepository) Update(e domain.OutboxEntry) error {
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	idx := or.indexLocked(e.Slot)
+	if idx < 0 {
+		return nil
+	}
+	or.data.Entries[idx] = e
+	return or.saveLocked()
+}
+
+// Remove deletes the entry of a slot, e.g. after it was delivered, and persists the outbox
+func (or DefaultOutboxRepository) Remove(slot string) error {
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	idx := or.indexLocked(slot)
+	if idx < 0 {
+		return nil
+	}
+	or.data.Entries = slices.Delete(or.data.Entries, idx, idx+1)
+	return or.saveLocked()
+}
+
+// Due returns the pending entries to be retried at the given time, earliest slot first
+func (or DefaultOutboxRepository) Due(now time.Time) []domain.OutboxEntry {
+	var due []domain.OutboxEntry
+	for _, e := range or.GetAll() {
+		if e.Due(now) {
+			due = append(due, e)
+		}
+	}
+	return due
+}
+
+// GetAll returns all entries, earliest slot first
+func (or DefaultOutboxRepository) GetAll() []domain.OutboxEntry {
+	or.data.mu.RLock()
+	entries := slices.Clone(or.data.Entries)
+	or.data.mu.RUnlock()
+	slices.SortStableFunc(entries, func(a, b domain.OutboxEntry) int {
+		return a.SlotStart.Compare(b.SlotStart)
+	})
+	return entries
+}
+
+// Prune removes failed entries for slots that started before the given time and persists the outbox
+func (or DefaultOutboxRepository) Prune(before time.Time) error {
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	size := len(or.data.Entries)
+	or.data.Entries = slices.DeleteFunc(or.data.Entries, func(e domain.OutboxEntry) bool {
+		return e.Status == domain.OutboxFailed && e.SlotStart.Before(before)
+	})
+	if len(or.data.Entries) == size {
+		return nil
+	}
+	return or.saveLocked()
+}
+
+func (or DefaultOutboxRepository) indexLocked(slot string) int {
+	return slices.IndexFunc(or.data.Entries, func(e domain.OutboxEntry) bool {
+		return e.Slot == slot
+	})
+}
+
+// saveLocked writes all entries to the configured file. The caller must hold the lock
+func (or DefaultOutboxRepository) saveLocked() error {
+	if or.Cfg.Misc.OutboxSaveFile == "" {
+		return nil
+	}
+	b, err := json.Marshal(or.data)
+	if err != nil {
+		return err
+	}
+	if err := helper.WriteFileAtomic(or.Cfg.Misc.OutboxSaveFile, b, 0644); err != nil {
+		logger.Error("Error while writing outbox to disk", err)
+		return err
+	}
+	return nil
+}
+
+// LoadFromDisk loads the entries from the configured file, so retries survive a restart. A missing file is not an error
+func (or DefaultOutboxRepository) LoadFromDisk() error {
+	var loaded outboxData
+	if or.Cfg.Misc.OutboxSaveFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(or.Cfg.Misc.OutboxSaveFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		return err
+	}
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	or.data.Entries = loaded.Entries
+	or.data.NextId = max(loaded.NextId, 1)
+	logger.Infof("Read outbox from disk (%v items)", len(loaded.Entries))
+	return nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var outboxSlotStart = time.Date(2026, 10, 19, 20, 0, 0, 0, time.Local)
+
+func setupOutboxTest(t *testing.T) DefaultOutboxRepository {
+	var outboxCfg config.AppConfig
+	outboxCfg.Misc.OutboxSaveFile = filepath.Join(t.TempDir(), "outbox.dta")
+	return NewOutboxRepository(&outboxCfg)
+}
+
+func outboxEntry(slot string, slotStart time.Time) domain.OutboxEntry {
+	return domain.OutboxEntry{
+		Slot:        slot,
+		FileName:    slot + ".tpi",
+		SlotStart:   slotStart,
+		Deadline:    slotStart.Add(10 * time.Minute),
+		NextAttempt: slotStart.Add(-time.Minute),
+		Status:      domain.OutboxPending,
+	}
+}
+
+func TestEnqueueSameSlotReplacesEntry(t *testing.T) {
+	outboxRepo := setupOutboxTest(t)
+
+	first, err1 := outboxRepo.Enqueue(outboxEntry("2026-10-19-20", outboxSlotStart))
+	replacement := outboxEntry("2026-10-19-20", outboxSlotStart)
+	replacement.FileName = "other.tpi"
+	second, err2 := outboxRepo.Enqueue(replacement)
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.EqualValues(t, first.Id, second.Id)
+	require.Len(t, outboxRepo.GetAll(), 1)
+	assert.EqualValues(t, "other.tpi", outboxRepo.GetAll()[0].FileName)
+}
+
+func TestDueReturnsPendingEntriesEarliestSlotFirst(t *testing.T) {
+	outboxRepo := setupOutboxTest(t)
+	outboxRepo.Enqueue(outboxEntry("2026-10-19-21", outboxSlotStart.Add(time.Hour)))
+	outboxRepo.Enqueue(outboxEntry("2026-10-19-20", outboxSlotStart))
+	failed := outboxEntry("2026-10-19-19", outboxSlotStart.Add(-time.Hour))
+	failed.Status = domain.OutboxFailed
+	outboxRepo.Enqueue(failed)
+
+	due := outboxRepo.Due(outboxSlotStart.Add(time.Hour))
+
+	require.Len(t, due, 2)
+	assert.EqualValues(t, "2026-10-19-20", due[0].Slot)
+	assert.EqualValues(t, "2026-10-19-21", due[1].Slot)
+	assert.Len(t, outboxRepo.Due(outboxSlotStart), 1)
+}
+
+func TestPruneRemovesOldFailedEntriesOnly(t *testing.T) {
+	outboxRepo := setupOutboxTest(t)
+	failed := outboxEntry("2026-10-18-20", outboxSlotStart.AddDate(0, 0, -1))
+	failed.Status = domain.OutboxFailed
+	outboxRepo.Enqueue(failed)
+	outboxRepo.Enqueue(outboxEntry("2026-10-18-21", outboxSlotStart.AddDate(0, 0, -1).Add(time.Hour)))
+
+	err := outboxRepo.Prune(outboxSlotStart)
+
+	require.NoError(t, err)
+	require.Len(t, outboxRepo.GetAll(), 1)
+	assert.EqualValues(t, "2026-10-18-21", outboxRepo.GetAll()[0].Slot)
+}
+
+func TestOutboxSurvivesRestart(t *testing.T) {
+	outboxRepo := setupOutboxTest(t)
+	outboxRepo.Enqueue(outboxEntry("2026-10-19-20", outboxSlotStart))
+	outboxRepo.Enqueue(outboxEntry("2026-10-19-21", outboxSlotStart.Add(time.Hour)))
+	require.NoError(t, outboxRepo.Remove("2026-10-19-21"))
+
+	loaded := NewOutboxRepository(outboxRepo.Cfg)
+	err := loaded.LoadFromDisk()
+	entry, _ := loaded.Enqueue(outboxEntry("2026-10-19-22", outboxSlotStart.Add(2*time.Hour)))
+
+	require.NoError(t, err)
+	require.Len(t, loaded.GetAll(), 2)
+	assert.EqualValues(t, "2026-10-19-20", loaded.GetAll()[0].Slot)
+	assert.EqualValues(t, "3", entry.Id)
+}
@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type OverrideRepository interface {
+	GetAll() []domain.Override
+	Get(string) *domain.Override
+	ForPath(string) []domain.Override
+	ForEvent(int, time.Time) []domain.Override
+	PinsForHour(time.Time, string) []domain.Override
+	Store(domain.Override) (domain.Override, error)
+	Delete(string, string) error
+	GetAudit() []domain.OverrideAudit
+	LoadFromDisk() error
+}
+
+type DefaultOverrideRepository struct {
+	Cfg  *config.AppConfig
+	Now  func() time.Time
+	data *overrideData
+}
+
+// overrideData is the persisted state of the override repository
+type overrideData struct {
+	mu        sync.RWMutex
+	Overrides []domain.Override
+	Audit     []domain.OverrideAudit
+	NextId    int
+}
+
+const (
+	maxOverrideAudit = 500
+)
+
+// NewOverrideRepository creates a new repository for manual schedule overrides. You need to pass in the configuration
+func NewOverrideRepository(cfg *config.AppConfig) DefaultOverrideRepository {
+	return DefaultOverrideRepository{
+		Cfg:  cfg,
+		Now:  time.Now,
+		data: &overrideData{NextId: 1},
+	}
+}
+
+// GetAll returns all overrides, oldest first
+func (or DefaultOverrideRepository) GetAll() []domain.Override {
+	or.data.mu.RLock()
+	defer or.data.mu.RUnlock()
+	return slices.Clone(or.data.Overrides)
+}
+
+// Get returns the override with the given id, nil if it doesn't exist
+func (or DefaultOverrideRepository) Get(id string) *domain.Override {
+	or.data.mu.RLock()
+	defer or.data.mu.RUnlock()
+	for _, o := range or.data.Overrides {
+		if o.Id == id {
+			return &o
+		}
+	}
+	return nil
+}
+
+// ForPath returns all overrides concerning a file
+func (or DefaultOverrideRepository) ForPath(path string) []domain.Override {
+	return or.filter(func(o domain.Override) bool {
+		return o.Path == path
+	})
+}
+
+// ForEvent returns the replace overrides for an event on a given date
+func (or DefaultOverrideRepository) ForEvent(eventId int, folderDate time.Time) []domain.Override {
+	return or.filter(func(o domain.Override) bool {
+		return o.Type == domain.OverrideReplace && o.EventId == eventId && o.AppliesToDate(folderDate)
+	})
+}
+
+// PinsForHour returns the files pinned to slots within the given date and hour
+func (or DefaultOverrideRepository) PinsForHour(folderDate time.Time, hour string) []domain.Override {
+	return or.filter(func(o domain.Override) bool {
+		return o.Type == domain.OverridePin && o.AppliesToDate(folderDate) && len(o.StartTime) == 5 && o.StartTime[:2] == hour
+	})
+}
+
+func (or DefaultOverrideRepository) filter(match func(domain.Override) bool) []domain.Override {
+	var overrides []domain.Override
+	or.data.mu.RLock()
+	defer or.data.mu.RUnlock()
+	for _, o := range or.data.Overrides {
+		if match(o) {
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides
+}
+
+// Store validates and adds an override, records it in the audit log and persists all overrides
+func (or DefaultOverrideRepository) Store(o domain.Override) (domain.Override, error) {
+	if err := o.Validate(); err != nil {
+		return domain.Override{}, err
+	}
+	if !o.FolderDate.IsZero() {
+		o.FolderDate = domain.NormalizeDate(o.FolderDate)
+	}
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	o.Id = strconv.Itoa(or.data.NextId)
+	o.Created = or.Now()
+	or.data.NextId++
+	or.data.Overrides = append(or.data.Overrides, o)
+	or.auditLocked("created", o.Note, o)
+	logger.Infof("Created override %v: %v %v (%v)", o.Id, o.Type, o.Path, o.Note)
+	return o, or.saveLocked()
+}
+
+// Delete removes an override, records the deletion with its note in the audit log and persists all overrides
+func (or DefaultOverrideRepository) Delete(id string, note string) error {
+	if note == "" {
+		return errors.New("note must be given")
+	}
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	idx := slices.IndexFunc(or.data.Overrides, func(o domain.Override) bool {
+		return o.Id == id
+	})
+	if idx < 0 {
+		return fmt.Errorf("override with id %v does not exist", id)
+	}
+	deleted := or.data.Overrides[idx]
+	or.data.Overrides = slices.Delete(or.data.Overrides, idx, idx+1)
+	or.auditLocked("deleted", note, deleted)
+	logger.Infof("Deleted override %v: %v %v (%v)", deleted.Id, deleted.Type, deleted.Path, note)
+	return or.saveLocked()
+}
+
+// GetAudit returns the audit log, newest entry first
+func (or DefaultOverrideRepository) GetAudit() []domain.OverrideAudit {
+	or.data.mu.RLock()
+	defer or.data.mu.RUnlock()
+	audit := slices.Clone(or.data.Audit)
+	slices.Reverse(audit)
+	return audit
+}
+
+func (or DefaultOverrideRepository) auditLocked(action string, note string, o domain.Override) {
+	or.data.Audit = append(or.data.Audit, domain.OverrideAudit{
+		Time:     or.Now(),
+		Action:   action,
+		Note:     note,
+		Override: o,
+	})
+	if excess := len(or.data.Audit) - maxOverrideAudit; excess > 0 {
+		or.data.Audit = or.data.Audit[excess:]
+	}
+}
+
+// saveLocked writes all overrides and the audit log to the configured file. The caller must hold the lock
+func (or DefaultOverrideRepository) saveLocked() error {
+	if or.Cfg.Misc.OverrideSaveFile == "" {
+		return nil
+	}
+	b, err := json.Marshal(or.data)
+	if err != nil {
+		return err
+	}
+	if err := helper.WriteFileAtomic(or.Cfg.Misc.OverrideSaveFile, b, 0644); err != nil {
+		logger.Error("Error while writing overrides to disk", err)
+		return err
+	}
+	return nil
+}
+
+// LoadFromDisk loads the overrides and the audit log from the configured file. A missing file is not an error
+func (or DefaultOverrideRepository) LoadFromDisk() error {
+	var loaded overrideData
+	if or.Cfg.Misc.OverrideSaveFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(or.Cfg.Misc.OverrideSaveFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		return err
+	}
+	or.data.mu.Lock()
+	defer or.data.mu.Unlock()
+	or.data.Overrides = loaded.Overrides
+	or.data.Audit = loaded.Audit
+	or.data.NextId = max(loaded.NextId, 1)
+	logger.Infof("Read overrides from disk (%v items)", len(loaded.Overrides))
+	return nil
+}
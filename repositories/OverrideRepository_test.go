@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var overrideDate = time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+func setupOverrideTest(t *testing.T) DefaultOverrideRepository {
+	var overrideCfg config.AppConfig
+	overrideCfg.Misc.OverrideSaveFile = filepath.Join(t.TempDir(), "overrides.dta")
+	return NewOverrideRepository(&overrideCfg)
+}
+
+func TestStoreInvalidOverrideReturnsError(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+
+	_, err := overrideRepo.Store(domain.Override{Type: domain.OverridePin, Path: "A.mp3", Note: "note"})
+
+	assert.EqualValues(t, "date must be given to pin a file", err.Error())
+	assert.Empty(t, overrideRepo.GetAll())
+}
+
+func TestStoreAssignsIdAndRecordsAudit(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+
+	o1, err1 := overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "broken file"})
+	o2, err2 := overrideRepo.Store(domain.Override{Type: domain.OverridePin, Path: "B.mp3", FolderDate: overrideDate, StartTime: "20:00", Note: "late delivery"})
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.EqualValues(t, "1", o1.Id)
+	assert.EqualValues(t, "2", o2.Id)
+	assert.False(t, o1.Created.IsZero())
+	assert.EqualValues(t, 2, len(overrideRepo.GetAudit()))
+	assert.EqualValues(t, "created", overrideRepo.GetAudit()[0].Action)
+}
+
+func TestOverrideLookups(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+	overrideRepo.Store(domain.Override{Type: domain.OverridePin, Path: "B.mp3", FolderDate: overrideDate, StartTime: "20:30", Note: "note"})
+	overrideRepo.Store(domain.Override{Type: domain.OverrideReplace, Path: "C.mp3", FolderDate: overrideDate, EventId: 42, Note: "note"})
+
+	assert.EqualValues(t, 1, len(overrideRepo.PinsForHour(overrideDate, "20")))
+	assert.Empty(t, overrideRepo.PinsForHour(overrideDate, "21"))
+	assert.Empty(t, overrideRepo.PinsForHour(overrideDate.AddDate(0, 0, 1), "20"))
+	assert.EqualValues(t, "C.mp3", overrideRepo.ForEvent(42, overrideDate)[0].Path)
+	assert.Empty(t, overrideRepo.ForEvent(42, overrideDate.AddDate(0, 0, 1)))
+	assert.EqualValues(t, 1, len(overrideRepo.ForPath("B.mp3")))
+}
+
+func TestDeleteOverrideRequiresNote(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+	o, _ := overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "note"})
+
+	err := overrideRepo.Delete(o.Id, "")
+
+	assert.EqualValues(t, "note must be given", err.Error())
+	assert.NotNil(t, overrideRepo.Get(o.Id))
+}
+
+func TestDeleteUnknownOverrideReturnsError(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+
+	err := overrideRepo.Delete("7", "note")
+
+	assert.EqualValues(t, "override with id 7 does not exist", err.Error())
+}
+
+func TestDeleteOverrideRecordsAudit(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+	o, _ := overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "note"})
+
+	err := overrideRepo.Delete(o.Id, "file fixed")
+
+	require.NoError(t, err)
+	assert.Nil(t, overrideRepo.Get(o.Id))
+	assert.EqualValues(t, 2, len(overrideRepo.GetAudit()))
+	assert.EqualValues(t, "file fixed", overrideRepo.GetAudit()[0].Note)
+	assert.EqualValues(t, "deleted", overrideRepo.GetAudit()[0].Action)
+}
+
+func TestOverridesArePersisted(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+	overrideRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "note"})
+	overrideRepo.Store(domain.Override{Type: domain.OverrideAccept, Path: "B.mp3", Note: "note"})
+	overrideRepo.Delete("1", "done")
+	loadedRepo := NewOverrideRepository(overrideRepo.Cfg)
+
+	err := loadedRepo.LoadFromDisk()
+	o, storeErr := loadedRepo.Store(domain.Override{Type: domain.OverrideExclude, Path: "C.mp3", Note: "note"})
+
+	require.NoError(t, err)
+	require.NoError(t, storeErr)
+	assert.EqualValues(t, 2, len(loadedRepo.GetAll()))
+	assert.EqualValues(t, "B.mp3", loadedRepo.GetAll()[0].Path)
+	assert.EqualValues(t, "3", o.Id)
+	assert.EqualValues(t, 4, len(loadedRepo.GetAudit()))
+}
+
+func TestLoadOverridesMissingFileIsNoError(t *testing.T) {
+	overrideRepo := setupOverrideTest(t)
+
+	err := overrideRepo.LoadFromDisk()
+
+	assert.Nil(t, err)
+	assert.Empty(t, overrideRepo.GetAll())
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type ReminderRepository interface {
+	Add(domain.Reminder) error
+	Sent(string) bool
+	GetAll() []domain.Reminder
+	Prune(time.Time) error
+	LoadFromDisk() error
+}
+
+type DefaultReminderRepository struct {
+	Cfg  *config.AppConfig
+	data *reminderData
+}
+
+// reminderData is the persisted state of the reminder repository
+type reminderData struct {
+	mu        sync.RWMutex
+	Reminders []domain.Reminder
+}
+
+// NewReminderRepository creates a new repository for the reminders sent to producers. You need to pass in the configuration
+func NewReminderRepository(cfg *config.AppConfig) DefaultReminderRepository {
+	return DefaultReminderRepository{
+		Cfg:  cfg,
+		data: &reminderData{},
+	}
+}
+
+// Add records a sent reminder and persists the repository
+func (rr DefaultReminderRepository) Add(r domain.Reminder) error {
+	rr.data.mu.Lock()
+	defer rr.data.mu.Unlock()
+	rr.data.Reminders = append(rr.data.Reminders, r)
+	return rr.saveLocked()
+}
+
+// Sent checks whether the reminder with the given key was sent already
+func (rr DefaultReminderRepository) Sent(key string) bool {
+	rr.data.mu.RLock()
+	defer rr.data.mu.RUnlock()
+	return slices.ContainsFunc(rr.data.Reminders, func(r domain.Reminder) bool {
+		return r.Key() == key
+	})
+}
+
+// GetAll returns all sent reminders, newest first
+func (rr DefaultReminderRepository) GetAll() []domain.Reminder {
+	rr.data.mu.RLock()
+	reminders := slices.Clone(rr.data.Reminders)
+	rr.data.mu.RUnlock()
+	slices.SortStableFunc(reminders, func(a, b domain.Reminder) int {
+		return b.Sent.Compare(a.Sent)
+	})
+	return reminders
+}
+
+// Prune removes the reminders sent before the given time and persists the repository
+func (rr DefaultReminderRepository) Prune(before time.Time) error {
+	rr.data.mu.Lock()
+	defer rr.data.mu.Unlock()
+	size := len(rr.data.Reminders)
+	rr.data.Reminders = slices.DeleteFunc(rr.data.Reminders, func(r domain.Reminder) bool {
+		return r.Sent.Before(before)
+	})
+	if len(rr.data.Reminders) == size {
+		return nil
+	}
+	return rr.saveLocked()
+}
+
+// saveLocked writes all reminders to the configured file. The caller must hold the lock
+func (rr DefaultReminderRepository) saveLocked() error {
+	if rr.Cfg.Misc.ReminderSaveFile == "" {
+		return nil
+	}
+	b, err := json.Marshal(rr.data)
+	if err != nil {
+		return err
+	}
+	if err := helper.WriteFileAtomic(rr.Cfg.Misc.ReminderSaveFile, b, 0644); err != nil {
+		logger.Error("Error while writing reminders to disk", err)
+		return err
+	}
+	return nil
+}
+
+// LoadFromDisk loads the reminders from the configured file, so no reminder is sent twice after a restart. A missing file is not an error
+func (rr DefaultReminderRepository) LoadFromDisk() error {
+	var loaded reminderData
+	if rr.Cfg.Misc.ReminderSaveFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(rr.Cfg.Misc.ReminderSaveFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		return err
+	}
+	rr.data.mu.Lock()
+	defer rr.data.mu.Unlock()
+	rr.data.Reminders = loaded.Reminders
+	logger.Infof("Read reminders from disk (%v items)", len(loaded.Reminders))
+	return nil
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var reminderSent = time.Date(2026, 10, 18, 14, 0, 0, 0, time.Local)
+
+func setupReminderTest(t *testing.T) DefaultReminderRepository {
+	var reminderCfg config.AppConfig
+	reminderCfg.Misc.ReminderSaveFile = filepath.Join(t.TempDir(), "reminders.dta")
+	return NewReminderRepository(&reminderCfg)
+}
+
+func TestReminderAddIsSentAndSurvivesRestart(t *testing.T) {
+	reminderRepo := setupReminderTest(t)
+
+	err := reminderRepo.Add(domain.Reminder{EventId: 1, Date: "2026-10-19", LeadHours: 48, To: []string{"a@example.org"}, Sent: reminderSent})
+	loaded := NewReminderRepository(reminderRepo.Cfg)
+	loadErr := loaded.LoadFromDisk()
+
+	require.NoError(t, err)
+	require.NoError(t, loadErr)
+	assert.True(t, loaded.Sent(domain.ReminderKey(1, "2026-10-19", 48)))
+	assert.False(t, loaded.Sent(domain.ReminderKey(1, "2026-10-19", 24)))
+	assert.False(t, loaded.Sent(domain.ReminderKey(2, "2026-10-19", 48)))
+}
+
+func TestReminderPruneRemovesOldReminders(t *testing.T) {
+	reminderRepo := setupReminderTest(t)
+	reminderRepo.Add(domain.Reminder{EventId: 1, Date: "2026-10-10", LeadHours: 3, Sent: reminderSent.AddDate(0, 0, -8)})
+	reminderRepo.Add(domain.Reminder{EventId: 2, Date: "2026-10-19", LeadHours: 48, Sent: reminderSent})
+
+	err := reminderRepo.Prune(reminderSent.AddDate(0, 0, -7))
+
+	require.NoError(t, err)
+	require.Len(t, reminderRepo.GetAll(), 1)
+	assert.EqualValues(t, 2, reminderRepo.GetAll()[0].EventId)
+}
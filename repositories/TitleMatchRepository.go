@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type TitleMatchRepository interface {
+	GetAll() []domain.TitleMatch
+	Get(string) *domain.TitleMatch
+	Rejected(string) []int
+	Store(domain.TitleMatch) error
+	Prune([]string) error
+	LoadFromDisk() error
+}
+
+// DefaultTitleMatchRepository keeps the title matches confirmed or rejected by an operator across restarts
+type DefaultTitleMatchRepository struct {
+	Cfg  *config.AppConfig
+	data *titleMatchData
+}
+
+// titleMatchData is the persisted state of the title match repository
+type titleMatchData struct {
+	mu       sync.RWMutex
+	Matches  map[string]domain.TitleMatch
+	Rejected map[string][]int
+}
+
+// NewTitleMatchRepository creates a new repository for the decided title matches. You need to pass in the configuration
+func NewTitleMatchRepository(cfg *config.AppConfig) DefaultTitleMatchRepository {
+	return DefaultTitleMatchRepository{
+		Cfg: cfg,
+		data: &titleMatchData{
+			Matches:  make(map[string]domain.TitleMatch),
+			Rejected: make(map[string][]int),
+		},
+	}
+}
+
+// GetAll returns the latest decision for each file
+func (tr DefaultTitleMatchRepository) GetAll() []domain.TitleMatch {
+	tr.data.mu.RLock()
+	defer tr.data.mu.RUnlock()
+	return slices.Collect(maps.Values(tr.data.Matches))
+}
+
+// Get returns the latest decision for a file, nil if there is none
+func (tr DefaultTitleMatchRepository) Get(path string) *domain.TitleMatch {
+	tr.data.mu.RLock()
+	defer tr.data.mu.RUnlock()
+	if match, found := tr.data.Matches[path]; found {
+		return &match
+	}
+	return nil
+}
+
+// Rejected returns the ids of all events rejected for a file
+func (tr DefaultTitleMatchRepository) Rejected(path string) []int {
+	tr.data.mu.RLock()
+	defer tr.data.mu.RUnlock()
+	return slices.Clone(tr.data.Rejected[path])
+}
+
+// Store records a confirmed or rejected match as the decision for its file and persists the repository
+func (tr DefaultTitleMatchRepository) Store(match domain.TitleMatch) error {
+	if match.Status != domain.TitleMatchConfirmed && match.Status != domain.TitleMatchRejected {
+		return errors.New("only confirmed or rejected matches can be stored")
+	}
+	tr.data.mu.Lock()
+	defer tr.data.mu.Unlock()
+	tr.data.Matches[match.Path] = match
+	if match.Status == domain.TitleMatchRejected && !slices.Contains(tr.data.Rejected[match.Path], match.EventId) {
+		tr.data.Rejected[match.Path] = append(tr.data.Rejected[match.Path], match.EventId)
+	}
+	return tr.saveLocked()
+}
+
+// Prune forgets the decisions for files not in the given list and persists the repository
+func (tr DefaultTitleMatchRepository) Prune(paths []string) error {
+	tr.data.mu.Lock()
+	defer tr.data.mu.Unlock()
+	size, rejected := len(tr.data.Matches), len(tr.data.Rejected)
+	maps.DeleteFunc(tr.data.Matches, func(path string, _ domain.TitleMatch) bool {
+		return !slices.Contains(paths, path)
+	})
+	maps.DeleteFunc(tr.data.Rejected, func(path string, _ []int) bool {
+		return !slices.Contains(paths, path)
+	})
+	if len(tr.data.Matches) == size && len(tr.data.Rejected) == rejected {
+		return nil
+	}
+	return tr.saveLocked()
+}
+
+// saveLocked writes all decisions to the configured file. The caller must hold the lock
+func (tr DefaultTitleMatchRepository) saveLocked() error {
+	if tr.Cfg.Misc.TitleMatchSaveFile == "" {
+		return nil
+	}
+	b, err := json.Marshal(tr.data)
+	if err != nil {
+		return err
+	}
+	if err := helper.WriteFileAtomic(tr.Cfg.Misc.TitleMatchSaveFile, b, 0644); err != nil {
+		logger.Error("Error while writing title matches to disk", err)
+		return err
+	}
+	return nil
+}
+
+// LoadFromDisk loads the decisions from the configured file. A missing file is not an error
+func (tr DefaultTitleMatchRepository) LoadFromDisk() error {
+	var loaded titleMatchData
+	if tr.Cfg.Misc.TitleMatchSaveFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(tr.Cfg.Misc.TitleMatchSaveFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &loaded); err != nil {
+		return err
+	}
+	tr.data.mu.Lock()
+	defer tr.data.mu.Unlock()
+	clear(tr.data.Matches)
+	maps.Copy(tr.data.Matches, loaded.Matches)
+	clear(tr.data.Rejected)
+	maps.Copy(tr.data.Rejected, loaded.Rejected)
+	logger.Infof("Read title matches from disk (%v items)", len(loaded.Matches))
+	return nil
+}
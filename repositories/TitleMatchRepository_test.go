@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTitleMatchTest(t *testing.T) DefaultTitleMatchRepository {
+	var titleMatchCfg config.AppConfig
+	titleMatchCfg.Misc.TitleMatchSaveFile = filepath.Join(t.TempDir(), "titlematches.dta")
+	return NewTitleMatchRepository(&titleMatchCfg)
+}
+
+func TestTitleMatchStoreSurvivesRestart(t *testing.T) {
+	titleMatchRepo := setupTitleMatchTest(t)
+
+	rejectErr := titleMatchRepo.Store(domain.TitleMatch{Path: "A.mp3", EventId: 10, Status: domain.TitleMatchRejected})
+	confirmErr := titleMatchRepo.Store(domain.TitleMatch{Path: "A.mp3", EventId: 11, Status: domain.TitleMatchConfirmed})
+	loaded := NewTitleMatchRepository(titleMatchRepo.Cfg)
+	loadErr := loaded.LoadFromDisk()
+
+	require.NoError(t, rejectErr)
+	require.NoError(t, confirmErr)
+	require.NoError(t, loadErr)
+	require.NotNil(t, loaded.Get("A.mp3"))
+	assert.EqualValues(t, 11, loaded.Get("A.mp3").EventId)
+	assert.EqualValues(t, domain.TitleMatchConfirmed, loaded.Get("A.mp3").Status)
+	assert.EqualValues(t, []int{10}, loaded.Rejected("A.mp3"))
+	assert.Nil(t, loaded.Get("B.mp3"))
+}
+
+func TestTitleMatchStoreSuggestionReturnsError(t *testing.T) {
+	titleMatchRepo := setupTitleMatchTest(t)
+
+	err := titleMatchRepo.Store(domain.TitleMatch{Path: "A.mp3", EventId: 10, Status: domain.TitleMatchSuggested})
+
+	assert.NotNil(t, err)
+	assert.Empty(t, titleMatchRepo.GetAll())
+}
+
+func TestTitleMatchPruneForgetsRemovedFiles(t *testing.T) {
+	titleMatchRepo := setupTitleMatchTest(t)
+	titleMatchRepo.Store(domain.TitleMatch{Path: "A.mp3", EventId: 10, Status: domain.TitleMatchRejected})
+	titleMatchRepo.Store(domain.TitleMatch{Path: "B.mp3", EventId: 11, Status: domain.TitleMatchConfirmed})
+
+	err := titleMatchRepo.Prune([]string{"B.mp3"})
+
+	require.NoError(t, err)
+	assert.Nil(t, titleMatchRepo.Get("A.mp3"))
+	assert.Empty(t, titleMatchRepo.Rejected("A.mp3"))
+	assert.NotNil(t, titleMatchRepo.Get("B.mp3"))
+}
@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// CalCmsProvider reads the schedule from the events API of calCms (racalmas)
+type CalCmsProvider struct {
+	Cfg        *config.AppConfig
+	HttpClient *http.Client
+}
+
+// NewCalCmsProvider creates a new calCms schedule provider
+func NewCalCmsProvider(cfg *config.AppConfig, httpClient *http.Client) CalCmsProvider {
+	return CalCmsProvider{
+		Cfg:        cfg,
+		HttpClient: httpClient,
+	}
+}
+
+func (p CalCmsProvider) Name() string {
+	return "calCMS"
+}
+
+func (p CalCmsProvider) Source() string {
+	return p.Cfg.CalCms.CmsUrl
+}
+
+// Events queries calCms for the events in the date range. The full calCms event is kept with each event
+func (p CalCmsProvider) Events(ctx context.Context, from time.Time, till time.Time) ([]domain.ScheduleEvent, error) {
+	data, err := p.Fetch(ctx, from, till)
+	if err != nil {
+		return nil, err
+	}
+	var calCmsData domain.CalCmsPgmData
+	if err := json.Unmarshal(data, &calCmsData); err != nil {
+		logger.Error("Cannot convert calCMS response data to Json", err)
+		return nil, err
+	}
+	events := make([]domain.ScheduleEvent, 0, len(calCmsData.Events))
+	for _, calCmsEvent := range calCmsData.Events {
+		if calCmsEvent.StudioName == "" {
+			calCmsEvent.StudioName = calCmsData.StudioName
+		}
+		events = append(events, scheduleEvent(calCmsEvent))
+	}
+	return events, nil
+}
+
+// scheduleEvent is a helper function converting a calCms event. Start and end stay empty if calCms sends invalid times
+func scheduleEvent(calCmsEvent domain.CalCmsEvent) domain.ScheduleEvent {
+	start, err := time.ParseInLocation("2006-01-02T15:04:05", calCmsEvent.StartDatetime, time.Local)
+	if err != nil {
+		logger.Warnf("Cannot parse start %v of calCMS event %v", calCmsEvent.StartDatetime, calCmsEvent.EventID)
+	}
+	end, err := time.ParseInLocation("2006-01-02T15:04:05", calCmsEvent.EndDatetime, time.Local)
+	if err != nil {
+		logger.Warnf("Cannot parse end %v of calCMS event %v", calCmsEvent.EndDatetime, calCmsEvent.EventID)
+	}
+	return domain.ScheduleEvent{
+		Id:     calCmsEvent.EventID,
+		Title:  calCmsEvent.FullTitle,
+		Start:  start,
+		End:    end,
+		Live:   calCmsEvent.Live == 1,
+		CalCms: &calCmsEvent,
+	}
+}
+
+// Fetch retrieves the raw event information for the date range from calCms
+func (p CalCmsProvider) Fetch(ctx context.Context, from time.Time, till time.Time) ([]byte, error) {
+	//API doc: https://github.com/rapilodev/racalmas/blob/master/docs/event-api.md
+	//URL old: https://programm.coloradio.org/agenda/events.cgi?date=2024-04-09&template=event.json-p
+	//URL new: https://programm.coloradio.org/agenda/events.cgi?from_date=2024-10-04&from_time=00:00&till_date=2024-10-05&till_time=00:00&template=event.json-p
+	calUrl, err := url.Parse(p.Cfg.CalCms.CmsUrl)
+	if err != nil {
+		logger.Error("Cannot parse calCMS Url", err)
+		return nil, err
+	}
+	query := url.Values{}
+	query.Add("from_date", domain.FormatFolderDate(from))
+	query.Add("from_time", "00:00")
+	query.Add("till_date", domain.FormatFolderDate(till))
+	query.Add("till_time", "00:00")
+	query.Add("template", p.Cfg.CalCms.Template)
+	calUrl.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, calUrl.String(), nil)
+	if err != nil {
+		logger.Error("Cannot build calCMS http request", err)
+		return nil, err
+	}
+	resp, err := p.HttpClient.Do(req)
+	if err != nil {
+		logger.Error("Cannot execute calCMS http request", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := errors.New(resp.Status)
+		logger.Errorf("Received status code %v from calCMS. %v", resp.StatusCode, err)
+		return nil, err
+	}
+	eventData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error("Cannot read response data from calCMS", err)
+		return nil, err
+	}
+	return eventData, nil
+}
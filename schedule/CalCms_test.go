@@ -0,0 +1,106 @@
+package schedule
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	calCmsResponseFile = "../samples/calCMS-response.json"
+)
+
+var (
+	calCmsFrom = time.Date(2024, 9, 17, 0, 0, 0, 0, time.Local)
+	calCmsTill = calCmsFrom.AddDate(0, 0, 2)
+)
+
+func setupCalCmsProvider(url string) CalCmsProvider {
+	var calCfg config.AppConfig
+	calCfg.CalCms.CmsUrl = url
+	calCfg.CalCms.Template = "event.json-p"
+	return NewCalCmsProvider(&calCfg, &http.Client{Timeout: 5 * time.Second})
+}
+
+func TestFetchWrongUrlReturnsError(t *testing.T) {
+	provider := setupCalCmsProvider("§$%&/()")
+
+	data, err := provider.Fetch(context.Background(), calCmsFrom, calCmsTill)
+
+	assert.Nil(t, data)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "parse \"§$%&/()\": invalid URL escape \"%&/\"", err.Error())
+}
+
+func TestFetchHttpRequestReturnsData(t *testing.T) {
+	respData, _ := os.ReadFile(calCmsResponseFile)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "2024-09-17", r.URL.Query().Get("from_date"))
+		assert.EqualValues(t, "2024-09-19", r.URL.Query().Get("till_date"))
+		assert.EqualValues(t, "event.json-p", r.URL.Query().Get("template"))
+		w.WriteHeader(http.StatusOK)
+		w.Write(respData)
+	}))
+	defer srv.Close()
+	provider := setupCalCmsProvider(srv.URL)
+
+	data, err := provider.Fetch(context.Background(), calCmsFrom, calCmsTill)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, respData, data)
+}
+
+func TestFetchHttpRequestReturnsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	provider := setupCalCmsProvider(srv.URL)
+
+	data, err := provider.Fetch(context.Background(), calCmsFrom, calCmsTill)
+
+	assert.NotNil(t, err)
+	assert.Nil(t, data)
+	assert.EqualValues(t, "400 Bad Request", err.Error())
+}
+
+func TestCalCmsEventsReturnsEvents(t *testing.T) {
+	respData, _ := os.ReadFile(calCmsResponseFile)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(respData)
+	}))
+	defer srv.Close()
+	provider := setupCalCmsProvider(srv.URL)
+
+	events, err := provider.Events(context.Background(), calCmsFrom, calCmsTill)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.EqualValues(t, "Morgenmagazin - der Freien Radios", events[0].Title)
+	assert.True(t, events[0].Start.Equal(time.Date(2024, 9, 24, 7, 0, 0, 0, time.Local)))
+	require.NotNil(t, events[0].CalCms)
+	assert.EqualValues(t, events[0].Id, events[0].CalCms.EventID)
+	assert.EqualValues(t, events[0].Live, events[0].CalCms.Live == 1)
+}
+
+func TestCalCmsEventsKeepStudioOfResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"studio_name": "Studio B", "events": [{"event_id": 1, "start_datetime": "2024-09-17T07:00:00", "end_datetime": "2024-09-17T08:00:00"}, {"event_id": 2, "studio_name": "Studio A", "start_datetime": "2024-09-17T08:00:00", "end_datetime": "2024-09-17T09:00:00"}]}`))
+	}))
+	defer srv.Close()
+	provider := setupCalCmsProvider(srv.URL)
+
+	events, err := provider.Events(context.Background(), calCmsFrom, calCmsTill)
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.EqualValues(t, "Studio B", events[0].CalCms.StudioName)
+	assert.EqualValues(t, "Studio A", events[1].CalCms.StudioName)
+}
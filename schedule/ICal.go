@@ -0,0 +1,441 @@
+package schedule
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// ICalProvider reads the schedule from an iCalendar feed, given as file path or http(s) URL.
+// Recurring events are expanded for daily and weekly rules, all-day and cancelled events are left out
+type ICalProvider struct {
+	Cfg        *config.AppConfig
+	HttpClient *http.Client
+}
+
+// icalEvent is a VEVENT as read from the feed
+type icalEvent struct {
+	uid          string
+	summary      string
+	start        time.Time
+	end          time.Time
+	duration     time.Duration
+	allDay       bool
+	cancelled    bool
+	categories   []string
+	rrule        string
+	exdates      []time.Time
+	recurrenceId time.Time
+}
+
+var (
+	icalDurationRegex = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+	icalWeekdays      = map[string]time.Weekday{"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday}
+)
+
+// NewICalProvider creates a new iCalendar schedule provider
+func NewICalProvider(cfg *config.AppConfig, httpClient *http.Client) ICalProvider {
+	return ICalProvider{
+		Cfg:        cfg,
+		HttpClient: httpClient,
+	}
+}
+
+func (p ICalProvider) Name() string {
+	return "iCalendar"
+}
+
+func (p ICalProvider) Source() string {
+	return p.Cfg.CalCms.ICalSource
+}
+
+// Events reads the feed and returns the events starting in the date range, ordered by start
+func (p ICalProvider) Events(ctx context.Context, from time.Time, till time.Time) ([]domain.ScheduleEvent, error) {
+	data, err := p.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	icalEvents, err := parseICal(data)
+	if err != nil {
+		logger.Error("Cannot parse iCalendar feed", err)
+		return nil, err
+	}
+	var events []domain.ScheduleEvent
+	for _, occurrence := range expandICal(icalEvents, till) {
+		if occurrence.start.Before(from) || !occurrence.start.Before(till) {
+			continue
+		}
+		events = append(events, domain.ScheduleEvent{
+			Id:    ICalEventId(occurrence.uid),
+			Title: occurrence.summary,
+			Start: occurrence.start,
+			End:   occurrence.end,
+			Live:  p.isLive(occurrence),
+		})
+	}
+	slices.SortStableFunc(events, func(a, b domain.ScheduleEvent) int {
+		return a.Start.Compare(b.Start)
+	})
+	return events, nil
+}
+
+// Fetch reads the raw feed from the file or URL
+func (p ICalProvider) Fetch(ctx context.Context) ([]byte, error) {
+	source := p.Cfg.CalCms.ICalSource
+	if strings.HasPrefix(source, "webcal://") {
+		source = "https://" + strings.TrimPrefix(source, "webcal://")
+	}
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			logger.Error("Cannot read iCalendar file", err)
+		}
+		return data, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		logger.Error("Cannot build iCalendar http request", err)
+		return nil, err
+	}
+	resp, err := p.HttpClient.Do(req)
+	if err != nil {
+		logger.Error("Cannot execute iCalendar http request", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := errors.New(resp.Status)
+		logger.Errorf("Received status code %v from iCalendar source. %v", resp.StatusCode, err)
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error("Cannot read iCalendar response data", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// isLive checks whether the event has the configured live category
+func (p ICalProvider) isLive(event icalEvent) bool {
+	return slices.ContainsFunc(event.categories, func(category string) bool {
+		return strings.EqualFold(category, p.Cfg.CalCms.ICalLiveCategory)
+	})
+}
+
+// ICalEventId derives the numeric event id used in file names from an event's UID. UIDs starting with a number
+// (e.g. 4711@station.org) keep that number, all others are hashed to a stable positive number
+func ICalEventId(uid string) int {
+	digits, _, _ := strings.Cut(uid, "@")
+	if id, err := strconv.Atoi(digits); err == nil && id > 0 {
+		return id
+	}
+	h := fnv.New32a()
+	h.Write([]byte(uid))
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+// parseICal reads the events of an iCalendar feed. Components nested in events, like alarms, are skipped
+func parseICal(data []byte) ([]icalEvent, error) {
+	var (
+		events  []icalEvent
+		current *icalEvent
+		nested  int
+	)
+	for lineNo, line := range unfoldICal(data) {
+		name, params, value, err := parseICalLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", lineNo+1, err)
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT") && current == nil:
+			current = &icalEvent{}
+		case name == "BEGIN" && current != nil:
+			nested++
+		case name == "END" && current != nil && nested > 0:
+			nested--
+		case name == "END" && strings.EqualFold(value, "VEVENT") && current != nil:
+			if err := current.complete(); err != nil {
+				return nil, fmt.Errorf("event %q: %w", current.uid, err)
+			}
+			events = append(events, *current)
+			current = nil
+		case current != nil && nested == 0:
+			if err := current.set(name, params, value); err != nil {
+				return nil, fmt.Errorf("line %v: %w", lineNo+1, err)
+			}
+		}
+	}
+	return events, nil
+}
+
+// set stores a property of an event
+func (e *icalEvent) set(name string, params map[string]string, value string) (err error) {
+	switch name {
+	case "UID":
+		e.uid = value
+	case "SUMMARY":
+		e.summary = unescapeICalText(value)
+	case "DTSTART":
+		e.start, e.allDay, err = parseICalTime(value, params)
+	case "DTEND":
+		e.end, _, err = parseICalTime(value, params)
+	case "DURATION":
+		e.duration, err = parseICalDuration(value)
+	case "STATUS":
+		e.cancelled = strings.EqualFold(value, "CANCELLED")
+	case "CATEGORIES":
+		for category := range strings.SplitSeq(value, ",") {
+			e.categories = append(e.categories, strings.TrimSpace(unescapeICalText(category)))
+		}
+	case "RRULE":
+		e.rrule = value
+	case "EXDATE":
+		for exdate := range strings.SplitSeq(value, ",") {
+			t, _, exErr := parseICalTime(exdate, params)
+			if exErr != nil {
+				return exErr
+			}
+			e.exdates = append(e.exdates, t)
+		}
+	case "RECURRENCE-ID":
+		e.recurrenceId, _, err = parseICalTime(value, params)
+	}
+	return err
+}
+
+// complete checks the event and calculates its end if the feed gives a duration instead
+func (e *icalEvent) complete() error {
+	if e.start.IsZero() {
+		return errors.New("event has no start")
+	}
+	if e.end.IsZero() {
+		e.end = e.start.Add(e.duration)
+	}
+	if e.end.Before(e.start) {
+		return errors.New("event ends before it starts")
+	}
+	return nil
+}
+
+// expandICal returns all occurrences of the events starting before till. Occurrences replaced by an event with
+// a RECURRENCE-ID are left out, the replacing event is returned instead
+func expandICal(events []icalEvent, till time.Time) []icalEvent {
+	replaced := make(map[string]bool)
+	for _, event := range events {
+		if !event.recurrenceId.IsZero() {
+			replaced[event.uid+"@"+event.recurrenceId.UTC().Format(time.RFC3339)] = true
+		}
+	}
+	var occurrences []icalEvent
+	for _, event := range events {
+		if event.allDay || event.cancelled {
+			continue
+		}
+		if event.rrule == "" || !event.recurrenceId.IsZero() {
+			occurrences = append(occurrences, event)
+			continue
+		}
+		starts, err := expandRRule(event.rrule, event.start, till)
+		if err != nil {
+			logger.Warnf("Cannot expand recurrence of %v (%v), using its first occurrence only. %v", event.summary, event.uid, err)
+			starts = []time.Time{event.start}
+		}
+		length := event.end.Sub(event.start)
+		for _, start := range starts {
+			if replaced[event.uid+"@"+start.UTC().Format(time.RFC3339)] || slices.ContainsFunc(event.exdates, start.Equal) {
+				continue
+			}
+			occurrence := event
+			occurrence.start = start
+			occurrence.end = start.Add(length)
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences
+}
+
+// expandRRule returns the starts of a daily or weekly recurring event before till. Starts keep the wall clock time
+// of the first occurrence across daylight saving changes
+func expandRRule(rrule string, start time.Time, till time.Time) ([]time.Time, error) {
+	var (
+		freq     string
+		interval = 1
+		count    int
+		until    time.Time
+		byDay    []time.Weekday
+		err      error
+	)
+	for part := range strings.SplitSeq(rrule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq = strings.ToUpper(value)
+		case "INTERVAL":
+			if interval, err = strconv.Atoi(value); err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid interval %q", value)
+			}
+		case "COUNT":
+			if count, err = strconv.Atoi(value); err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid count %q", value)
+			}
+		case "UNTIL":
+			var untilDate bool
+			if until, untilDate, err = parseICalTime(value, map[string]string{"TZID": start.Location().String()}); err != nil {
+				return nil, err
+			}
+			if untilDate {
+				until = until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+		case "BYDAY":
+			for day := range strings.SplitSeq(value, ",") {
+				weekday, found := icalWeekdays[strings.ToUpper(day)]
+				if !found {
+					return nil, fmt.Errorf("unsupported day %q", day)
+				}
+				byDay = append(byDay, weekday)
+			}
+		case "WKST":
+		default:
+			return nil, fmt.Errorf("unsupported rule part %v", key)
+		}
+	}
+	var step func(time.Time, int) []time.Time
+	switch freq {
+	case "DAILY":
+		if len(byDay) > 0 {
+			return nil, errors.New("BYDAY is only supported for weekly rules")
+		}
+		step = func(t time.Time, n int) []time.Time { return []time.Time{t.AddDate(0, 0, n*interval)} }
+	case "WEEKLY":
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{start.Weekday()}
+		}
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		step = func(_ time.Time, n int) []time.Time {
+			var starts []time.Time
+			for _, weekday := range byDay {
+				starts = append(starts, weekStart.AddDate(0, 0, n*7*interval+(int(weekday)+6)%7))
+			}
+			slices.SortFunc(starts, func(a, b time.Time) int { return a.Compare(b) })
+			return starts
+		}
+	default:
+		return nil, fmt.Errorf("unsupported frequency %q", freq)
+	}
+	var starts []time.Time
+	for n := 0; ; n++ {
+		for _, candidate := range step(start, n) {
+			if candidate.Before(start) {
+				continue
+			}
+			if !candidate.Before(till) || (!until.IsZero() && candidate.After(until)) || (count > 0 && len(starts) >= count) {
+				return starts, nil
+			}
+			starts = append(starts, candidate)
+		}
+	}
+}
+
+// unfoldICal splits the feed into its logical lines, joining continuation lines
+func unfoldICal(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseICalLine splits a content line into its upper case name, its parameters and its value
+func parseICalLine(line string) (name string, params map[string]string, value string, err error) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("content line %q has no value", line)
+	}
+	fields := strings.Split(line[:colon], ";")
+	params = make(map[string]string)
+	for _, param := range fields[1:] {
+		key, paramValue, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(paramValue, `"`)
+	}
+	return strings.ToUpper(fields[0]), params, line[colon+1:], nil
+}
+
+// parseICalTime parses a date or date-time value. Values without time zone are local time
+func parseICalTime(value string, params map[string]string) (t time.Time, allDay bool, err error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err = time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	location := time.Local
+	if tzid := params["TZID"]; tzid != "" && tzid != "Local" {
+		if location, err = time.LoadLocation(tzid); err != nil {
+			return t, false, fmt.Errorf("unknown time zone %q", tzid)
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, location)
+	return t, false, err
+}
+
+// parseICalDuration parses a duration like PT1H30M
+func parseICalDuration(value string) (time.Duration, error) {
+	matches := icalDurationRegex.FindStringSubmatch(value)
+	if matches == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	var d time.Duration
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if matches[i+2] != "" {
+			n, _ := strconv.Atoi(matches[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if matches[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// unescapeICalText resolves the escapes of a text value
+func unescapeICalText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
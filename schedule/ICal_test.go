@@ -0,0 +1,187 @@
+package schedule
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const icalFeed = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//Planner//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:4711@radio.example.org\r\n" +
+	"SUMMARY:Evening Show\\, with Guests\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261019T200000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20261019T210000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"SUMMARY:Not an event\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:live-talk\r\n" +
+	"SUMMARY:Live Talk from the Studio with a very long title that is folded\r\n" +
+	"  across two lines\r\n" +
+	"CATEGORIES:Talk,LIVE\r\n" +
+	"DTSTART:20261019T190000Z\r\n" +
+	"DURATION:PT1H30M\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:morning@radio.example.org\r\n" +
+	"SUMMARY:Morning Show\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261012T070000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20261012T090000\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,TU;COUNT=6\r\n" +
+	"EXDATE;TZID=Europe/Berlin:20261020T070000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:morning@radio.example.org\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Berlin:20261019T070000\r\n" +
+	"SUMMARY:Morning Show Special\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261019T060000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20261019T090000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Public Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20261019\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"SUMMARY:Cancelled Show\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261019T220000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20261019T230000\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func setupICalProvider(t *testing.T, source string) ICalProvider {
+	t.Helper()
+	location, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	local := time.Local
+	time.Local = location
+	t.Cleanup(func() { time.Local = local })
+	var icalCfg config.AppConfig
+	icalCfg.CalCms.ICalSource = source
+	icalCfg.CalCms.ICalLiveCategory = "live"
+	return NewICalProvider(&icalCfg, &http.Client{Timeout: 5 * time.Second})
+}
+
+func writeICalFeed(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schedule.ics")
+	require.NoError(t, os.WriteFile(path, []byte(icalFeed), 0644))
+	return path
+}
+
+func eventSummaries(events []domain.ScheduleEvent) []string {
+	var summaries []string
+	for _, event := range events {
+		summaries = append(summaries, event.Start.In(time.Local).Format("2006-01-02 15:04")+" "+event.Title)
+	}
+	return summaries
+}
+
+func TestICalEventsReturnsEventsOfDateRange(t *testing.T) {
+	provider := setupICalProvider(t, writeICalFeed(t))
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	events, err := provider.Events(context.Background(), from, from.AddDate(0, 0, 2))
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{
+		"2026-10-19 06:00 Morning Show Special",
+		"2026-10-19 20:00 Evening Show, with Guests",
+		"2026-10-19 21:00 Live Talk from the Studio with a very long title that is folded across two lines",
+	}, eventSummaries(events))
+	assert.EqualValues(t, 4711, events[1].Id)
+	assert.True(t, events[1].End.Equal(time.Date(2026, 10, 19, 21, 0, 0, 0, time.Local)))
+	assert.False(t, events[1].Live)
+	assert.Nil(t, events[1].CalCms)
+	assert.True(t, events[2].Live)
+	assert.EqualValues(t, "22:30", events[2].End.In(time.Local).Format("15:04"))
+}
+
+func TestICalEventsExpandsWeeklyRule(t *testing.T) {
+	provider := setupICalProvider(t, writeICalFeed(t))
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+
+	events, err := provider.Events(context.Background(), from, from.AddDate(0, 0, 28))
+
+	require.NoError(t, err)
+	var mornings []string
+	for _, summary := range eventSummaries(events) {
+		if summary[11:16] <= "07:00" {
+			mornings = append(mornings, summary)
+		}
+	}
+	assert.EqualValues(t, []string{
+		"2026-10-12 07:00 Morning Show",
+		"2026-10-13 07:00 Morning Show",
+		"2026-10-19 06:00 Morning Show Special",
+		"2026-10-26 07:00 Morning Show",
+		"2026-10-27 07:00 Morning Show",
+	}, mornings)
+	assert.EqualValues(t, ICalEventId("morning@radio.example.org"), events[0].Id)
+}
+
+func TestICalEventsFromUrlReturnsEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write([]byte(icalFeed))
+	}))
+	defer srv.Close()
+	provider := setupICalProvider(t, srv.URL+"/schedule.ics")
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	events, err := provider.Events(context.Background(), from, from.AddDate(0, 0, 1))
+
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+}
+
+func TestICalEventsInvalidFeedReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.ics")
+	require.NoError(t, os.WriteFile(path, []byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:No start\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"), 0644))
+	provider := setupICalProvider(t, path)
+
+	events, err := provider.Events(context.Background(), time.Now(), time.Now().AddDate(0, 0, 1))
+
+	require.Error(t, err)
+	assert.Nil(t, events)
+	assert.EqualValues(t, "event \"\": event has no start", err.Error())
+}
+
+func TestICalEventIdKeepsNumericUid(t *testing.T) {
+	assert.EqualValues(t, 4711, ICalEventId("4711@radio.example.org"))
+	assert.EqualValues(t, ICalEventId("show@radio.example.org"), ICalEventId("show@radio.example.org"))
+	assert.Positive(t, ICalEventId("show@radio.example.org"))
+}
+
+func TestExpandRRuleDailyKeepsWallClockAcrossDstChange(t *testing.T) {
+	location, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2026, 10, 24, 20, 0, 0, 0, location)
+
+	starts, err := expandRRule("FREQ=DAILY;UNTIL=20261026", start, start.AddDate(0, 1, 0))
+
+	require.NoError(t, err)
+	require.Len(t, starts, 3)
+	assert.EqualValues(t, "2026-10-26 20:00 CET", starts[2].Format("2006-01-02 15:04 MST"))
+}
+
+func TestExpandRRuleUnsupportedFrequencyReturnsError(t *testing.T) {
+	_, err := expandRRule("FREQ=MONTHLY;BYMONTHDAY=1", time.Now(), time.Now().AddDate(1, 0, 0))
+
+	require.Error(t, err)
+}
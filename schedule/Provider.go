@@ -0,0 +1,29 @@
+// package schedule implements the sources the feeder reads the broadcast schedule from
+package schedule
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+)
+
+// Provider delivers the events of the broadcast schedule
+type Provider interface {
+	// Name is the name of the schedule source for logs and the web UI
+	Name() string
+	// Source is the URL or path the schedule is read from
+	Source() string
+	// Events returns the events starting in the range from (inclusive) to till (exclusive)
+	Events(ctx context.Context, from time.Time, till time.Time) ([]domain.ScheduleEvent, error)
+}
+
+// NewProvider creates the schedule provider selected in the configuration
+func NewProvider(cfg *config.AppConfig, httpClient *http.Client) Provider {
+	if cfg.CalCms.Provider == config.ScheduleProviderICal {
+		return NewICalProvider(cfg, httpClient)
+	}
+	return NewCalCmsProvider(cfg, httpClient)
+}
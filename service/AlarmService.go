@@ -0,0 +1,191 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	maxRecentAlarms = 50
+)
+
+// The alarm service combines mAirList's play status with the calCMS schedule and raises alarms on dead air or a live event conflict.
+// Raised and cleared alarms are sent to the webhooks through the notifier
+type DefaultAlarmService struct {
+	Cfg      *config.AppConfig
+	State    *appstate.AppState
+	Events   todayEvents
+	Now      func() time.Time
+	Notifier notifier
+	alarms   *alarmState
+}
+
+type todayEvents interface {
+	GetTodayEvents() ([]dto.Event, error)
+}
+
+type alarmState struct {
+	mu       sync.Mutex
+	watching time.Time // first evaluation, mAirList's status is unknown before
+	active   map[domain.AlarmKind]domain.Alarm
+	recent   []domain.Alarm
+}
+
+// NewAlarmServiceWithState creates a new alarm service and injects its dependencies
+func NewAlarmServiceWithState(cfg *config.AppConfig, state *appstate.AppState, events todayEvents) DefaultAlarmService {
+	return DefaultAlarmService{
+		Cfg:    cfg,
+		State:  state,
+		Events: events,
+		Now:    time.Now,
+		alarms: &alarmState{active: make(map[domain.AlarmKind]domain.Alarm)},
+	}
+}
+
+// Evaluate checks the alarm conditions, raises and clears alarms and sends the notifications
+func (s DefaultAlarmService) Evaluate() error {
+	return s.EvaluateContext(context.Background())
+}
+
+func (s DefaultAlarmService) EvaluateContext(ctx context.Context) error {
+	now := s.Now()
+	events, err := s.Events.GetTodayEvents()
+	if err != nil {
+		return err
+	}
+	s.alarms.mu.Lock()
+	if s.alarms.watching.IsZero() {
+		s.alarms.watching = now
+	}
+	watching := s.alarms.watching
+	s.alarms.mu.Unlock()
+	conditions := s.conditions(now, watching, events)
+	for _, kind := range []domain.AlarmKind{domain.AlarmDeadAir, domain.AlarmLiveConflict} {
+		s.apply(now, kind, conditions[kind])
+	}
+	return nil
+}
+
+// conditions returns the alarms whose conditions are currently met
+func (s DefaultAlarmService) conditions(now time.Time, watching time.Time, events []dto.Event) map[domain.AlarmKind]*domain.Alarm {
+	conditions := make(map[domain.AlarmKind]*domain.Alarm)
+	event, start, found := currentEvent(events, now)
+	if !found {
+		return conditions
+	}
+	runtime := s.State.Runtime.Snapshot()
+	if event.EventType != "Live" && s.Cfg.Alarm.DeadAirSec > 0 {
+		silentSince := latest(runtime.LastMairListPlaying, watching, start)
+		if now.Sub(silentSince) >= time.Duration(s.Cfg.Alarm.DeadAirSec)*time.Second {
+			conditions[domain.AlarmDeadAir] = &domain.Alarm{
+				Kind:       domain.AlarmDeadAir,
+				Message:    fmt.Sprintf("mAirList is not playing during %v", event.Title),
+				EventId:    event.EventId,
+				EventTitle: event.Title,
+				Since:      silentSince,
+			}
+		}
+	}
+	statusAge := 2 * time.Duration(s.Cfg.Export.StatusQueryCycleSec) * time.Second
+	graceEnd := start.Add(time.Duration(s.Cfg.Alarm.LiveGraceSec) * time.Second)
+	if event.EventType == "Live" && !now.Before(graceEnd) && runtime.MairListPlaying && now.Sub(runtime.LastMairListPlaying) <= statusAge {
+		conditions[domain.AlarmLiveConflict] = &domain.Alarm{
+			Kind:       domain.AlarmLiveConflict,
+			Message:    fmt.Sprintf("mAirList is still playing during live event %v", event.Title),
+			EventId:    event.EventId,
+			EventTitle: event.Title,
+			Since:      graceEnd,
+		}
+	}
+	return conditions
+}
+
+// apply raises an alarm whose condition is met and clears an active alarm whose condition isn't met anymore
+func (s DefaultAlarmService) apply(now time.Time, kind domain.AlarmKind, condition *domain.Alarm) {
+	s.alarms.mu.Lock()
+	alarm, active := s.alarms.active[kind]
+	var eventType domain.NotificationType
+	switch {
+	case condition != nil && !active:
+		alarm = *condition
+		alarm.Raised = now
+		s.alarms.active[kind] = alarm
+		eventType = domain.NotificationAlarmRaised
+		logger.Warnf("Alarm raised: %v", alarm.Message)
+	case condition == nil && active:
+		alarm.Cleared = now
+		delete(s.alarms.active, kind)
+		s.alarms.recent = append(s.alarms.recent, alarm)
+		if excess := len(s.alarms.recent) - maxRecentAlarms; excess > 0 {
+			s.alarms.recent = s.alarms.recent[excess:]
+		}
+		eventType = domain.NotificationAlarmCleared
+		logger.Infof("Alarm cleared: %v", alarm.Message)
+	}
+	s.alarms.mu.Unlock()
+	if condition != nil {
+		s.State.Metrics.SetAlarmActive(string(kind), 1)
+	} else {
+		s.State.Metrics.SetAlarmActive(string(kind), 0)
+	}
+	if eventType != "" && s.Notifier != nil {
+		s.Notifier.Notify(eventType, alarm.Message, map[string]string{
+			"kind":     string(alarm.Kind),
+			"event_id": alarm.EventId,
+			"title":    alarm.EventTitle,
+			"since":    alarm.Since.Format(time.RFC3339),
+		})
+	}
+}
+
+// ActiveAlarms returns the currently raised alarms, oldest first
+func (s DefaultAlarmService) ActiveAlarms() []domain.Alarm {
+	s.alarms.mu.Lock()
+	defer s.alarms.mu.Unlock()
+	alarms := make([]domain.Alarm, 0, len(s.alarms.active))
+	for _, alarm := range s.alarms.active {
+		alarms = append(alarms, alarm)
+	}
+	slices.SortFunc(alarms, func(a, b domain.Alarm) int {
+		return a.Raised.Compare(b.Raised)
+	})
+	return alarms
+}
+
+// RecentAlarms returns the last cleared alarms, newest first
+func (s DefaultAlarmService) RecentAlarms() []domain.Alarm {
+	s.alarms.mu.Lock()
+	defer s.alarms.mu.Unlock()
+	recent := slices.Clone(s.alarms.recent)
+	slices.Reverse(recent)
+	return recent
+}
+
+// currentEvent is a helper function returning the event running at the given time and its start
+func currentEvent(events []dto.Event, now time.Time) (dto.Event, time.Time, bool) {
+	for _, event := range events {
+		start, end, err := eventPeriod(event.StartDate, event.StartTime, event.EndTime)
+		if err != nil {
+			continue
+		}
+		if !now.Before(start) && now.Before(end) {
+			return event, start, true
+		}
+	}
+	return dto.Event{}, time.Time{}, false
+}
+
+// latest is a helper function returning the latest of the given times
+func latest(times ...time.Time) time.Time {
+	return slices.MaxFunc(times, time.Time.Compare)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAlarmEvents struct {
+	events []dto.Event
+}
+
+func (f fakeAlarmEvents) GetTodayEvents() ([]dto.Event, error) {
+	return f.events, nil
+}
+
+// alarmStates returns the raised and cleared alarms sent to the notifier, e.g. "raised dead_air"
+func alarmStates(notifications *fakeNotifier) []string {
+	notifications.mu.Lock()
+	defer notifications.mu.Unlock()
+	var states []string
+	for _, n := range notifications.notifications {
+		state := "raised"
+		if n.Type == domain.NotificationAlarmCleared {
+			state = "cleared"
+		}
+		states = append(states, state+" "+n.Data["kind"])
+	}
+	return states
+}
+
+// setupAlarmTest creates an alarm service with a preproduced event from 20:00 to 21:00 and a live event from 21:00 to 22:00
+func setupAlarmTest(t *testing.T) (DefaultAlarmService, *time.Time, *fakeNotifier) {
+	t.Helper()
+	cfg.Alarm.DeadAirSec = 30
+	cfg.Alarm.LiveGraceSec = 120
+	cfg.Export.StatusQueryCycleSec = 5
+	events := fakeAlarmEvents{events: []dto.Event{
+		{EventId: "1", Title: "Evening Show", StartDate: "2026-10-19", StartTime: "20:00", EndTime: "21:00", EventType: "Preproduction"},
+		{EventId: "2", Title: "Live Talk", StartDate: "2026-10-19", StartTime: "21:00", EndTime: "22:00", EventType: "Live"},
+	}}
+	notifications := &fakeNotifier{}
+	alarmService := NewAlarmServiceWithState(&cfg, stateEx, events)
+	alarmService.Notifier = notifications
+	now := dayDate.Add(20 * time.Hour)
+	alarmService.Now = func() time.Time { return now }
+	return alarmService, &now, notifications
+}
+
+func setPlaying(playing bool, at time.Time) {
+	stateEx.Runtime.Update(func(runtime *appstate.RuntimeState) {
+		runtime.MairListPlaying = playing
+		if playing {
+			runtime.LastMairListPlaying = at
+		}
+	})
+}
+
+func TestEvaluateSilenceShorterThanDeadAirTimeRaisesNoAlarm(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	alarmService, now, notifications := setupAlarmTest(t)
+
+	require.NoError(t, alarmService.Evaluate())
+	*now = now.Add(29 * time.Second)
+	require.NoError(t, alarmService.Evaluate())
+
+	assert.Empty(t, alarmService.ActiveAlarms())
+	assert.Empty(t, alarmStates(notifications))
+}
+
+func TestEvaluateDeadAirRaisesAndClearsAlarm(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	alarmService, now, notifications := setupAlarmTest(t)
+	setPlaying(true, now.Add(time.Minute))
+	*now = now.Add(time.Minute)
+	require.NoError(t, alarmService.Evaluate())
+	setPlaying(false, *now)
+
+	*now = now.Add(30 * time.Second)
+	require.NoError(t, alarmService.Evaluate())
+	active := alarmService.ActiveAlarms()
+	*now = now.Add(5 * time.Second)
+	setPlaying(true, *now)
+	require.NoError(t, alarmService.Evaluate())
+
+	require.Len(t, active, 1)
+	assert.EqualValues(t, domain.AlarmDeadAir, active[0].Kind)
+	assert.EqualValues(t, "mAirList is not playing during Evening Show", active[0].Message)
+	assert.EqualValues(t, dayDate.Add(20*time.Hour+time.Minute), active[0].Since)
+	assert.Empty(t, alarmService.ActiveAlarms())
+	require.Len(t, alarmService.RecentAlarms(), 1)
+	assert.False(t, alarmService.RecentAlarms()[0].Active())
+	assert.EqualValues(t, []string{"raised dead_air", "cleared dead_air"}, alarmStates(notifications))
+}
+
+func TestEvaluateDeadAirDisabledRaisesNoAlarm(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	alarmService, now, _ := setupAlarmTest(t)
+	cfg.Alarm.DeadAirSec = 0
+
+	require.NoError(t, alarmService.Evaluate())
+	*now = now.Add(10 * time.Minute)
+	require.NoError(t, alarmService.Evaluate())
+
+	assert.Empty(t, alarmService.ActiveAlarms())
+}
+
+func TestEvaluatePlayingDuringLiveEventRaisesAlarmAfterGraceTime(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	alarmService, now, notifications := setupAlarmTest(t)
+	*now = dayDate.Add(21*time.Hour + time.Minute)
+	setPlaying(true, *now)
+	require.NoError(t, alarmService.Evaluate())
+	assert.Empty(t, alarmService.ActiveAlarms())
+
+	*now = dayDate.Add(21*time.Hour + 2*time.Minute)
+	setPlaying(true, *now)
+	require.NoError(t, alarmService.Evaluate())
+
+	active := alarmService.ActiveAlarms()
+	require.Len(t, active, 1)
+	assert.EqualValues(t, domain.AlarmLiveConflict, active[0].Kind)
+	assert.EqualValues(t, "2", active[0].EventId)
+	assert.EqualValues(t, []string{"raised live_conflict"}, alarmStates(notifications))
+}
+
+func TestEvaluateSilentDuringLiveEventRaisesNoAlarm(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	alarmService, now, _ := setupAlarmTest(t)
+	*now = dayDate.Add(21*time.Hour + 10*time.Minute)
+
+	require.NoError(t, alarmService.Evaluate())
+	*now = now.Add(time.Minute)
+	require.NoError(t, alarmService.Evaluate())
+
+	assert.Empty(t, alarmService.ActiveAlarms())
+}
+
+func TestEvaluateRaisedAlarmNotifiesKindAndEvent(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	alarmService, now, notifications := setupAlarmTest(t)
+	require.NoError(t, alarmService.Evaluate())
+	*now = now.Add(time.Minute)
+
+	require.NoError(t, alarmService.Evaluate())
+
+	require.Len(t, notifications.notifications, 1)
+	n := notifications.notifications[0]
+	assert.EqualValues(t, domain.NotificationAlarmRaised, n.Type)
+	assert.EqualValues(t, "mAirList is not playing during Evening Show", n.Message)
+	assert.EqualValues(t, map[string]string{"kind": "dead_air", "event_id": "1", "title": "Evening Show", "since": dayDate.Add(20 * time.Hour).Format(time.RFC3339)}, n.Data)
+}
@@ -0,0 +1,87 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// asRunTracker remembers the item on air between two playlist queries
+type asRunTracker struct {
+	mu       sync.Mutex
+	resumed  bool
+	current  *domain.AsRunEntry
+	position time.Duration // last playback position seen for the current item
+}
+
+func newAsRunTracker() *asRunTracker {
+	return &asRunTracker{}
+}
+
+// recordAsRun compares the item playing in mAirList with the item playing at the last query and logs the transitions.
+// The start is calculated from the playback position, so items already playing when the feeder starts get their real start time
+func (s DefaultExportService) recordAsRun(playlist mairlist.Playlist) {
+	now := s.Now()
+	t := s.asRun
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.resumed {
+		t.current = s.AsRun.LastOpen(now)
+		t.resumed = true
+	}
+	item := playlist.Current()
+	if t.current != nil && item != nil && asRunKey(t.current.ItemId, t.current.Filename) == asRunKey(item.ID, item.Filename) {
+		t.position = max(t.position, item.PlaybackPosition)
+		return
+	}
+	if t.current != nil {
+		s.stopAsRun(*t.current, t.position, now)
+		t.current = nil
+	}
+	if item == nil {
+		return
+	}
+	entry, err := s.AsRun.Start(domain.AsRunEntry{
+		ItemId:   item.ID,
+		Filename: item.Filename,
+		Title:    item.Title,
+		Artist:   item.Artist,
+		Album:    item.Album,
+		Genre:    item.Genre,
+		Year:     item.Year,
+		Duration: item.Duration,
+		Started:  now.Add(-item.PlaybackPosition).Truncate(time.Second),
+		Status:   domain.AsRunPlaying,
+	})
+	if err != nil {
+		logger.Error("Error writing as-run log", err)
+		return
+	}
+	t.current = &entry
+	t.position = item.PlaybackPosition
+}
+
+// stopAsRun closes an entry. An item counts as played to the end, if it stopped within one query cycle of its end
+func (s DefaultExportService) stopAsRun(entry domain.AsRunEntry, position time.Duration, now time.Time) {
+	entry.Stopped = now
+	entry.Status = domain.AsRunCompleted
+	tolerance := time.Duration(max(s.Cfg.Export.StatusQueryCycleSec, 1))*time.Second + 2*time.Second
+	if played := max(position, now.Sub(entry.Started)); entry.Duration > 0 && played+tolerance < entry.Duration {
+		entry.Status = domain.AsRunSkipped
+	}
+	if err := s.AsRun.Update(entry); err != nil {
+		logger.Error("Error writing as-run log", err)
+	}
+}
+
+// asRunKey identifies an item of mAirList's playlist. Uses mAirList's id, the file name if there is none
+func asRunKey(id string, fileName string) string {
+	if id != "" {
+		return id
+	}
+	return fileName
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAsRun(t *testing.T, now *time.Time) (*mairlisttest.Server, *repositories.DefaultAsRunRepository) {
+	t.Helper()
+	srv := mairlisttest.NewServer(6)
+	t.Cleanup(srv.Close)
+	cfg.Export.MairListUrl = srv.URL
+	cfg.Export.MairListVersion = 6
+	cfg.Export.StatusQueryCycleSec = 5
+	cfg.Export.AsRunFolder = t.TempDir()
+	asRunRepo := repositories.NewAsRunRepository(&cfg)
+	exportService.AsRun = &asRunRepo
+	exportService.Now = func() time.Time { return *now }
+	return srv, &asRunRepo
+}
+
+func TestGetPlaylistRecordsAsRunTransitions(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	now := dayDate.Add(20*time.Hour + 10*time.Second)
+	srv, asRunRepo := setupAsRun(t, &now)
+	srv.SetItems(
+		mairlisttest.Item{ID: "1", Filename: "a.mp3", Title: "A", Class: "File", State: "playing", Duration: 600, PlaybackPosition: 10},
+		mairlisttest.Item{ID: "2", Filename: "b.mp3", Title: "B", Class: "File", State: "none", Duration: 600},
+	)
+
+	require.NoError(t, exportService.GetPlaylist())
+	now = now.Add(3 * time.Minute)
+	srv.SetItems(
+		mairlisttest.Item{ID: "1", Filename: "a.mp3", Title: "A", Class: "File", State: "played", Duration: 600},
+		mairlisttest.Item{ID: "2", Filename: "b.mp3", Title: "B", Class: "File", State: "playing", Duration: 600, PlaybackPosition: 1},
+	)
+	require.NoError(t, exportService.GetPlaylist())
+	now = now.Add(10 * time.Minute)
+	srv.SetItems(mairlisttest.Item{ID: "2", Filename: "b.mp3", Title: "B", Class: "File", State: "played", Duration: 600})
+	require.NoError(t, exportService.GetPlaylist())
+	entries, err := asRunRepo.GetByDate(dayDate)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.EqualValues(t, dayDate.Add(20*time.Hour), entries[0].Started)
+	assert.EqualValues(t, domain.AsRunSkipped, entries[0].Status)
+	assert.EqualValues(t, "A", entries[0].Title)
+	assert.EqualValues(t, domain.AsRunCompleted, entries[1].Status)
+	assert.EqualValues(t, 10*time.Minute, entries[1].Duration)
+}
+
+func TestGetPlaylistResumesOpenAsRunEntry(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	now := dayDate.Add(20*time.Hour + 5*time.Minute)
+	srv, asRunRepo := setupAsRun(t, &now)
+	_, err := asRunRepo.Start(domain.AsRunEntry{ItemId: "1", Filename: "a.mp3", Started: dayDate.Add(20 * time.Hour), Status: domain.AsRunPlaying})
+	require.NoError(t, err)
+	srv.SetItems(mairlisttest.Item{ID: "1", Filename: "a.mp3", Class: "File", State: "playing", Duration: 600, PlaybackPosition: 300})
+
+	require.NoError(t, exportService.GetPlaylist())
+	entries, _ := asRunRepo.GetByDate(dayDate)
+
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, domain.AsRunPlaying, entries[0].Status)
+}
@@ -0,0 +1,119 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// loadedProgram is the calCms program for a range of dates
+type loadedProgram struct {
+	data   domain.CalCmsPgmData
+	cached bool // calCms couldn't be queried, the program was read from the cache
+}
+
+// loadProgramContext retrieves the calCms program for the given dates. A program received from calCms is cached per day,
+// if calCms can't be queried the cached program is returned instead. After recovery, a reconciliation of the data
+// derived from the cached program is pending until the next refresh of today's events, whichever caller saw the recovery
+func (s DefaultCalCmsService) loadProgramContext(ctx context.Context, dates []time.Time) (program loadedProgram, err error) {
+	program.data.Events, err = s.getEventsForDatesContext(ctx, dates)
+	if err != nil {
+		cached, fetched, found := s.loadCachedProgram(dates)
+		if !found {
+			return loadedProgram{}, err
+		}
+		logger.Warnf("calCMS could not be queried, using cached program from %v", fetched.Format("2006-01-02 15:04:05"))
+		s.setCalCmsDataState(fetched, true)
+		return loadedProgram{data: cached, cached: true}, nil
+	}
+	s.storeCachedProgram(dates, program.data)
+	if s.setCalCmsDataState(s.Now(), false) {
+		logger.Info("calCMS is reachable again, replacing cached program")
+		s.setReconcilePending(true)
+	}
+	return program, nil
+}
+
+// setReconcilePending records whether the file information derived from a cached program has to be reconciled
+func (s DefaultCalCmsService) setReconcilePending(pending bool) {
+	s.calCmsPgm.Lock()
+	defer s.calCmsPgm.Unlock()
+	s.calCmsPgm.reconcilePending = pending
+}
+
+// takeReconcilePending returns whether a reconciliation is pending and clears it
+func (s DefaultCalCmsService) takeReconcilePending() bool {
+	s.calCmsPgm.Lock()
+	defer s.calCmsPgm.Unlock()
+	pending := s.calCmsPgm.reconcilePending
+	s.calCmsPgm.reconcilePending = false
+	return pending
+}
+
+// storeCachedProgram caches the program of each of the dates and removes cached days that are no longer needed
+func (s DefaultCalCmsService) storeCachedProgram(dates []time.Time, calCmsData domain.CalCmsPgmData) {
+	if s.Cache == nil {
+		return
+	}
+	now := s.Now()
+	for _, date := range dates {
+		day := domain.FormatFolderDate(date)
+		entry := domain.CalCmsCacheEntry{Date: day, Fetched: now}
+		for _, event := range calCmsData.Events {
+			if event.StartDate == day {
+				entry.Data.Events = append(entry.Data.Events, event)
+			}
+		}
+		if err := s.Cache.Store(entry); err != nil {
+			logger.Error(fmt.Sprintf("Could not cache calCMS program for %v", day), err)
+		}
+	}
+	if err := s.Cache.Prune(domain.FormatFolderDate(now.AddDate(0, 0, -s.Cfg.CalCms.CacheDays))); err != nil {
+		logger.Error("Could not prune cached calCMS program", err)
+	}
+}
+
+// loadCachedProgram merges the cached programs of the dates. fetched is the time the oldest of them was received
+func (s DefaultCalCmsService) loadCachedProgram(dates []time.Time) (calCmsData domain.CalCmsPgmData, fetched time.Time, found bool) {
+	if s.Cache == nil {
+		return calCmsData, fetched, false
+	}
+	for _, date := range dates {
+		entry, err := s.Cache.Load(domain.FormatFolderDate(date))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				logger.Error(fmt.Sprintf("Could not read cached calCMS program for %v", domain.FormatFolderDate(date)), err)
+			}
+			continue
+		}
+		calCmsData.Events = append(calCmsData.Events, entry.Data.Events...)
+		if !found || entry.Fetched.Before(fetched) {
+			fetched = entry.Fetched
+		}
+		found = true
+	}
+	return calCmsData, fetched, found
+}
+
+// setCalCmsDataState records when the program in use was received and whether it came from the cache.
+// It returns true if the cached program was in use before and calCms answered again
+func (s DefaultCalCmsService) setCalCmsDataState(fetched time.Time, cached bool) (recovered bool) {
+	s.State.Runtime.Update(func(runtime *appstate.RuntimeState) {
+		recovered = runtime.CalCmsDataCached && !cached
+		runtime.CalCmsDataFetched = fetched
+		runtime.CalCmsDataCached = cached
+	})
+	if cached {
+		s.State.Metrics.SetCalCmsDataAge("cache", s.Now().Sub(fetched).Seconds())
+	} else {
+		s.State.Metrics.SetCalCmsDataAge("calcms", 0)
+	}
+	return recovered
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCalCmsCacheTest points the calCms service to a server answering with the sample program while available is true
+func setupCalCmsCacheTest(t *testing.T) (*atomic.Bool, *time.Time) {
+	t.Helper()
+	respData, err := os.ReadFile(calCmsResponseFile)
+	require.NoError(t, err)
+	available := &atomic.Bool{}
+	available.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(respData)
+	}))
+	t.Cleanup(srv.Close)
+	cfgCal.CalCms.CmsUrl = srv.URL
+	cfgCal.CalCms.QueryCalCms = true
+	cfgCal.CalCms.CacheFolder = t.TempDir()
+	cfgCal.CalCms.CacheDays = 7
+	cfgCal.Misc.TestCrawl = true
+	cfgCal.Misc.TestDate = "2024/09/24"
+	cacheRepo := repositories.NewCalCmsCacheRepository(&cfgCal)
+	calCmsService.Cache = &cacheRepo
+	now := time.Date(2024, 9, 24, 8, 0, 0, 0, time.Local)
+	calCmsService.Now = func() time.Time { return now }
+	return available, &now
+}
+
+func TestRefreshTodayEventsUnreachableCalCmsUsesCache(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	available, now := setupCalCmsCacheTest(t)
+	fresh, err1 := calCmsService.RefreshTodayEvents()
+	available.Store(false)
+	*now = now.Add(2 * time.Hour)
+
+	cached, err2 := calCmsService.RefreshTodayEvents()
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.NotEmpty(t, cached)
+	assert.EqualValues(t, len(fresh), len(cached))
+	runtime := stateCal.Runtime.Snapshot()
+	assert.True(t, runtime.CalCmsDataCached)
+	assert.True(t, runtime.CalCmsDataFetched.Equal(now.Add(-2*time.Hour)))
+}
+
+func TestRefreshTodayEventsReachableAgainReplacesCache(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	available, now := setupCalCmsCacheTest(t)
+	calCmsService.RefreshTodayEvents()
+	available.Store(false)
+	calCmsService.RefreshTodayEvents()
+	available.Store(true)
+	*now = now.Add(time.Hour)
+
+	_, err := calCmsService.RefreshTodayEvents()
+
+	require.NoError(t, err)
+	runtime := stateCal.Runtime.Snapshot()
+	assert.False(t, runtime.CalCmsDataCached)
+	assert.True(t, runtime.CalCmsDataFetched.Equal(*now))
+}
+
+func TestRefreshTodayEventsReconcilesAfterRecoverySeenByOtherQuery(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	available, now := setupCalCmsCacheTest(t)
+	calCmsService.RefreshTodayEvents()
+	available.Store(false)
+	calCmsService.RefreshTodayEvents()
+	available.Store(true)
+	*now = now.Add(time.Hour)
+
+	_, err := calCmsService.GetUpcomingEventsContext(t.Context(), 0)
+
+	require.NoError(t, err)
+	assert.True(t, calCmsService.calCmsPgm.reconcilePending)
+	_, err = calCmsService.RefreshTodayEvents()
+	require.NoError(t, err)
+	assert.False(t, calCmsService.calCmsPgm.reconcilePending)
+}
+
+func TestRefreshTodayEventsWithoutCacheReturnsError(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	available, _ := setupCalCmsCacheTest(t)
+	available.Store(false)
+
+	events, err := calCmsService.RefreshTodayEvents()
+
+	require.Error(t, err)
+	assert.Nil(t, events)
+	assert.False(t, stateCal.Runtime.Snapshot().CalCmsDataCached)
+}
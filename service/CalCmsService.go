@@ -3,13 +3,10 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"math"
 	"net/http"
-	"net/url"
 	"slices"
 	"strconv"
 	"strings"
@@ -22,6 +19,7 @@ import (
 	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
 	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
 	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/mairlist-feeder/schedule"
 	"github.com/johannes-kuhfuss/services_utils/logger"
 )
 
@@ -35,16 +33,31 @@ type DefaultCalCmsService struct {
 	Cfg             *config.AppConfig
 	State           *appstate.AppState
 	Repo            *repositories.DefaultFileRepository
-	httpClient      *http.Client
+	Schedule        schedule.Provider
 	Now             func() time.Time
 	calCmsPgm       *safeCalCmsPgm
 	eventsToday     *safeEvents
 	eventsYesterday *safeEvents
+	Notifier        notifier
+	Cache           repositories.CalCmsCacheRepository
+	Exports         hourExports
+	missingNotified *safeNotified
+	changes         *safeScheduleChanges
+	titleMatches    *safeTitleMatches
+	TitleMatches    repositories.TitleMatchRepository
+	EventFiles      repositories.EventFileRepository
+	RunCmd          func(context.Context, string, ...string) ([]byte, error)
+	rerunLookups    *safeRerunLookups
+	UploadStatus    uploadStatusWriter
+	uploadStatus    *safeUploadStatus
+	filters         []config.EventFilter
 }
 
 type safeCalCmsPgm struct {
 	sync.RWMutex
-	data domain.CalCmsPgmData
+	data             domain.CalCmsPgmData
+	days             []string // dates the program covers, YYYY-MM-DD
+	reconcilePending bool     // calCms answered again after the cached program was used, cleared by the refresh of today's events
 }
 
 type safeEvents struct {
@@ -52,6 +65,12 @@ type safeEvents struct {
 	events []dto.Event
 }
 
+// safeNotified remembers the events whose missing file was already notified
+type safeNotified struct {
+	sync.Mutex
+	keys map[string]bool
+}
+
 // InitHttpCalClient sets the defaukt values for the http client used to query calCms
 func InitHttpCalClient() *http.Client {
 	httpCalTr := http.Transport{
@@ -72,15 +91,26 @@ func NewCalCmsService(cfg *config.AppConfig, repo *repositories.DefaultFileRepos
 }
 
 func NewCalCmsServiceWithState(cfg *config.AppConfig, state *appstate.AppState, repo *repositories.DefaultFileRepository) DefaultCalCmsService {
+	// filters were validated on startup
+	filters, _ := config.EventFilters(cfg)
+	titleMatchRepo := repositories.NewTitleMatchRepository(cfg)
 	return DefaultCalCmsService{
 		Cfg:             cfg,
 		State:           state,
 		Repo:            repo,
-		httpClient:      InitHttpCalClient(),
+		Schedule:        schedule.NewProvider(cfg, InitHttpCalClient()),
 		Now:             time.Now,
 		calCmsPgm:       &safeCalCmsPgm{},
 		eventsToday:     &safeEvents{},
 		eventsYesterday: &safeEvents{},
+		missingNotified: &safeNotified{keys: make(map[string]bool)},
+		changes:         &safeScheduleChanges{},
+		titleMatches:    &safeTitleMatches{matches: make(map[string]domain.TitleMatch)},
+		TitleMatches:    &titleMatchRepo,
+		RunCmd:          runCommand,
+		rerunLookups:    &safeRerunLookups{lookups: make(map[int]rerunLookup)},
+		uploadStatus:    newSafeUploadStatus(),
+		filters:         filters,
 	}
 }
 
@@ -107,7 +137,9 @@ func calcCalCmsEndDateForDays(startDate string, days int) (endDate string, e err
 
 // setCalCmsQueryState sets staus of last calCms interaction with result and time for status overview
 func (s DefaultCalCmsService) setCalCmsQueryState(success bool) {
+	var wasFailed bool
 	s.State.Runtime.Update(func(runtime *appstate.RuntimeState) {
+		wasFailed = strings.HasPrefix(runtime.LastCalCmsState, "Failed")
 		if success {
 			runtime.LastCalCmsState = fmt.Sprintf("Succeeded (%v)", s.Now().Format("2006-01-02 15:04:05 -0700 MST"))
 		} else {
@@ -119,69 +151,55 @@ func (s DefaultCalCmsService) setCalCmsQueryState(success bool) {
 	} else {
 		s.State.Metrics.SetConnected("calCMS", 0)
 	}
-
-}
-
-// getCalCmsEventData retrieves the event information for the crawled date range from calCms.
-func (s DefaultCalCmsService) getCalCmsEventData() (eventData []byte, e error) {
-	return s.getCalCmsEventDataContext(context.Background())
-}
-
-func (s DefaultCalCmsService) getCalCmsEventDataContext(ctx context.Context) (eventData []byte, e error) {
-	return s.getCalCmsEventDataForDatesContext(ctx, helper.GetCrawlDates(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate))
+	if !success && !wasFailed && s.Notifier != nil {
+		s.Notifier.Notify(domain.NotificationCalCmsUnreachable, fmt.Sprintf("%v could not be queried", s.Schedule.Name()), map[string]string{"url": s.Schedule.Source()})
+	}
 }
 
-func (s DefaultCalCmsService) getCalCmsEventDataForDatesContext(ctx context.Context, dates []time.Time) (eventData []byte, e error) {
-	//API doc: https://github.com/rapilodev/racalmas/blob/master/docs/event-api.md
-	//URL old: https://programm.coloradio.org/agenda/events.cgi?date=2024-04-09&template=event.json-p
-	//URL new: https://programm.coloradio.org/agenda/events.cgi?from_date=2024-10-04&from_time=00:00&till_date=2024-10-05&till_time=00:00&template=event.json-p
+// getEventsForDatesContext retrieves the events of the given dates from the schedule provider
+func (s DefaultCalCmsService) getEventsForDatesContext(ctx context.Context, dates []time.Time) ([]domain.CalCmsEvent, error) {
 	if len(dates) == 0 {
 		return nil, errors.New("no calCMS query dates configured")
 	}
-	calUrl, err := url.Parse(s.Cfg.CalCms.CmsUrl)
-	if err != nil {
-		logger.Error("Cannot parse calCMS Url", err)
-		return nil, err
-	}
-	query := url.Values{}
-	calCmsStartDate := domain.FormatFolderDate(dates[0])
-	calCmsEndDate, err := calcCalCmsEndDateForDays(calCmsStartDate, len(dates))
+	from := domain.NormalizeDate(dates[0])
+	events, err := s.Schedule.Events(ctx, from, from.AddDate(0, 0, len(dates)))
+	s.setCalCmsQueryState(err == nil)
 	if err != nil {
 		return nil, err
 	}
-	query.Add("from_date", calCmsStartDate)
-	query.Add("from_time", "00:00")
-	query.Add("till_date", calCmsEndDate)
-	query.Add("till_time", "00:00")
-	query.Add("template", s.Cfg.CalCms.Template)
-	calUrl.RawQuery = query.Encode()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, calUrl.String(), nil)
-	if err != nil {
-		s.setCalCmsQueryState(false)
-		logger.Error("Cannot build calCMS http request", err)
-		return nil, err
+	programEvents := make([]domain.CalCmsEvent, 0, len(events))
+	for _, event := range events {
+		programEvents = append(programEvents, programEvent(event))
 	}
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.setCalCmsQueryState(false)
-		logger.Error("Cannot execute calCMS http request", err)
-		return nil, err
+	return programEvents, nil
+}
+
+// programEvent is a helper function returning the program entry of a schedule event. Events from calCms keep all their
+// information, for other providers only the fields needed for enrichment and the event list are filled
+func programEvent(event domain.ScheduleEvent) domain.CalCmsEvent {
+	if event.CalCms != nil {
+		return *event.CalCms
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		s.setCalCmsQueryState(false)
-		err := errors.New(resp.Status)
-		logger.Errorf("Received status code %v from calCMS. %v", resp.StatusCode, err)
-		return nil, err
+	start, end := event.Start.In(time.Local), event.End.In(time.Local)
+	duration := end.Sub(start)
+	programEvent := domain.CalCmsEvent{
+		EventID:       event.Id,
+		FullTitle:     event.Title,
+		Title:         event.Title,
+		StartDate:     start.Format("2006-01-02"),
+		StartTime:     start.Format("15:04"),
+		StartTimeName: start.Format("15:04"),
+		StartDatetime: start.Format("2006-01-02T15:04:05"),
+		EndDate:       end.Format("2006-01-02"),
+		EndTime:       end.Format("15:04"),
+		EndTimeName:   end.Format("15:04"),
+		EndDatetime:   end.Format("2006-01-02T15:04:05"),
+		Duration:      fmt.Sprintf("%02d:%02d:%02d", int(duration.Hours()), int(duration.Minutes())%60, int(duration.Seconds())%60),
 	}
-	eventData, err = io.ReadAll(resp.Body)
-	if err != nil {
-		s.setCalCmsQueryState(false)
-		logger.Error("Cannot read response data from calCMS", err)
-		return nil, err
+	if event.Live {
+		programEvent.Live = 1
 	}
-	s.setCalCmsQueryState(true)
-	return eventData, nil
+	return programEvent
 }
 
 // Query orchestrates the process of querying calCms and adding the retrieved information to the file representations in memory
@@ -193,17 +211,13 @@ func (s DefaultCalCmsService) QueryContext(ctx context.Context) error {
 	if s.Cfg.CalCms.QueryCalCms {
 		logger.Info("Starting to add information from calCMS...")
 		start := s.Now().UTC()
-		data, err := s.getCalCmsEventDataContext(ctx)
+		dates := helper.GetCrawlDates(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate)
+		program, err := s.loadProgramContext(ctx, dates)
 		if err != nil {
 			logger.Error("error getting data from calCms", err)
 			return err
 		}
-		var calCmsData domain.CalCmsPgmData
-		if err := json.Unmarshal(data, &calCmsData); err != nil {
-			logger.Error("Cannot convert calCMS response data to Json", err)
-			return err
-		}
-		s.insertData(calCmsData)
+		s.updateProgram(program, dates)
 		fc := s.EnrichFileInformation()
 		end := s.Now().UTC()
 		updateDur := end.Sub(start)
@@ -216,29 +230,68 @@ func (s DefaultCalCmsService) QueryContext(ctx context.Context) error {
 
 }
 
-// EnrichFileInformation runs through all file representations and adds information from calCms where applicable
+// GetUpcomingEventsContext queries calCms for the events of today and the given number of following days, leaving out excluded events
+func (s DefaultCalCmsService) GetUpcomingEventsContext(ctx context.Context, days int) ([]domain.CalCmsEvent, error) {
+	var dates []time.Time
+	for day := range days + 1 {
+		dates = append(dates, helper.DateForFolder(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate, day))
+	}
+	program, err := s.loadProgramContext(ctx, dates)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(program.data.Events, func(event domain.CalCmsEvent) bool {
+		return s.excluded(event)
+	}), nil
+}
+
+// EnrichFileInformation runs through all file representations and adds information from calCms where applicable.
+// Files without event id are linked to an event by title matching if enabled. The upload status is written once per event
 func (s DefaultCalCmsService) EnrichFileInformation() (fc dto.FileCounts) {
+	var (
+		paths    []string
+		received []domain.FileInfo
+	)
 	for _, folderDate := range helper.GetCrawlDates(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate) {
 		if files := s.Repo.GetByDate(folderDate); files != nil {
 			for _, file := range files {
-				if file.EventId != 0 {
-					calCmsInfo, err := s.checkCalCmsEventData(file)
-					if err != nil {
-						logger.Errorf("Error while checking calCms event data for file %v: %v", file.Path, err)
+				paths = append(paths, file.Path)
+				if file.EventId != 0 && s.excludedId(file.EventId) {
+					continue
+				}
+				if file.EventId == 0 {
+					var linked bool
+					if file, linked = s.matchByTitle(file); !linked {
 						continue
 					}
-					newFile, nfc := mergeInfo(file, *calCmsInfo)
+				}
+				if nfc, err := s.enrichFile(file); err == nil {
 					fc.Add(nfc)
-					if err := s.Repo.Store(newFile); err != nil {
-						logger.Error("Error updating information in file repository", err)
-					}
+					received = append(received, file)
 				}
 			}
 		}
 	}
+	s.pruneTitleMatches(paths)
+	s.reportReceived(received)
 	return fc
 }
 
+// enrichFile adds the information from calCms to a file with event id and updates it in the file repository
+func (s DefaultCalCmsService) enrichFile(file domain.FileInfo) (fc dto.FileCounts, e error) {
+	calCmsInfo, err := s.checkCalCmsEventData(file)
+	if err != nil {
+		logger.Errorf("Error while checking calCms event data for file %v: %v", file.Path, err)
+		return fc, err
+	}
+	newFile, fc := mergeInfo(file, *calCmsInfo)
+	if err := s.Repo.Store(newFile); err != nil {
+		logger.Error("Error updating information in file repository", err)
+	}
+	s.rememberEventFile(newFile)
+	return fc, nil
+}
+
 // mergeInfo combines the information from the existing file entry and data from calCms inot the new file entry
 func mergeInfo(oldFileInfo domain.FileInfo, calCmsInfo dto.CalCmsEntry) (newFileInfo domain.FileInfo, fc dto.FileCounts) {
 	newFileInfo = oldFileInfo
@@ -307,14 +360,14 @@ func (s DefaultCalCmsService) checkCalCmsEventData(file domain.FileInfo) (*dto.C
 	return &info[0], nil
 }
 
-// GetCalCmsEntriesForHour retrieves all event data from the calCms data that start within a given hour
+// GetCalCmsEntriesForHour retrieves all event data from the calCms data that start within a given hour, leaving out excluded events
 func (s DefaultCalCmsService) GetCalCmsEntriesForHour(hour string) (entries []dto.CalCmsEntry, e error) {
 	s.calCmsPgm.RLock()
 	events := append([]domain.CalCmsEvent(nil), s.calCmsPgm.data.Events...)
 	s.calCmsPgm.RUnlock()
 	if len(events) > 0 {
 		for _, event := range events {
-			if (event.Live == 0) && (strings.HasPrefix(event.StartTimeName, hour)) {
+			if (event.Live == 0) && (strings.HasPrefix(event.StartTimeName, hour)) && !s.excluded(event) {
 				entry, err := s.convertEventToEntry(event)
 				if err == nil {
 					entries = append(entries, entry)
@@ -327,14 +380,14 @@ func (s DefaultCalCmsService) GetCalCmsEntriesForHour(hour string) (entries []dt
 	return entries, nil
 }
 
-// GetCalCmsEventDataForId retrieves all event data from the calCms data for a given Event Id
+// GetCalCmsEventDataForId retrieves all event data from the calCms data for a given Event Id, leaving out excluded events
 func (s DefaultCalCmsService) GetCalCmsEventDataForId(id int) (entries []dto.CalCmsEntry, e error) {
 	s.calCmsPgm.RLock()
 	events := append([]domain.CalCmsEvent(nil), s.calCmsPgm.data.Events...)
 	s.calCmsPgm.RUnlock()
 	if len(events) > 0 {
 		for _, event := range events {
-			if event.EventID == id {
+			if event.EventID == id && !s.excluded(event) {
 				entry, err := s.convertEventToEntry(event)
 				if err == nil {
 					entries = append(entries, entry)
@@ -457,7 +510,7 @@ func (s DefaultCalCmsService) convertEvent(calCmsData domain.CalCmsPgmData) []dt
 		files domain.FileList
 	)
 	for _, event := range calCmsData.Events {
-		if !slices.Contains(s.Cfg.CalCms.EventExclusion, event.Skey) {
+		if !s.excluded(event) {
 			var ev dto.Event
 			ev.CurrentEvent = isCurrent(event.StartDate, event.StartTime, event.EndTime)
 			ev.EventId = strconv.Itoa(event.EventID)
@@ -491,8 +544,23 @@ func (s DefaultCalCmsService) convertEvent(calCmsData domain.CalCmsPgmData) []dt
 					files = s.Repo.GetByEventIdAndDate(event.EventID, eventDate)
 				}
 			}
+			originalId, isRerun := event.RerunOf()
+			if isRerun {
+				ev.Rerun = fmt.Sprintf("Rerun of event %v", originalId)
+			}
 			if files == nil {
-				if event.Live == 0 {
+				var (
+					rerun    domain.FileInfo
+					source   string
+					hasRerun bool
+				)
+				if s.Cfg.Rerun.ScheduleReruns && event.Live == 0 {
+					rerun, source, hasRerun = s.rerunFile(event)
+				}
+				if hasRerun {
+					ev.FileStatus, ev.ActualDuration, _ = extractFileInfo(domain.FileList{rerun}, false)
+					ev.FileSource = "Rerun (" + source + ")"
+				} else if event.Live == 0 {
 					ev.FileStatus = "Missing"
 				} else {
 					ev.FileStatus = "N/A"
@@ -512,30 +580,37 @@ func isCurrent(eventDate, startTime, endTime string) string {
 }
 
 func isCurrentAt(eventDate, startTime, endTime string, now time.Time) string {
-	date, err := domain.ParseFolderDate(eventDate)
+	st, et, err := eventPeriod(eventDate, startTime, endTime)
 	if err != nil {
-		logger.Errorf("Could not parse event date %q. %v", eventDate, err)
+		logger.Error("Could not determine event period", err)
 		return ""
 	}
+	if now.After(st) && now.Before(et) {
+		return "***"
+	}
+	return ""
+}
+
+// eventPeriod is a helper function returning start and end of an event. Events ending at or before their start time end on the next day
+func eventPeriod(eventDate, startTime, endTime string) (time.Time, time.Time, error) {
+	date, err := domain.ParseFolderDate(eventDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse event date %q: %w", eventDate, err)
+	}
 	sth, stm, err := parseCompactHourMinute(startTime)
 	if err != nil {
-		logger.Errorf("Could not parse event start time %q. %v", startTime, err)
-		return ""
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse event start time %q: %w", startTime, err)
 	}
 	eth, etm, err := parseCompactHourMinute(endTime)
 	if err != nil {
-		logger.Errorf("Could not parse event end time %q. %v", endTime, err)
-		return ""
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse event end time %q: %w", endTime, err)
 	}
 	st := time.Date(date.Year(), date.Month(), date.Day(), sth, stm, 0, 0, time.Local)
 	et := time.Date(date.Year(), date.Month(), date.Day(), eth, etm, 0, 0, time.Local)
 	if !et.After(st) {
 		et = et.AddDate(0, 0, 1)
 	}
-	if now.After(st) && now.Before(et) {
-		return "***"
-	}
-	return ""
+	return st, et, nil
 }
 
 func parseCompactHourMinute(timeValue string) (hour int, minute int, err error) {
@@ -571,27 +646,26 @@ func (s DefaultCalCmsService) RefreshTodayEvents() ([]dto.Event, error) {
 }
 
 func (s DefaultCalCmsService) RefreshTodayEventsContext(ctx context.Context) ([]dto.Event, error) {
-	var (
-		calCmsData domain.CalCmsPgmData
-	)
 	if s.Cfg.CalCms.QueryCalCms {
-		data, err := s.getCalCmsEventDataContext(ctx)
+		dates := helper.GetCrawlDates(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate)
+		program, err := s.loadProgramContext(ctx, dates)
 		if err != nil {
 			logger.Error("error getting data from calCms", err)
 			s.setTodayRefreshState(err)
 			return nil, err
 		}
-		if err := json.Unmarshal(data, &calCmsData); err != nil {
-			logger.Error("Cannot convert calCMS response data to Json", err)
-			s.setTodayRefreshState(err)
-			return nil, err
+		s.updateProgram(program, dates)
+		if s.takeReconcilePending() {
+			fc := s.EnrichFileInformation()
+			logger.Infof("Reconciled information from calCMS for %v file(s)", fc.TotalCount)
 		}
-		s.insertData(calCmsData)
-		el := s.convertEvent(calCmsData)
+		el := s.convertEvent(program.data)
 		s.eventsToday.Lock()
 		s.eventsToday.events = append([]dto.Event(nil), el...)
 		s.eventsToday.Unlock()
 		s.countEvents(el)
+		s.notifyMissingFiles(el)
+		s.reportMissingFiles(el)
 		s.setTodayRefreshState(nil)
 		return el, nil
 	} else {
@@ -611,6 +685,42 @@ func (s DefaultCalCmsService) setTodayRefreshState(err error) {
 	})
 }
 
+// notifyMissingFiles emits a notification for every preproduced event starting soon without a file. Each event is notified once per date
+func (s DefaultCalCmsService) notifyMissingFiles(events []dto.Event) {
+	if s.Notifier == nil {
+		return
+	}
+	now := s.Now()
+	leadTime := time.Duration(s.Cfg.Notify.MissingMin) * time.Minute
+	s.missingNotified.Lock()
+	defer s.missingNotified.Unlock()
+	for _, event := range events {
+		if event.EventType != "Preproduction" || event.FileStatus != "Missing" {
+			continue
+		}
+		start, _, err := eventPeriod(event.StartDate, event.StartTime, event.EndTime)
+		if err != nil || start.Before(now) || start.Sub(now) > leadTime {
+			continue
+		}
+		key := event.EventId + "@" + event.StartDate
+		if s.missingNotified.keys[key] {
+			continue
+		}
+		s.missingNotified.keys[key] = true
+		s.Notifier.Notify(domain.NotificationFileMissing, fmt.Sprintf("No file for %v starting at %v", event.Title, start.Format("15:04")), map[string]string{
+			"event_id": event.EventId,
+			"title":    event.Title,
+			"date":     event.StartDate,
+			"start":    event.StartTime,
+		})
+	}
+	for key := range s.missingNotified.keys {
+		if _, date, _ := strings.Cut(key, "@"); date < domain.FormatFolderDate(now.AddDate(0, 0, -1)) {
+			delete(s.missingNotified.keys, key)
+		}
+	}
+}
+
 // GetTodayEvents returns the cached event list for display on the web UI.
 func (s DefaultCalCmsService) GetTodayEvents() ([]dto.Event, error) {
 	s.eventsToday.RLock()
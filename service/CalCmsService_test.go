@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
 	metrics "github.com/johannes-kuhfuss/mairlist-feeder/metrics"
 	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/mairlist-feeder/schedule"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 )
@@ -558,54 +560,6 @@ func TestEnrichFileInformationOneStreamReturnsEnriched(t *testing.T) {
 	assert.EqualValues(t, 0, n.AudioCount)
 }
 
-func TestGetCalCmsDataWrongUrlReturnsError(t *testing.T) {
-	teardown := setupTestCal()
-	defer teardown()
-	cfgCal.CalCms.CmsUrl = "§$%&/()"
-	data, err := calCmsService.getCalCmsEventData()
-	assert.Nil(t, data)
-	assert.NotNil(t, err)
-	assert.EqualValues(t, "parse \"§$%&/()\": invalid URL escape \"%&/\"", err.Error())
-}
-
-func TestGetCalCmsDatahttpRequestReturnsData(t *testing.T) {
-	teardown := setupTestCal()
-	defer teardown()
-	respData, _ := os.ReadFile(calCmsResponseFile)
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.EqualValues(t, "2024-09-17", r.URL.Query().Get("from_date"))
-		assert.EqualValues(t, "2024-09-19", r.URL.Query().Get("till_date"))
-		w.WriteHeader(http.StatusOK)
-		w.Header().Add("Content-Type", "application/json")
-		w.Write(respData)
-	}))
-	defer srv.Close()
-	cfgCal.CalCms.CmsUrl = srv.URL
-	cfgCal.Misc.TestCrawl = true
-	cfgCal.Misc.TestDate = folderDateSlash
-	data, err := calCmsService.getCalCmsEventData()
-	assert.Nil(t, err)
-	assert.NotNil(t, data)
-	assert.EqualValues(t, respData, data)
-}
-
-func TestGetCalCmsDatahttpRequestReturnsError(t *testing.T) {
-	teardown := setupTestCal()
-	defer teardown()
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Header().Add("Content-Type", "application/json")
-	}))
-	defer srv.Close()
-	cfgCal.CalCms.CmsUrl = srv.URL
-	cfgCal.Misc.TestCrawl = true
-	cfgCal.Misc.TestDate = folderDateSlash
-	data, err := calCmsService.getCalCmsEventData()
-	assert.NotNil(t, err)
-	assert.Nil(t, data)
-	assert.EqualValues(t, "400 Bad Request", err.Error())
-}
-
 func TestQueryReturnsNoError(t *testing.T) {
 	teardown := setupTestCal()
 	defer teardown()
@@ -1127,3 +1081,30 @@ func TestMergeLiveWasReset(t *testing.T) {
 	assert.EqualValues(t, 1, ct.TotalCount)
 	assert.False(t, ni.EventIsLive)
 }
+
+func TestRefreshTodayEventsWithICalProviderListsEvents(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	feed := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:4711@radio.example.org\r\nSUMMARY:Evening Show\r\n" +
+		"DTSTART:20240924T200000\r\nDTEND:20240924T210000\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	cfgCal.CalCms.ICalSource = filepath.Join(t.TempDir(), "schedule.ics")
+	os.WriteFile(cfgCal.CalCms.ICalSource, []byte(feed), 0644)
+	cfgCal.Misc.TestCrawl = true
+	cfgCal.Misc.TestDate = "2024/09/24"
+	cfgCal.CalCms.QueryCalCms = true
+	calCmsService.Schedule = schedule.NewICalProvider(&cfgCal, InitHttpCalClient())
+
+	events, err := calCmsService.RefreshTodayEvents()
+	entries, entryErr := calCmsService.GetCalCmsEventDataForId(4711)
+
+	assert.Nil(t, err)
+	assert.Nil(t, entryErr)
+	assert.Len(t, events, 1)
+	assert.EqualValues(t, "4711", events[0].EventId)
+	assert.EqualValues(t, "Evening Show", events[0].Title)
+	assert.EqualValues(t, "Preproduction", events[0].EventType)
+	assert.EqualValues(t, "Missing", events[0].FileStatus)
+	assert.EqualValues(t, "60.0", events[0].PlannedDuration)
+	assert.Len(t, entries, 1)
+	assert.EqualValues(t, time.Hour, entries[0].Duration)
+}
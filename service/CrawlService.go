@@ -43,13 +43,14 @@ var (
 
 // The crawl service handles the cyclical scanning of the supervised folder and the extraction and enrichment of data for all files
 type DefaultCrawlService struct {
-	Cfg    *config.AppConfig
-	State  *appstate.AppState
-	Repo   *repositories.DefaultFileRepository
-	CalSvc CalCmsQuerier
-	Now    func() time.Time
-	RunCmd func(context.Context, string, ...string) ([]byte, error)
-	mu     *sync.Mutex
+	Cfg      *config.AppConfig
+	State    *appstate.AppState
+	Repo     *repositories.DefaultFileRepository
+	CalSvc   CalCmsQuerier
+	Now      func() time.Time
+	RunCmd   func(context.Context, string, ...string) ([]byte, error)
+	Notifier notifier
+	mu       *sync.Mutex
 }
 
 // NewCrawlService creates a new crawling service and injects its dependencies
@@ -389,6 +390,9 @@ func (s DefaultCrawlService) extractAudioInfoContext(ctx context.Context, oldInf
 	techMd, err := analyzeTechMdWithRunnerContext(ctx, oldInfo.Path, s.Cfg.Crawl.FFprobeTimeout, s.Cfg.Crawl.FFprobePath, s.RunCmd)
 	if err != nil {
 		logger.Error("Could not analyze file length", err)
+		if s.Notifier != nil {
+			s.Notifier.Notify(domain.NotificationFfprobeFailed, fmt.Sprintf("Could not analyze file %v", oldInfo.Path), map[string]string{"path": oldInfo.Path, "error": err.Error()})
+		}
 		return newInfo, err
 	} else {
 		newInfo.Duration = techMd.Duration
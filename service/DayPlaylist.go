@@ -0,0 +1,223 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	// carryOverHours is how many hours of the previous day are checked for shows running past midnight
+	carryOverHours = 4
+	// dayBlockComment starts the comment in front of each hour block of the day playlist
+	dayBlockComment = "Block "
+)
+
+// dayBlock holds the plan of one hour of the day playlist
+type dayBlock struct {
+	hour string
+	plan exportPlan
+}
+
+// carryOver describes a show of the previous day still running after midnight
+type carryOver struct {
+	timeKey string
+	path    string
+	until   time.Time
+}
+
+// ExportDayPlaylist writes a single playlist covering all of tomorrow
+func (s DefaultExportService) ExportDayPlaylist() error {
+	return s.ExportDayPlaylistContext(context.Background())
+}
+
+func (s DefaultExportService) ExportDayPlaylistContext(ctx context.Context) (err error) {
+	start := s.Now()
+	defer func() {
+		recordRunMetrics(s.State, "dayexport", start, err)
+	}()
+	_, err = s.ExportDayPlaylistForDateContext(ctx, helper.DateForFolder(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate, 1))
+	return err
+}
+
+// ExportDayPlaylistForDate writes a single playlist covering 00:00 - 24:00 of the given date.
+// The day playlist is a separate export target and is never appended to mAirList automatically
+func (s DefaultExportService) ExportDayPlaylistForDate(folderDate time.Time) (exportedFile string, err error) {
+	return s.ExportDayPlaylistForDateContext(context.Background(), folderDate)
+}
+
+func (s DefaultExportService) ExportDayPlaylistForDateContext(ctx context.Context, folderDate time.Time) (exportedFile string, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.State.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.ExportRunning = true })
+	defer func() {
+		s.State.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.ExportRunning = false })
+	}()
+	logger.Infof("Starting day playlist export for %v ...", domain.FormatFolderDate(folderDate))
+	carried := s.carryOvers(folderDate.AddDate(0, 0, -1))
+	blocks := dropCarriedOver(s.dayBlocks(folderDate), carried)
+	if len(blocks) == 0 {
+		logger.Infof("No elements to export for day playlist %v.", domain.FormatFolderDate(folderDate))
+		return "", nil
+	}
+	exportPath, err := s.setDayExportPath(folderDate)
+	if err != nil {
+		logger.Error("Error when setting day playlist export path", err)
+		return "", err
+	}
+	err = s.writePlaylistFile(exportPath, func(w *bufio.Writer) error {
+		return s.writeDayPlaylist(ctx, w, folderDate, blocks, carried)
+	})
+	if err != nil {
+		return "", err
+	}
+	s.recordExport(exportPath)
+	s.State.Runtime.Update(func(runtime *appstate.RuntimeState) {
+		runtime.LastDayExportFileName = exportPath
+		runtime.LastDayExportDate = s.Now()
+	})
+	logger.Infof("Finished day playlist export for %v to %v", domain.FormatFolderDate(folderDate), exportPath)
+	return exportPath, nil
+}
+
+// dayBlocks collects the plans for all hours of a day which have items to export
+func (s DefaultExportService) dayBlocks(folderDate time.Time) []dayBlock {
+	var blocks []dayBlock
+	for hour := range 24 {
+		hourStr := fmt.Sprintf("%02d", hour)
+		if plan, _, found := s.planForDateAndHour(folderDate, hourStr); found && len(plan) > 0 {
+			blocks = append(blocks, dayBlock{hour: hourStr, plan: plan})
+		}
+	}
+	return blocks
+}
+
+// carryOvers returns the shows of the given day which are planned to run past midnight
+func (s DefaultExportService) carryOvers(previousDate time.Time) []carryOver {
+	var carried []carryOver
+	for hour := 24 - carryOverHours; hour < 24; hour++ {
+		plan, _, _ := s.planForDateAndHour(previousDate, fmt.Sprintf("%02d", hour))
+		for timeKey, file := range plan {
+			start, err := time.Parse("15:04", timeKey)
+			if err != nil {
+				continue
+			}
+			if end := start.Add(plannedLength(file)); end.After(midnight()) {
+				carried = append(carried, carryOver{timeKey: timeKey, path: file.Path, until: end})
+			}
+		}
+	}
+	sort.Slice(carried, func(i, j int) bool {
+		return carried[i].timeKey < carried[j].timeKey
+	})
+	return carried
+}
+
+// dropCarriedOver removes the items starting while a show carried over from the previous day is still running.
+// Blocks left without items are dropped
+func dropCarriedOver(blocks []dayBlock, carried []carryOver) []dayBlock {
+	var busyUntil time.Time
+	for _, show := range carried {
+		if until := show.until.Add(-24 * time.Hour); busyUntil.IsZero() || until.After(busyUntil) {
+			busyUntil = until
+		}
+	}
+	if busyUntil.IsZero() {
+		return blocks
+	}
+	var kept []dayBlock
+	for _, block := range blocks {
+		plan := make(exportPlan, len(block.plan))
+		for timeKey, file := range block.plan {
+			if start, err := time.Parse("15:04", timeKey); err == nil && start.Before(busyUntil) {
+				logger.Warnf("Skipping %v at %v, show carried over from previous day runs until %v", file.Path, timeKey, busyUntil.Format("15:04:05"))
+				continue
+			}
+			plan[timeKey] = file
+		}
+		if len(plan) > 0 {
+			kept = append(kept, dayBlock{hour: block.hour, plan: plan})
+		}
+	}
+	return kept
+}
+
+// writeDayPlaylist writes all hour blocks of a day, terminated by a single stopper after the last item if enabled.
+// Shows carried over from the previous day are noted as comments, their overlapping items have been dropped before.
+// If the last item runs past midnight, no stopper is written and the next day's playlist continues after it
+func (s DefaultExportService) writeDayPlaylist(ctx context.Context, w *bufio.Writer, folderDate time.Time, blocks []dayBlock, carried []carryOver) error {
+	var dayEnd time.Time
+	line := fmt.Sprintf("\t\tR\tDay playlist for %v\n", domain.FormatFolderDate(folderDate))
+	if err := s.writeLine(w, line); err != nil {
+		return err
+	}
+	for _, show := range carried {
+		line := fmt.Sprintf("\t\tR\tCarried over from %v %v: %v runs until %v\n",
+			domain.FormatFolderDate(folderDate.AddDate(0, 0, -1)), show.timeKey, show.path, show.until.Format("15:04:05"))
+		if err := s.writeLine(w, line); err != nil {
+			return err
+		}
+	}
+	for _, block := range blocks {
+		if err := s.writeLine(w, fmt.Sprintf("\t\tR\t%v%v:00\n", dayBlockComment, block.hour)); err != nil {
+			return err
+		}
+		startTime, totalLength, err := s.writePlanBlock(ctx, w, block.plan)
+		if err != nil {
+			return err
+		}
+		if blockEnd := startTime.Add(totalLength); dayEnd.IsZero() || blockEnd.After(dayEnd) {
+			dayEnd = blockEnd
+		}
+	}
+	if !s.Cfg.Export.TerminateAfterDuration {
+		return nil
+	}
+	if dayEnd.Before(midnight()) {
+		return s.WriteStopper(w, dayEnd, 0)
+	}
+	line = fmt.Sprintf("\t\tR\tDay continues into next day until %v\n", dayEnd.Format("15:04:05"))
+	return s.writeLine(w, line)
+}
+
+// midnight is a helper function returning midnight at the end of the day, in the same reference date "15:04" times are parsed to
+func midnight() time.Time {
+	dayStart, _ := time.Parse("15:04", "00:00")
+	return dayStart.Add(24 * time.Hour)
+}
+
+// setDayExportPath is a helper function creating the export path for the day playlist.
+// Uses the day playlist folder if configured, the export folder otherwise
+func (s DefaultExportService) setDayExportPath(folderDate time.Time) (exportPath string, e error) {
+	var exportFileName string
+	exportFolder := s.Cfg.Export.DayPlaylistFolder
+	if exportFolder == "" {
+		exportFolder = s.Cfg.Export.ExportFolder
+	}
+	if s.Cfg.Misc.TestCrawl {
+		exportFileName = "Test_day.tpi"
+	} else {
+		exportFileName = domain.FormatFolderDate(folderDate) + "-day.tpi"
+	}
+	absExpPath, err := filepath.Abs(filepath.Join(exportFolder, exportFileName))
+	if err != nil {
+		return "", err
+	}
+	if !helper.IsPathWithin(absExpPath, exportFolder) {
+		return "", errors.New("invalid export path")
+	}
+	return absExpPath, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dayDate = time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+func storeDayFile(t *testing.T, folderDate time.Time, path string, hour int, minute int, duration time.Duration) {
+	t.Helper()
+	require.NoError(t, fileRepo.Store(domain.FileInfo{
+		Path:       path,
+		Duration:   duration,
+		StartTime:  helper.TimeFromHourAndMinute(hour, minute),
+		FolderDate: folderDate,
+		FileType:   domain.FileTypeAudio,
+	}))
+}
+
+func readDayPlaylist(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestExportDayPlaylistNoFilesReturnsEmptyPath(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+
+	exportedFile, err := exportService.ExportDayPlaylistForDate(dayDate)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", exportedFile)
+}
+
+func TestExportDayPlaylistWritesAllHoursInOrder(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "evening.mp3", 20, 0, time.Hour)
+	storeDayFile(t, dayDate, "morning.mp3", 8, 0, time.Hour)
+	storeDayFile(t, dayDate.AddDate(0, 0, 1), "nextday.mp3", 9, 0, time.Hour)
+
+	exportedFile, err := exportService.ExportDayPlaylistForDate(dayDate)
+	lines := readDayPlaylist(t, exportedFile)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, filepath.Join(cfg.Export.ExportFolder, "2026-10-19-day.tpi"), exportedFile)
+	assert.EqualValues(t, []string{
+		"\t\tR\tDay playlist for 2026-10-19",
+		"\t\tR\tBlock 08:00",
+		"08:00:00\tH\tF\tmorning.mp3",
+		"\t\tR\tBlock 20:00",
+		"20:00:00\tH\tF\tevening.mp3",
+		"21:00:00\tH\tD\tEnd of block",
+		"\t\tR\tEnd of auto-generated playlist",
+	}, lines[1:])
+	assert.EqualValues(t, exportedFile, stateEx.Runtime.Snapshot().LastDayExportFileName)
+}
+
+func TestExportDayPlaylistRunningPastMidnightHasNoStopper(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "evening.mp3", 20, 0, time.Hour)
+	storeDayFile(t, dayDate, "late.mp3", 23, 0, 2*time.Hour)
+
+	exportedFile, err := exportService.ExportDayPlaylistForDate(dayDate)
+	lines := readDayPlaylist(t, exportedFile)
+
+	require.NoError(t, err)
+	assert.Contains(t, lines, "\t\tR\tDay continues into next day until 01:00:00")
+	assert.NotContains(t, strings.Join(lines, "\n"), "End of block")
+}
+
+func TestExportDayPlaylistNotesShowCarriedOverFromPreviousDay(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate.AddDate(0, 0, -1), "late.mp3", 23, 0, 2*time.Hour)
+	storeDayFile(t, dayDate.AddDate(0, 0, -1), "early.mp3", 21, 0, time.Hour)
+	storeDayFile(t, dayDate, "midnight.mp3", 0, 0, time.Hour)
+	storeDayFile(t, dayDate, "morning.mp3", 8, 0, time.Hour)
+
+	exportedFile, err := exportService.ExportDayPlaylistForDate(dayDate)
+	lines := readDayPlaylist(t, exportedFile)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{
+		"\t\tR\tDay playlist for 2026-10-19",
+		"\t\tR\tCarried over from 2026-10-18 23:00: late.mp3 runs until 01:00:00",
+		"\t\tR\tBlock 08:00",
+		"08:00:00\tH\tF\tmorning.mp3",
+		"09:00:00\tH\tD\tEnd of block",
+		"\t\tR\tEnd of auto-generated playlist",
+	}, lines[1:])
+}
+
+func TestExportDayPlaylistOnlyCarriedOverItemsReturnsEmptyPath(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate.AddDate(0, 0, -1), "late.mp3", 23, 0, 2*time.Hour)
+	storeDayFile(t, dayDate, "midnight.mp3", 0, 30, time.Hour)
+
+	exportedFile, err := exportService.ExportDayPlaylistForDate(dayDate)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", exportedFile)
+}
+
+func TestExportDayPlaylistUsesDayPlaylistFolder(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	cfg.Export.DayPlaylistFolder = t.TempDir()
+	storeDayFile(t, dayDate, "morning.mp3", 8, 0, time.Hour)
+
+	exportedFile, err := exportService.ExportDayPlaylistForDate(dayDate)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, filepath.Join(cfg.Export.DayPlaylistFolder, "2026-10-19-day.tpi"), exportedFile)
+}
+
+func TestExportDayPlaylistTestCrawlUsesTestFileName(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	cfg.Misc.TestCrawl = true
+
+	exportPath, err := exportService.setDayExportPath(dayDate)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "Test_day.tpi", filepath.Base(exportPath))
+}
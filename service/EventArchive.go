@@ -0,0 +1,260 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// The archive service keeps the end-of-day status of the events and evaluates it for the history view
+type DefaultArchiveService struct {
+	Cfg    *config.AppConfig
+	State  *appstate.AppState
+	Events eventRecords
+	Repo   repositories.EventArchiveRepository
+	Now    func() time.Time
+}
+
+type eventRecords interface {
+	EventRecordsForDateContext(context.Context, time.Time) ([]domain.EventRecord, error)
+}
+
+// NewArchiveServiceWithState creates a new archive service and injects its dependencies
+func NewArchiveServiceWithState(cfg *config.AppConfig, state *appstate.AppState, events eventRecords, repo repositories.EventArchiveRepository) DefaultArchiveService {
+	return DefaultArchiveService{
+		Cfg:    cfg,
+		State:  state,
+		Events: events,
+		Repo:   repo,
+		Now:    time.Now,
+	}
+}
+
+// ArchiveDay stores the status of the events of a day in the archive
+func (s DefaultArchiveService) ArchiveDay(date time.Time) (int, error) {
+	return s.ArchiveDayContext(context.Background(), date)
+}
+
+// ArchiveDayContext stores the status of the events of a day in the archive, replacing a previous status of that day.
+// Late and missing uploads are counted per series. Days older than the configured number of days are removed afterwards
+func (s DefaultArchiveService) ArchiveDayContext(ctx context.Context, date time.Time) (int, error) {
+	records, err := s.Events.EventRecordsForDateContext(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+	day := domain.FormatFolderDate(date)
+	previous, _ := s.Repo.Load(day)
+	if err := s.Repo.Store(day, records); err != nil {
+		return 0, err
+	}
+	s.countLateUploads(previous, records)
+	if s.Cfg.Archive.Days > 0 {
+		before := domain.NormalizeDate(s.Now()).AddDate(0, 0, -s.Cfg.Archive.Days)
+		if err := s.Repo.Prune(domain.FormatFolderDate(before)); err != nil {
+			logger.Error("Error pruning event archive", err)
+		}
+	}
+	return len(records), nil
+}
+
+// countLateUploads counts the late and missing uploads of a day in the metrics. Events already counted
+// as late or missing when the day was archived before aren't counted again
+func (s DefaultArchiveService) countLateUploads(previous []domain.EventRecord, records []domain.EventRecord) {
+	if s.State == nil {
+		return
+	}
+	deadline := s.UploadDeadline()
+	counted := make(map[int]string)
+	for _, record := range previous {
+		counted[record.EventId] = record.Punctuality(deadline)
+	}
+	for _, record := range records {
+		punctuality := record.Punctuality(deadline)
+		if punctuality != domain.PunctualityLate && punctuality != domain.PunctualityMissing {
+			continue
+		}
+		if before := counted[record.EventId]; before == domain.PunctualityLate || before == domain.PunctualityMissing {
+			continue
+		}
+		s.State.Metrics.AddLateUpload(recordSeries(record), punctuality)
+	}
+}
+
+// Records returns the archived events from the first to the last date, sorted by start
+func (s DefaultArchiveService) Records(first time.Time, last time.Time) ([]domain.EventRecord, error) {
+	var records []domain.EventRecord
+	from, to := domain.FormatFolderDate(first), domain.FormatFolderDate(last)
+	for _, day := range s.Repo.GetDates() {
+		if day < from || day > to {
+			continue
+		}
+		dayRecords, err := s.Repo.Load(day)
+		if err != nil {
+			return nil, fmt.Errorf("could not load archived events of %v: %w", day, err)
+		}
+		records = append(records, dayRecords...)
+	}
+	slices.SortStableFunc(records, func(a domain.EventRecord, b domain.EventRecord) int {
+		return a.Start.Compare(b.Start)
+	})
+	return records, nil
+}
+
+// MonthlyStats returns the statistics per series for the archived events of the month, sorted by series
+func (s DefaultArchiveService) MonthlyStats(month time.Time) ([]dto.SeriesStats, error) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	records, err := s.Records(first, first.AddDate(0, 1, -1))
+	if err != nil {
+		return nil, err
+	}
+	return seriesStats(records), nil
+}
+
+// seriesStats is a helper function summing up archived events per series
+func seriesStats(records []domain.EventRecord) []dto.SeriesStats {
+	type sums struct {
+		stats     dto.SeriesStats
+		leadTotal time.Duration
+		leadCount int
+	}
+	bySeries := make(map[string]*sums)
+	for _, record := range records {
+		series := recordSeries(record)
+		sum, found := bySeries[series]
+		if !found {
+			sum = &sums{stats: dto.SeriesStats{Series: series}}
+			bySeries[series] = sum
+		}
+		sum.stats.Events++
+		if record.Exported {
+			sum.stats.Exported++
+		}
+		if record.Live {
+			sum.stats.Live++
+			continue
+		}
+		sum.stats.Preproduced++
+		if !record.HasFile() {
+			sum.stats.Missing++
+			continue
+		}
+		sum.stats.WithFile++
+		if lead, known := record.UploadLead(); known {
+			sum.leadTotal += lead
+			sum.leadCount++
+		}
+	}
+	stats := make([]dto.SeriesStats, 0, len(bySeries))
+	for _, sum := range bySeries {
+		if sum.leadCount > 0 {
+			hours := (sum.leadTotal / time.Duration(sum.leadCount)).Hours()
+			sum.stats.AvgLeadHours = strconv.FormatFloat(math.Round(hours*10)/10, 'f', 1, 64)
+		} else {
+			sum.stats.AvgLeadHours = "N/A"
+		}
+		stats = append(stats, sum.stats)
+	}
+	slices.SortFunc(stats, func(a dto.SeriesStats, b dto.SeriesStats) int {
+		return cmp.Compare(a.Series, b.Series)
+	})
+	return stats
+}
+
+// recordSeries is a helper function returning the series of an archived event. Events without series count under their title
+func recordSeries(record domain.EventRecord) string {
+	if record.Series != "" {
+		return record.Series
+	}
+	return record.Title
+}
+
+// EventRecordsForDateContext returns the status of the events of a day as recorded in the event archive.
+// Other than the event view, a failing query of the schedule provider fails the whole day
+func (s DefaultCalCmsService) EventRecordsForDateContext(ctx context.Context, date time.Time) ([]domain.EventRecord, error) {
+	data, err := s.loadEventsForDatesContext(ctx, []time.Time{date})
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := loadReminderAddresses(s.Cfg.Reminder.AddressFile)
+	if err != nil {
+		logger.Error("Could not resolve producers for archive", err)
+	}
+	now := s.Now()
+	records := make([]domain.EventRecord, 0, len(data.Events))
+	for _, event := range data.Events {
+		if s.excluded(event) {
+			continue
+		}
+		start, end, err := eventPeriod(event.StartDate, event.StartTime, event.EndTime)
+		if err != nil {
+			logger.Error("Could not determine event period for archive", err)
+			continue
+		}
+		events := s.convertEvent(domain.CalCmsPgmData{Events: []domain.CalCmsEvent{event}})
+		s.addRememberedFiles(events)
+		converted := events[0]
+		record := domain.EventRecord{
+			Date:            event.StartDate,
+			EventId:         event.EventID,
+			Title:           event.FullTitle,
+			Series:          event.SeriesName,
+			Start:           start,
+			End:             end,
+			Live:            event.Live != 0,
+			Rerun:           converted.Rerun,
+			PlannedDuration: end.Sub(start),
+			FileStatus:      converted.FileStatus,
+			FileSource:      converted.FileSource,
+			Producer:        eventRecipients(s.Cfg, event, addresses),
+			Recorded:        now,
+		}
+		if file, found := s.recordedFile(event, date, converted.FileSource); found {
+			record.Path = file.Path
+			record.ActualDuration = file.Duration
+			record.FileModified = file.ModTime
+			record.FileScanned = file.ScanTime
+			if s.Exports != nil {
+				record.Exported = s.Exports.IsFileExported(date, start.Format("15"), file)
+			}
+		} else if converted.FileSource == "History" && s.EventFiles != nil {
+			if remembered := s.EventFiles.Get(event.EventID); remembered != nil {
+				record.Path = remembered.Path
+				record.ActualDuration = remembered.Duration
+			}
+		}
+		records = append(records, record)
+	}
+	slices.SortStableFunc(records, func(a domain.EventRecord, b domain.EventRecord) int {
+		return a.Start.Compare(b.Start)
+	})
+	return records, nil
+}
+
+// recordedFile is a helper function returning the file used for an event: the most recently modified file of the event
+// or, for reruns, the file of the original broadcast
+func (s DefaultCalCmsService) recordedFile(event domain.CalCmsEvent, date time.Time, fileSource string) (domain.FileInfo, bool) {
+	if files := s.Repo.GetByEventIdAndDate(event.EventID, date); len(files) > 0 {
+		return slices.MaxFunc(files, func(a domain.FileInfo, b domain.FileInfo) int {
+			return a.ModTime.Compare(b.ModTime)
+		}), true
+	}
+	if strings.HasPrefix(fileSource, "Rerun") {
+		if rerun, _, ok := s.rerunFile(event); ok {
+			return rerun, true
+		}
+	}
+	return domain.FileInfo{}, false
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEventRecords struct {
+	records []domain.EventRecord
+	err     error
+}
+
+func (f fakeEventRecords) EventRecordsForDateContext(ctx context.Context, date time.Time) ([]domain.EventRecord, error) {
+	return f.records, f.err
+}
+
+var archiveDate = domain.MustParseFolderDate("2024-09-24")
+
+func archiveRecord(id int, series string, live bool, status string, lead time.Duration) domain.EventRecord {
+	start := time.Date(2024, 9, 24, 10, 0, 0, 0, time.Local).Add(time.Duration(id) * time.Hour)
+	record := domain.EventRecord{Date: "2024-09-24", EventId: id, Title: "Show " + series, Series: series, Start: start, End: start.Add(time.Hour), Live: live, FileStatus: status}
+	if lead != 0 {
+		record.FileModified = start.Add(-lead)
+	}
+	return record
+}
+
+func setupArchive(t *testing.T, events fakeEventRecords) (DefaultArchiveService, repositories.DefaultEventArchiveRepository) {
+	t.Helper()
+	var archiveCfg config.AppConfig
+	archiveCfg.Archive.Folder = t.TempDir()
+	repo := repositories.NewEventArchiveRepository(&archiveCfg)
+	archive := NewArchiveServiceWithState(&archiveCfg, nil, events, &repo)
+	archive.Now = func() time.Time { return time.Date(2024, 9, 24, 23, 15, 0, 0, time.Local) }
+	return archive, repo
+}
+
+func TestArchiveDayStoresRecords(t *testing.T) {
+	archive, repo := setupArchive(t, fakeEventRecords{records: []domain.EventRecord{archiveRecord(1, "Jazz", false, "Present", 30*time.Hour)}})
+
+	count, err := archive.ArchiveDay(archiveDate)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	stored, err := repo.Load("2024-09-24")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.EqualValues(t, "Jazz", stored[0].Series)
+}
+
+func TestArchiveDayErrorKeepsPreviousRecords(t *testing.T) {
+	archive, repo := setupArchive(t, fakeEventRecords{err: errors.New("calCms not reachable")})
+	require.NoError(t, repo.Store("2024-09-24", []domain.EventRecord{archiveRecord(1, "Jazz", false, "Present", 0)}))
+
+	_, err := archive.ArchiveDay(archiveDate)
+
+	assert.EqualError(t, err, "calCms not reachable")
+	stored, _ := repo.Load("2024-09-24")
+	assert.Len(t, stored, 1)
+}
+
+func TestArchiveDayPrunesOldDays(t *testing.T) {
+	archive, repo := setupArchive(t, fakeEventRecords{})
+	archive.Cfg.Archive.Days = 30
+	require.NoError(t, repo.Store("2024-08-01", []domain.EventRecord{archiveRecord(1, "Jazz", false, "Present", 0)}))
+
+	_, err := archive.ArchiveDay(archiveDate)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"2024-09-24"}, repo.GetDates())
+}
+
+func TestRecordsReturnsRangeSortedByStart(t *testing.T) {
+	archive, repo := setupArchive(t, fakeEventRecords{})
+	require.NoError(t, repo.Store("2024-09-24", []domain.EventRecord{archiveRecord(2, "Rock", false, "Present", 0), archiveRecord(1, "Jazz", false, "Present", 0)}))
+	require.NoError(t, repo.Store("2024-09-26", []domain.EventRecord{archiveRecord(3, "Pop", false, "Present", 0)}))
+
+	records, err := archive.Records(domain.MustParseFolderDate("2024-09-23"), domain.MustParseFolderDate("2024-09-25"))
+
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.EqualValues(t, 1, records[0].EventId)
+	assert.EqualValues(t, 2, records[1].EventId)
+}
+
+func TestMonthlyStatsSumsUpPerSeries(t *testing.T) {
+	archive, repo := setupArchive(t, fakeEventRecords{})
+	jazzExported := archiveRecord(1, "Jazz", false, "Present", 30*time.Hour)
+	jazzExported.Exported = true
+	live := archiveRecord(4, "", true, "N/A", 0)
+	live.Title = "Morning Live"
+	require.NoError(t, repo.Store("2024-09-24", []domain.EventRecord{
+		jazzExported,
+		archiveRecord(2, "Jazz", false, "Present", 10*time.Hour),
+		archiveRecord(3, "Jazz", false, "Missing", 0),
+		live,
+	}))
+	require.NoError(t, repo.Store("2024-10-01", []domain.EventRecord{archiveRecord(5, "Jazz", false, "Missing", 0)}))
+
+	stats, err := archive.MonthlyStats(domain.MustParseFolderDate("2024-09-15"))
+
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.EqualValues(t, "Jazz", stats[0].Series)
+	assert.EqualValues(t, 3, stats[0].Events)
+	assert.EqualValues(t, 3, stats[0].Preproduced)
+	assert.EqualValues(t, 2, stats[0].WithFile)
+	assert.EqualValues(t, 1, stats[0].Missing)
+	assert.EqualValues(t, 1, stats[0].Exported)
+	assert.EqualValues(t, "20.0", stats[0].AvgLeadHours)
+	assert.EqualValues(t, "Morning Live", stats[1].Series)
+	assert.EqualValues(t, 1, stats[1].Live)
+	assert.EqualValues(t, "N/A", stats[1].AvgLeadHours)
+}
+
+func TestEventRecordsForDateRecordsFilesAndExports(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusOK)
+	modified := time.Date(2024, 9, 23, 8, 0, 0, 0, time.Local)
+	fileRepoCal.Store(domain.FileInfo{Path: "/root/2024/09/24/12-00/show-id1-.mp3", FolderDate: archiveDate, EventId: 1, FileType: domain.FileTypeAudio, Duration: 58 * time.Minute, ModTime: modified})
+	calCmsService.Exports = fakeHourExports{"2024-09-24 12 /root/2024/09/24/12-00/show-id1-.mp3": true}
+
+	records, err := calCmsService.EventRecordsForDateContext(t.Context(), archiveDate)
+
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.EqualValues(t, "Program Event", records[0].Title)
+	assert.EqualValues(t, "Present", records[0].FileStatus)
+	assert.EqualValues(t, time.Hour, records[0].PlannedDuration)
+	assert.EqualValues(t, 58*time.Minute, records[0].ActualDuration)
+	assert.EqualValues(t, modified, records[0].FileModified)
+	assert.True(t, records[0].Exported)
+	lead, known := records[0].UploadLead()
+	assert.True(t, known)
+	assert.EqualValues(t, 28*time.Hour, lead)
+}
+
+func TestEventRecordsForDateRecordsProjectEmailAsProducer(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusOK)
+	cfgCal.Reminder.ProjectEmail = true
+	program := loadedProgram{data: domain.CalCmsPgmData{Events: []domain.CalCmsEvent{
+		{EventID: 1, FullTitle: "Program Event", StartDate: "2024-09-24", StartTime: "12:00", EndTime: "13:00", ProjectEmail: "info@example.org"},
+	}}}
+	calCmsService.updateProgram(program, []time.Time{archiveDate})
+
+	records, err := calCmsService.EventRecordsForDateContext(t.Context(), archiveDate)
+
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.EqualValues(t, []string{"info@example.org"}, records[0].Producer)
+}
+
+func TestEventRecordsForDateQueryErrorReturnsError(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusInternalServerError)
+
+	records, err := calCmsService.EventRecordsForDateContext(t.Context(), domain.MustParseFolderDate("2024-09-20"))
+
+	assert.NotNil(t, err)
+	assert.Nil(t, records)
+}
+
+func TestIsFileExportedFindsHardTimedItems(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	exportPath, err := exportService.setExportPathForDate(dayDate, "20")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(exportPath), 0755))
+	playlist := "\t\tR\tPlaylist auto-generated by mAirList Feeder\r\n20:00:00\tH\tF\t/audio/show.mp3\r\n20:58:00\tN\tF\t/audio/filler.mp3\r\n21:00:00\tH\tI\t42\r\n"
+	require.NoError(t, os.WriteFile(exportPath, []byte(playlist), 0644))
+
+	assert.True(t, exportService.IsFileExported(dayDate, "20", domain.FileInfo{Path: "/audio/show.mp3"}))
+	assert.True(t, exportService.IsFileExported(dayDate, "20", domain.FileInfo{FileType: domain.FileTypeStream, StreamId: 42}))
+	assert.False(t, exportService.IsFileExported(dayDate, "20", domain.FileInfo{Path: "/audio/filler.mp3"}))
+	assert.False(t, exportService.IsFileExported(dayDate, "21", domain.FileInfo{Path: "/audio/show.mp3"}))
+}
+
+func TestIsFileExportedFindsItemsOfDayPlaylist(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "/audio/morning.mp3", 8, 0, time.Hour)
+	storeDayFile(t, dayDate, "/audio/evening.mp3", 20, 0, time.Hour)
+	_, err := exportService.ExportDayPlaylistForDate(dayDate)
+	require.NoError(t, err)
+
+	assert.True(t, exportService.IsExported(dayDate, "20"))
+	assert.False(t, exportService.IsExported(dayDate, "21"))
+	assert.True(t, exportService.IsFileExported(dayDate, "20", domain.FileInfo{Path: "/audio/evening.mp3"}))
+	assert.False(t, exportService.IsFileExported(dayDate, "20", domain.FileInfo{Path: "/audio/morning.mp3"}))
+}
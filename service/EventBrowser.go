@@ -0,0 +1,97 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+)
+
+// GetEventsForDatesContext returns the events of the given dates, sorted by start. Dates of the current program are
+// taken from it, other dates from the calCms cache or, if not cached, queried from the schedule provider. The crawl
+// dates are left to the regular refresh. If the query fails, the events found so far are returned with the error.
+// Files of past days no longer in the file list are looked up in the files remembered per event
+func (s DefaultCalCmsService) GetEventsForDatesContext(ctx context.Context, dates []time.Time) ([]dto.Event, error) {
+	data, err := s.loadEventsForDatesContext(ctx, dates)
+	events := s.convertEvent(data)
+	s.addRememberedFiles(events)
+	slices.SortStableFunc(events, func(a dto.Event, b dto.Event) int {
+		return strings.Compare(a.StartDate+" "+a.StartTime, b.StartDate+" "+b.StartTime)
+	})
+	return events, err
+}
+
+// loadEventsForDatesContext collects the calCms events of the given dates from the program, the cache or the schedule provider
+func (s DefaultCalCmsService) loadEventsForDatesContext(ctx context.Context, dates []time.Time) (data domain.CalCmsPgmData, e error) {
+	var missing []time.Time
+	crawlDates := helper.GetCrawlDates(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate)
+	s.calCmsPgm.RLock()
+	for _, date := range dates {
+		day := domain.FormatFolderDate(date)
+		if !slices.Contains(s.calCmsPgm.days, day) {
+			missing = append(missing, date)
+			continue
+		}
+		for _, event := range s.calCmsPgm.data.Events {
+			if event.StartDate == day {
+				data.Events = append(data.Events, event)
+			}
+		}
+	}
+	s.calCmsPgm.RUnlock()
+	var uncached []time.Time
+	for _, date := range missing {
+		if cached, _, found := s.loadCachedProgram([]time.Time{date}); found {
+			data.Events = append(data.Events, cached.Events...)
+		} else if !slices.ContainsFunc(crawlDates, date.Equal) {
+			uncached = append(uncached, date)
+		}
+	}
+	if len(uncached) > 0 && s.Cfg.CalCms.QueryCalCms {
+		queried, err := s.queryDateRange(ctx, uncached[0], uncached[len(uncached)-1])
+		for _, event := range queried {
+			if slices.ContainsFunc(uncached, func(date time.Time) bool { return domain.FormatFolderDate(date) == event.StartDate }) {
+				data.Events = append(data.Events, event)
+			}
+		}
+		e = err
+	}
+	return data, e
+}
+
+// queryDateRange queries the schedule provider for the events of the days from first to last
+func (s DefaultCalCmsService) queryDateRange(ctx context.Context, first time.Time, last time.Time) ([]domain.CalCmsEvent, error) {
+	var dates []time.Time
+	for date := domain.NormalizeDate(first); !date.After(domain.NormalizeDate(last)); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return s.getEventsForDatesContext(ctx, dates)
+}
+
+// addRememberedFiles marks the preproduced events of past days as present, if a file was remembered for them
+func (s DefaultCalCmsService) addRememberedFiles(events []dto.Event) {
+	if s.EventFiles == nil {
+		return
+	}
+	today := domain.FormatFolderDate(helper.DateForFolder(s.Cfg.Misc.TestCrawl, s.Cfg.Misc.TestDate, 0))
+	for i, event := range events {
+		if event.StartDate >= today || event.FileStatus != "Missing" {
+			continue
+		}
+		id, err := strconv.Atoi(event.EventId)
+		if err != nil {
+			continue
+		}
+		if file := s.EventFiles.Get(id); file != nil && file.Date == event.StartDate {
+			events[i].FileStatus = "Present"
+			events[i].ActualDuration = strconv.FormatFloat(file.Duration.Round(time.Minute).Minutes(), 'f', 1, 64)
+			events[i].FileSource = "History"
+		}
+	}
+}
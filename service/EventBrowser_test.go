@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupEventBrowser loads a program for the crawl dates 2024-09-24 and 2024-09-25 and points the calCms service
+// to a server answering with events of 2024-09-20 and 2024-09-24
+func setupEventBrowser(t *testing.T, status int) {
+	t.Helper()
+	respData, err := json.Marshal(domain.CalCmsPgmData{Events: []domain.CalCmsEvent{
+		{EventID: 20, FullTitle: "Past Event", StartDate: "2024-09-20", StartTime: "10:00", EndTime: "11:00"},
+		{EventID: 24, FullTitle: "Server Event", StartDate: "2024-09-24", StartTime: "10:00", EndTime: "11:00"},
+	}})
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write(respData)
+	}))
+	t.Cleanup(srv.Close)
+	cfgCal.CalCms.CmsUrl = srv.URL
+	cfgCal.CalCms.QueryCalCms = true
+	cfgCal.Misc.TestCrawl = true
+	cfgCal.Misc.TestDate = "2024/09/24"
+	program := loadedProgram{data: domain.CalCmsPgmData{Events: []domain.CalCmsEvent{
+		{EventID: 1, FullTitle: "Program Event", StartDate: "2024-09-24", StartTime: "12:00", EndTime: "13:00"},
+	}}}
+	calCmsService.updateProgram(program, []time.Time{domain.MustParseFolderDate("2024-09-24"), domain.MustParseFolderDate("2024-09-25")})
+}
+
+func TestGetEventsForDatesCombinesProgramAndQuery(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusOK)
+
+	events, err := calCmsService.GetEventsForDatesContext(t.Context(), []time.Time{domain.MustParseFolderDate("2024-09-20"), domain.MustParseFolderDate("2024-09-24")})
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.EqualValues(t, "Past Event", events[0].Title)
+	assert.EqualValues(t, "Missing", events[0].FileStatus)
+	assert.EqualValues(t, "Program Event", events[1].Title)
+}
+
+func TestGetEventsForDatesUsesRememberedFilesForPastDays(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusOK)
+	cfgCal.Misc.EventFileSaveFile = filepath.Join(t.TempDir(), "eventfiles.dta")
+	eventFileRepo := repositories.NewEventFileRepository(&cfgCal)
+	calCmsService.EventFiles = &eventFileRepo
+	require.NoError(t, eventFileRepo.Store(domain.EventFile{EventId: 20, Path: "past.mp3", Duration: 59 * time.Minute, Date: "2024-09-20"}))
+
+	events, err := calCmsService.GetEventsForDatesContext(t.Context(), []time.Time{domain.MustParseFolderDate("2024-09-20")})
+
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.EqualValues(t, "Present", events[0].FileStatus)
+	assert.EqualValues(t, "59.0", events[0].ActualDuration)
+	assert.EqualValues(t, "History", events[0].FileSource)
+}
+
+func TestGetEventsForDatesQueryErrorReturnsProgramEvents(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusBadGateway)
+
+	events, err := calCmsService.GetEventsForDatesContext(t.Context(), []time.Time{domain.MustParseFolderDate("2024-09-20"), domain.MustParseFolderDate("2024-09-24")})
+
+	require.Error(t, err)
+	require.Len(t, events, 1)
+	assert.EqualValues(t, "Program Event", events[0].Title)
+}
+
+func TestGetEventsForDatesDoesNotQueryCrawlDates(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusBadGateway)
+
+	events, err := calCmsService.GetEventsForDatesContext(t.Context(), []time.Time{domain.MustParseFolderDate("2024-09-25")})
+
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
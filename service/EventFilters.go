@@ -0,0 +1,71 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"slices"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+)
+
+// excluded returns true, if the event is excluded by its series key or matches one of the configured event filters
+func (s DefaultCalCmsService) excluded(event domain.CalCmsEvent) bool {
+	if slices.Contains(s.Cfg.CalCms.EventExclusion, event.Skey) {
+		return true
+	}
+	for _, filter := range s.filters {
+		if filter.Matches(func(field string) string { return eventFieldValue(event, field) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedId returns true, if the program contains events with the id and all of them are excluded
+func (s DefaultCalCmsService) excludedId(id int) bool {
+	s.calCmsPgm.RLock()
+	defer s.calCmsPgm.RUnlock()
+	found := false
+	for _, event := range s.calCmsPgm.data.Events {
+		if event.EventID == id {
+			if !s.excluded(event) {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// eventFieldValue returns the value of the event field a filter condition refers to, flags as "1" or "0"
+func eventFieldValue(event domain.CalCmsEvent, field string) string {
+	switch field {
+	case "project":
+		return event.Project
+	case "location":
+		return event.Location
+	case "studio":
+		return event.StudioName
+	case "series":
+		return event.SeriesName
+	case "skey":
+		return event.Skey
+	case "title":
+		return event.FullTitle
+	case "live":
+		return flagValue(event.Live)
+	case "draft":
+		return flagValue(event.Draft)
+	case "published":
+		return flagValue(event.Published)
+	case "playout":
+		return flagValue(event.Playout)
+	}
+	return ""
+}
+
+func flagValue(flag int) string {
+	if flag != 0 {
+		return "1"
+	}
+	return "0"
+}
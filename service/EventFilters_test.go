@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func filteredEvents() domain.CalCmsPgmData {
+	return domain.CalCmsPgmData{
+		Events: []domain.CalCmsEvent{
+			{EventID: 1, FullTitle: "Studio Show", StartDate: "2024-04-01", StartTime: "11:00", StartTimeName: "11:00", EndTime: "12:00", StartDatetime: startDate, EndDatetime: endDate, Location: "Studio 1"},
+			{EventID: 2, FullTitle: "Draft Show", StartDate: "2024-04-01", StartTime: "11:00", StartTimeName: "11:00", EndTime: "12:00", StartDatetime: startDate, EndDatetime: endDate, Location: "Studio 1", Draft: 1},
+			{EventID: 3, FullTitle: "Outside Show", StartDate: "2024-04-01", StartTime: "11:00", StartTimeName: "11:00", EndTime: "12:00", StartDatetime: startDate, EndDatetime: endDate, Location: "Außenstudio"},
+			{EventID: 4, FullTitle: "Excluded Show", StartDate: "2024-04-01", StartTime: "11:00", StartTimeName: "11:00", EndTime: "12:00", StartDatetime: startDate, EndDatetime: endDate, Skey: "excluded"},
+		},
+	}
+}
+
+func setupEventFilters() func() {
+	teardown := setupTestCal()
+	cfgCal.CalCms.EventFilters = []string{"draft=yes", "location=außenstudio"}
+	cfgCal.CalCms.EventExclusion = []string{"excluded"}
+	calCmsService = NewCalCmsServiceWithState(&cfgCal, stateCal, &fileRepoCal)
+	return func() {
+		cfgCal.CalCms.EventFilters = nil
+		cfgCal.CalCms.EventExclusion = nil
+		teardown()
+	}
+}
+
+func TestConvertEventLeavesOutFilteredEvents(t *testing.T) {
+	teardown := setupEventFilters()
+	defer teardown()
+
+	events := calCmsService.convertEvent(filteredEvents())
+
+	assert.EqualValues(t, 1, len(events))
+	assert.EqualValues(t, "1", events[0].EventId)
+}
+
+func TestGetCalCmsEntriesForHourLeavesOutFilteredEvents(t *testing.T) {
+	teardown := setupEventFilters()
+	defer teardown()
+	calCmsService.insertData(filteredEvents())
+
+	entries, err := calCmsService.GetCalCmsEntriesForHour("11")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, len(entries))
+	assert.EqualValues(t, 1, entries[0].EventId)
+}
+
+func TestEnrichFileInformationSkipsFilteredEvents(t *testing.T) {
+	teardown := setupEventFilters()
+	defer teardown()
+	calCmsService.insertData(filteredEvents())
+	calCmsService.Cfg.Misc.TestCrawl = true
+	calCmsService.Cfg.Misc.TestDate = "2024/04/01"
+	for id, path := range map[int]string{1: "A", 2: "B", 3: "C"} {
+		fileRepoCal.Store(domain.FileInfo{Path: path, FolderDate: domain.MustParseFolderDate("2024-04-01"), EventId: id, FileType: "Audio", StartTime: time.Date(2024, 4, 1, 11, 0, 0, 0, time.Local)})
+	}
+
+	n := calCmsService.EnrichFileInformation()
+
+	assert.EqualValues(t, 1, n.TotalCount)
+	assert.False(t, fileRepoCal.GetByPath("B").CalCmsInfoExtracted)
+	assert.False(t, fileRepoCal.GetByPath("C").CalCmsInfoExtracted)
+}
+
+func TestExcludedMatchesStudioAndLocationSeparately(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	cfgCal.CalCms.EventFilters = []string{"studio=Studio B"}
+	defer func() { cfgCal.CalCms.EventFilters = nil }()
+	calCmsService = NewCalCmsServiceWithState(&cfgCal, stateCal, &fileRepoCal)
+
+	assert.True(t, calCmsService.excluded(domain.CalCmsEvent{EventID: 1, StudioName: "Studio B", Location: "Funkhaus"}))
+	assert.False(t, calCmsService.excluded(domain.CalCmsEvent{EventID: 2, StudioName: "Studio A", Location: "Studio B"}))
+}
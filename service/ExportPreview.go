@@ -0,0 +1,105 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+)
+
+// PreviewForDateAndHours runs a dry-run export for a range of hours of a given date
+func (s DefaultExportService) PreviewForDateAndHours(folderDate time.Time, fromHour int, toHour int) ([]dto.HourPreview, error) {
+	return s.PreviewForDateAndHoursContext(context.Background(), folderDate, fromHour, toHour)
+}
+
+func (s DefaultExportService) PreviewForDateAndHoursContext(ctx context.Context, folderDate time.Time, fromHour int, toHour int) ([]dto.HourPreview, error) {
+	var previews []dto.HourPreview
+	// previews must not advance the rotation of the filler pools used by the real export
+	preview := s
+	preview.filler = s.filler.clone()
+	for hour := fromHour; hour <= toHour; hour++ {
+		hourPreview, err := preview.previewHour(ctx, folderDate, fmt.Sprintf("%02d", hour))
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, hourPreview)
+	}
+	return previews, nil
+}
+
+// PreviewForDateAndHour runs the export for a given date and hour without writing files or appending the playlist to mAirList.
+// Returns the planned and rejected files as well as the playlist that would be written
+func (s DefaultExportService) PreviewForDateAndHour(folderDate time.Time, hour string) (dto.HourPreview, error) {
+	return s.PreviewForDateAndHourContext(context.Background(), folderDate, hour)
+}
+
+func (s DefaultExportService) PreviewForDateAndHourContext(ctx context.Context, folderDate time.Time, hour string) (dto.HourPreview, error) {
+	preview := s
+	preview.filler = s.filler.clone()
+	return preview.previewHour(ctx, folderDate, hour)
+}
+
+// previewHour builds the preview of one hour. Gap fillers are picked from the service's filler, which should be a clone
+func (s DefaultExportService) previewHour(ctx context.Context, folderDate time.Time, hour string) (dto.HourPreview, error) {
+	preview := dto.HourPreview{
+		Date: domain.FormatFolderDate(folderDate),
+		Hour: hour,
+	}
+	if err := ctx.Err(); err != nil {
+		return preview, err
+	}
+	plan, rejected, found := s.planForDateAndHour(folderDate, hour)
+	for _, file := range rejected {
+		preview.Rejected = append(preview.Rejected, previewFile(file.file, file.reason))
+	}
+	sort.Slice(preview.Rejected, func(i, j int) bool {
+		return preview.Rejected[i].StartTime < preview.Rejected[j].StartTime
+	})
+	if !found || len(plan) == 0 {
+		return preview, nil
+	}
+	keys := make([]string, 0, len(plan))
+	for timeKey := range plan {
+		keys = append(keys, timeKey)
+	}
+	sort.Strings(keys)
+	for _, timeKey := range keys {
+		_, _, info := checkTime(plan[timeKey], s.Cfg.Export.ShortDeltaAllowance, s.Cfg.Export.LongDeltaAllowance)
+		preview.Planned = append(preview.Planned, previewFile(plan[timeKey], info))
+	}
+	content, err := s.renderPlaylist(func(w *bufio.Writer) error {
+		return s.writeHourBlock(ctx, w, plan)
+	})
+	if err != nil {
+		return preview, err
+	}
+	preview.Playlist = string(content)
+	return preview, nil
+}
+
+// previewFile converts a file's information to its display format in the preview
+func previewFile(file domain.FileInfo, info string) dto.PreviewFile {
+	pf := dto.PreviewFile{
+		StartTime: createIndexFromTime(file.StartTime),
+		Path:      file.Path,
+		Duration:  strconv.FormatFloat(math.Round(file.Duration.Minutes()*100)/100, 'f', 2, 64),
+		Info:      info,
+	}
+	if file.FileType == domain.FileTypeStream {
+		pf.Path = fmt.Sprintf("%v (stream %v)", file.StreamName, file.StreamId)
+	}
+	if file.SlotLength > 0 {
+		pf.SlotLength = strconv.Itoa(int(file.SlotLength.Minutes())) + "min"
+	}
+	if file.EventId != 0 {
+		pf.EventId = strconv.Itoa(file.EventId)
+	}
+	return pf
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewForDateAndHourReturnsPlanRejectedAndPlaylist(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "show.mp3", 20, 0, time.Hour)
+	storeDayFile(t, dayDate, "short.mp3", 20, 30, 5*time.Minute)
+	require.NoError(t, fileRepo.Store(domain.FileInfo{
+		Path:        "live.mp3",
+		Duration:    time.Hour,
+		StartTime:   helper.TimeFromHourAndMinute(20, 0),
+		FolderDate:  dayDate,
+		EventIsLive: true,
+	}))
+
+	preview, err := exportService.PreviewForDateAndHour(dayDate, "20")
+	entries, _ := os.ReadDir(cfg.Export.ExportFolder)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "2026-10-19", preview.Date)
+	require.Len(t, preview.Planned, 1)
+	assert.EqualValues(t, "show.mp3", preview.Planned[0].Path)
+	assert.EqualValues(t, "60min", preview.Planned[0].SlotLength)
+	require.Len(t, preview.Rejected, 2)
+	assert.EqualValues(t, "Live event, live items are not exported", preview.Rejected[0].Info)
+	assert.Contains(t, preview.Rejected[1].Info, "Length not accepted. Rounded actual duration: 5 min")
+	assert.Contains(t, preview.Playlist, "20:00:00\tH\tF\tshow.mp3\n21:00:00\tH\tD\tEnd of block\n")
+	assert.Empty(t, entries)
+}
+
+func TestPreviewForDateAndHourReportsSupersededFile(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	require.NoError(t, fileRepo.Store(domain.FileInfo{Path: "old.mp3", Duration: time.Hour, StartTime: helper.TimeFromHourAndMinute(20, 0), FolderDate: dayDate, ModTime: time.Now().Add(-time.Hour)}))
+	require.NoError(t, fileRepo.Store(domain.FileInfo{Path: "new.mp3", Duration: time.Hour, StartTime: helper.TimeFromHourAndMinute(20, 0), FolderDate: dayDate, ModTime: time.Now()}))
+
+	preview, err := exportService.PreviewForDateAndHour(dayDate, "20")
+
+	require.NoError(t, err)
+	require.Len(t, preview.Planned, 1)
+	assert.EqualValues(t, "new.mp3", preview.Planned[0].Path)
+	require.Len(t, preview.Rejected, 1)
+	assert.EqualValues(t, "old.mp3", preview.Rejected[0].Path)
+	assert.EqualValues(t, "Superseded by newer file new.mp3", preview.Rejected[0].Info)
+}
+
+func TestPreviewForDateAndHoursReturnsEachHour(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "show.mp3", 20, 0, time.Hour)
+
+	previews, err := exportService.PreviewForDateAndHours(dayDate, 19, 21)
+
+	require.NoError(t, err)
+	require.Len(t, previews, 3)
+	assert.Empty(t, previews[0].Planned)
+	assert.EqualValues(t, "", previews[0].Playlist)
+	assert.Len(t, previews[1].Planned, 1)
+}
+
+func TestPreviewDoesNotAdvanceFillerRotation(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	ids := setupFillerPool(t, "a_30.mp3", "b_30.mp3")
+	exportService.filler = newTestFiller("ids=" + ids)
+	exportService.Now = func() time.Time { return dayDate }
+	storeDayFile(t, dayDate, "show.mp3", 20, 0, 59*time.Minute)
+
+	first, err1 := exportService.PreviewForDateAndHour(dayDate, "20")
+	second, err2 := exportService.PreviewForDateAndHour(dayDate, "20")
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Contains(t, first.Playlist, filepath.Join(ids, "a_30.mp3"))
+	assert.EqualValues(t, first.Playlist, second.Playlist)
+}
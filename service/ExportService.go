@@ -3,17 +3,13 @@ package service
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
 	"math"
 	"net/http"
-	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -26,6 +22,7 @@ import (
 	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
 	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
 	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist"
 	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
 	"github.com/johannes-kuhfuss/services_utils/logger"
 )
@@ -44,9 +41,33 @@ type DefaultExportService struct {
 	Cfg        *config.AppConfig
 	State      *appstate.AppState
 	Repo       *repositories.DefaultFileRepository
+	Targets    []config.MairListTarget // parsed from MAIRLIST_TARGETS, empty to use MAIRLIST_URL only
 	httpClient *http.Client
 	Now        func() time.Time
 	mu         *sync.Mutex
+	filler     *gapFiller
+	History    repositories.ExportHistoryRepository
+	Overrides  repositories.OverrideRepository
+	AsRun      repositories.AsRunRepository
+	Outbox     repositories.OutboxRepository
+	NowPlaying nowPlayingPublisher
+	Notifier   notifier
+	Reruns     rerunScheduler
+	Uploads    uploadStatusReporter
+	reconcile  *reconcileState
+	asRun      *asRunTracker
+}
+
+type nowPlayingPublisher interface {
+	Queue(mairlist.Playlist)
+}
+
+type uploadStatusReporter interface {
+	ReportUploadStatus(int, string, string)
+}
+
+type rerunScheduler interface {
+	RerunFilesForHour(time.Time, string) domain.FileList
 }
 
 type exportPlan map[string]domain.FileInfo
@@ -78,6 +99,9 @@ func NewExportServiceWithState(cfg *config.AppConfig, state *appstate.AppState,
 		httpClient: InitHttpExClient(),
 		Now:        time.Now,
 		mu:         &sync.Mutex{},
+		filler:     newGapFiller(cfg),
+		reconcile:  newReconcileState(),
+		asRun:      newAsRunTracker(),
 	}
 }
 
@@ -155,24 +179,23 @@ func (s DefaultExportService) ExportForDateAndHourContext(ctx context.Context, f
 	defer func() {
 		s.State.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.ExportRunning = false })
 	}()
-	if files := s.Repo.GetByDateAndHour(folderDate, hour, s.Cfg.Export.ExportLiveItems); files != nil {
+	if plan, rejected, found := s.planForDateAndHour(folderDate, hour); found {
 		logger.Infof("Starting export for %v %v:00 ...", domain.FormatFolderDate(folderDate), hour)
+		s.notifyRejected(rejected)
+		s.reportRejected(rejected)
 		start := s.Now().UTC()
-		sort.Sort(files)
-		plan := s.checkTimeAndLength(files)
-		exportPath, err := s.exportToPlayoutForDate(folderDate, hour, plan)
+		exportPath, err := s.exportToPlayoutForDate(ctx, folderDate, hour, plan)
 		if s.Cfg.Export.AppendPlaylist && exportPath != "" && err == nil {
-			req := dto.MairListRequest{
-				ReqType:  dto.MairListRequestAppendPlaylist,
-				FileName: exportPath,
-			}
-			err := s.ExecuteMairListRequestContext(ctx, req)
+			delivered, err := s.appendPlaylist(ctx, exportPath, nil)
 			if err != nil {
 				logger.Error("Error appending playlist", err)
+				s.notifyExportFailed(folderDate, hour, "Could not append playlist "+exportPath, err)
+				s.enqueueAppend(folderDate, hour, exportPath, delivered, err)
 				return err
 			}
 		}
 		if err != nil {
+			s.notifyExportFailed(folderDate, hour, "Could not export playlist", err)
 			return err
 		}
 		end := s.Now().UTC()
@@ -184,29 +207,145 @@ func (s DefaultExportService) ExportForDateAndHourContext(ctx context.Context, f
 	return nil
 }
 
+// rejectedFile describes a file that was not taken into an export plan and why
+type rejectedFile struct {
+	file   domain.FileInfo
+	reason string
+	length bool // rejected by the length check
+}
+
+// notifyExportFailed emits a notification for a playlist that could not be exported or appended
+func (s DefaultExportService) notifyExportFailed(folderDate time.Time, hour string, message string, err error) {
+	if s.Notifier == nil {
+		return
+	}
+	s.Notifier.Notify(domain.NotificationExportFailed, fmt.Sprintf("%v for %v %v:00", message, domain.FormatFolderDate(folderDate), hour), map[string]string{
+		"date":  domain.FormatFolderDate(folderDate),
+		"hour":  hour,
+		"error": err.Error(),
+	})
+}
+
+// notifyRejected emits a notification for every file whose length doesn't match its slot
+func (s DefaultExportService) notifyRejected(rejected []rejectedFile) {
+	if s.Notifier == nil {
+		return
+	}
+	for _, r := range rejected {
+		if r.length {
+			s.Notifier.Notify(domain.NotificationFileRejected, fmt.Sprintf("File %v was not exported. %v", r.file.Path, r.reason), map[string]string{
+				"path":     r.file.Path,
+				"event_id": strconv.Itoa(r.file.EventId),
+				"reason":   r.reason,
+			})
+		}
+	}
+}
+
+// reportRejected writes the upload status of files whose length doesn't match their slot back into calCms
+func (s DefaultExportService) reportRejected(rejected []rejectedFile) {
+	if s.Uploads == nil {
+		return
+	}
+	for _, r := range rejected {
+		if r.length {
+			s.Uploads.ReportUploadStatus(r.file.EventId, domain.FormatFolderDate(r.file.FolderDate), uploadStatusRejected(r.file, r.reason))
+		}
+	}
+}
+
+// planForDateAndHour selects the files for a given date and hour and builds the export plan from them.
+// Manual overrides replace the files of events and add the files pinned to slots of that hour. Reruns without
+// an own file are scheduled with the file of their original broadcast.
+// Returns false if there are no files to export for that hour
+func (s DefaultExportService) planForDateAndHour(folderDate time.Time, hour string) (exportPlan, []rejectedFile, bool) {
+	var (
+		files    domain.FileList
+		rejected []rejectedFile
+	)
+	for _, file := range s.Repo.GetByDateAndHour(folderDate, hour, true) {
+		if replaced, ok := s.applyReplacement(file, folderDate); ok {
+			rejected = append(rejected, rejectedFile{file: file, reason: "Replaced by override with file " + replaced.Path})
+			files = append(files, replaced)
+			continue
+		}
+		if file.EventIsLive && !s.Cfg.Export.ExportLiveItems {
+			rejected = append(rejected, rejectedFile{file: file, reason: "Live event, live items are not exported"})
+			continue
+		}
+		files = append(files, file)
+	}
+	if s.Reruns != nil {
+		files = append(files, s.Reruns.RerunFilesForHour(folderDate, hour)...)
+	}
+	files = append(files, s.pinnedFiles(folderDate, hour)...)
+	if len(files) == 0 {
+		return nil, rejected, false
+	}
+	sort.Sort(files)
+	plan, notPlanned := s.evaluateFiles(files)
+	return plan, append(rejected, notPlanned...), true
+}
+
 // checkTimeAndLength determines suitability of files for playout, based on their length
 // Also resolves conflicts if there are multiple matching files for the same time
 func (s DefaultExportService) checkTimeAndLength(files domain.FileList) exportPlan {
+	plan, _ := s.evaluateFiles(files)
+	return plan
+}
+
+// evaluateFiles builds the export plan from the given files and returns the files that were not taken into the plan.
+// Manual overrides take precedence: excluded files are never planned, accepted and pinned files skip the length check
+// and pinned files win conflicts with other files in the same slot
+func (s DefaultExportService) evaluateFiles(files domain.FileList) (exportPlan, []rejectedFile) {
+	var rejected []rejectedFile
 	plan := make(exportPlan)
 	for _, file := range files {
+		if o := s.overrideFor(file, domain.OverrideExclude); o != nil {
+			logger.Infof("File %v excluded by override. %v", file.Path, o.Note)
+			rejected = append(rejected, rejectedFile{file: file, reason: "Excluded by override. " + o.Note})
+			continue
+		}
+		pinned := s.isPinned(file)
+		if o := s.pinnedElsewhere(file); o != nil && !pinned {
+			rejected = append(rejected, rejectedFile{file: file, reason: o.Describe()})
+			continue
+		}
 		lengthOk, slotLen, info := checkTime(file, s.Cfg.Export.ShortDeltaAllowance, s.Cfg.Export.LongDeltaAllowance)
 		logger.Infof("File: %v, ModDate: %v, IsOK: %v, Info: %v", file.Path, file.ModTime, lengthOk, info)
-		if lengthOk {
-			file.SlotLength = slotLen
-			preFile, exists := plan[createIndexFromTime(file.StartTime)]
-			if exists {
-				if preFile.ModTime.After(file.ModTime) {
-					logger.Infof("Existing file %v is newer than file %v. Not updating.", preFile.Path, file.Path)
-				} else {
-					logger.Infof("Existing file %v is older than file %v. Updating.", preFile.Path, file.Path)
-					plan[createIndexFromTime(file.StartTime)] = file
-				}
-			} else {
-				plan[createIndexFromTime(file.StartTime)] = file
+		if !lengthOk {
+			if !pinned && s.overrideFor(file, domain.OverrideAccept) == nil {
+				rejected = append(rejected, rejectedFile{file: file, reason: "Length not accepted. " + info, length: true})
+				continue
 			}
+			logger.Infof("Length of file %v accepted by override.", file.Path)
+			slotLen = overrideSlotLength(file)
+		}
+		file.SlotLength = slotLen
+		timeKey := createIndexFromTime(file.StartTime)
+		preFile, exists := plan[timeKey]
+		switch {
+		case !exists:
+			plan[timeKey] = file
+		case preFile.Path == file.Path:
+			continue
+		case s.isPinned(preFile) && !pinned:
+			logger.Infof("Existing file %v is pinned. Not updating with file %v.", preFile.Path, file.Path)
+			rejected = append(rejected, rejectedFile{file: file, reason: "Superseded by pinned file " + preFile.Path})
+		case pinned && !s.isPinned(preFile):
+			logger.Infof("File %v is pinned. Replacing existing file %v.", file.Path, preFile.Path)
+			rejected = append(rejected, rejectedFile{file: preFile, reason: "Superseded by pinned file " + file.Path})
+			plan[timeKey] = file
+		case preFile.ModTime.After(file.ModTime):
+			logger.Infof("Existing file %v is newer than file %v. Not updating.", preFile.Path, file.Path)
+			rejected = append(rejected, rejectedFile{file: file, reason: "Superseded by newer file " + preFile.Path})
+		default:
+			logger.Infof("Existing file %v is older than file %v. Updating.", preFile.Path, file.Path)
+			rejected = append(rejected, rejectedFile{file: preFile, reason: "Superseded by newer file " + file.Path})
+			plan[timeKey] = file
 		}
 	}
-	return plan
+	return plan, rejected
 }
 
 // getNextHour is a helper function that returns the next hour
@@ -301,14 +440,13 @@ func (s DefaultExportService) ExportToPlayout(hour string) (exportedFile string,
 
 // ExportToPlayoutForDate writes a ".tpi" playlist to disk for a given date and hour.
 func (s DefaultExportService) ExportToPlayoutForDate(folderDate time.Time, hour string) (exportedFile string, err error) {
-	if files := s.Repo.GetByDateAndHour(folderDate, hour, s.Cfg.Export.ExportLiveItems); files != nil {
-		sort.Sort(files)
-		return s.exportToPlayoutForDate(folderDate, hour, s.checkTimeAndLength(files))
+	if plan, _, found := s.planForDateAndHour(folderDate, hour); found {
+		return s.exportToPlayoutForDate(context.Background(), folderDate, hour, plan)
 	}
 	return "", nil
 }
 
-func (s DefaultExportService) exportToPlayoutForDate(folderDate time.Time, hour string, plan exportPlan) (exportedFile string, err error) {
+func (s DefaultExportService) exportToPlayoutForDate(ctx context.Context, folderDate time.Time, hour string, plan exportPlan) (exportedFile string, err error) {
 	// write export list ot mAirlist-compatible file
 	// Documentation: https://wiki.mairlist.com/reference:text_playlist_import_format_specification
 	// Tab separated
@@ -325,9 +463,10 @@ func (s DefaultExportService) exportToPlayoutForDate(folderDate time.Time, hour
 			logger.Error("Error when setting export path", err)
 			return "", err
 		}
-		if err := s.WritePlaylist(exportPath, plan); err != nil {
+		if err := s.WritePlaylistContext(ctx, exportPath, plan); err != nil {
 			return "", err
 		}
+		s.recordExport(exportPath)
 		s.State.Runtime.Update(func(runtime *appstate.RuntimeState) {
 			runtime.LastExportFileName = exportPath
 			runtime.LastExportedFileDate = s.Now()
@@ -339,43 +478,73 @@ func (s DefaultExportService) exportToPlayoutForDate(folderDate time.Time, hour
 }
 
 func (s DefaultExportService) WritePlaylist(exportPath string, plan exportPlan) error {
-	var (
-		totalLength time.Duration
-		startTime   time.Time
-		line        string
-	)
-	exportDir := filepath.Dir(exportPath)
-	exportFile, err := os.CreateTemp(exportDir, filepath.Base(exportPath)+".*.tmp")
+	return s.WritePlaylistContext(context.Background(), exportPath, plan)
+}
+
+func (s DefaultExportService) WritePlaylistContext(ctx context.Context, exportPath string, plan exportPlan) error {
+	return s.writePlaylistFile(exportPath, func(w *bufio.Writer) error {
+		return s.writeHourBlock(ctx, w, plan)
+	})
+}
+
+// writeHourBlock writes the lines of an hourly playlist, terminated by a stopper if enabled
+func (s DefaultExportService) writeHourBlock(ctx context.Context, w *bufio.Writer, plan exportPlan) error {
+	startTime, totalLength, err := s.writePlanBlock(ctx, w, plan)
 	if err != nil {
-		logger.Error("Error when creating playlist file for mAirlist", err)
 		return err
 	}
-	tmpPath := exportFile.Name()
-	defer os.Remove(tmpPath)
-	closeWithError := func(currentErr error) error {
-		if closeErr := exportFile.Close(); currentErr == nil {
-			return closeErr
-		}
-		return currentErr
+	if s.Cfg.Export.TerminateAfterDuration {
+		return s.WriteStopper(w, startTime, totalLength)
 	}
-	dataWriter := bufio.NewWriter(exportFile)
+	return nil
+}
+
+// renderPlaylist renders a ".tpi" playlist with start and end comments in memory.
+// The playlist's body is written by the given function
+func (s DefaultExportService) renderPlaylist(writeBody func(*bufio.Writer) error) ([]byte, error) {
+	var buf bytes.Buffer
+	dataWriter := bufio.NewWriter(&buf)
 	if err := s.writeStartComment(dataWriter); err != nil {
-		return closeWithError(err)
+		return nil, err
+	}
+	if err := writeBody(dataWriter); err != nil {
+		return nil, err
+	}
+	if err := s.writeEndComment(dataWriter); err != nil {
+		return nil, err
+	}
+	if err := dataWriter.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePlaylistFile renders a ".tpi" playlist, writes it to a temporary file and installs it at the export path
+func (s DefaultExportService) writePlaylistFile(exportPath string, writeBody func(*bufio.Writer) error) error {
+	content, err := s.renderPlaylist(writeBody)
+	if err != nil {
+		return err
 	}
+	if err := helper.WriteFileAtomic(exportPath, content, 0644); err != nil {
+		logger.Error("Error when writing playlist file for mAirlist", err)
+		return err
+	}
+	return nil
+}
+
+// writePlanBlock writes the hard-timed lines of a plan in time order, each followed by gap fillers if enabled.
+// Returns the block's earliest start time and its total planned length, e.g. to place a stopper
+func (s DefaultExportService) writePlanBlock(ctx context.Context, w *bufio.Writer, plan exportPlan) (startTime time.Time, totalLength time.Duration, err error) {
+	var line string
 	keys := make([]string, 0, len(plan))
 	for timeKey := range plan {
 		keys = append(keys, timeKey)
 	}
 	sort.Strings(keys)
-	for _, timeKey := range keys {
+	for i, timeKey := range keys {
 		file := plan[timeKey]
 		startTime = setStartTime(startTime, timeKey)
-		if file.FromCalCMS && !file.EndTime.IsZero() {
-			plannedDur := file.EndTime.Sub(file.StartTime)
-			totalLength = totalLength + plannedDur
-		} else {
-			totalLength = totalLength + file.SlotLength
-		}
+		totalLength = totalLength + plannedLength(file)
 		listTime := timeKey + ":00"
 		switch file.FileType {
 		case domain.FileTypeStream:
@@ -383,74 +552,101 @@ func (s DefaultExportService) WritePlaylist(exportPath string, plan exportPlan)
 		default:
 			line = fmt.Sprintf("%v\tH\tF\t%v\n", listTime, file.Path)
 		}
-		if err := s.writeLine(dataWriter, line); err != nil {
-			return closeWithError(err)
+		if err := s.writeLine(w, line); err != nil {
+			return startTime, totalLength, err
 		}
-	}
-	if s.Cfg.Export.TerminateAfterDuration {
-		if err := s.WriteStopper(dataWriter, startTime, totalLength); err != nil {
-			return closeWithError(err)
+		if file.FileType != domain.FileTypeStream && s.filler.enabled() {
+			var nextKey string
+			if i+1 < len(keys) {
+				nextKey = keys[i+1]
+			}
+			if err := s.writeFiller(ctx, w, timeKey, nextKey, file); err != nil {
+				return startTime, totalLength, err
+			}
 		}
 	}
-	if err := s.writeEndComment(dataWriter); err != nil {
-		return closeWithError(err)
+	return startTime, totalLength, nil
+}
+
+// recordExport stores a version of the exported playlist in the export history, if enabled.
+// Failing to record the version is logged, but doesn't fail the export
+func (s DefaultExportService) recordExport(exportPath string) {
+	if s.History == nil {
+		return
 	}
-	if err := dataWriter.Flush(); err != nil {
-		logger.Error("Error flushing playlist file for mAirlist", err)
-		return closeWithError(err)
+	content, err := os.ReadFile(exportPath)
+	if err != nil {
+		logger.Error("Error reading exported playlist for export history", err)
+		return
 	}
-	if err := exportFile.Sync(); err != nil {
-		return closeWithError(err)
+	version, created, err := s.History.Record(exportPath, content, s.Now())
+	if err != nil {
+		logger.Error("Error recording exported playlist in export history", err)
+		return
 	}
-	if err := exportFile.Chmod(0644); err != nil {
-		return closeWithError(err)
+	if created {
+		logger.Infof("Recorded version %v of playlist %v", version.Version, version.Slot)
 	}
-	if err := exportFile.Close(); err != nil {
-		return err
+}
+
+// recordAppended marks the latest version of a playlist in the export history as appended to mAirList
+func (s DefaultExportService) recordAppended(exportPath string) {
+	if s.History == nil {
+		return
 	}
-	if err := replaceFile(tmpPath, exportPath); err != nil {
-		return err
+	if err := s.History.MarkAppended(exportPath, s.Now()); err != nil {
+		logger.Error("Error marking playlist as appended in export history", err)
 	}
-	return nil
 }
 
-// replaceFile installs a fully-written temporary file. On platforms where rename
-// cannot replace an existing file, the old file is restored if installation fails.
-func replaceFile(tmpPath, destination string) error {
-	if err := os.Rename(tmpPath, destination); err == nil {
-		return nil
+// plannedLength is a helper function returning the time a file occupies in the playlist.
+// Uses the planned duration from calCMS if available, the detected slot length otherwise
+func plannedLength(file domain.FileInfo) time.Duration {
+	if file.FromCalCMS && !file.EndTime.IsZero() {
+		return file.EndTime.Sub(file.StartTime)
 	}
-	backupFile, err := os.CreateTemp(filepath.Dir(destination), filepath.Base(destination)+".*.bak")
+	return file.SlotLength
+}
+
+// writeFiller analyzes the gap between the end of a file and the end of its slot and fills it with items from the filler pools.
+// Filler items are written as normal (N) timed lines after the hard-timed show
+func (s DefaultExportService) writeFiller(ctx context.Context, w *bufio.Writer, timeKey string, nextKey string, file domain.FileInfo) error {
+	var nextStart time.Time
+	start, err := time.Parse("15:04", timeKey)
 	if err != nil {
 		return err
 	}
-	backupPath := backupFile.Name()
-	if err := backupFile.Close(); err != nil {
-		return err
-	}
-	if err := os.Remove(backupPath); err != nil {
-		return err
-	}
-	destinationMoved := false
-	if err := os.Rename(destination, backupPath); err != nil {
-		if !errors.Is(err, os.ErrNotExist) {
+	if nextKey != "" {
+		if nextStart, err = time.Parse("15:04", nextKey); err != nil {
 			return err
 		}
-	} else {
-		destinationMoved = true
 	}
-	if err := os.Rename(tmpPath, destination); err != nil {
-		if destinationMoved {
-			_ = os.Rename(backupPath, destination)
+	gap := analyzeGap(start, file.Duration, plannedLength(file), nextStart)
+	items := s.filler.fill(ctx, gap.Gap)
+	var filled time.Duration
+	itemStart := gap.ContentEnd
+	for _, item := range items {
+		line := fmt.Sprintf("%v\tN\tF\t%v\n", itemStart.Format("15:04:05"), item.Path)
+		if err := s.writeLine(w, line); err != nil {
+			return err
 		}
-		return err
-	}
-	if destinationMoved {
-		_ = os.Remove(backupPath)
+		itemStart = itemStart.Add(item.Duration)
+		filled += item.Duration
 	}
+	logger.Infof("Gap analysis for %v (%v): content ends %v, slot ends %v, gap %v, filled %v with %v item(s)",
+		timeKey, file.Path, gap.ContentEnd.Format("15:04:05"), gap.SlotEnd.Format("15:04:05"), gap.Gap, filled, len(items))
 	return nil
 }
 
+// RefreshFillerPools rescans the filler pool folders, so playlists are filled with the current pool contents
+func (s DefaultExportService) RefreshFillerPools() {
+	s.RefreshFillerPoolsContext(context.Background())
+}
+
+func (s DefaultExportService) RefreshFillerPoolsContext(ctx context.Context) {
+	s.filler.refresh(ctx)
+}
+
 // setStartTime is a helper function that determines the correct start time value for a playlist element
 func setStartTime(startTime time.Time, hour string) time.Time {
 	sh, _ := time.Parse("15:04", hour)
@@ -488,6 +684,63 @@ func (s DefaultExportService) setExportPathForDate(folderDate time.Time, hour st
 	return absExpPath, nil
 }
 
+// IsExported checks whether the playlist of a date and hour was written to the export folder, on its own or as a block of the day playlist
+func (s DefaultExportService) IsExported(folderDate time.Time, hour string) bool {
+	_, exported := s.exportedHourLines(folderDate, hour)
+	return exported
+}
+
+// IsFileExported returns whether the playlist of the given date and hour contains the file as a hard-timed item
+func (s DefaultExportService) IsFileExported(folderDate time.Time, hour string, file domain.FileInfo) bool {
+	lines, _ := s.exportedHourLines(folderDate, hour)
+	item := file.Path
+	if file.FileType == domain.FileTypeStream {
+		item = strconv.Itoa(file.StreamId)
+	}
+	for _, line := range lines {
+		fields := strings.Split(line, "\t")
+		if len(fields) == 4 && fields[1] == "H" && fields[3] == item {
+			return true
+		}
+	}
+	return false
+}
+
+// exportedHourLines returns the lines exported for a date and hour: the hour's playlist and the hour's block of the day playlist.
+// Returns false if neither has been exported
+func (s DefaultExportService) exportedHourLines(folderDate time.Time, hour string) (lines []string, exported bool) {
+	if exportPath, err := s.setExportPathForDate(folderDate, hour); err == nil {
+		if content, err := os.ReadFile(exportPath); err == nil {
+			exported = true
+			for line := range strings.Lines(string(content)) {
+				lines = append(lines, strings.TrimRight(line, "\r\n"))
+			}
+		}
+	}
+	dayPath, err := s.setDayExportPath(folderDate)
+	if err != nil {
+		return lines, exported
+	}
+	content, err := os.ReadFile(dayPath)
+	if err != nil {
+		return lines, exported
+	}
+	inBlock := false
+	for line := range strings.Lines(string(content)) {
+		line = strings.TrimRight(line, "\r\n")
+		fields := strings.Split(line, "\t")
+		if len(fields) == 4 && fields[2] == "R" && strings.HasPrefix(fields[3], dayBlockComment) {
+			inBlock = fields[3] == dayBlockComment+hour+":00"
+			exported = exported || inBlock
+			continue
+		}
+		if inBlock {
+			lines = append(lines, line)
+		}
+	}
+	return lines, exported
+}
+
 // writeStartComment is a helper function creating the ".tpi" file's start comment
 func (s DefaultExportService) writeStartComment(w *bufio.Writer) error {
 	line := fmt.Sprintf("\t\tR\tPlaylist auto-generated by mAirList Feeder at %v\n", s.Now().Format("2006-01-02 15:04:05"))
@@ -522,22 +775,37 @@ func (s DefaultExportService) ExecuteMairListRequest(req dto.MairListRequest) er
 }
 
 func (s DefaultExportService) ExecuteMairListRequestContext(ctx context.Context, req dto.MairListRequest) error {
-	// ReqType = appendpl, getpl
+	client := s.mairList()
 	switch req.ReqType {
 	case dto.MairListRequestAppendPlaylist:
-		err := s.AppendPlaylistContext(ctx, req.FileName)
-		if err != nil {
-			return err
-		}
+		return s.AppendPlaylistContext(ctx, req.FileName)
 	case dto.MairListRequestGetPlaylist:
-		err := s.GetPlaylistContext(ctx)
-		if err != nil {
-			return err
+		return s.GetPlaylistContext(ctx)
+	case dto.MairListRequestInsert:
+		return client.Insert(ctx, req.Position, req.FileName)
+	case dto.MairListRequestClearAfter:
+		return client.ClearAfter(ctx, req.Position)
+	case dto.MairListRequestPlayerStart:
+		return client.PlayerStart(ctx, req.Player)
+	case dto.MairListRequestPlayerStop:
+		return client.PlayerStop(ctx, req.Player)
+	case dto.MairListRequestAutomationOn:
+		return client.SetAutomation(ctx, true)
+	case dto.MairListRequestAutomationOff:
+		return client.SetAutomation(ctx, false)
+	case dto.MairListRequestCommand:
+		if strings.TrimSpace(req.Command) == "" {
+			return errors.New("command cannot be empty")
 		}
+		return client.Execute(ctx, req.Command)
 	default:
 		return errors.New("not implemented")
 	}
-	return nil
+}
+
+// mairList returns the client for the active mAirList instance. The outcome of each request is reported to the runtime state
+func (s DefaultExportService) mairList() mairlist.Client {
+	return s.activeTarget(s.mairListTargets()).client
 }
 
 // AppendPlaylist appends the playlist written to the ".tpi" file to the current playlist in mAirList using the API
@@ -546,32 +814,20 @@ func (s DefaultExportService) AppendPlaylist(fileName string) error {
 }
 
 func (s DefaultExportService) AppendPlaylistContext(ctx context.Context, fileName string) error {
-	// POST to http://<server>:9300/execute
-	// Basic Auth
-	// Body is Form URL encoded
-	// command = PLAYLIST 1 APPEND <filename>
-	var (
-		okReply bool
-	)
-	req, err := s.buildAppendRequest(fileName)
-	if err != nil {
-		return err
-	}
-	req = req.WithContext(ctx)
-	data, statusCode, err := s.execRequest(req)
+	_, err := s.appendPlaylist(ctx, fileName, nil)
+	return err
+}
+
+// appendPlaylist appends the playlist to the mAirList instances it hasn't been delivered to yet.
+// Returns the instances it has been delivered to, so a failed append is only retried on the others
+func (s DefaultExportService) appendPlaylist(ctx context.Context, fileName string, delivered []string) ([]string, error) {
+	delivered, err := s.appendToTargets(ctx, fileName, delivered)
 	if err != nil {
-		return err
+		return delivered, err
 	}
-	if s.Cfg.Export.MairListVersion >= 6 {
-		okReply = string(data) == "\"ok\""
-	} else {
-		okReply = string(data) == "ok"
-	}
-	if statusCode == 200 && okReply {
-		logger.Infof("Successfully appended playlist %v to mAirList", fileName)
-		return nil
-	}
-	return errors.New(string(data))
+	s.recordAppended(fileName)
+	s.removeFromOutbox(fileName)
+	return delivered, nil
 }
 
 func (s DefaultExportService) SetMairListCommState(success bool) {
@@ -589,65 +845,15 @@ func (s DefaultExportService) SetMairListCommState(success bool) {
 	}
 }
 
-// execRequest executes the request against mAirList and returns the data
-func (s DefaultExportService) execRequest(req *http.Request) (respData []byte, respStatus int, err error) {
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		s.SetMairListCommState(false)
-		return nil, 0, err
-	}
-	if resp.StatusCode == 404 {
-		s.SetMairListCommState(false)
-		err := errors.New("url not found")
-		return nil, resp.StatusCode, err
-	}
-	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		s.SetMairListCommState(false)
-		return nil, resp.StatusCode, err
-	}
-	s.SetMairListCommState(true)
-	return b, resp.StatusCode, nil
-}
-
 // buildAppendRequest is a helper function constructing the mAirList API request to append the playlist
 func (s DefaultExportService) buildAppendRequest(fileName string) (req *http.Request, e error) {
-	if s.Cfg.Export.MairListUrl == "" {
-		return nil, errors.New("url cannot be empty")
-	}
-	mairListUrl, err := url.Parse(s.Cfg.Export.MairListUrl)
-	if err != nil {
-		return nil, err
-	}
-	mairListUrl.Path = path.Join(mairListUrl.Path, "/execute")
-	cmd := url.Values{}
-	cmd.Set("command", fmt.Sprintf("PLAYLIST 1 APPEND %v", fileName))
-	req, err = http.NewRequest(http.MethodPost, mairListUrl.String(), strings.NewReader(cmd.Encode()))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.SetBasicAuth(s.Cfg.Export.MairListUser, s.Cfg.Export.MairListPassword)
-	return req, nil
+	client := s.mairList()
+	return client.BuildCommandRequest(context.Background(), client.AppendCommand(fileName))
 }
 
 // buildGetPlaylistRequest is a helper function constructing the mAirList API request the current playlist
 func (s DefaultExportService) buildGetPlaylistRequest() (req *http.Request, e error) {
-	if s.Cfg.Export.MairListUrl == "" {
-		return nil, errors.New("url cannot be empty")
-	}
-	mairListUrl, err := url.Parse(s.Cfg.Export.MairListUrl)
-	if err != nil {
-		return nil, err
-	}
-	mairListUrl.Path = path.Join(mairListUrl.Path, "/playlist/0/content")
-	req, err = http.NewRequest(http.MethodGet, mairListUrl.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.SetBasicAuth(s.Cfg.Export.MairListUser, s.Cfg.Export.MairListPassword)
-	return req, nil
+	return s.mairList().BuildGetPlaylistRequest(context.Background())
 }
 
 func (s DefaultExportService) GetPlaylist() error {
@@ -655,78 +861,46 @@ func (s DefaultExportService) GetPlaylist() error {
 }
 
 func (s DefaultExportService) GetPlaylistContext(ctx context.Context) error {
-	// GET to http://<server>:9300/playlist/0/content
-	// Basic Auth
-	// Returns the current playlist as XML
-	var (
-		playing bool
-	)
-	req, err := s.buildGetPlaylistRequest()
-	if err != nil {
-		return err
-	}
-	req = req.WithContext(ctx)
-	data, statusCode, err := s.execRequest(req)
+	playlist, err := s.playlistOfTargets(ctx)
 	if err != nil {
+		var statusErr *mairlist.StatusError
+		if errors.As(err, &statusErr) {
+			logger.Error("could not get mAirList playlist", err)
+		}
 		return err
 	}
-	if statusCode == 200 {
-		if s.Cfg.Export.MairListVersion >= 6 {
-			playing, err = parseMairListPlaylistJson(data)
-			if err != nil {
-				return err
-			}
-		} else {
-			playing, err = parseMairListPlaylistXml(data)
-			if err != nil {
-				return err
-			}
-		}
+	playing := playlist.Playing()
+	s.State.Runtime.Update(func(runtime *appstate.RuntimeState) {
+		runtime.MairListPlaying = playing
 		if playing {
-			s.State.Metrics.SetMairListPlaying(s.Cfg.Export.MairListUrl, 1)
-		} else {
-			s.State.Metrics.SetMairListPlaying(s.Cfg.Export.MairListUrl, 0)
+			runtime.LastMairListPlaying = s.Now()
 		}
-		s.State.Runtime.Update(func(runtime *appstate.RuntimeState) { runtime.MairListPlaying = playing })
-		return nil
+	})
+	if s.AsRun != nil {
+		s.recordAsRun(playlist)
 	}
-	err = fmt.Errorf("mAirList playlist request failed: HTTP %d", statusCode)
-	logger.Error("could not get mAirList playlist", err)
-	return err
+	if s.NowPlaying != nil {
+		s.NowPlaying.Queue(playlist)
+	}
+	return nil
 }
 
 func parseMairListPlaylistXml(playlistData []byte) (playing bool, e error) {
-	var (
-		playList domain.MairListPlaylistXml
-	)
-	err := xml.Unmarshal(playlistData, &playList)
+	playList, err := mairlist.ParsePlaylistXml(playlistData)
 	if err != nil {
 		logger.Error("Error converting mAirList playlist data into playlist", err)
 		return false, err
 	}
-	for _, item := range playList.PlaylistItem {
-		if item.State == "playing" && item.Class != "InfiniteSilence" {
-			return true, nil
-		}
-	}
-	return false, nil
+	return playList.Playing(), nil
 }
 
 func parseMairListPlaylistJson(playlistData []byte) (playing bool, e error) {
-	var (
-		playList domain.MairListPlaylistJson
-	)
-	err := json.Unmarshal(playlistData, &playList)
+	playList, err := mairlist.ParsePlaylistJson(playlistData)
 	if err != nil {
 		logger.Error("Error converting mAirList playlist data into playlist", err)
 		return false, err
 	}
-	for _, item := range playList.Items {
-		if item.State == "playing" && item.Class != "InfiniteSilence" {
-			return true, nil
-		}
-	}
-	return false, nil
+	return playList.Playing(), nil
 }
 
 func (s DefaultExportService) QueryStatus(ctx context.Context) {
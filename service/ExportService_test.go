@@ -15,7 +15,9 @@ import (
 	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
 	"github.com/johannes-kuhfuss/mairlist-feeder/config"
 	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
 	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
 	metrics "github.com/johannes-kuhfuss/mairlist-feeder/metrics"
 	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
 	"github.com/prometheus/client_golang/prometheus"
@@ -381,7 +383,7 @@ func TestExportToPlayoutOneFilesExport(t *testing.T) {
 		SlotLength: time.Hour,
 	}
 	plan := exportPlan{"13:00": fi}
-	file, err := exportService.exportToPlayoutForDate(helper.DateForFolder(exportService.Cfg.Misc.TestCrawl, exportService.Cfg.Misc.TestDate, 0), "13", plan)
+	file, err := exportService.exportToPlayoutForDate(t.Context(), helper.DateForFolder(exportService.Cfg.Misc.TestCrawl, exportService.Cfg.Misc.TestDate, 0), "13", plan)
 	assert.Nil(t, err)
 	readFile, _ := os.Open(file)
 	fileScanner := bufio.NewScanner(readFile)
@@ -432,6 +434,7 @@ func TestExportToPlayoutReturnsPlaylistWriteFailure(t *testing.T) {
 	}}
 
 	file, err := exportService.exportToPlayoutForDate(
+		t.Context(),
 		helper.DateForFolder(exportService.Cfg.Misc.TestCrawl, exportService.Cfg.Misc.TestDate, 0),
 		"13",
 		plan,
@@ -463,22 +466,6 @@ func TestWritePlaylistTruncatesExistingFile(t *testing.T) {
 	assert.NotContains(t, string(data), "stale data")
 }
 
-func TestReplaceFileRestoresDestinationWhenInstallFails(t *testing.T) {
-	dir := t.TempDir()
-	destination := filepath.Join(dir, "playlist.tpi")
-	require.NoError(t, os.WriteFile(destination, []byte("existing playlist"), 0644))
-
-	err := replaceFile(filepath.Join(dir, "missing.tmp"), destination)
-
-	require.Error(t, err)
-	data, readErr := os.ReadFile(destination)
-	require.NoError(t, readErr)
-	assert.Equal(t, "existing playlist", string(data))
-	backups, globErr := filepath.Glob(filepath.Join(dir, "*.bak"))
-	require.NoError(t, globErr)
-	assert.Empty(t, backups)
-}
-
 func TestWritePlaylistWritesEntriesInTimeOrder(t *testing.T) {
 	var fileLines []string
 	tearDown := setupTestEx()
@@ -637,3 +624,85 @@ func TestParseMairListPlaylistJsonPlayingReturnsTrue(t *testing.T) {
 	assert.True(t, playing)
 	assert.Nil(t, err)
 }
+
+func TestExportToPlayoutRecordsVersionInHistory(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	historyRepo := repositories.NewExportHistoryRepository(&cfg)
+	exportService.History = &historyRepo
+	plan := exportPlan{"13:00": domain.FileInfo{
+		Path:       "A",
+		Duration:   time.Hour,
+		StartTime:  helper.TimeFromHourAndMinute(13, 0),
+		SlotLength: time.Hour,
+	}}
+	folderDate := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	exportPath, err := exportService.exportToPlayoutForDate(t.Context(), folderDate, "13", plan)
+	_, reExportErr := exportService.exportToPlayoutForDate(t.Context(), folderDate, "13", plan)
+
+	require.NoError(t, err)
+	require.NoError(t, reExportErr)
+	versions := historyRepo.GetVersions("2026-10-19-13")
+	require.Len(t, versions, 1)
+	assert.EqualValues(t, exportPath, versions[0].ExportPath)
+}
+
+func TestAppendPlaylistMarksVersionAsAppended(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("\"ok\""))
+	}))
+	defer srv.Close()
+	exportService.Cfg.Export.MairListUrl = srv.URL
+	historyRepo := repositories.NewExportHistoryRepository(&cfg)
+	exportService.History = &historyRepo
+	exportPath := filepath.Join(cfg.Export.ExportFolder, "2026-10-19-13.tpi")
+	historyRepo.Record(exportPath, []byte("13:00:00\tH\tF\tA\n"), time.Now())
+
+	err := exportService.AppendPlaylist(exportPath)
+
+	assert.Nil(t, err)
+	assert.True(t, historyRepo.GetVersion("2026-10-19-13", 1).Appended)
+}
+
+func TestExecuteMairListRequestSendsRemoteControlCommands(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv := mairlisttest.NewServer(6)
+	defer srv.Close()
+	exportService.Cfg.Export.MairListUrl = srv.URL
+	exportService.Cfg.Export.MairListVersion = 6
+	exportService.Cfg.Export.MairListPlaylist = 1
+
+	errs := []error{
+		exportService.ExecuteMairListRequest(dto.MairListRequest{ReqType: dto.MairListRequestInsert, Position: 0, FileName: "a.tpi"}),
+		exportService.ExecuteMairListRequest(dto.MairListRequest{ReqType: dto.MairListRequestClearAfter, Position: 0}),
+		exportService.ExecuteMairListRequest(dto.MairListRequest{ReqType: dto.MairListRequestPlayerStart, Player: "A"}),
+		exportService.ExecuteMairListRequest(dto.MairListRequest{ReqType: dto.MairListRequestAutomationOff}),
+		exportService.ExecuteMairListRequest(dto.MairListRequest{ReqType: dto.MairListRequestCommand, Command: "AUTOMATION 1 ON"}),
+	}
+
+	for _, err := range errs {
+		assert.Nil(t, err)
+	}
+	assert.EqualValues(t, []string{
+		"PLAYLIST 1 INSERT 0 a.tpi",
+		"PLAYLIST 1 CLEAR AFTER 0",
+		"PLAYER 1-A START",
+		"AUTOMATION 1 OFF",
+		"AUTOMATION 1 ON",
+	}, srv.Commands())
+	assert.Contains(t, stateEx.Runtime.Snapshot().LastMairListCommState, "Succeeded")
+}
+
+func TestExecuteMairListRequestEmptyCommandReturnsError(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+
+	err := exportService.ExecuteMairListRequest(dto.MairListRequest{ReqType: dto.MairListRequestCommand})
+
+	assert.EqualValues(t, "command cannot be empty", err.Error())
+}
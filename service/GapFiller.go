@@ -0,0 +1,296 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// gapFiller picks jingles, station IDs and music beds from the configured filler pools
+// to fill the time between the end of a show file and the end of its slot.
+// The pool folders are scanned on the crawl cycle, playlists are filled from the cached pool contents
+type gapFiller struct {
+	cfg       *config.AppConfig
+	runCmd    func(context.Context, string, ...string) ([]byte, error)
+	mu        sync.Mutex
+	loaded    bool
+	pools     map[string]*fillerPool
+	durations map[string]fillerDuration
+}
+
+// fillerPool keeps the rotation state of one filler pool
+type fillerPool struct {
+	name   string
+	folder string
+	items  []fillerItem
+	next   int
+	recent []string
+}
+
+type fillerItem struct {
+	Path     string
+	Duration time.Duration
+	Pool     string
+}
+
+type fillerDuration struct {
+	modTime  time.Time
+	duration time.Duration
+}
+
+// slotGap describes the result of the gap analysis of one exported slot
+type slotGap struct {
+	ContentEnd time.Time
+	SlotEnd    time.Time
+	Gap        time.Duration
+}
+
+func newGapFiller(cfg *config.AppConfig) *gapFiller {
+	return &gapFiller{
+		cfg:       cfg,
+		runCmd:    runCommand,
+		pools:     make(map[string]*fillerPool),
+		durations: make(map[string]fillerDuration),
+	}
+}
+
+// clone returns a copy of the filler with its own rotation state, e.g. for previews that must not advance the rotation
+func (g *gapFiller) clone() *gapFiller {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := newGapFiller(g.cfg)
+	c.runCmd = g.runCmd
+	c.loaded = g.loaded
+	maps.Copy(c.durations, g.durations)
+	for name, pool := range g.pools {
+		c.pools[name] = &fillerPool{
+			name:   pool.name,
+			folder: pool.folder,
+			items:  pool.items,
+			next:   pool.next,
+			recent: slices.Clone(pool.recent),
+		}
+	}
+	return c
+}
+
+// enabled returns true, if gap filling is switched on and at least one pool is configured
+func (g *gapFiller) enabled() bool {
+	return g != nil && g.cfg.Filler.FillGaps && len(g.cfg.Filler.Pools) > 0
+}
+
+// minGap returns the smallest gap worth filling
+func (g *gapFiller) minGap() time.Duration {
+	return time.Duration(g.cfg.Filler.MinGapSec) * time.Second
+}
+
+// analyzeGap calculates the unused time between the end of a file's content and the end of its slot.
+// The slot ends at the planned end time (or after the slot length) or when the next item starts, whichever is earlier
+func analyzeGap(start time.Time, contentLength time.Duration, slotLength time.Duration, nextStart time.Time) slotGap {
+	contentEnd := start.Add(contentLength)
+	slotEnd := start.Add(slotLength)
+	if !nextStart.IsZero() && nextStart.After(start) && nextStart.Before(slotEnd) {
+		slotEnd = nextStart
+	}
+	gap := slotEnd.Sub(contentEnd)
+	if gap < 0 {
+		gap = 0
+	}
+	return slotGap{
+		ContentEnd: contentEnd,
+		SlotEnd:    slotEnd,
+		Gap:        gap,
+	}
+}
+
+// fill selects filler items whose total length fits into the given gap.
+// Pools are visited in their configured order, so a gap is filled e.g. with a station ID followed by a music bed
+func (g *gapFiller) fill(ctx context.Context, gap time.Duration) []fillerItem {
+	var items []fillerItem
+	if !g.enabled() || gap < g.minGap() || gap <= 0 {
+		return nil
+	}
+	g.mu.Lock()
+	loaded := g.loaded
+	g.mu.Unlock()
+	if !loaded {
+		g.refresh(ctx)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	pools := g.configuredPools()
+	used := make(map[string]bool)
+	remaining := gap
+	for {
+		progress := false
+		for _, pool := range pools {
+			if remaining <= 0 || remaining < g.minGap() {
+				return items
+			}
+			item, ok := pool.pick(remaining, g.cfg.Filler.RepeatBlock, used)
+			if ok {
+				used[item.Path] = true
+				items = append(items, item)
+				remaining -= item.Duration
+				progress = true
+			}
+		}
+		if !progress {
+			return items
+		}
+	}
+}
+
+// refresh rescans the folders of all configured pools. Scanning runs ffprobe for new and changed files,
+// so it is done on the crawl cycle and not while a playlist is written
+func (g *gapFiller) refresh(ctx context.Context) {
+	if !g.enabled() {
+		return
+	}
+	scanned := make(map[string][]fillerItem)
+	for _, poolCfg := range g.cfg.Filler.Pools {
+		name, folder, found := poolConfig(poolCfg)
+		if !found {
+			logger.Warnf("Ignoring filler pool %q. Expected format name=folder", poolCfg)
+			continue
+		}
+		scanned[name] = g.scanPool(ctx, name, folder)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, poolCfg := range g.cfg.Filler.Pools {
+		name, folder, found := poolConfig(poolCfg)
+		if !found {
+			continue
+		}
+		pool, exists := g.pools[name]
+		if !exists || pool.folder != folder {
+			pool = &fillerPool{name: name, folder: folder}
+			g.pools[name] = pool
+		}
+		pool.items = scanned[name]
+	}
+	g.loaded = true
+}
+
+// configuredPools returns the scanned pools in their configured order. The caller holds the lock
+func (g *gapFiller) configuredPools() []*fillerPool {
+	var pools []*fillerPool
+	for _, poolCfg := range g.cfg.Filler.Pools {
+		name, folder, found := poolConfig(poolCfg)
+		if pool, exists := g.pools[name]; found && exists && pool.folder == folder {
+			pools = append(pools, pool)
+		}
+	}
+	return pools
+}
+
+// poolConfig is a helper function splitting a pool configuration of the form name=folder
+func poolConfig(poolCfg string) (name string, folder string, found bool) {
+	name, folder, found = strings.Cut(poolCfg, "=")
+	return strings.TrimSpace(name), strings.TrimSpace(folder), found
+}
+
+// scanPool lists all audio files in a pool's folder together with their durations
+func (g *gapFiller) scanPool(ctx context.Context, name string, folder string) []fillerItem {
+	var items []fillerItem
+	err := filepath.WalkDir(folder, func(srcPath string, entry fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !helper.IsAudioFile(g.cfg, srcPath) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		dur, err := g.duration(ctx, srcPath, info.ModTime())
+		if err != nil {
+			logger.Errorf("Could not determine duration of filler %v: %v", srcPath, err)
+			return nil
+		}
+		items = append(items, fillerItem{Path: srcPath, Duration: dur, Pool: name})
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("Error reading filler pool %v (%v): %v", name, folder, err)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
+
+// duration returns the cached duration of a filler file or determines it using ffprobe
+func (g *gapFiller) duration(ctx context.Context, path string, modTime time.Time) (time.Duration, error) {
+	g.mu.Lock()
+	cached, ok := g.durations[path]
+	g.mu.Unlock()
+	if ok && cached.modTime.Equal(modTime) {
+		return cached.duration, nil
+	}
+	techMd, err := analyzeTechMdWithRunnerContext(ctx, path, g.cfg.Crawl.FFprobeTimeout, g.cfg.Crawl.FFprobePath, g.runCmd)
+	if err != nil {
+		return 0, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.durations[path] = fillerDuration{modTime: modTime, duration: techMd.Duration}
+	return techMd.Duration, nil
+}
+
+// pick returns the next item in rotation that fits into the remaining time and was not played recently.
+// Items already used for the current gap are never picked twice
+func (p *fillerPool) pick(remaining time.Duration, repeatBlock int, used map[string]bool) (fillerItem, bool) {
+	size := len(p.items)
+	if size == 0 {
+		return fillerItem{}, false
+	}
+	block := min(repeatBlock, size-1)
+	for i := range size {
+		idx := (p.next + i) % size
+		item := p.items[idx]
+		if item.Duration <= 0 || item.Duration > remaining || used[item.Path] {
+			continue
+		}
+		if slices.Contains(p.recentItems(block), item.Path) {
+			continue
+		}
+		p.next = (idx + 1) % size
+		p.recent = append(p.recent, item.Path)
+		if len(p.recent) > repeatBlock {
+			p.recent = p.recent[len(p.recent)-repeatBlock:]
+		}
+		return item, true
+	}
+	return fillerItem{}, false
+}
+
+// recentItems returns the last n items picked from the pool
+func (p *fillerPool) recentItems(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	if len(p.recent) <= n {
+		return p.recent
+	}
+	return p.recent[len(p.recent)-n:]
+}
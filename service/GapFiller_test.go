@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFfprobe returns ffprobe output with a duration derived from the file name, e.g. "id_30.mp3" lasts 30 seconds
+func fakeFfprobe(_ context.Context, _ string, args ...string) ([]byte, error) {
+	name := strings.TrimSuffix(filepath.Base(args[len(args)-1]), filepath.Ext(args[len(args)-1]))
+	_, secs, _ := strings.Cut(name, "_")
+	return []byte(fmt.Sprintf(`{"format": {"duration": "%v.000000", "bit_rate": "128000", "format_long_name": "MP2/3 (MPEG audio layer 2/3)"}}`, secs)), nil
+}
+
+func setupFillerPool(t *testing.T, files ...string) string {
+	t.Helper()
+	folder := t.TempDir()
+	for _, file := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(folder, file), []byte("audio"), 0644))
+	}
+	return folder
+}
+
+func newTestFiller(pools ...string) *gapFiller {
+	var fillerCfg config.AppConfig
+	config.InitConfig(config.EnvFile, &fillerCfg)
+	fillerCfg.Filler.FillGaps = true
+	fillerCfg.Filler.Pools = pools
+	fillerCfg.Filler.MinGapSec = 10
+	fillerCfg.Filler.RepeatBlock = 2
+	filler := newGapFiller(&fillerCfg)
+	filler.runCmd = fakeFfprobe
+	return filler
+}
+
+func TestAnalyzeGapShortFileReturnsGap(t *testing.T) {
+	start := helper.TimeFromHourAndMinute(20, 0)
+
+	gap := analyzeGap(start, 52*time.Minute, time.Hour, time.Time{})
+
+	assert.EqualValues(t, 8*time.Minute, gap.Gap)
+	assert.EqualValues(t, helper.TimeFromHourAndMinute(20, 52), gap.ContentEnd)
+	assert.EqualValues(t, helper.TimeFromHourAndMinute(21, 0), gap.SlotEnd)
+}
+
+func TestAnalyzeGapNextItemLimitsGap(t *testing.T) {
+	start := helper.TimeFromHourAndMinute(20, 0)
+
+	gap := analyzeGap(start, 25*time.Minute, time.Hour, helper.TimeFromHourAndMinute(20, 30))
+
+	assert.EqualValues(t, 5*time.Minute, gap.Gap)
+}
+
+func TestAnalyzeGapLongFileReturnsNoGap(t *testing.T) {
+	start := helper.TimeFromHourAndMinute(20, 0)
+
+	gap := analyzeGap(start, 62*time.Minute, time.Hour, time.Time{})
+
+	assert.EqualValues(t, 0, gap.Gap)
+}
+
+func TestFillDisabledReturnsNothing(t *testing.T) {
+	filler := newTestFiller("ids=" + setupFillerPool(t, "id_30.mp3"))
+	filler.cfg.Filler.FillGaps = false
+
+	items := filler.fill(context.Background(), 5*time.Minute)
+
+	assert.Empty(t, items)
+}
+
+func TestFillGapBelowMinimumReturnsNothing(t *testing.T) {
+	filler := newTestFiller("ids=" + setupFillerPool(t, "id_5.mp3"))
+
+	items := filler.fill(context.Background(), 9*time.Second)
+
+	assert.Empty(t, items)
+}
+
+func TestFillUsesPoolsInOrderAndFitsGap(t *testing.T) {
+	ids := setupFillerPool(t, "id_30.mp3")
+	beds := setupFillerPool(t, "bed_240.mp3", "bed_600.mp3")
+	filler := newTestFiller("ids="+ids, "beds="+beds)
+
+	items := filler.fill(context.Background(), 5*time.Minute)
+
+	require.Len(t, items, 2)
+	assert.EqualValues(t, "ids", items[0].Pool)
+	assert.EqualValues(t, 30*time.Second, items[0].Duration)
+	assert.EqualValues(t, filepath.Join(beds, "bed_240.mp3"), items[1].Path)
+}
+
+func TestFillAvoidsRecentRepeats(t *testing.T) {
+	ids := setupFillerPool(t, "a_30.mp3", "b_30.mp3", "c_60.mp3")
+	filler := newTestFiller("ids=" + ids)
+	var picked []string
+	for range 2 {
+		items := filler.fill(context.Background(), 40*time.Second)
+		require.Len(t, items, 1)
+		picked = append(picked, filepath.Base(items[0].Path))
+	}
+
+	items := filler.fill(context.Background(), 40*time.Second)
+
+	assert.EqualValues(t, []string{"a_30.mp3", "b_30.mp3"}, picked)
+	assert.Empty(t, items, "rotation continues with a_30.mp3, which was played within the repeat block")
+}
+
+func TestFillWithoutRepeatBlockRepeatsRecentItems(t *testing.T) {
+	ids := setupFillerPool(t, "a_30.mp3", "b_30.mp3", "c_60.mp3")
+	filler := newTestFiller("ids=" + ids)
+	filler.cfg.Filler.RepeatBlock = 0
+	filler.fill(context.Background(), 40*time.Second)
+	filler.fill(context.Background(), 40*time.Second)
+
+	items := filler.fill(context.Background(), 40*time.Second)
+
+	require.Len(t, items, 1)
+	assert.EqualValues(t, "a_30.mp3", filepath.Base(items[0].Path))
+}
+
+func TestFillUsesCachedPoolsUntilRefresh(t *testing.T) {
+	ids := setupFillerPool(t, "a_30.mp3")
+	filler := newTestFiller("ids=" + ids)
+	probes := 0
+	filler.runCmd = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		probes++
+		return fakeFfprobe(ctx, name, args...)
+	}
+	filler.fill(context.Background(), 40*time.Second)
+	require.NoError(t, os.WriteFile(filepath.Join(ids, "b_30.mp3"), []byte("audio"), 0644))
+
+	cached := filler.fill(context.Background(), 40*time.Second)
+	filler.refresh(context.Background())
+	refreshed := filler.fill(context.Background(), 40*time.Second)
+
+	require.Len(t, cached, 1)
+	assert.EqualValues(t, "a_30.mp3", filepath.Base(cached[0].Path))
+	require.Len(t, refreshed, 1)
+	assert.EqualValues(t, "b_30.mp3", filepath.Base(refreshed[0].Path))
+	assert.EqualValues(t, 2, probes)
+}
+
+func TestFillDoesNotRepeatItemWithinGap(t *testing.T) {
+	ids := setupFillerPool(t, "id_30.mp3")
+	filler := newTestFiller("ids=" + ids)
+
+	items := filler.fill(context.Background(), 10*time.Minute)
+
+	assert.Len(t, items, 1)
+}
+
+func TestWritePlaylistFillsGapWithNormalTimedLines(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	ids := setupFillerPool(t, "id_30.mp3")
+	beds := setupFillerPool(t, "bed_420.mp3")
+	exportService.filler = newTestFiller("ids="+ids, "beds="+beds)
+	plan := exportPlan{"20:00": domain.FileInfo{
+		Path:       "show.mp3",
+		Duration:   52 * time.Minute,
+		StartTime:  helper.TimeFromHourAndMinute(20, 0),
+		SlotLength: time.Hour,
+		FileType:   domain.FileTypeAudio,
+	}}
+	file := filepath.Join(t.TempDir(), "playlist.tpi")
+
+	err := exportService.WritePlaylist(file, plan)
+	data, readErr := os.ReadFile(file)
+	lines := strings.Split(string(data), "\n")
+
+	require.NoError(t, err)
+	require.NoError(t, readErr)
+	assert.EqualValues(t, "20:00:00\tH\tF\tshow.mp3", lines[1])
+	assert.EqualValues(t, "20:52:00\tN\tF\t"+filepath.Join(ids, "id_30.mp3"), lines[2])
+	assert.EqualValues(t, "20:52:30\tN\tF\t"+filepath.Join(beds, "bed_420.mp3"), lines[3])
+	assert.EqualValues(t, "21:00:00\tH\tD\tEnd of block", lines[4])
+}
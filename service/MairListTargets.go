@@ -0,0 +1,186 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// mairListTarget is a configured mAirList instance together with its client
+type mairListTarget struct {
+	config.MairListTarget
+	client      mairlist.Client
+	metricLabel string
+}
+
+// mairListTargets returns the clients for the mAirList instances parsed from MAIRLIST_TARGETS, the instance given by
+// MAIRLIST_URL if there are none. Each client reports the outcome of its requests to the runtime state.
+// The play status metric is labelled with the instance's name, with the url for the instance given by MAIRLIST_URL as before
+func (s DefaultExportService) mairListTargets() []mairListTarget {
+	configured := s.Targets
+	implicit := len(configured) == 0
+	if implicit {
+		configured = []config.MairListTarget{config.DefaultMairListTarget(s.Cfg)}
+	}
+	targets := make([]mairListTarget, 0, len(configured))
+	for _, target := range configured {
+		client := mairlist.NewTargetClient(s.Cfg, target, s.httpClient)
+		client.OnResult = func(success bool) {
+			s.setTargetCommState(target.Name, success)
+		}
+		label := target.Name
+		if implicit {
+			label = target.Url
+		}
+		targets = append(targets, mairListTarget{MairListTarget: target, client: client, metricLabel: label})
+	}
+	return targets
+}
+
+// activeTarget returns the instance playlists, commands and status queries go to: the primary or, after a failover, the backup that took over
+func (s DefaultExportService) activeTarget(targets []mairListTarget) mairListTarget {
+	active := s.State.Runtime.Snapshot().MairListActiveTarget
+	primary := targets[0]
+	for _, target := range targets {
+		if target.Name == active {
+			return target
+		}
+		if target.Role == config.MairListRolePrimary {
+			primary = target
+		}
+	}
+	return primary
+}
+
+// setActiveTarget records the instance playlists, commands and status queries go to
+func (s DefaultExportService) setActiveTarget(target mairListTarget) {
+	var previous string
+	s.State.Runtime.Update(func(runtime *appstate.RuntimeState) {
+		previous = runtime.MairListActiveTarget
+		runtime.MairListActiveTarget = target.Name
+	})
+	if previous != "" && previous != target.Name {
+		logger.Warnf("mAirList instance %v (%v) is now active instead of %v", target.Name, target.Role, previous)
+	}
+}
+
+// failOver tries the backups in order if the primary is unreachable and failover is enabled.
+// The first backup the action succeeds on becomes the active instance. Returns false if no backup took over
+func (s DefaultExportService) failOver(targets []mairListTarget, primaryErr error, action func(mairListTarget) error) bool {
+	if !s.Cfg.Export.MairListFailover || !mairlist.IsUnreachable(primaryErr) {
+		return false
+	}
+	for _, target := range targets {
+		if target.Role != config.MairListRoleBackup {
+			continue
+		}
+		if err := action(target); err != nil {
+			logger.Errorf("Failover to mAirList instance %v failed: %v", target.Name, err)
+			continue
+		}
+		s.setActiveTarget(target)
+		return true
+	}
+	return false
+}
+
+// setTargetCommState records the outcome of the last request to a mAirList instance
+func (s DefaultExportService) setTargetCommState(name string, success bool) {
+	state := fmt.Sprintf("Failed (%v)", s.Now().Format(dateFormat))
+	if success {
+		state = fmt.Sprintf("Succeeded (%v)", s.Now().Format(dateFormat))
+	}
+	s.State.Runtime.Update(func(runtime *appstate.RuntimeState) {
+		if runtime.MairListTargetStates == nil {
+			runtime.MairListTargetStates = make(map[string]string)
+		}
+		runtime.MairListTargetStates[name] = state
+	})
+	s.SetMairListCommState(success)
+}
+
+// appendToTargets appends a playlist to the primary and all mirrors, skipping the instances it has been delivered to already.
+// Mirrors failing don't fail the append. If the primary is unreachable and failover is enabled, the playlist goes to the
+// first backup accepting it. Returns the instances the playlist has been delivered to, including the ones passed in
+func (s DefaultExportService) appendToTargets(ctx context.Context, fileName string, delivered []string) ([]string, error) {
+	targets := s.mairListTargets()
+	delivered = slices.Clone(delivered)
+	var primaryErr error
+	for _, target := range targets {
+		if target.Role == config.MairListRoleBackup || slices.Contains(delivered, target.Name) {
+			continue
+		}
+		err := target.client.Append(ctx, fileName)
+		switch {
+		case err == nil:
+			logger.Infof("Successfully appended playlist %v to mAirList %v", fileName, target.Name)
+			delivered = append(delivered, target.Name)
+			if target.Role == config.MairListRolePrimary {
+				s.setActiveTarget(target)
+			}
+		case target.Role == config.MairListRolePrimary:
+			primaryErr = err
+		default:
+			logger.Errorf("Error appending playlist %v to mAirList mirror %v: %v", fileName, target.Name, err)
+		}
+	}
+	if primaryErr == nil {
+		return delivered, nil
+	}
+	tookOver := s.failOver(targets, primaryErr, func(target mairListTarget) error {
+		return target.client.Append(ctx, fileName)
+	})
+	if !tookOver {
+		return delivered, primaryErr
+	}
+	backup := s.activeTarget(targets).Name
+	logger.Warnf("Appended playlist %v to backup mAirList %v, primary is unreachable: %v", fileName, backup, primaryErr)
+	return append(delivered, backup), nil
+}
+
+// playlistOfTargets queries the playlists of all instances, sets their play status metrics and returns the playlist of the active instance.
+// A reachable primary becomes active again after a failover
+func (s DefaultExportService) playlistOfTargets(ctx context.Context) (mairlist.Playlist, error) {
+	targets := s.mairListTargets()
+	playlists := make(map[string]mairlist.Playlist)
+	errs := make(map[string]error)
+	var primary mairListTarget
+	for _, target := range targets {
+		if target.Role == config.MairListRolePrimary {
+			primary = target
+		}
+		playlist, err := target.client.GetPlaylist(ctx)
+		if err != nil {
+			errs[target.Name] = err
+			s.State.Metrics.SetMairListPlaying(target.metricLabel, 0)
+			continue
+		}
+		playlists[target.Name] = playlist
+		if playlist.Playing() {
+			s.State.Metrics.SetMairListPlaying(target.metricLabel, 1)
+		} else {
+			s.State.Metrics.SetMairListPlaying(target.metricLabel, 0)
+		}
+	}
+	if _, ok := playlists[primary.Name]; ok {
+		s.setActiveTarget(primary)
+		return playlists[primary.Name], nil
+	}
+	active := s.activeTarget(targets)
+	if _, ok := playlists[active.Name]; !ok {
+		s.failOver(targets, errs[primary.Name], func(target mairListTarget) error {
+			return errs[target.Name]
+		})
+		active = s.activeTarget(targets)
+	}
+	if playlist, ok := playlists[active.Name]; ok {
+		return playlist, nil
+	}
+	return mairlist.Playlist{}, errs[primary.Name]
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTargets starts fake instances for a primary, a mirror and a backup. A primary that is down is closed right away
+func setupTargets(t *testing.T, primaryDown bool) (primary, mirror, backup *mairlisttest.Server) {
+	t.Helper()
+	primary = mairlisttest.NewServer(6)
+	mirror = mairlisttest.NewServer(6)
+	backup = mairlisttest.NewServer(5)
+	t.Cleanup(primary.Close)
+	t.Cleanup(mirror.Close)
+	t.Cleanup(backup.Close)
+	if primaryDown {
+		primary.Close()
+	}
+	cfg.Export.MairListUser = "user"
+	cfg.Export.MairListPassword = "pass"
+	cfg.Export.MairListTargets = []string{
+		"main|primary|" + primary.URL + "|||6",
+		"mirror|mirror|" + mirror.URL + "|||6",
+		"standby|backup|" + backup.URL + "|||5",
+	}
+	targets, err := config.MairListTargets(&cfg)
+	require.NoError(t, err)
+	exportService.Targets = targets
+	return primary, mirror, backup
+}
+
+func TestAppendPlaylistGoesToPrimaryAndMirrors(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	primary, mirror, backup := setupTargets(t, false)
+
+	err := exportService.AppendPlaylist("a.tpi")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"PLAYLIST 1 APPEND a.tpi"}, primary.Commands())
+	assert.EqualValues(t, []string{"PLAYLIST 1 APPEND a.tpi"}, mirror.Commands())
+	assert.Empty(t, backup.Commands())
+	assert.EqualValues(t, "main", stateEx.Runtime.Snapshot().MairListActiveTarget)
+}
+
+func TestAppendPlaylistMirrorFailureDoesNotFail(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	_, mirror, _ := setupTargets(t, false)
+	mirror.FailWith("error")
+
+	err := exportService.AppendPlaylist("a.tpi")
+
+	assert.NoError(t, err)
+	assert.Contains(t, stateEx.Runtime.Snapshot().MairListTargetStates["mirror"], "Succeeded")
+}
+
+func TestAppendPlaylistPrimaryDownWithoutFailoverReturnsError(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	_, _, backup := setupTargets(t, true)
+
+	err := exportService.AppendPlaylist("a.tpi")
+
+	assert.Error(t, err)
+	assert.Empty(t, backup.Commands())
+	assert.Contains(t, stateEx.Runtime.Snapshot().MairListTargetStates["main"], "Failed")
+}
+
+func TestAppendPlaylistPrimaryDownFailsOverToBackup(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	_, mirror, backup := setupTargets(t, true)
+	cfg.Export.MairListFailover = true
+
+	err := exportService.AppendPlaylist("a.tpi")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"PLAYLIST 1 APPEND a.tpi"}, backup.Commands())
+	assert.EqualValues(t, []string{"PLAYLIST 1 APPEND a.tpi"}, mirror.Commands())
+	assert.EqualValues(t, "standby", stateEx.Runtime.Snapshot().MairListActiveTarget)
+}
+
+func TestAppendPlaylistPrimaryRefusingDoesNotFailOver(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	primary, _, backup := setupTargets(t, false)
+	primary.FailWith("file not found")
+	cfg.Export.MairListFailover = true
+
+	err := exportService.AppendPlaylist("a.tpi")
+
+	assert.EqualError(t, err, "file not found")
+	assert.Empty(t, backup.Commands())
+}
+
+func TestGetPlaylistUsesBackupWhilePrimaryIsDown(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	_, _, backup := setupTargets(t, true)
+	cfg.Export.MairListFailover = true
+	backup.SetItems(mairlisttest.Item{Filename: "a.mp3", Class: "File", State: "playing"})
+
+	err := exportService.GetPlaylist()
+
+	require.NoError(t, err)
+	assert.True(t, stateEx.Runtime.Snapshot().MairListPlaying)
+	assert.EqualValues(t, "standby", stateEx.Runtime.Snapshot().MairListActiveTarget)
+}
+
+func TestGetPlaylistSwitchesBackToReachablePrimary(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	setupTargets(t, false)
+	cfg.Export.MairListFailover = true
+	stateEx.Runtime.MairListActiveTarget = "standby"
+
+	err := exportService.GetPlaylist()
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "main", stateEx.Runtime.Snapshot().MairListActiveTarget)
+}
+
+func TestGetPlaylistLabelsPlayStatusOfMairListUrlWithUrl(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv := mairlisttest.NewServer(6)
+	t.Cleanup(srv.Close)
+	srv.SetItems(mairlisttest.Item{Filename: "a.mp3", Class: "File", State: "playing"})
+	cfg.Export.MairListUrl = srv.URL
+	cfg.Export.MairListVersion = 6
+
+	err := exportService.GetPlaylist()
+
+	require.NoError(t, err)
+	assert.EqualValues(t, map[string]float64{srv.URL: 1}, playStatus(t))
+}
+
+func TestGetPlaylistLabelsPlayStatusOfTargetsWithName(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	setupTargets(t, false)
+
+	err := exportService.GetPlaylist()
+
+	require.NoError(t, err)
+	assert.EqualValues(t, map[string]float64{"main": 0, "mirror": 0, "standby": 0}, playStatus(t))
+}
+
+// playStatus returns the play status metric by instance label
+func playStatus(t *testing.T) map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(stateEx.Metrics.MairListPlaying))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	status := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			status[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+		}
+	}
+	return status
+}
@@ -0,0 +1,195 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+)
+
+const (
+	maxMusicReportDays = 366
+)
+
+// The music report service aggregates the music played in mAirList from the as-run log for the collecting societies
+type DefaultMusicReportService struct {
+	Cfg   *config.AppConfig
+	Repo  *repositories.DefaultFileRepository
+	AsRun repositories.AsRunRepository
+}
+
+// NewMusicReportService creates a new music report service and injects its dependencies
+func NewMusicReportService(cfg *config.AppConfig, repo *repositories.DefaultFileRepository, asRun repositories.AsRunRepository) DefaultMusicReportService {
+	return DefaultMusicReportService{
+		Cfg:   cfg,
+		Repo:  repo,
+		AsRun: asRun,
+	}
+}
+
+// MusicReport aggregates the music items played between two dates, both included, by artist and title.
+// Items still playing, items without artist and title and our own show files are left out
+func (s DefaultMusicReportService) MusicReport(from time.Time, to time.Time) ([]dto.MusicReportRow, error) {
+	if to.Before(from) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > maxMusicReportDays {
+		return nil, fmt.Errorf("reporting period must not exceed %v days", maxMusicReportDays)
+	}
+	rows := make(map[string]*dto.MusicReportRow)
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		entries, err := s.AsRun.GetByDate(date)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !s.isMusic(entry) {
+				continue
+			}
+			key := strings.ToLower(entry.Artist + "\x00" + entry.Title)
+			row, ok := rows[key]
+			if !ok {
+				row = &dto.MusicReportRow{
+					Artist:      entry.Artist,
+					Title:       entry.Title,
+					File:        entry.Filename,
+					FirstPlayed: entry.Started,
+				}
+				rows[key] = row
+			}
+			row.Album = cmp.Or(row.Album, entry.Album)
+			row.Genre = cmp.Or(row.Genre, entry.Genre)
+			row.Year = cmp.Or(row.Year, entry.Year)
+			row.Plays++
+			row.Duration += entry.Played()
+			row.LastPlayed = entry.Started
+		}
+	}
+	report := make([]dto.MusicReportRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if !strings.EqualFold(report[i].Artist, report[j].Artist) {
+			return strings.ToLower(report[i].Artist) < strings.ToLower(report[j].Artist)
+		}
+		return strings.ToLower(report[i].Title) < strings.ToLower(report[j].Title)
+	})
+	return report, nil
+}
+
+// isMusic checks whether an as-run entry is a finished music item.
+// Show files are recognized by being in the file list or below the root folder or one of the configured exclude folders
+func (s DefaultMusicReportService) isMusic(entry domain.AsRunEntry) bool {
+	if entry.Status == domain.AsRunPlaying || strings.TrimSpace(entry.Artist) == "" || strings.TrimSpace(entry.Title) == "" {
+		return false
+	}
+	if s.Repo != nil && s.Repo.Exists(entry.Filename) {
+		return false
+	}
+	folders := append([]string{s.Cfg.Crawl.RootFolder}, s.Cfg.Report.MusicExcludeFolders...)
+	for _, folder := range folders {
+		if folder != "" && isBelowFolder(entry.Filename, folder) {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteMusicReportCsv writes the report using the configured columns and separator.
+// Starts with a byte order mark, so spreadsheet applications detect the encoding
+func (s DefaultMusicReportService) WriteMusicReportCsv(w io.Writer, report []dto.MusicReportRow) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if separator, _ := utf8.DecodeRuneInString(s.Cfg.Report.MusicSeparator); separator != utf8.RuneError {
+		cw.Comma = separator
+	}
+	columns := s.Cfg.Report.MusicColumns
+	if len(columns) == 0 {
+		columns = config.MusicReportColumns
+	}
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, musicReportHeader(column))
+	}
+	cw.Write(header)
+	for _, row := range report {
+		record := make([]string, 0, len(columns))
+		for _, column := range columns {
+			record = append(record, musicReportValue(column, row))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func musicReportHeader(column string) string {
+	switch column {
+	case "seconds":
+		return "Duration (s)"
+	case "first":
+		return "First Played"
+	case "last":
+		return "Last Played"
+	default:
+		return strings.ToUpper(column[:1]) + column[1:]
+	}
+}
+
+func musicReportValue(column string, row dto.MusicReportRow) string {
+	switch column {
+	case "artist":
+		return row.Artist
+	case "title":
+		return row.Title
+	case "album":
+		return row.Album
+	case "genre":
+		return row.Genre
+	case "year":
+		return row.Year
+	case "plays":
+		return fmt.Sprint(row.Plays)
+	case "duration":
+		return formatDuration(row.Duration)
+	case "seconds":
+		return fmt.Sprint(int(row.Duration.Seconds()))
+	case "first":
+		return row.FirstPlayed.Format("2006-01-02 15:04:05")
+	case "last":
+		return row.LastPlayed.Format("2006-01-02 15:04:05")
+	case "file":
+		return row.File
+	default:
+		return ""
+	}
+}
+
+// formatDuration is a helper function formatting a duration as "hh:mm:ss"
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// isBelowFolder is a helper function checking whether a path is inside a folder, ignoring case and the kind of path separator
+func isBelowFolder(filePath string, folder string) bool {
+	normalize := func(value string) string {
+		return strings.ToLower(strings.ReplaceAll(value, "\\", "/"))
+	}
+	prefix := strings.TrimSuffix(normalize(folder), "/") + "/"
+	return strings.HasPrefix(normalize(filePath), prefix)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMusicReport(t *testing.T) DefaultMusicReportService {
+	t.Helper()
+	cfg.Export.AsRunFolder = t.TempDir()
+	cfg.Crawl.RootFolder = "/shows"
+	cfg.Report.MusicExcludeFolders = []string{`\\server\shows`}
+	cfg.Report.MusicColumns = []string{"artist", "title", "plays", "duration", "seconds"}
+	cfg.Report.MusicSeparator = ";"
+	asRunRepo := repositories.NewAsRunRepository(&cfg)
+	play := func(started time.Time, artist string, title string, file string, played time.Duration, status domain.AsRunStatus) {
+		_, err := asRunRepo.Start(domain.AsRunEntry{Filename: file, Artist: artist, Title: title, Genre: "Pop", Started: started, Stopped: started.Add(played), Status: status})
+		require.NoError(t, err)
+	}
+	play(dayDate.Add(8*time.Hour), "Band", "Song", "/music/song.mp3", 3*time.Minute, domain.AsRunCompleted)
+	play(dayDate.Add(9*time.Hour), "band", "song", "/music/song.mp3", 2*time.Minute, domain.AsRunSkipped)
+	play(dayDate.Add(10*time.Hour), "Artist", "Other; Song", "/music/other.mp3", time.Minute, domain.AsRunCompleted)
+	play(dayDate.Add(11*time.Hour), "Host", "Show", "/shows/2026/10/19/show.mp3", time.Hour, domain.AsRunCompleted)
+	play(dayDate.Add(12*time.Hour), "Host", "Show", `\\SERVER\shows\show.mp3`, time.Hour, domain.AsRunCompleted)
+	play(dayDate.Add(13*time.Hour), "", "Jingle", "/music/jingle.mp3", 10*time.Second, domain.AsRunCompleted)
+	play(dayDate.AddDate(0, 0, 1).Add(8*time.Hour), "Band", "Song", "/music/song.mp3", 3*time.Minute, domain.AsRunPlaying)
+	return NewMusicReportService(&cfg, &fileRepo, &asRunRepo)
+}
+
+func TestMusicReportAggregatesPlays(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reportSvc := setupMusicReport(t)
+
+	report, err := reportSvc.MusicReport(dayDate, dayDate.AddDate(0, 0, 1))
+
+	require.NoError(t, err)
+	require.Len(t, report, 2)
+	assert.EqualValues(t, "Artist", report[0].Artist)
+	assert.EqualValues(t, "Band", report[1].Artist)
+	assert.EqualValues(t, 2, report[1].Plays)
+	assert.EqualValues(t, 5*time.Minute, report[1].Duration)
+	assert.EqualValues(t, "Pop", report[1].Genre)
+	assert.True(t, dayDate.Add(9*time.Hour).Equal(report[1].LastPlayed))
+}
+
+func TestMusicReportExcludesFilesInFileList(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reportSvc := setupMusicReport(t)
+	storeDayFile(t, dayDate, "/music/other.mp3", 10, 0, time.Minute)
+
+	report, err := reportSvc.MusicReport(dayDate, dayDate)
+
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.EqualValues(t, "Song", report[0].Title)
+}
+
+func TestMusicReportInvalidPeriodReturnsError(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reportSvc := setupMusicReport(t)
+
+	_, errOrder := reportSvc.MusicReport(dayDate, dayDate.AddDate(0, 0, -1))
+	_, errLength := reportSvc.MusicReport(dayDate, dayDate.AddDate(2, 0, 0))
+
+	assert.EqualError(t, errOrder, "end date must not be before start date")
+	assert.EqualError(t, errLength, "reporting period must not exceed 366 days")
+}
+
+func TestWriteMusicReportCsvUsesConfiguredLayout(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reportSvc := setupMusicReport(t)
+	report, _ := reportSvc.MusicReport(dayDate, dayDate)
+	var buf bytes.Buffer
+
+	err := reportSvc.WriteMusicReportCsv(&buf, report)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "\ufeffArtist;Title;Plays;Duration;Duration (s)\n"+
+		"Artist;\"Other; Song\";1;00:01:00;60\n"+
+		"Band;Song;2;00:05:00;300\n", buf.String())
+}
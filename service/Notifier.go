@@ -0,0 +1,187 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	notificationSignatureHeader = "X-Feeder-Signature"
+	notificationEventHeader     = "X-Feeder-Event"
+	notificationDeliveryHeader  = "X-Feeder-Delivery"
+)
+
+// The notifier delivers the events emitted by the services to the configured webhooks in the background.
+// Each webhook has its own queue, so a webhook failing doesn't delay the deliveries to the others
+type DefaultNotifier struct {
+	Cfg        *config.AppConfig
+	Now        func() time.Time
+	Sleep      func(context.Context, time.Duration) error
+	httpClient *http.Client
+	webhooks   []config.Webhook
+	queues     []chan domain.Notification
+	seq        *atomic.Int64
+}
+
+// notifier is implemented by the notifier and used by the services emitting events
+type notifier interface {
+	Notify(domain.NotificationType, string, map[string]string)
+}
+
+// NewNotifier creates a new notifier. Deliveries start once Run is called
+func NewNotifier(cfg *config.AppConfig) DefaultNotifier {
+	webhooks, err := config.NotificationWebhooks(cfg)
+	if err != nil {
+		logger.Error("Error reading notification webhooks", err)
+	}
+	queues := make([]chan domain.Notification, len(webhooks))
+	for i := range queues {
+		queues[i] = make(chan domain.Notification, max(cfg.Notify.QueueSize, 1))
+	}
+	return DefaultNotifier{
+		Cfg:        cfg,
+		Now:        time.Now,
+		Sleep:      sleepContext,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		webhooks:   webhooks,
+		queues:     queues,
+		seq:        &atomic.Int64{},
+	}
+}
+
+// Notify queues an event for delivery to each webhook subscribed to its type.
+// It never blocks, events are dropped for webhooks whose queue is full
+func (n DefaultNotifier) Notify(eventType domain.NotificationType, message string, data map[string]string) {
+	if !n.subscribed(eventType) {
+		return
+	}
+	notification := domain.Notification{
+		Id:      strconv.FormatInt(n.Now().UnixMilli(), 10) + "-" + strconv.FormatInt(n.seq.Add(1), 10),
+		Type:    eventType,
+		Time:    n.Now(),
+		Message: message,
+		Data:    data,
+	}
+	for i, webhook := range n.webhooks {
+		if !webhook.Accepts(string(eventType)) {
+			continue
+		}
+		select {
+		case n.queues[i] <- notification:
+		default:
+			logger.Warnf("Notification queue of %v is full, dropping %v notification: %v", webhook.Url, eventType, message)
+		}
+	}
+}
+
+// Run delivers the queued events to each webhook independently until the context is cancelled
+func (n DefaultNotifier) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, webhook := range n.webhooks {
+		wg.Go(func() {
+			n.runWebhook(ctx, webhook, n.queues[i])
+		})
+	}
+	wg.Wait()
+}
+
+// runWebhook delivers the events queued for one webhook until the context is cancelled
+func (n DefaultNotifier) runWebhook(ctx context.Context, webhook config.Webhook, queue chan domain.Notification) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-queue:
+			body, err := json.Marshal(notification)
+			if err == nil {
+				err = n.deliverWithRetry(ctx, webhook, notification, body)
+			}
+			if err != nil {
+				logger.Error("Error delivering notification", err)
+			}
+		}
+	}
+}
+
+// deliverWithRetry posts the body to a webhook, doubling the delay between attempts
+func (n DefaultNotifier) deliverWithRetry(ctx context.Context, webhook config.Webhook, notification domain.Notification, body []byte) error {
+	delay := time.Duration(n.Cfg.Notify.RetrySec) * time.Second
+	err := n.post(ctx, webhook, notification, body)
+	for attempt := 1; err != nil && attempt <= n.Cfg.Notify.Retries; attempt++ {
+		logger.Warnf("Delivering notification %v to %v failed, retrying in %v. %v", notification.Id, webhook.Url, delay, err)
+		if sleepErr := n.Sleep(ctx, delay); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+		delay *= 2
+		err = n.post(ctx, webhook, notification, body)
+	}
+	return err
+}
+
+// post sends the JSON body to a webhook. Requests to webhooks with a secret carry the HMAC-SHA256 signature of the body
+func (n DefaultNotifier) post(ctx context.Context, webhook config.Webhook, notification domain.Notification, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(notificationEventHeader, string(notification.Type))
+	req.Header.Set(notificationDeliveryHeader, notification.Id)
+	if webhook.Secret != "" {
+		req.Header.Set(notificationSignatureHeader, "sha256="+SignNotification(webhook.Secret, body))
+	}
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send notification to %v: %w", webhook.Url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook %v answered with HTTP %d", webhook.Url, resp.StatusCode)
+	}
+	return nil
+}
+
+// subscribed checks whether any webhook receives events of the given type
+func (n DefaultNotifier) subscribed(eventType domain.NotificationType) bool {
+	for _, webhook := range n.webhooks {
+		if webhook.Accepts(string(eventType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignNotification returns the hex encoded HMAC-SHA256 of a notification body, so receivers can verify it
+func SignNotification(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sleepContext waits for the given duration or until the context is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
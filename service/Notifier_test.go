@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotifier records the emitted notifications instead of delivering them
+type fakeNotifier struct {
+	mu            sync.Mutex
+	notifications []domain.Notification
+}
+
+func (f *fakeNotifier) Notify(eventType domain.NotificationType, message string, data map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifications = append(f.notifications, domain.Notification{Type: eventType, Message: message, Data: data})
+}
+
+func (f *fakeNotifier) types() []domain.NotificationType {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var types []domain.NotificationType
+	for _, n := range f.notifications {
+		types = append(types, n.Type)
+	}
+	return types
+}
+
+type receivedHook struct {
+	body    []byte
+	headers http.Header
+}
+
+// hookRecorder records the requests to a webhook and the waits between delivery attempts
+type hookRecorder struct {
+	mu       sync.Mutex
+	received []receivedHook
+	waits    []time.Duration
+}
+
+func (hr *hookRecorder) hooks() []receivedHook {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	return append([]receivedHook(nil), hr.received...)
+}
+
+func (hr *hookRecorder) backoff() []time.Duration {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	return append([]time.Duration(nil), hr.waits...)
+}
+
+// waitForHooks waits until the webhook has received the given number of requests
+func (hr *hookRecorder) waitForHooks(t *testing.T, count int) []receivedHook {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return len(hr.hooks()) >= count
+	}, 2*time.Second, 5*time.Millisecond)
+	return hr.hooks()
+}
+
+// setupNotifier creates a notifier for a webhook answering with the given status codes in turn, the last one repeated
+func setupNotifier(t *testing.T, secret string, types string, statusCodes ...int) (DefaultNotifier, *hookRecorder) {
+	t.Helper()
+	recorder := &hookRecorder{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		recorder.mu.Lock()
+		recorder.received = append(recorder.received, receivedHook{body: body, headers: r.Header.Clone()})
+		status := statusCodes[min(len(recorder.received), len(statusCodes))-1]
+		recorder.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	var notifyCfg config.AppConfig
+	notifyCfg.Notify.Webhooks = []string{srv.URL + "|" + secret + "|" + types}
+	notifyCfg.Notify.Retries = 2
+	notifyCfg.Notify.RetrySec = 5
+	notifyCfg.Notify.QueueSize = 10
+	n := NewNotifier(&notifyCfg)
+	n.Now = func() time.Time { return dayDate.Add(20 * time.Hour) }
+	n.Sleep = func(ctx context.Context, d time.Duration) error {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		recorder.waits = append(recorder.waits, d)
+		return nil
+	}
+	return n, recorder
+}
+
+// runNotifier delivers the notifications in the background until the test ends
+func runNotifier(t *testing.T, n DefaultNotifier) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestRunSignsBodyWithSecret(t *testing.T) {
+	n, recorder := setupNotifier(t, "s3cret", "", http.StatusOK)
+	runNotifier(t, n)
+
+	n.Notify(domain.NotificationExportFailed, "Could not export playlist", map[string]string{"hour": "20"})
+
+	hooks := recorder.waitForHooks(t, 1)
+	require.Len(t, hooks, 1)
+	hook := hooks[0]
+	assert.EqualValues(t, "sha256="+SignNotification("s3cret", hook.body), hook.headers.Get("X-Feeder-Signature"))
+	assert.EqualValues(t, "export_failed", hook.headers.Get("X-Feeder-Event"))
+	var notification domain.Notification
+	require.NoError(t, json.Unmarshal(hook.body, &notification))
+	assert.EqualValues(t, domain.NotificationExportFailed, notification.Type)
+	assert.EqualValues(t, "Could not export playlist", notification.Message)
+	assert.EqualValues(t, "20", notification.Data["hour"])
+	assert.EqualValues(t, notification.Id, hook.headers.Get("X-Feeder-Delivery"))
+}
+
+func TestRunWithoutSecretSendsNoSignature(t *testing.T) {
+	n, recorder := setupNotifier(t, "", "", http.StatusOK)
+	runNotifier(t, n)
+
+	n.Notify(domain.NotificationFileMissing, "No file", nil)
+
+	hooks := recorder.waitForHooks(t, 1)
+	assert.Empty(t, hooks[0].headers.Get("X-Feeder-Signature"))
+}
+
+func TestNotifyDropsTypesNoWebhookSubscribed(t *testing.T) {
+	n, recorder := setupNotifier(t, "", "export_failed;file_missing", http.StatusOK)
+	runNotifier(t, n)
+
+	n.Notify(domain.NotificationFfprobeFailed, "Could not analyze file", nil)
+	n.Notify(domain.NotificationFileMissing, "No file", nil)
+
+	hooks := recorder.waitForHooks(t, 1)
+	require.Len(t, hooks, 1)
+	assert.EqualValues(t, "file_missing", hooks[0].headers.Get("X-Feeder-Event"))
+}
+
+func TestRunRetriesWithBackoff(t *testing.T) {
+	n, recorder := setupNotifier(t, "", "", http.StatusBadGateway, http.StatusBadGateway, http.StatusOK)
+	runNotifier(t, n)
+
+	n.Notify(domain.NotificationCalCmsUnreachable, "calCMS could not be queried", nil)
+
+	hooks := recorder.waitForHooks(t, 3)
+	assert.Len(t, hooks, 3)
+	assert.EqualValues(t, []time.Duration{5 * time.Second, 10 * time.Second}, recorder.backoff())
+}
+
+func TestRunGivesUpAfterRetriesAndDeliversNextNotification(t *testing.T) {
+	n, recorder := setupNotifier(t, "", "", http.StatusInternalServerError)
+	runNotifier(t, n)
+
+	n.Notify(domain.NotificationCalCmsUnreachable, "calCMS could not be queried", nil)
+	recorder.waitForHooks(t, 3)
+	n.Notify(domain.NotificationExportFailed, "Could not export playlist", nil)
+
+	hooks := recorder.waitForHooks(t, 6)
+	assert.Len(t, hooks, 6)
+	assert.EqualValues(t, "calcms_unreachable", hooks[2].headers.Get("X-Feeder-Event"))
+	assert.EqualValues(t, "export_failed", hooks[3].headers.Get("X-Feeder-Event"))
+}
+
+func TestNotifyFullQueueDropsNotification(t *testing.T) {
+	n, _ := setupNotifier(t, "", "", http.StatusOK)
+
+	for range 12 {
+		n.Notify(domain.NotificationFileRejected, "File was not exported", nil)
+	}
+
+	assert.Len(t, n.queues[0], 10)
+}
+
+func TestRunDeliversToWebhooksIndependently(t *testing.T) {
+	delivered := make(chan domain.NotificationType, 1)
+	alive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- domain.NotificationType(r.Header.Get("X-Feeder-Event"))
+	}))
+	t.Cleanup(alive.Close)
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(dead.Close)
+	var notifyCfg config.AppConfig
+	notifyCfg.Notify.Webhooks = []string{dead.URL + "||", alive.URL + "||"}
+	notifyCfg.Notify.Retries = 3
+	notifyCfg.Notify.RetrySec = 5
+	notifyCfg.Notify.QueueSize = 10
+	n := NewNotifier(&notifyCfg)
+	n.Sleep = func(ctx context.Context, d time.Duration) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	runNotifier(t, n)
+
+	n.Notify(domain.NotificationExportFailed, "Could not export playlist", nil)
+
+	select {
+	case eventType := <-delivered:
+		assert.EqualValues(t, domain.NotificationExportFailed, eventType)
+	case <-time.After(2 * time.Second):
+		t.Error("notification was not delivered while another webhook is retrying")
+	}
+}
+
+func TestExportFailureEmitsNotification(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	notifications := &fakeNotifier{}
+	exportService.Notifier = notifications
+	cfg.Export.ExportFolder = "/does/not/exist"
+	storeDayFile(t, dayDate, "show.mp3", 20, 0, 60*time.Minute)
+
+	err := exportService.ExportForDateAndHour(dayDate, "20")
+
+	require.Error(t, err)
+	assert.EqualValues(t, []domain.NotificationType{domain.NotificationExportFailed}, notifications.types())
+	assert.EqualValues(t, "20", notifications.notifications[0].Data["hour"])
+}
+
+func TestExportWithRejectedLengthEmitsNotification(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	notifications := &fakeNotifier{}
+	exportService.Notifier = notifications
+	cfg.Export.ExportFolder = t.TempDir()
+	storeDayFile(t, dayDate, "show.mp3", 20, 0, 60*time.Minute)
+	storeDayFile(t, dayDate, "short.mp3", 20, 30, 5*time.Minute)
+
+	err := exportService.ExportForDateAndHour(dayDate, "20")
+
+	require.NoError(t, err)
+	require.EqualValues(t, []domain.NotificationType{domain.NotificationFileRejected}, notifications.types())
+	assert.Contains(t, notifications.notifications[0].Data["path"], "short.mp3")
+}
+
+func TestCalCmsFailureEmitsNotificationOnce(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	notifications := &fakeNotifier{}
+	calCmsService.Notifier = notifications
+
+	calCmsService.setCalCmsQueryState(false)
+	calCmsService.setCalCmsQueryState(false)
+	calCmsService.setCalCmsQueryState(true)
+	calCmsService.setCalCmsQueryState(false)
+
+	assert.EqualValues(t, []domain.NotificationType{domain.NotificationCalCmsUnreachable, domain.NotificationCalCmsUnreachable}, notifications.types())
+}
+
+func TestMissingFileForNextHourEmitsNotificationOnce(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	notifications := &fakeNotifier{}
+	calCmsService.Notifier = notifications
+	cfgCal.Notify.MissingMin = 60
+	calCmsService.Now = func() time.Time { return dayDate.Add(19*time.Hour + 30*time.Minute) }
+	events := []dto.Event{
+		{EventId: "1", Title: "Evening Show", StartDate: "2026-10-19", StartTime: "20:00", EndTime: "21:00", EventType: "Preproduction", FileStatus: "Missing"},
+		{EventId: "2", Title: "Live Talk", StartDate: "2026-10-19", StartTime: "20:00", EndTime: "21:00", EventType: "Live", FileStatus: "N/A"},
+		{EventId: "3", Title: "Night Show", StartDate: "2026-10-19", StartTime: "22:00", EndTime: "23:00", EventType: "Preproduction", FileStatus: "Missing"},
+		{EventId: "4", Title: "Present Show", StartDate: "2026-10-19", StartTime: "20:00", EndTime: "21:00", EventType: "Preproduction", FileStatus: "Present"},
+	}
+
+	calCmsService.notifyMissingFiles(events)
+	calCmsService.notifyMissingFiles(events)
+
+	require.EqualValues(t, []domain.NotificationType{domain.NotificationFileMissing}, notifications.types())
+	assert.EqualValues(t, "1", notifications.notifications[0].Data["event_id"])
+}
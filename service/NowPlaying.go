@@ -0,0 +1,293 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	nowPlayingRetryDelay    = 10 * time.Second
+	nowPlayingMaxRetryDelay = 5 * time.Minute
+)
+
+// The now-playing service pushes the item on air and the current show to the streaming server, the RDS encoder and the website.
+// Updates are queued by the status query and published in the background, so slow sinks don't delay the status query
+type DefaultNowPlayingService struct {
+	Cfg        *config.AppConfig
+	Events     todayEvents
+	Now        func() time.Time
+	httpClient *http.Client
+	last       *nowPlayingState
+	queue      chan mairlist.Playlist
+}
+
+// nowPlayingState remembers what was published last to each sink, so sinks are only updated on a change.
+// A sink failing is retried on a later update, waiting longer after each failure
+type nowPlayingState struct {
+	mu       sync.Mutex
+	keys     map[string]string
+	failures map[string]nowPlayingFailure
+}
+
+// nowPlayingFailure is the backoff of a failing sink
+type nowPlayingFailure struct {
+	attempts int
+	next     time.Time
+}
+
+// nowPlayingSink is a destination for now-playing updates
+type nowPlayingSink struct {
+	name    string
+	publish func(context.Context, dto.NowPlaying) error
+}
+
+// NewNowPlayingService creates a new now-playing service and injects its dependencies
+func NewNowPlayingService(cfg *config.AppConfig, events todayEvents) DefaultNowPlayingService {
+	return DefaultNowPlayingService{
+		Cfg:        cfg,
+		Events:     events,
+		Now:        time.Now,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		last:       &nowPlayingState{keys: make(map[string]string), failures: make(map[string]nowPlayingFailure)},
+		queue:      make(chan mairlist.Playlist, 1),
+	}
+}
+
+// Queue hands mAirList's playlist to the background publisher. It never blocks, a playlist not yet published is
+// replaced by the newer one
+func (s DefaultNowPlayingService) Queue(playlist mairlist.Playlist) {
+	if len(s.sinks()) == 0 {
+		return
+	}
+	select {
+	case <-s.queue:
+	default:
+	}
+	select {
+	case s.queue <- playlist:
+	default:
+	}
+}
+
+// Run publishes the queued playlists until the context is cancelled
+func (s DefaultNowPlayingService) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case playlist := <-s.queue:
+			if err := s.PublishContext(ctx, playlist); err != nil {
+				logger.Error("Error publishing now playing", err)
+			}
+		}
+	}
+}
+
+// Publish sends the item playing in mAirList to all configured sinks, if it changed since the sink's last successful update.
+// Sinks which failed before are skipped until their backoff has passed
+func (s DefaultNowPlayingService) Publish(playlist mairlist.Playlist) error {
+	return s.PublishContext(context.Background(), playlist)
+}
+
+func (s DefaultNowPlayingService) PublishContext(ctx context.Context, playlist mairlist.Playlist) error {
+	sinks := s.sinks()
+	if len(sinks) == 0 {
+		return nil
+	}
+	item := playlist.Current()
+	if item == nil {
+		s.last.mu.Lock()
+		clear(s.last.keys)
+		s.last.mu.Unlock()
+		return nil
+	}
+	nowPlaying, err := s.nowPlaying(item)
+	if err != nil {
+		return err
+	}
+	key := asRunKey(item.ID, item.Filename) + "\x00" + nowPlaying.ShowId
+	var (
+		publishErr error
+		published  bool
+	)
+	// the sinks are called without holding the lock, they may take until their timeout
+	for _, sink := range s.dueSinks(sinks, key) {
+		err := sink.publish(ctx, nowPlaying)
+		s.recordPublished(sink.name, key, err)
+		if err != nil {
+			publishErr = errors.Join(publishErr, fmt.Errorf("now-playing sink %v: %w", sink.name, err))
+			continue
+		}
+		published = true
+	}
+	if published && publishErr == nil {
+		logger.Infof("Published now playing: %v", nowPlaying.Text)
+	}
+	return publishErr
+}
+
+// dueSinks returns the sinks which haven't received the item yet and aren't waiting for their backoff to pass
+func (s DefaultNowPlayingService) dueSinks(sinks []nowPlayingSink, key string) []nowPlayingSink {
+	now := s.Now()
+	s.last.mu.Lock()
+	defer s.last.mu.Unlock()
+	var due []nowPlayingSink
+	for _, sink := range sinks {
+		if s.last.keys[sink.name] == key || now.Before(s.last.failures[sink.name].next) {
+			continue
+		}
+		due = append(due, sink)
+	}
+	return due
+}
+
+// recordPublished records the outcome of an update of a sink. Each failure doubles the sink's backoff up to the maximum
+func (s DefaultNowPlayingService) recordPublished(name string, key string, err error) {
+	s.last.mu.Lock()
+	defer s.last.mu.Unlock()
+	if err == nil {
+		s.last.keys[name] = key
+		delete(s.last.failures, name)
+		return
+	}
+	failure := s.last.failures[name]
+	failure.attempts++
+	delay := nowPlayingRetryDelay
+	for i := 1; i < failure.attempts && delay < nowPlayingMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	failure.next = s.Now().Add(min(delay, nowPlayingMaxRetryDelay))
+	s.last.failures[name] = failure
+}
+
+// nowPlaying combines the playing item with the current calCMS event. Items without title, e.g. show files, get the show's title
+func (s DefaultNowPlayingService) nowPlaying(item *mairlist.PlaylistItem) (dto.NowPlaying, error) {
+	now := s.Now()
+	nowPlaying := dto.NowPlaying{
+		Title:       item.Title,
+		Artist:      item.Artist,
+		Album:       item.Album,
+		File:        item.Filename,
+		Started:     now.Add(-item.PlaybackPosition).Truncate(time.Second),
+		DurationSec: int(item.Duration.Seconds()),
+		Updated:     now,
+	}
+	if s.Events != nil {
+		events, err := s.Events.GetTodayEvents()
+		if err != nil {
+			logger.Error("Error getting today's events for now playing", err)
+		}
+		if event, _, found := currentEvent(events, now); found {
+			nowPlaying.Show = event.Title
+			nowPlaying.ShowId = event.EventId
+		}
+	}
+	if strings.TrimSpace(nowPlaying.Title) == "" {
+		nowPlaying.Title = nowPlaying.Show
+	}
+	text, err := renderNowPlaying("now-playing", s.Cfg.NowPlaying.Template, nowPlaying)
+	if err != nil {
+		return dto.NowPlaying{}, err
+	}
+	nowPlaying.Text = text
+	return nowPlaying, nil
+}
+
+// sinks returns the configured now-playing sinks
+func (s DefaultNowPlayingService) sinks() []nowPlayingSink {
+	var sinks []nowPlayingSink
+	if s.Cfg.NowPlaying.IcecastUrl != "" {
+		sinks = append(sinks, nowPlayingSink{name: "icecast", publish: s.publishIcecast})
+	}
+	if s.Cfg.NowPlaying.LineAddress != "" {
+		sinks = append(sinks, nowPlayingSink{name: "line", publish: s.publishLine})
+	}
+	if s.Cfg.NowPlaying.JsonFile != "" {
+		sinks = append(sinks, nowPlayingSink{name: "json", publish: s.publishJson})
+	}
+	return sinks
+}
+
+// publishIcecast updates the stream title of the configured mount using Icecast's admin API
+func (s DefaultNowPlayingService) publishIcecast(ctx context.Context, nowPlaying dto.NowPlaying) error {
+	metadataUrl, err := url.JoinPath(s.Cfg.NowPlaying.IcecastUrl, "admin", "metadata")
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("mount", s.Cfg.NowPlaying.IcecastMount)
+	query.Set("mode", "updinfo")
+	query.Set("charset", "UTF-8")
+	query.Set("song", nowPlaying.Text)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataUrl+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.Cfg.NowPlaying.IcecastUser, s.Cfg.NowPlaying.IcecastPassword)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("icecast answered with HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// publishLine sends one text line, e.g. "RT=Artist - Title", to an RDS encoder via TCP or UDP
+func (s DefaultNowPlayingService) publishLine(ctx context.Context, nowPlaying dto.NowPlaying) error {
+	line, err := renderNowPlaying("now-playing line", s.Cfg.NowPlaying.LineTemplate, nowPlaying)
+	if err != nil {
+		return err
+	}
+	network, address, _ := strings.Cut(s.Cfg.NowPlaying.LineAddress, "://")
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+// publishJson writes the now-playing information as JSON file for the website, replacing the file atomically
+func (s DefaultNowPlayingService) publishJson(_ context.Context, nowPlaying dto.NowPlaying) error {
+	content, err := json.MarshalIndent(nowPlaying, "", "  ")
+	if err != nil {
+		return err
+	}
+	return helper.WriteFileAtomic(s.Cfg.NowPlaying.JsonFile, content, 0644)
+}
+
+// renderNowPlaying is a helper function executing a now-playing text template
+func renderNowPlaying(name string, text string, nowPlaying dto.NowPlaying) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%v template is invalid: %w", name, err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, nowPlaying); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type icecastRecorder struct {
+	mu    sync.Mutex
+	songs []string
+	auth  string
+	mount string
+}
+
+func (ir *icecastRecorder) received() []string {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	return append([]string(nil), ir.songs...)
+}
+
+func setupNowPlayingTest(t *testing.T) (DefaultNowPlayingService, *icecastRecorder) {
+	t.Helper()
+	recorder := &icecastRecorder{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		recorder.mu.Lock()
+		recorder.songs = append(recorder.songs, r.URL.Query().Get("song"))
+		recorder.auth = user + ":" + pass
+		recorder.mount = r.URL.Path + "?mount=" + r.URL.Query().Get("mount") + "&mode=" + r.URL.Query().Get("mode")
+		recorder.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	cfg.NowPlaying.IcecastUrl = srv.URL
+	cfg.NowPlaying.IcecastMount = "/live"
+	cfg.NowPlaying.IcecastUser = "admin"
+	cfg.NowPlaying.IcecastPassword = "hackme"
+	events := fakeAlarmEvents{events: []dto.Event{
+		{EventId: "1", Title: "Evening Show", StartDate: "2026-10-19", StartTime: "20:00", EndTime: "21:00", EventType: "Preproduction"},
+	}}
+	nowPlayingService := NewNowPlayingService(&cfg, events)
+	nowPlayingService.Now = func() time.Time { return dayDate.Add(20*time.Hour + 5*time.Minute) }
+	return nowPlayingService, recorder
+}
+
+func playingItem(id string, title string, artist string) mairlist.Playlist {
+	return mairlist.Playlist{Items: []mairlist.PlaylistItem{
+		{ID: id, Class: "File", State: "playing", Filename: id + ".mp3", Title: title, Artist: artist, Duration: 3 * time.Minute, PlaybackPosition: time.Minute},
+	}}
+}
+
+func TestPublishSendsChangesToIcecastOnly(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, recorder := setupNowPlayingTest(t)
+
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+	require.NoError(t, nowPlayingService.Publish(playingItem("2", "", "")))
+
+	assert.EqualValues(t, []string{"Band - Song", "Evening Show"}, recorder.received())
+	assert.EqualValues(t, "admin:hackme", recorder.auth)
+	assert.EqualValues(t, "/admin/metadata?mount=/live&mode=updinfo", recorder.mount)
+}
+
+func TestPublishNothingPlayingRepublishesNextItem(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, recorder := setupNowPlayingTest(t)
+
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+	require.NoError(t, nowPlayingService.Publish(mairlist.Playlist{}))
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+
+	assert.Len(t, recorder.received(), 2)
+}
+
+func TestPublishWritesJsonFile(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, _ := setupNowPlayingTest(t)
+	cfg.NowPlaying.IcecastUrl = ""
+	cfg.NowPlaying.JsonFile = filepath.Join(t.TempDir(), "nowplaying.json")
+
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+
+	data, err := os.ReadFile(cfg.NowPlaying.JsonFile)
+	require.NoError(t, err)
+	var nowPlaying dto.NowPlaying
+	require.NoError(t, json.Unmarshal(data, &nowPlaying))
+	assert.EqualValues(t, "Band - Song", nowPlaying.Text)
+	assert.EqualValues(t, "Evening Show", nowPlaying.Show)
+	assert.EqualValues(t, "1", nowPlaying.ShowId)
+	assert.EqualValues(t, 180, nowPlaying.DurationSec)
+	assert.EqualValues(t, dayDate.Add(20*time.Hour+4*time.Minute), nowPlaying.Started.Local())
+}
+
+func TestPublishSendsLineViaTcp(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, _ := setupNowPlayingTest(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+	cfg.NowPlaying.IcecastUrl = ""
+	cfg.NowPlaying.LineAddress = "tcp://" + listener.Addr().String()
+	cfg.NowPlaying.LineTemplate = "RT={{ .Text }} ({{ .Show }})"
+
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+
+	select {
+	case line := <-lines:
+		assert.EqualValues(t, "RT=Band - Song (Evening Show)\r\n", line)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no line received")
+	}
+}
+
+func TestPublishSendsLineViaUdp(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, _ := setupNowPlayingTest(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	cfg.NowPlaying.IcecastUrl = ""
+	cfg.NowPlaying.LineAddress = "udp://" + conn.LocalAddr().String()
+
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.EqualValues(t, "RT=Band - Song\r\n", string(buf[:n]))
+}
+
+func TestPublishFailingSinkReturnsError(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, _ := setupNowPlayingTest(t)
+	cfg.NowPlaying.LineAddress = "tcp://127.0.0.1:1"
+
+	err := nowPlayingService.Publish(playingItem("1", "Song", "Band"))
+
+	assert.ErrorContains(t, err, "now-playing sink line")
+}
+
+func TestPublishRetriesFailedSinkOnlyAfterBackoff(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, recorder := setupNowPlayingTest(t)
+	now := dayDate.Add(20*time.Hour + 5*time.Minute)
+	nowPlayingService.Now = func() time.Time { return now }
+	cfg.NowPlaying.JsonFile = filepath.Join(t.TempDir(), "missing", "nowplaying.json")
+
+	firstErr := nowPlayingService.Publish(playingItem("1", "Song", "Band"))
+	require.NoError(t, os.MkdirAll(filepath.Dir(cfg.NowPlaying.JsonFile), 0755))
+	require.NoError(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+	assert.NoFileExists(t, cfg.NowPlaying.JsonFile)
+	now = now.Add(nowPlayingRetryDelay)
+	secondErr := nowPlayingService.Publish(playingItem("1", "Song", "Band"))
+
+	assert.ErrorContains(t, firstErr, "now-playing sink json")
+	assert.NoError(t, secondErr)
+	assert.FileExists(t, cfg.NowPlaying.JsonFile)
+	assert.EqualValues(t, []string{"Band - Song"}, recorder.received())
+}
+
+func TestPublishFailingSinkDoublesBackoff(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, _ := setupNowPlayingTest(t)
+	now := dayDate.Add(20*time.Hour + 5*time.Minute)
+	nowPlayingService.Now = func() time.Time { return now }
+	cfg.NowPlaying.IcecastUrl = ""
+	cfg.NowPlaying.JsonFile = filepath.Join(t.TempDir(), "missing", "nowplaying.json")
+
+	require.Error(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+	now = now.Add(nowPlayingRetryDelay)
+	require.Error(t, nowPlayingService.Publish(playingItem("1", "Song", "Band")))
+
+	assert.EqualValues(t, 2, nowPlayingService.last.failures["json"].attempts)
+	assert.EqualValues(t, now.Add(2*nowPlayingRetryDelay), nowPlayingService.last.failures["json"].next)
+}
+
+func TestGetPlaylistPublishesNowPlaying(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, recorder := setupNowPlayingTest(t)
+	exportService.NowPlaying = &nowPlayingService
+	srv := mairlisttest.NewServer(6)
+	defer srv.Close()
+	srv.SetItems(mairlisttest.Item{Filename: "song.mp3", Title: "Song", Artist: "Band", Class: "File", State: "playing"})
+	cfg.Export.MairListUrl = srv.URL
+	go nowPlayingService.Run(t.Context())
+
+	require.NoError(t, exportService.GetPlaylist())
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.received()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, []string{"Band - Song"}, recorder.received())
+}
+
+func TestQueueKeepsOnlyLatestPlaylist(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	nowPlayingService, _ := setupNowPlayingTest(t)
+
+	nowPlayingService.Queue(playingItem("1", "Song", "Band"))
+	nowPlayingService.Queue(playingItem("2", "Other", "Band"))
+
+	require.Len(t, nowPlayingService.queue, 1)
+	assert.EqualValues(t, "2", (<-nowPlayingService.queue).Items[0].ID)
+}
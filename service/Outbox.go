@@ -0,0 +1,145 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	outboxKeepFailed = 24 * time.Hour
+)
+
+// enqueueAppend puts a failed playlist append into the outbox, if retrying is enabled.
+// Appends whose deadline has already passed, e.g. from exporting past hours, are not retried.
+// The instances the playlist has been delivered to are recorded, so retries don't append it there again
+func (s DefaultExportService) enqueueAppend(folderDate time.Time, hour string, exportPath string, delivered []string, appendErr error) {
+	if s.Outbox == nil || !s.Cfg.Export.MairListRetry {
+		return
+	}
+	now := s.Now()
+	h, _ := strconv.Atoi(hour)
+	slotStart := time.Date(folderDate.Year(), folderDate.Month(), folderDate.Day(), h, 0, 0, 0, folderDate.Location())
+	entry := domain.OutboxEntry{
+		Slot:        repositories.SlotFromPath(exportPath),
+		FileName:    exportPath,
+		SlotStart:   slotStart,
+		Deadline:    slotStart.Add(time.Duration(s.Cfg.Export.RetryDeadlineMin) * time.Minute),
+		Created:     now,
+		Attempts:    1,
+		LastAttempt: now,
+		NextAttempt: now.Add(s.retryDelay(1)),
+		LastError:   appendErr.Error(),
+		Delivered:   delivered,
+		Status:      domain.OutboxPending,
+	}
+	if !now.Before(entry.Deadline) {
+		logger.Warnf("Not retrying playlist %v, its deadline %v has passed", entry.Slot, entry.Deadline.Format(dateFormat))
+		return
+	}
+	if _, err := s.Outbox.Enqueue(entry); err != nil {
+		logger.Error("Error putting playlist append into the outbox", err)
+	} else {
+		logger.Infof("Retrying append of playlist %v until %v", entry.Slot, entry.Deadline.Format(dateFormat))
+	}
+	s.updateOutboxMetrics()
+}
+
+// removeFromOutbox drops the outbox entry of a playlist that was appended, so it is not delivered again
+func (s DefaultExportService) removeFromOutbox(exportPath string) {
+	if s.Outbox == nil {
+		return
+	}
+	if err := s.Outbox.Remove(repositories.SlotFromPath(exportPath)); err != nil {
+		logger.Error("Error removing playlist from the outbox", err)
+	}
+	s.updateOutboxMetrics()
+}
+
+// retryDelay is the exponential backoff before the next attempt, doubling from the minimum up to the maximum interval
+func (s DefaultExportService) retryDelay(attempts int) time.Duration {
+	delay := time.Duration(s.Cfg.Export.RetryMinSec) * time.Second
+	maxDelay := time.Duration(s.Cfg.Export.RetryMaxSec) * time.Second
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// RetryOutbox retries all appends in the outbox that are due. Entries that can't be delivered before their deadline are marked as failed
+func (s DefaultExportService) RetryOutbox() error {
+	return s.RetryOutboxContext(context.Background())
+}
+
+func (s DefaultExportService) RetryOutboxContext(ctx context.Context) (err error) {
+	if s.Outbox == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	due := s.Outbox.Due(s.Now())
+	if len(due) > 0 {
+		start := s.Now()
+		defer func() {
+			recordRunMetrics(s.State, "outbox", start, err)
+		}()
+	}
+	for _, entry := range due {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errors.Join(err, ctxErr)
+		}
+		err = errors.Join(err, s.retryEntry(ctx, entry))
+	}
+	if pruneErr := s.Outbox.Prune(s.Now().Add(-outboxKeepFailed)); pruneErr != nil {
+		logger.Error("Error pruning the outbox", pruneErr)
+	}
+	s.updateOutboxMetrics()
+	return err
+}
+
+// retryEntry makes one attempt to append the playlist of an outbox entry to the instances it hasn't been delivered to.
+// A successful append removes the entry
+func (s DefaultExportService) retryEntry(ctx context.Context, entry domain.OutboxEntry) error {
+	now := s.Now()
+	if !now.Before(entry.Deadline) {
+		entry.Status = domain.OutboxFailed
+		logger.Errorf("Giving up appending playlist %v after %v attempts, deadline has passed: %v", entry.Slot, entry.Attempts, entry.LastError)
+		return errors.Join(s.Outbox.Update(entry), fmt.Errorf("deadline for appending playlist %v has passed", entry.Slot))
+	}
+	delivered, appendErr := s.appendPlaylist(ctx, entry.FileName, entry.Delivered)
+	entry.Delivered = delivered
+	entry.Attempts++
+	entry.LastAttempt = now
+	if appendErr == nil {
+		logger.Infof("Appended playlist %v after %v attempts", entry.Slot, entry.Attempts)
+		return nil
+	}
+	entry.LastError = appendErr.Error()
+	entry.NextAttempt = now.Add(s.retryDelay(entry.Attempts))
+	if !entry.NextAttempt.Before(entry.Deadline) {
+		entry.Status = domain.OutboxFailed
+		logger.Errorf("Giving up appending playlist %v after %v attempts, next attempt would be after the deadline: %v", entry.Slot, entry.Attempts, appendErr)
+	}
+	return errors.Join(s.Outbox.Update(entry), appendErr)
+}
+
+// updateOutboxMetrics sets the number of pending and failed appends
+func (s DefaultExportService) updateOutboxMetrics() {
+	counts := map[domain.OutboxStatus]int{
+		domain.OutboxPending: 0,
+		domain.OutboxFailed:  0,
+	}
+	for _, entry := range s.Outbox.GetAll() {
+		counts[entry.Status]++
+	}
+	for status, count := range counts {
+		s.State.Metrics.SetOutboxEntries(string(status), float64(count))
+	}
+}
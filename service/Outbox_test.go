@@ -0,0 +1,160 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupOutboxTest exports a playlist for 20:00 at 19:57 while mAirList refuses to append it
+func setupOutboxTest(t *testing.T) (*mairlisttest.Server, *repositories.DefaultOutboxRepository, *time.Time) {
+	t.Helper()
+	srv := mairlisttest.NewServer(6)
+	t.Cleanup(srv.Close)
+	srv.FailWith("\"error\"")
+	cfg.Export.MairListUrl = srv.URL
+	cfg.Export.MairListUser = "user"
+	cfg.Export.MairListPassword = "pass"
+	cfg.Export.AppendPlaylist = true
+	cfg.Misc.OutboxSaveFile = ""
+	outboxRepo := repositories.NewOutboxRepository(&cfg)
+	exportService.Outbox = &outboxRepo
+	now := dayDate.Add(19*time.Hour + 57*time.Minute)
+	exportService.Now = func() time.Time { return now }
+	storeDayFile(t, dayDate, "A.mp3", 20, 0, time.Hour)
+
+	err := exportService.ExportForDateAndHour(dayDate, "20")
+
+	require.Error(t, err)
+	return srv, &outboxRepo, &now
+}
+
+func TestFailedAppendIsPutIntoOutbox(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	_, outboxRepo, now := setupOutboxTest(t)
+
+	entries := outboxRepo.GetAll()
+
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, "2026-10-19-20", entries[0].Slot)
+	assert.EqualValues(t, domain.OutboxPending, entries[0].Status)
+	assert.EqualValues(t, 1, entries[0].Attempts)
+	assert.EqualValues(t, now.Add(15*time.Second), entries[0].NextAttempt)
+	assert.EqualValues(t, dayDate.Add(20*time.Hour+10*time.Minute), entries[0].Deadline)
+	assert.EqualValues(t, "\"error\"", entries[0].LastError)
+}
+
+func TestRetryOutboxBacksOffWhileAppendFails(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv, outboxRepo, now := setupOutboxTest(t)
+
+	require.NoError(t, exportService.RetryOutbox())
+	*now = now.Add(15 * time.Second)
+	err := exportService.RetryOutbox()
+
+	assert.Error(t, err)
+	assert.Len(t, srv.Commands(), 2)
+	entries := outboxRepo.GetAll()
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, 2, entries[0].Attempts)
+	assert.EqualValues(t, now.Add(30*time.Second), entries[0].NextAttempt)
+}
+
+func TestRetryOutboxDeliversAndRemovesEntry(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv, outboxRepo, now := setupOutboxTest(t)
+	srv.FailWith("")
+	*now = now.Add(time.Minute)
+
+	err := exportService.RetryOutbox()
+
+	assert.NoError(t, err)
+	assert.Len(t, srv.Commands(), 2)
+	assert.Empty(t, outboxRepo.GetAll())
+	require.NoError(t, exportService.RetryOutbox())
+	assert.Len(t, srv.Commands(), 2)
+}
+
+func TestRetryOutboxAfterDeadlineMarksEntryAsFailed(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv, outboxRepo, now := setupOutboxTest(t)
+	srv.FailWith("")
+	*now = dayDate.Add(20*time.Hour + 10*time.Minute)
+
+	err := exportService.RetryOutbox()
+
+	assert.EqualError(t, err, "deadline for appending playlist 2026-10-19-20 has passed")
+	assert.Len(t, srv.Commands(), 1)
+	entries := outboxRepo.GetAll()
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, domain.OutboxFailed, entries[0].Status)
+}
+
+func TestSuccessfulExportRemovesOutboxEntry(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv, outboxRepo, now := setupOutboxTest(t)
+	srv.FailWith("")
+
+	require.NoError(t, exportService.ExportForDateAndHour(dayDate, "20"))
+	*now = now.Add(time.Minute)
+	require.NoError(t, exportService.RetryOutbox())
+
+	assert.Empty(t, outboxRepo.GetAll())
+	assert.Len(t, srv.Commands(), 2)
+}
+
+func TestFailedAppendOfPastSlotIsNotRetried(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	_, outboxRepo, now := setupOutboxTest(t)
+	outboxRepo.Remove("2026-10-19-20")
+	*now = dayDate.Add(22 * time.Hour)
+
+	err := exportService.ExportForDateAndHour(dayDate, "20")
+
+	assert.Error(t, err)
+	assert.Empty(t, outboxRepo.GetAll())
+}
+
+func TestRetryDelayIsCappedAtMaximum(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+
+	assert.EqualValues(t, 15*time.Second, exportService.retryDelay(1))
+	assert.EqualValues(t, 60*time.Second, exportService.retryDelay(3))
+	assert.EqualValues(t, 300*time.Second, exportService.retryDelay(10))
+}
+
+func TestRetryOutboxAppendsOnlyToFailedTargets(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	primary, mirror, _ := setupTargets(t, false)
+	primary.FailWith("\"error\"")
+	cfg.Export.AppendPlaylist = true
+	cfg.Misc.OutboxSaveFile = ""
+	outboxRepo := repositories.NewOutboxRepository(&cfg)
+	exportService.Outbox = &outboxRepo
+	now := dayDate.Add(19*time.Hour + 57*time.Minute)
+	exportService.Now = func() time.Time { return now }
+	storeDayFile(t, dayDate, "A.mp3", 20, 0, time.Hour)
+	require.Error(t, exportService.ExportForDateAndHour(dayDate, "20"))
+	primary.FailWith("")
+	now = now.Add(time.Minute)
+
+	err := exportService.RetryOutbox()
+
+	assert.NoError(t, err)
+	assert.Len(t, primary.Commands(), 2)
+	assert.Len(t, mirror.Commands(), 1)
+	assert.Empty(t, outboxRepo.GetAll())
+}
@@ -0,0 +1,115 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// overrideFor returns the newest override of the given type for a file that applies to the file's date, nil if there is none
+func (s DefaultExportService) overrideFor(file domain.FileInfo, overrideType domain.OverrideType) *domain.Override {
+	if s.Overrides == nil {
+		return nil
+	}
+	var found *domain.Override
+	for _, o := range s.Overrides.ForPath(file.Path) {
+		if o.Type == overrideType && o.AppliesToDate(file.FolderDate) {
+			found = &o
+		}
+	}
+	return found
+}
+
+// pinnedElsewhere returns the pin of a file, if the file is pinned to a slot other than its own on the file's date.
+// Pins for other dates don't affect the file's own broadcast
+func (s DefaultExportService) pinnedElsewhere(file domain.FileInfo) *domain.Override {
+	if s.Overrides == nil {
+		return nil
+	}
+	for _, o := range s.Overrides.ForPath(file.Path) {
+		if o.Type == domain.OverridePin && o.AppliesToDate(file.FolderDate) && !isPinnedSlot(o, file) {
+			return &o
+		}
+	}
+	return nil
+}
+
+// isPinnedSlot returns true, if the file is scheduled exactly in the slot it is pinned to
+func isPinnedSlot(o domain.Override, file domain.FileInfo) bool {
+	return o.AppliesToDate(file.FolderDate) && o.StartTime == createIndexFromTime(file.StartTime)
+}
+
+// isPinned returns true, if the file is scheduled in a slot it is pinned to
+func (s DefaultExportService) isPinned(file domain.FileInfo) bool {
+	if s.Overrides == nil {
+		return false
+	}
+	for _, o := range s.Overrides.ForPath(file.Path) {
+		if o.Type == domain.OverridePin && isPinnedSlot(o, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// pinnedFiles returns the files pinned to slots within the given date and hour, scheduled to their pinned slot
+func (s DefaultExportService) pinnedFiles(folderDate time.Time, hour string) domain.FileList {
+	var files domain.FileList
+	if s.Overrides == nil {
+		return nil
+	}
+	for _, o := range s.Overrides.PinsForHour(folderDate, hour) {
+		file := s.Repo.GetByPath(o.Path)
+		if file == nil {
+			logger.Warnf("File %v pinned to %v %v is not known. Ignoring pin.", o.Path, domain.FormatFolderDate(folderDate), o.StartTime)
+			continue
+		}
+		pinned := *file
+		start, _ := time.Parse("15:04", o.StartTime)
+		pinned.StartTime = helper.TimeFromHourAndMinute(start.Hour(), start.Minute())
+		pinned.EndTime = time.Time{}
+		pinned.FolderDate = folderDate
+		pinned.EventIsLive = false
+		files = append(files, pinned)
+	}
+	return files
+}
+
+// applyReplacement substitutes the file of an event with the replacement file given by an override.
+// Returns the original file and false, if there is no replacement
+func (s DefaultExportService) applyReplacement(file domain.FileInfo, folderDate time.Time) (domain.FileInfo, bool) {
+	if s.Overrides == nil || file.EventId == 0 {
+		return file, false
+	}
+	replacements := s.Overrides.ForEvent(file.EventId, folderDate)
+	if len(replacements) == 0 {
+		return file, false
+	}
+	o := replacements[len(replacements)-1]
+	replacement := s.Repo.GetByPath(o.Path)
+	if replacement == nil {
+		logger.Warnf("Replacement file %v for event %v is not known. Keeping file %v.", o.Path, file.EventId, file.Path)
+		return file, false
+	}
+	replaced := file
+	replaced.Path = replacement.Path
+	replaced.Duration = replacement.Duration
+	replaced.ModTime = replacement.ModTime
+	replaced.FileType = replacement.FileType
+	replaced.StreamId = replacement.StreamId
+	replaced.StreamName = replacement.StreamName
+	replaced.EventIsLive = false
+	return replaced, true
+}
+
+// overrideSlotLength returns the slot length of a file force-accepted by an override.
+// Uses the planned duration if available, the rounded actual duration otherwise
+func overrideSlotLength(file domain.FileInfo) time.Duration {
+	if !file.EndTime.IsZero() && file.EndTime.After(file.StartTime) {
+		return file.EndTime.Sub(file.StartTime)
+	}
+	return file.Duration.Round(time.Minute)
+}
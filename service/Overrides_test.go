@@ -0,0 +1,131 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOverrides(t *testing.T, overrides ...domain.Override) {
+	t.Helper()
+	cfg.Misc.OverrideSaveFile = filepath.Join(t.TempDir(), "overrides.dta")
+	overrideRepo := repositories.NewOverrideRepository(&cfg)
+	for _, o := range overrides {
+		_, err := overrideRepo.Store(o)
+		require.NoError(t, err)
+	}
+	exportService.Overrides = &overrideRepo
+}
+
+func rejectionReasons(rejected []rejectedFile) map[string]string {
+	reasons := make(map[string]string)
+	for _, r := range rejected {
+		reasons[r.file.Path] = r.reason
+	}
+	return reasons
+}
+
+func TestPlanExcludedFileIsRejected(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "A.mp3", 20, 0, time.Hour)
+	setupOverrides(t, domain.Override{Type: domain.OverrideExclude, Path: "A.mp3", Note: "broken"})
+
+	plan, rejected, _ := exportService.planForDateAndHour(dayDate, "20")
+
+	assert.Empty(t, plan)
+	assert.EqualValues(t, "Excluded by override. broken", rejectionReasons(rejected)["A.mp3"])
+}
+
+func TestPlanAcceptedFileSkipsLengthCheck(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "short.mp3", 20, 0, 10*time.Minute)
+	setupOverrides(t, domain.Override{Type: domain.OverrideAccept, Path: "short.mp3", FolderDate: dayDate, Note: "short show"})
+
+	plan, _, _ := exportService.planForDateAndHour(dayDate, "20")
+
+	require.Contains(t, plan, "20:00")
+	assert.EqualValues(t, 10*time.Minute, plan["20:00"].SlotLength)
+}
+
+func TestPlanAcceptOverrideForOtherDateIsIgnored(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "short.mp3", 20, 0, 10*time.Minute)
+	setupOverrides(t, domain.Override{Type: domain.OverrideAccept, Path: "short.mp3", FolderDate: dayDate.AddDate(0, 0, 1), Note: "short show"})
+
+	plan, _, _ := exportService.planForDateAndHour(dayDate, "20")
+
+	assert.Empty(t, plan)
+}
+
+func TestPlanPinnedFileMovesToSlotAndWinsConflict(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "scheduled.mp3", 20, 0, time.Hour)
+	storeDayFile(t, dayDate, "pinned.mp3", 8, 0, time.Hour)
+	setupOverrides(t, domain.Override{Type: domain.OverridePin, Path: "pinned.mp3", FolderDate: dayDate, StartTime: "20:00", Note: "swap"})
+
+	eveningPlan, eveningRejected, _ := exportService.planForDateAndHour(dayDate, "20")
+	morningPlan, morningRejected, _ := exportService.planForDateAndHour(dayDate, "08")
+
+	assert.EqualValues(t, "pinned.mp3", eveningPlan["20:00"].Path)
+	assert.EqualValues(t, helper.TimeFromHourAndMinute(20, 0), eveningPlan["20:00"].StartTime)
+	assert.EqualValues(t, "Superseded by pinned file pinned.mp3", rejectionReasons(eveningRejected)["scheduled.mp3"])
+	assert.Empty(t, morningPlan)
+	assert.EqualValues(t, "Pinned to 2026-10-19 20:00 (swap)", rejectionReasons(morningRejected)["pinned.mp3"])
+}
+
+func TestPlanFilePinnedToNextDayKeepsOwnSlot(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "repeat.mp3", 8, 0, time.Hour)
+	setupOverrides(t, domain.Override{Type: domain.OverridePin, Path: "repeat.mp3", FolderDate: dayDate.AddDate(0, 0, 1), StartTime: "20:00", Note: "repeat"})
+
+	morningPlan, _, _ := exportService.planForDateAndHour(dayDate, "08")
+	nextDayPlan, _, _ := exportService.planForDateAndHour(dayDate.AddDate(0, 0, 1), "20")
+
+	assert.EqualValues(t, "repeat.mp3", morningPlan["08:00"].Path)
+	assert.EqualValues(t, "repeat.mp3", nextDayPlan["20:00"].Path)
+}
+
+func TestPlanReplaceOverrideSubstitutesEventFile(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	require.NoError(t, fileRepo.Store(domain.FileInfo{
+		Path:       "original.mp3",
+		Duration:   time.Hour,
+		StartTime:  helper.TimeFromHourAndMinute(20, 0),
+		EndTime:    helper.TimeFromHourAndMinute(21, 0),
+		FolderDate: dayDate,
+		EventId:    42,
+		FileType:   domain.FileTypeAudio,
+	}))
+	storeDayFile(t, dayDate.AddDate(0, 0, -7), "rerun.mp3", 20, 0, 59*time.Minute)
+	setupOverrides(t, domain.Override{Type: domain.OverrideReplace, Path: "rerun.mp3", FolderDate: dayDate, EventId: 42, Note: "host ill"})
+
+	plan, rejected, _ := exportService.planForDateAndHour(dayDate, "20")
+
+	assert.EqualValues(t, "rerun.mp3", plan["20:00"].Path)
+	assert.EqualValues(t, 59*time.Minute, plan["20:00"].Duration)
+	assert.EqualValues(t, "Replaced by override with file rerun.mp3", rejectionReasons(rejected)["original.mp3"])
+}
+
+func TestPlanWithoutOverridesIsUnchanged(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	storeDayFile(t, dayDate, "A.mp3", 20, 0, time.Hour)
+
+	plan, rejected, found := exportService.planForDateAndHour(dayDate, "20")
+
+	assert.True(t, found)
+	assert.Empty(t, rejected)
+	assert.EqualValues(t, "A.mp3", plan["20:00"].Path)
+}
@@ -0,0 +1,129 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+)
+
+const (
+	PunctualityBySeries   = "series"
+	PunctualityByProducer = "producer"
+	unknownProducer       = "(unknown)"
+)
+
+// UploadDeadline returns how long before air the files of preproduced events are due
+func (s DefaultArchiveService) UploadDeadline() time.Duration {
+	return time.Duration(s.Cfg.Archive.UploadDeadlineHours) * time.Hour
+}
+
+// PunctualityReport evaluates the uploads of the archived preproduced events from the first to the last date, in total
+// and per week starting on Monday. Events are grouped by series or by producer, the producers being the addresses
+// reminders went to when the event was archived. Events archived without producer use the reminder address file
+func (s DefaultArchiveService) PunctualityReport(first time.Time, last time.Time, by string) (dto.PunctualityReport, error) {
+	records, err := s.Records(first, last)
+	if err != nil {
+		return dto.PunctualityReport{}, err
+	}
+	var addresses map[string][]string
+	if by == PunctualityByProducer {
+		if addresses, err = loadReminderAddresses(s.Cfg.Reminder.AddressFile); err != nil {
+			return dto.PunctualityReport{}, err
+		}
+	}
+	deadline := s.UploadDeadline()
+	firstMonday := domain.NormalizeDate(first)
+	firstMonday = firstMonday.AddDate(0, 0, -(int(firstMonday.Weekday())+6)%7)
+	report := dto.PunctualityReport{
+		From:          domain.FormatFolderDate(first),
+		To:            domain.FormatFolderDate(last),
+		By:            by,
+		DeadlineHours: s.Cfg.Archive.UploadDeadlineHours,
+	}
+	for week := firstMonday; !week.After(last); week = week.AddDate(0, 0, 7) {
+		report.Weeks = append(report.Weeks, domain.FormatFolderDate(week))
+	}
+	total := newPunctualitySums("Total", len(report.Weeks))
+	rows := make(map[string]*punctualitySums)
+	for _, record := range records {
+		punctuality := record.Punctuality(deadline)
+		if punctuality == "" {
+			continue
+		}
+		date, err := domain.ParseFolderDate(record.Date)
+		if err != nil {
+			continue
+		}
+		week := int(math.Round(date.Sub(firstMonday).Hours()/24)) / 7
+		name := punctualityName(record, by, addresses)
+		row, found := rows[name]
+		if !found {
+			row = newPunctualitySums(name, len(report.Weeks))
+			rows[name] = row
+		}
+		row.add(record, punctuality, week)
+		total.add(record, punctuality, week)
+		if punctuality == domain.PunctualityLate || punctuality == domain.PunctualityMissing {
+			report.Late = append(report.Late, dto.NewHistoryEvent(record, deadline))
+		}
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, row.result())
+	}
+	slices.SortFunc(report.Rows, func(a dto.PunctualityRow, b dto.PunctualityRow) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	report.Total = total.result()
+	return report, nil
+}
+
+type punctualitySums struct {
+	row       dto.PunctualityRow
+	leadTotal time.Duration
+	leadCount int
+}
+
+func newPunctualitySums(name string, weeks int) *punctualitySums {
+	return &punctualitySums{row: dto.PunctualityRow{Name: name, Weeks: make([]dto.PunctualityCount, weeks)}}
+}
+
+func (p *punctualitySums) add(record domain.EventRecord, punctuality string, week int) {
+	p.row.Total.Add(punctuality)
+	if week >= 0 && week < len(p.row.Weeks) {
+		p.row.Weeks[week].Add(punctuality)
+	}
+	if lead, known := record.UploadLead(); known && record.HasFile() {
+		p.leadTotal += lead
+		p.leadCount++
+	}
+}
+
+func (p *punctualitySums) result() dto.PunctualityRow {
+	p.row.AvgLeadHours = "N/A"
+	if p.leadCount > 0 {
+		hours := (p.leadTotal / time.Duration(p.leadCount)).Hours()
+		p.row.AvgLeadHours = strconv.FormatFloat(math.Round(hours*10)/10, 'f', 1, 64)
+	}
+	return p.row
+}
+
+// punctualityName is a helper function returning the series or the producer an event is counted for
+func punctualityName(record domain.EventRecord, by string, addresses map[string][]string) string {
+	if by == PunctualityByProducer {
+		if len(record.Producer) > 0 {
+			return strings.Join(record.Producer, ", ")
+		}
+		if to, _ := mappedAddresses(addresses, strconv.Itoa(record.EventId), record.Series, record.Title); len(to) > 0 {
+			return strings.Join(to, ", ")
+		}
+		return unknownProducer
+	}
+	return recordSeries(record)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// punctualityRecords returns preproduced events of the week from 2024-09-23: Jazz on time on Tuesday and late on Wednesday,
+// Rock missing on Tuesday and a live event on Wednesday. The following Tuesday Jazz is on time again
+func punctualityRecords() map[string][]domain.EventRecord {
+	return map[string][]domain.EventRecord{
+		"2024-09-24": {archiveRecord(1, "Jazz", false, "Present", 30*time.Hour), archiveRecord(3, "Rock", false, "Missing", 0)},
+		"2024-09-25": {laterRecord(archiveRecord(2, "Jazz", false, "Present", 3*time.Hour), 1), laterRecord(archiveRecord(4, "Talk", true, "N/A", 0), 1)},
+		"2024-10-01": {laterRecord(archiveRecord(5, "Jazz", false, "Present", 48*time.Hour), 7)},
+	}
+}
+
+// laterRecord moves an archived event by the given number of days
+func laterRecord(record domain.EventRecord, days int) domain.EventRecord {
+	record.Start = record.Start.AddDate(0, 0, days)
+	record.End = record.End.AddDate(0, 0, days)
+	record.Date = domain.FormatFolderDate(record.Start)
+	if !record.FileModified.IsZero() {
+		record.FileModified = record.FileModified.AddDate(0, 0, days)
+	}
+	return record
+}
+
+func setupPunctuality(t *testing.T) DefaultArchiveService {
+	t.Helper()
+	archive, repo := setupArchive(t, fakeEventRecords{})
+	archive.Cfg.Archive.UploadDeadlineHours = 24
+	for day, records := range punctualityRecords() {
+		require.NoError(t, repo.Store(day, records))
+	}
+	return archive
+}
+
+func TestPunctualityReportBySeriesCountsPerWeek(t *testing.T) {
+	archive := setupPunctuality(t)
+
+	report, err := archive.PunctualityReport(domain.MustParseFolderDate("2024-09-24"), domain.MustParseFolderDate("2024-10-02"), PunctualityBySeries)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"2024-09-23", "2024-09-30"}, report.Weeks)
+	require.Len(t, report.Rows, 2)
+	jazz := report.Rows[0]
+	assert.EqualValues(t, "Jazz", jazz.Name)
+	assert.EqualValues(t, 3, jazz.Total.Events)
+	assert.EqualValues(t, 2, jazz.Total.OnTime)
+	assert.EqualValues(t, 1, jazz.Total.Late)
+	assert.EqualValues(t, "66.7 %", jazz.Total.OnTimeRate())
+	assert.EqualValues(t, "27.0", jazz.AvgLeadHours)
+	assert.EqualValues(t, 1, jazz.Weeks[0].Late)
+	assert.EqualValues(t, 1, jazz.Weeks[1].OnTime)
+	assert.EqualValues(t, "Rock", report.Rows[1].Name)
+	assert.EqualValues(t, 1, report.Rows[1].Total.Missing)
+	assert.EqualValues(t, 4, report.Total.Total.Events)
+	require.Len(t, report.Late, 2)
+	assert.EqualValues(t, domain.PunctualityMissing, report.Late[0].Punctuality)
+	assert.EqualValues(t, domain.PunctualityLate, report.Late[1].Punctuality)
+}
+
+func TestPunctualityReportByProducerUsesAddressFile(t *testing.T) {
+	archive := setupPunctuality(t)
+	archive.Cfg.Reminder.AddressFile = filepath.Join(t.TempDir(), "addresses.txt")
+	require.NoError(t, os.WriteFile(archive.Cfg.Reminder.AddressFile, []byte("jazz=jazz@example.org\n"), 0644))
+
+	report, err := archive.PunctualityReport(domain.MustParseFolderDate("2024-09-23"), domain.MustParseFolderDate("2024-09-29"), PunctualityByProducer)
+
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 2)
+	assert.EqualValues(t, "(unknown)", report.Rows[0].Name)
+	assert.EqualValues(t, 1, report.Rows[0].Total.Missing)
+	assert.EqualValues(t, "jazz@example.org", report.Rows[1].Name)
+	assert.EqualValues(t, 2, report.Rows[1].Total.Events)
+}
+
+func TestPunctualityReportByProducerPrefersArchivedProducer(t *testing.T) {
+	archive, repo := setupArchive(t, fakeEventRecords{})
+	archive.Cfg.Archive.UploadDeadlineHours = 24
+	record := archiveRecord(1, "Jazz", false, "Present", 30*time.Hour)
+	record.Producer = []string{"info@example.org"}
+	require.NoError(t, repo.Store("2024-09-24", []domain.EventRecord{record}))
+
+	report, err := archive.PunctualityReport(domain.MustParseFolderDate("2024-09-23"), domain.MustParseFolderDate("2024-09-29"), PunctualityByProducer)
+
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 1)
+	assert.EqualValues(t, "info@example.org", report.Rows[0].Name)
+}
+
+func TestArchiveDayCountsLateUploadsOnce(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	state := appstate.New()
+	metrics.InitMetrics(state, registry)
+	defer metrics.UnregisterMetrics(state, registry)
+	archive, _ := setupArchive(t, fakeEventRecords{records: []domain.EventRecord{
+		archiveRecord(1, "Jazz", false, "Present", 30*time.Hour),
+		archiveRecord(2, "Jazz", false, "Present", 3*time.Hour),
+		archiveRecord(3, "Rock", false, "Missing", 0),
+	}})
+	archive.State = state
+	archive.Cfg.Archive.UploadDeadlineHours = 24
+
+	_, err := archive.ArchiveDay(archiveDate)
+	require.NoError(t, err)
+	_, err = archive.ArchiveDay(archiveDate)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, map[string]float64{"Jazz late": 1, "Rock missing": 1}, lateUploads(t, registry))
+}
+
+// lateUploads returns the late upload counters by series and status
+func lateUploads(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	counters := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "coloradio_mairlistfeeder_late_uploads_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counters[labels["series"]+" "+labels["status"]] = metric.GetCounter().GetValue()
+		}
+	}
+	return counters
+}
@@ -0,0 +1,368 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// reconcileState keeps the last reconciliation report and the items already re-appended to mAirList
+// together with the end of their slot
+type reconcileState struct {
+	mu         sync.Mutex
+	last       dto.ReconcileReport
+	reappended map[string]time.Time
+}
+
+// expectedItem is an item of an exported playlist expected in mAirList's playlist
+type expectedItem struct {
+	slot  string
+	start time.Time
+	path  string
+}
+
+// matchedItem is an expected item together with the result of the comparison
+type matchedItem struct {
+	expectedItem
+	position int // position in mAirList's playlist, -1 if missing
+}
+
+func newReconcileState() *reconcileState {
+	return &reconcileState{reappended: make(map[string]time.Time)}
+}
+
+// Reconcile downloads mAirList's playlist and compares it with the exported playlists of the coming hours
+func (s DefaultExportService) Reconcile() (dto.ReconcileReport, error) {
+	return s.ReconcileContext(context.Background())
+}
+
+func (s DefaultExportService) ReconcileContext(ctx context.Context) (report dto.ReconcileReport, err error) {
+	start := s.Now()
+	defer func() {
+		recordRunMetrics(s.State, "reconcile", start, err)
+	}()
+	playlist, err := s.mairList().GetPlaylist(ctx)
+	if err != nil {
+		report = dto.ReconcileReport{Checked: s.Now().Format(dateFormat), Error: err.Error()}
+		s.storeReconcile(report)
+		return report, err
+	}
+	return s.reconcileWith(ctx, playlist), nil
+}
+
+// LastReconcile returns the report of the last reconciliation
+func (s DefaultExportService) LastReconcile() dto.ReconcileReport {
+	s.reconcile.mu.Lock()
+	defer s.reconcile.mu.Unlock()
+	return s.reconcile.last
+}
+
+func (s DefaultExportService) storeReconcile(report dto.ReconcileReport) {
+	s.reconcile.mu.Lock()
+	defer s.reconcile.mu.Unlock()
+	s.reconcile.last = report
+}
+
+// reconcileWith compares the given playlist with the exported items, updates the metrics and re-appends missing items if configured
+func (s DefaultExportService) reconcileWith(ctx context.Context, playlist mairlist.Playlist) dto.ReconcileReport {
+	now := s.Now()
+	s.forgetEndedSlots(now)
+	expected, slots := s.expectedItems(now)
+	matched, extra := compareWithPlaylist(expected, playlist, now)
+	report := dto.ReconcileReport{
+		Checked:  now.Format(dateFormat),
+		Slots:    slots,
+		Expected: len(expected),
+		Extra:    extra,
+	}
+	for _, item := range matched {
+		if item.position < 0 {
+			report.Missing = append(report.Missing, item.toDto())
+		} else {
+			report.Matched++
+		}
+	}
+	report.OutOfOrder = outOfOrder(matched)
+	previous := s.LastReconcile()
+	if s.Cfg.Export.ReconcileReappend && len(report.Missing) > 0 {
+		report.Reappended = s.reappendMissing(ctx, matched)
+	}
+	s.State.Metrics.SetReconcileItems("matched", float64(report.Matched))
+	s.State.Metrics.SetReconcileItems("missing", float64(len(report.Missing)))
+	s.State.Metrics.SetReconcileItems("extra", float64(len(report.Extra)))
+	s.State.Metrics.SetReconcileItems("outoforder", float64(len(report.OutOfOrder)))
+	// only differences which changed since the last check are logged, the report keeps the current state
+	if (len(report.Missing) > 0 || len(report.OutOfOrder) > 0) && !sameDifferences(previous, report) {
+		logger.Warnf("mAirList playlist differs from export: %v missing, %v extra, %v out of order", len(report.Missing), len(report.Extra), len(report.OutOfOrder))
+	}
+	s.storeReconcile(report)
+	return report
+}
+
+// sameDifferences returns true, if both reports list the same missing, extra and out-of-order items.
+// Positions are ignored, they move as mAirList plays the playlist
+func sameDifferences(a dto.ReconcileReport, b dto.ReconcileReport) bool {
+	sameItem := func(x dto.ReconcileItem, y dto.ReconcileItem) bool {
+		return x.Slot == y.Slot && x.StartTime == y.StartTime && x.Path == y.Path
+	}
+	return slices.EqualFunc(a.Missing, b.Missing, sameItem) && slices.EqualFunc(a.Extra, b.Extra, sameItem) && slices.EqualFunc(a.OutOfOrder, b.OutOfOrder, sameItem)
+}
+
+// expectedItems collects the items exported for the current and the coming hours.
+// Uses the versions appended to mAirList recorded in the export history, the current export plan if there is no history
+func (s DefaultExportService) expectedItems(now time.Time) ([]expectedItem, []string) {
+	var (
+		items []expectedItem
+		slots []string
+	)
+	hourStart := now.Truncate(time.Hour)
+	for h := range max(s.Cfg.Export.ReconcileHours, 1) {
+		slotStart := hourStart.Add(time.Duration(h) * time.Hour)
+		folderDate := domain.NormalizeDate(slotStart)
+		hour := fmt.Sprintf("%02d", slotStart.Hour())
+		exportPath, err := s.setExportPathForDate(folderDate, hour)
+		if err != nil {
+			continue
+		}
+		slot := repositories.SlotFromPath(exportPath)
+		slotItems, exported := s.exportedItems(slot, folderDate, slotStart, now)
+		if exported {
+			slots = append(slots, slot)
+			items = append(items, slotItems...)
+		}
+	}
+	return items, slots
+}
+
+// exportedItems returns the items of one slot. Returns false if the slot hasn't been exported to mAirList
+func (s DefaultExportService) exportedItems(slot string, folderDate time.Time, slotStart time.Time, now time.Time) ([]expectedItem, bool) {
+	if s.History != nil {
+		versions := s.History.GetVersions(slot)
+		for i := len(versions) - 1; i >= 0; i-- {
+			if !versions[i].Appended {
+				continue
+			}
+			content, err := s.History.ReadVersion(versions[i])
+			if err != nil {
+				logger.Errorf("Error reading playlist version %v of slot %v: %v", versions[i].Version, slot, err)
+				return nil, false
+			}
+			return playlistItems(slot, folderDate, content), true
+		}
+		return nil, false
+	}
+	// without history the current plan is used for the slots the regular export has already handled
+	if slotStart.After(now.Add(time.Hour)) {
+		return nil, false
+	}
+	plan, _, found := s.planForDateAndHour(folderDate, fmt.Sprintf("%02d", slotStart.Hour()))
+	if !found {
+		return nil, false
+	}
+	var items []expectedItem
+	for timeKey, file := range plan {
+		if file.FileType == domain.FileTypeStream {
+			continue
+		}
+		start, _ := time.Parse("15:04", timeKey)
+		items = append(items, expectedItem{
+			slot:  slot,
+			start: slotStart.Add(time.Duration(start.Minute()) * time.Minute),
+			path:  file.Path,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].start.Before(items[j].start)
+	})
+	return items, true
+}
+
+// playlistItems extracts the file items of an exported ".tpi" playlist
+func playlistItems(slot string, folderDate time.Time, content []byte) []expectedItem {
+	var items []expectedItem
+	for line := range strings.SplitSeq(string(content), "\n") {
+		fields := strings.Split(strings.TrimSuffix(line, "\r"), "\t")
+		if len(fields) != 4 || fields[2] != "F" {
+			continue
+		}
+		start, err := time.Parse("15:04:05", fields[0])
+		if err != nil {
+			continue
+		}
+		items = append(items, expectedItem{
+			slot:  slot,
+			start: time.Date(folderDate.Year(), folderDate.Month(), folderDate.Day(), start.Hour(), start.Minute(), start.Second(), 0, folderDate.Location()),
+			path:  fields[3],
+		})
+	}
+	return items
+}
+
+// compareWithPlaylist finds the expected items in mAirList's playlist. Items already played are only used for matching.
+// Expected items which should have started already are not reported as missing, since mAirList may have removed them after playback
+func compareWithPlaylist(expected []expectedItem, playlist mairlist.Playlist, now time.Time) (matched []matchedItem, extra []dto.ReconcileItem) {
+	used := make([]bool, len(playlist.Items))
+	for _, item := range expected {
+		position := -1
+		for i, plItem := range playlist.Items {
+			if !used[i] && playlistKey(plItem.Filename) == playlistKey(item.path) {
+				position = i
+				used[i] = true
+				break
+			}
+		}
+		if position < 0 && item.start.Before(now) {
+			continue
+		}
+		matched = append(matched, matchedItem{expectedItem: item, position: position})
+	}
+	for i, plItem := range playlist.Items {
+		if used[i] || plItem.State == "played" || plItem.Filename == "" || plItem.Class == "InfiniteSilence" {
+			continue
+		}
+		extra = append(extra, dto.ReconcileItem{StartTime: plItem.Time, Path: plItem.Filename, Position: i})
+	}
+	return matched, extra
+}
+
+// outOfOrder returns the items present in mAirList's playlist, but not in the exported order
+func outOfOrder(matched []matchedItem) []dto.ReconcileItem {
+	var (
+		present  []matchedItem
+		expected []string
+		actual   []string
+		items    []dto.ReconcileItem
+	)
+	for _, item := range matched {
+		if item.position >= 0 {
+			present = append(present, item)
+		}
+	}
+	for i := range present {
+		expected = append(expected, fmt.Sprint(i))
+	}
+	byPosition := make([]int, len(present))
+	for i := range byPosition {
+		byPosition[i] = i
+	}
+	sort.Slice(byPosition, func(i, j int) bool {
+		return present[byPosition[i]].position < present[byPosition[j]].position
+	})
+	for _, i := range byPosition {
+		actual = append(actual, fmt.Sprint(i))
+	}
+	i := 0
+	for _, line := range helper.DiffLines(expected, actual) {
+		switch line.Op {
+		case "-":
+			items = append(items, present[i].toDto())
+			i++
+		case " ":
+			i++
+		}
+	}
+	return items
+}
+
+// reappendMissing inserts missing items after the preceding item of the export found in mAirList's playlist,
+// before the following one if there is none. If no exported item is left in the playlist, missing items are appended.
+// Each item is re-appended only once, so items mAirList refuses are not sent again on every check
+func (s DefaultExportService) reappendMissing(ctx context.Context, matched []matchedItem) []dto.ReconcileItem {
+	var reappended []dto.ReconcileItem
+	client := s.mairList()
+	insertAt := make([]int, len(matched))
+	previous := -1
+	for i, item := range matched {
+		if item.position >= 0 {
+			previous = item.position + 1
+		}
+		insertAt[i] = previous
+	}
+	next := -1
+	for i := len(matched) - 1; i >= 0; i-- {
+		if matched[i].position >= 0 {
+			next = matched[i].position
+		}
+		if insertAt[i] < 0 {
+			insertAt[i] = next
+		}
+	}
+	// insert from the end, so positions taken from the unchanged playlist stay valid
+	for i := len(matched) - 1; i >= 0; i-- {
+		item := matched[i]
+		if item.position >= 0 || insertAt[i] < 0 || !s.markReappended(item) {
+			continue
+		}
+		if err := client.Insert(ctx, insertAt[i], item.path); err != nil {
+			logger.Errorf("Error re-appending %v to mAirList: %v", item.path, err)
+			continue
+		}
+		reappended = slices.Insert(reappended, 0, item.toDto())
+	}
+	for i, item := range matched {
+		if insertAt[i] >= 0 || !s.markReappended(item) {
+			continue
+		}
+		if err := client.Append(ctx, item.path); err != nil {
+			logger.Errorf("Error re-appending %v to mAirList: %v", item.path, err)
+			continue
+		}
+		reappended = append(reappended, item.toDto())
+	}
+	for _, item := range reappended {
+		logger.Infof("Re-appended missing item %v of slot %v to mAirList", item.Path, item.Slot)
+	}
+	return reappended
+}
+
+// markReappended records that an item is re-appended. Returns false if it has been re-appended before
+func (s DefaultExportService) markReappended(item matchedItem) bool {
+	key := item.slot + "|" + item.start.Format("15:04:05") + "|" + item.path
+	s.reconcile.mu.Lock()
+	defer s.reconcile.mu.Unlock()
+	if _, found := s.reconcile.reappended[key]; found {
+		return false
+	}
+	slotStart := time.Date(item.start.Year(), item.start.Month(), item.start.Day(), item.start.Hour(), 0, 0, 0, item.start.Location())
+	s.reconcile.reappended[key] = slotStart.Add(time.Hour)
+	return true
+}
+
+// forgetEndedSlots removes the re-appended items of slots which have ended, since they are no longer checked
+func (s DefaultExportService) forgetEndedSlots(now time.Time) {
+	s.reconcile.mu.Lock()
+	defer s.reconcile.mu.Unlock()
+	for key, slotEnd := range s.reconcile.reappended {
+		if !slotEnd.After(now) {
+			delete(s.reconcile.reappended, key)
+		}
+	}
+}
+
+func (item matchedItem) toDto() dto.ReconcileItem {
+	return dto.ReconcileItem{
+		Slot:      item.slot,
+		StartTime: item.start.Format("15:04:05"),
+		Path:      item.path,
+		Position:  item.position,
+	}
+}
+
+// playlistKey is a helper function returning the key used to match files with items in mAirList's playlist.
+// mAirList may see the files under a different drive or share, so only the file name is compared, ignoring case
+func playlistKey(filePath string) string {
+	return strings.ToLower(path.Base(strings.ReplaceAll(filePath, "\\", "/")))
+}
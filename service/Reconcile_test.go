@@ -0,0 +1,180 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mairlist/mairlisttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReconcile(t *testing.T, items ...mairlisttest.Item) *mairlisttest.Server {
+	t.Helper()
+	srv := mairlisttest.NewServer(6)
+	t.Cleanup(srv.Close)
+	srv.SetItems(items...)
+	exportService.Cfg.Export.MairListUrl = srv.URL
+	exportService.Cfg.Export.MairListVersion = 6
+	exportService.Cfg.Export.MairListPlaylist = 1
+	exportService.Cfg.Export.ReconcileHours = 2
+	exportService.Now = func() time.Time { return dayDate.Add(20*time.Hour + 10*time.Minute) }
+	storeDayFile(t, dayDate, "/audio/a.mp3", 20, 30, 30*time.Minute)
+	storeDayFile(t, dayDate, "/audio/b.mp3", 21, 0, time.Hour)
+	return srv
+}
+
+func paths(items []dto.ReconcileItem) []string {
+	var result []string
+	for _, item := range items {
+		result = append(result, item.Path)
+	}
+	return result
+}
+
+func TestReconcileReportsMissingAndExtraItems(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	setupReconcile(t,
+		mairlisttest.Item{Filename: "/audio/old.mp3", Class: "File", State: "played"},
+		mairlisttest.Item{Filename: "/audio/jingle.mp3", Class: "File", State: "playing"},
+		mairlisttest.Item{Filename: `D:\audio\B.MP3`, Class: "File", State: "none"},
+		mairlisttest.Item{Class: "InfiniteSilence", State: "none"},
+	)
+
+	report, err := exportService.Reconcile()
+
+	require.Nil(t, err)
+	assert.EqualValues(t, 2, report.Expected)
+	assert.EqualValues(t, 1, report.Matched)
+	assert.EqualValues(t, []string{"/audio/a.mp3"}, paths(report.Missing))
+	assert.EqualValues(t, -1, report.Missing[0].Position)
+	assert.EqualValues(t, "20:30:00", report.Missing[0].StartTime)
+	assert.EqualValues(t, []string{"/audio/jingle.mp3"}, paths(report.Extra))
+	assert.Empty(t, report.OutOfOrder)
+	assert.Empty(t, report.Reappended)
+	assert.EqualValues(t, report, exportService.LastReconcile())
+}
+
+func TestReconcileIgnoresMissingItemsAlreadyStarted(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	setupReconcile(t, mairlisttest.Item{Filename: "/audio/b.mp3", Class: "File", State: "none"})
+	exportService.Now = func() time.Time { return dayDate.Add(20*time.Hour + 40*time.Minute) }
+
+	report, err := exportService.Reconcile()
+
+	require.Nil(t, err)
+	assert.Empty(t, report.Missing)
+	assert.EqualValues(t, 1, report.Matched)
+}
+
+func TestReconcileReportsItemsOutOfOrder(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	setupReconcile(t,
+		mairlisttest.Item{Filename: "/audio/b.mp3", Class: "File", State: "none"},
+		mairlisttest.Item{Filename: "/audio/a.mp3", Class: "File", State: "none"},
+	)
+
+	report, err := exportService.Reconcile()
+
+	require.Nil(t, err)
+	assert.EqualValues(t, 2, report.Matched)
+	assert.Len(t, report.OutOfOrder, 1)
+	assert.Empty(t, report.Missing)
+}
+
+func TestReconcileReappendsMissingItemsOnce(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv := setupReconcile(t, mairlisttest.Item{Filename: "/audio/b.mp3", Class: "File", State: "none"})
+	exportService.Cfg.Export.ReconcileReappend = true
+
+	report, err := exportService.Reconcile()
+	srv.SetItems(mairlisttest.Item{Filename: "/audio/b.mp3", Class: "File", State: "none"})
+	_, errAgain := exportService.Reconcile()
+
+	require.Nil(t, err)
+	require.Nil(t, errAgain)
+	assert.EqualValues(t, []string{"/audio/a.mp3"}, paths(report.Reappended))
+	assert.EqualValues(t, []string{"PLAYLIST 1 INSERT 0 /audio/a.mp3"}, srv.Commands())
+}
+
+func TestReconcileForgetsReappendedItemsOfEndedSlots(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	setupReconcile(t, mairlisttest.Item{Filename: "/audio/b.mp3", Class: "File", State: "none"})
+	exportService.Cfg.Export.ReconcileReappend = true
+
+	_, err := exportService.Reconcile()
+	require.Nil(t, err)
+	require.Len(t, exportService.reconcile.reappended, 1)
+	exportService.Now = func() time.Time { return dayDate.Add(21*time.Hour + 5*time.Minute) }
+	_, err = exportService.Reconcile()
+
+	require.Nil(t, err)
+	assert.Empty(t, exportService.reconcile.reappended)
+}
+
+func TestReconcileAppendsMissingItemsWithoutExportedItemsInPlaylist(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	srv := setupReconcile(t)
+	exportService.Cfg.Export.ReconcileReappend = true
+
+	report, err := exportService.Reconcile()
+
+	require.Nil(t, err)
+	assert.Len(t, report.Reappended, 2)
+	assert.EqualValues(t, []string{"PLAYLIST 1 APPEND /audio/a.mp3", "PLAYLIST 1 APPEND /audio/b.mp3"}, srv.Commands())
+}
+
+func TestReconcileUnreachableMairListStoresError(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	cfg.Export.MairListUrl = ""
+
+	report, err := exportService.Reconcile()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, err.Error(), report.Error)
+	assert.EqualValues(t, report, exportService.LastReconcile())
+}
+
+func TestGetPlaylistDoesNotReconcile(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	setupReconcile(t, mairlisttest.Item{Filename: "/audio/b.mp3", Class: "File", State: "none"})
+	exportService.Cfg.Export.Reconcile = true
+
+	err := exportService.GetPlaylist()
+
+	require.Nil(t, err)
+	assert.Empty(t, exportService.LastReconcile().Checked)
+}
+
+func TestSameDifferencesComparesItems(t *testing.T) {
+	missing := dto.ReconcileReport{Checked: "a", Missing: []dto.ReconcileItem{{Slot: "2026-10-19-21", StartTime: "21:00:00", Path: "/audio/b.mp3", Position: -1}}}
+	sameLater := dto.ReconcileReport{Checked: "b", Missing: []dto.ReconcileItem{{Slot: "2026-10-19-21", StartTime: "21:00:00", Path: "/audio/b.mp3", Position: 3}}}
+
+	assert.True(t, sameDifferences(missing, sameLater))
+	assert.False(t, sameDifferences(missing, dto.ReconcileReport{}))
+}
+
+func TestPlaylistItemsReadsFileLines(t *testing.T) {
+	content := strings.Join([]string{
+		"# exported 2026-10-19 19:59:00",
+		"20:00:00\tH\tF\t/audio/a.mp3",
+		"20:30:00\tN\tS\thttp://stream",
+		"20:45:00\tN\tF\t/audio/b.mp3",
+	}, "\r\n")
+
+	items := playlistItems("2026-10-19-20", dayDate, []byte(content))
+
+	require.Len(t, items, 2)
+	assert.EqualValues(t, "/audio/a.mp3", items[0].path)
+	assert.EqualValues(t, dayDate.Add(20*time.Hour+45*time.Minute), items[1].start)
+}
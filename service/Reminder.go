@@ -0,0 +1,303 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/appstate"
+	"github.com/johannes-kuhfuss/mairlist-feeder/config"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mailer"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	reminderKeepDays    = 7
+	defaultReminderBody = `Hello,
+
+there is no file yet for "{{ .Title }}" on {{ .Date }} at {{ .Start }}, which is on air in {{ .HoursLeft }} hours.
+Please upload it as soon as possible.
+
+This is an automatic reminder from the mAirList feeder.
+`
+)
+
+// The reminder service emails the producers of preproduced events whose file wasn't uploaded yet at the configured lead times before air
+type DefaultReminderService struct {
+	Cfg    *config.AppConfig
+	State  *appstate.AppState
+	Events upcomingEvents
+	Repo   *repositories.DefaultFileRepository
+	Sent   repositories.ReminderRepository
+	Mailer mailSender
+	Now    func() time.Time
+	Reruns rerunFiles
+}
+
+type rerunFiles interface {
+	HasRerunFile(domain.CalCmsEvent) bool
+}
+
+type upcomingEvents interface {
+	GetUpcomingEventsContext(context.Context, int) ([]domain.CalCmsEvent, error)
+}
+
+type mailSender interface {
+	Send(context.Context, mailer.Message) error
+}
+
+// reminderData is passed to the subject and body templates
+type reminderData struct {
+	EventId   int
+	Title     string
+	Series    string
+	Project   string
+	Date      string
+	Start     string
+	End       string
+	LeadHours int
+	HoursLeft int
+}
+
+// NewReminderServiceWithState creates a new reminder service and injects its dependencies
+func NewReminderServiceWithState(cfg *config.AppConfig, state *appstate.AppState, events upcomingEvents, repo *repositories.DefaultFileRepository, sent repositories.ReminderRepository) DefaultReminderService {
+	return DefaultReminderService{
+		Cfg:    cfg,
+		State:  state,
+		Events: events,
+		Repo:   repo,
+		Sent:   sent,
+		Mailer: mailer.NewClient(cfg),
+		Now:    time.Now,
+	}
+}
+
+// Remind sends the reminders that are due for events without file
+func (s DefaultReminderService) Remind() error {
+	return s.RemindContext(context.Background())
+}
+
+func (s DefaultReminderService) RemindContext(ctx context.Context) (err error) {
+	start := s.Now()
+	defer func() {
+		recordRunMetrics(s.State, "reminder", start, err)
+	}()
+	now := s.Now()
+	maxLead := slices.Max(s.Cfg.Reminder.LeadHours)
+	events, err := s.Events.GetUpcomingEventsContext(ctx, (maxLead+23)/24)
+	if err != nil {
+		return err
+	}
+	addresses, err := loadReminderAddresses(s.Cfg.Reminder.AddressFile)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errors.Join(err, ctxErr)
+		}
+		err = errors.Join(err, s.remindEvent(ctx, now, event, addresses))
+	}
+	if pruneErr := s.Sent.Prune(now.AddDate(0, 0, -reminderKeepDays)); pruneErr != nil {
+		logger.Error("Error pruning sent reminders", pruneErr)
+	}
+	return err
+}
+
+// remindEvent sends the reminder for an event if it is preproduced, its file is missing and a lead time was reached that wasn't reminded of yet
+func (s DefaultReminderService) remindEvent(ctx context.Context, now time.Time, event domain.CalCmsEvent, addresses map[string][]string) error {
+	if event.Live != 0 {
+		return nil
+	}
+	eventStart, _, err := eventPeriod(event.StartDate, event.StartTime, event.EndTime)
+	if err != nil {
+		return err
+	}
+	leadHours, due := reminderLead(s.Cfg.Reminder.LeadHours, eventStart.Sub(now))
+	if !due || s.Sent.Sent(domain.ReminderKey(event.EventID, event.StartDate, leadHours)) {
+		return nil
+	}
+	if s.fileUploaded(event, eventStart) {
+		return nil
+	}
+	if s.Reruns != nil && s.Reruns.HasRerunFile(event) {
+		return nil
+	}
+	to := s.recipients(event, addresses)
+	if len(to) == 0 {
+		logger.Warnf("No address to remind of missing file for %v on %v %v", event.FullTitle, event.StartDate, event.StartTime)
+		return nil
+	}
+	data := reminderData{
+		EventId:   event.EventID,
+		Title:     event.FullTitle,
+		Series:    event.SeriesName,
+		Project:   event.ProjectTitle,
+		Date:      event.StartDate,
+		Start:     event.StartTime,
+		End:       event.EndTime,
+		LeadHours: leadHours,
+		HoursLeft: int(eventStart.Sub(now).Hours()),
+	}
+	msg, err := s.renderReminder(data, to)
+	if err != nil {
+		return err
+	}
+	if err := s.Mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("could not send reminder for %v: %w", event.FullTitle, err)
+	}
+	logger.Infof("Reminded %v of missing file for %v on %v %v", strings.Join(to, ", "), event.FullTitle, event.StartDate, event.StartTime)
+	return s.Sent.Add(domain.Reminder{
+		EventId:   event.EventID,
+		Title:     event.FullTitle,
+		Date:      event.StartDate,
+		Start:     event.StartTime,
+		LeadHours: leadHours,
+		To:        to,
+		Sent:      now,
+	})
+}
+
+// reminderLead is a helper function returning the shortest lead time not shorter than the time until air.
+// Lead times already passed when the service first sees an event are skipped, so producers get only the most urgent reminder
+func reminderLead(leadHours []int, untilAir time.Duration) (int, bool) {
+	if untilAir <= 0 {
+		return 0, false
+	}
+	lead, found := 0, false
+	for _, hours := range leadHours {
+		if untilAir <= time.Duration(hours)*time.Hour && (!found || hours < lead) {
+			lead, found = hours, true
+		}
+	}
+	return lead, found
+}
+
+// fileUploaded checks whether a file for the event is known or lies in the event's folder.
+// Files for days beyond the crawl are not in the file list, so the folder of the event date is searched as well
+func (s DefaultReminderService) fileUploaded(event domain.CalCmsEvent, eventStart time.Time) bool {
+	folderDate := domain.NormalizeDate(eventStart)
+	if len(s.Repo.GetByEventIdAndDate(event.EventID, folderDate)) > 0 {
+		return true
+	}
+	if s.Cfg.Crawl.RootFolder == "" {
+		return false
+	}
+	idTag := "-id" + strconv.Itoa(event.EventID) + "-"
+	startFolder := eventStart.Format("15-04")
+	found := false
+	folder := filepath.Join(s.Cfg.Crawl.RootFolder, helper.FolderForDate(folderDate))
+	filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || found {
+			return nil
+		}
+		if !slices.ContainsFunc(s.Cfg.Crawl.CrawlExtensions, func(ext string) bool { return strings.EqualFold(ext, filepath.Ext(path)) }) {
+			return nil
+		}
+		if strings.Contains(filepath.Base(path), idTag) || strings.HasPrefix(filepath.Base(filepath.Dir(path)), startFolder) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// recipients returns the addresses mapped to the event id, the series or the title, falling back to calCMS' project email if enabled
+func (s DefaultReminderService) recipients(event domain.CalCmsEvent, addresses map[string][]string) []string {
+	return eventRecipients(s.Cfg, event, addresses)
+}
+
+// eventRecipients is a helper function returning the producer addresses of an event, see recipients
+func eventRecipients(cfg *config.AppConfig, event domain.CalCmsEvent, addresses map[string][]string) []string {
+	if to, found := mappedAddresses(addresses, strconv.Itoa(event.EventID), event.SeriesName, event.FullTitle); found {
+		return to
+	}
+	if cfg.Reminder.ProjectEmail && event.ProjectEmail != "" {
+		return []string{event.ProjectEmail}
+	}
+	return nil
+}
+
+// renderReminder renders subject and body of a reminder
+func (s DefaultReminderService) renderReminder(data reminderData, to []string) (mailer.Message, error) {
+	subject, err := template.New("subject").Parse(s.Cfg.Reminder.Subject)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	var body *template.Template
+	if s.Cfg.Reminder.TemplateFile != "" {
+		body, err = template.ParseFiles(s.Cfg.Reminder.TemplateFile)
+	} else {
+		body, err = template.New("body").Parse(defaultReminderBody)
+	}
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	var subjectText, bodyText bytes.Buffer
+	if err := subject.Execute(&subjectText, data); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := body.Execute(&bodyText, data); err != nil {
+		return mailer.Message{}, err
+	}
+	return mailer.Message{To: to, Subject: subjectText.String(), Body: bodyText.String()}, nil
+}
+
+// mappedAddresses is a helper function returning the addresses mapped to the first of the keys found in the address file
+func mappedAddresses(addresses map[string][]string, keys ...string) ([]string, bool) {
+	for _, key := range keys {
+		if to, found := addresses[strings.ToLower(strings.TrimSpace(key))]; found && key != "" {
+			return to, true
+		}
+	}
+	return nil, false
+}
+
+// loadReminderAddresses reads the address mapping file. Each line has the form key=address[,address...], key being
+// an event id, a series name or an event title. Keys are case-insensitive, empty lines and lines starting with # are ignored.
+// The file is read on every run, so changes apply without restart
+func loadReminderAddresses(path string) (map[string][]string, error) {
+	addresses := make(map[string][]string)
+	if path == "" {
+		return addresses, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read reminder addresses: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("line %v of reminder addresses must have the form key=address", lineNo)
+		}
+		var to []string
+		for address := range strings.SplitSeq(value, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				to = append(to, address)
+			}
+		}
+		addresses[strings.ToLower(strings.TrimSpace(key))] = to
+	}
+	return addresses, scanner.Err()
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mailer"
+	"github.com/johannes-kuhfuss/mairlist-feeder/mailer/mailertest"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUpcomingEvents struct {
+	events []domain.CalCmsEvent
+}
+
+func (f fakeUpcomingEvents) GetUpcomingEventsContext(ctx context.Context, days int) ([]domain.CalCmsEvent, error) {
+	return f.events, nil
+}
+
+// setupReminderTest creates a reminder service sending to a fake SMTP server, with a preproduced event on dayDate from 20:00 to 21:00
+func setupReminderTest(t *testing.T) (DefaultReminderService, *time.Time, *mailertest.Server) {
+	t.Helper()
+	srv := mailertest.NewServer()
+	t.Cleanup(srv.Close)
+	cfg.Reminder.SmtpHost = srv.Host()
+	cfg.Reminder.SmtpPort = srv.Port()
+	cfg.Reminder.From = "feeder@coloradio.org"
+	cfg.Reminder.LeadHours = []int{48, 24, 3}
+	cfg.Reminder.Subject = "No file for {{ .Title }} on {{ .Date }} {{ .Start }}"
+	cfg.Reminder.AddressFile = filepath.Join(t.TempDir(), "addresses.txt")
+	cfg.Misc.ReminderSaveFile = filepath.Join(t.TempDir(), "reminders.dta")
+	cfg.Crawl.RootFolder = t.TempDir()
+	require.NoError(t, os.WriteFile(cfg.Reminder.AddressFile, []byte("# producers\nEvening Series = evening@example.org, second@example.org\n4711=special@example.org\n"), 0644))
+	events := fakeUpcomingEvents{events: []domain.CalCmsEvent{
+		{EventID: 1, FullTitle: "Evening Show", SeriesName: "Evening Series", StartDate: "2026-10-19", StartTime: "20:00", EndTime: "21:00"},
+		{EventID: 2, FullTitle: "Live Talk", SeriesName: "Evening Series", StartDate: "2026-10-19", StartTime: "21:00", EndTime: "22:00", Live: 1},
+	}}
+	sent := repositories.NewReminderRepository(&cfg)
+	reminderService := NewReminderServiceWithState(&cfg, stateEx, events, &fileRepo, &sent)
+	mail := mailer.NewClient(&cfg)
+	mail.Timeout = 5 * time.Second
+	reminderService.Mailer = mail
+	now := dayDate.Add(-10 * time.Hour)
+	reminderService.Now = func() time.Time { return now }
+	return reminderService, &now, srv
+}
+
+func TestRemindSendsMostUrgentReminderOnce(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reminderService, _, srv := setupReminderTest(t)
+
+	err1 := reminderService.Remind()
+	err2 := reminderService.Remind()
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	messages := srv.Messages()
+	require.Len(t, messages, 1)
+	assert.EqualValues(t, []string{"evening@example.org", "second@example.org"}, messages[0].To)
+	assert.EqualValues(t, "No file for Evening Show on 2026-10-19 20:00", messages[0].Header("Subject"))
+	assert.Contains(t, messages[0].Body(), "which is on air in 30 hours")
+	sent := reminderService.Sent.GetAll()
+	require.Len(t, sent, 1)
+	assert.EqualValues(t, 48, sent[0].LeadHours)
+}
+
+func TestRemindSendsNextReminderAtShorterLeadTime(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reminderService, now, srv := setupReminderTest(t)
+
+	reminderService.Remind()
+	*now = dayDate.Add(18 * time.Hour)
+	reminderService.Remind()
+	*now = dayDate.Add(18*time.Hour + 30*time.Minute)
+	reminderService.Remind()
+
+	require.Len(t, srv.Messages(), 2)
+	assert.True(t, reminderService.Sent.Sent(domain.ReminderKey(1, "2026-10-19", 3)))
+	assert.False(t, reminderService.Sent.Sent(domain.ReminderKey(1, "2026-10-19", 24)))
+}
+
+func TestRemindBeyondLeadTimesSendsNothing(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reminderService, now, srv := setupReminderTest(t)
+	*now = dayDate.Add(-30 * time.Hour)
+
+	err := reminderService.Remind()
+
+	require.NoError(t, err)
+	assert.Empty(t, srv.Messages())
+}
+
+func TestRemindUploadedFileSendsNothing(t *testing.T) {
+	tests := map[string]string{
+		"event id": "2026/10/19/evening-id1-show.mp3",
+		"folder":   "2026/10/19/20-00/show.mp3",
+	}
+	for name, path := range tests {
+		t.Run(name, func(t *testing.T) {
+			tearDown := setupTestEx()
+			defer tearDown()
+			reminderService, _, srv := setupReminderTest(t)
+			fullPath := filepath.Join(cfg.Crawl.RootFolder, path)
+			require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+			require.NoError(t, os.WriteFile(fullPath, []byte("audio"), 0644))
+
+			err := reminderService.Remind()
+
+			require.NoError(t, err)
+			assert.Empty(t, srv.Messages())
+		})
+	}
+}
+
+func TestRemindWithoutAddressSendsNothing(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reminderService, _, srv := setupReminderTest(t)
+	require.NoError(t, os.WriteFile(cfg.Reminder.AddressFile, nil, 0644))
+
+	err := reminderService.Remind()
+
+	require.NoError(t, err)
+	assert.Empty(t, srv.Messages())
+}
+
+func TestRemindFallsBackToProjectEmail(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reminderService, _, srv := setupReminderTest(t)
+	require.NoError(t, os.WriteFile(cfg.Reminder.AddressFile, nil, 0644))
+	cfg.Reminder.ProjectEmail = true
+	reminderService.Events = fakeUpcomingEvents{events: []domain.CalCmsEvent{
+		{EventID: 1, FullTitle: "Evening Show", ProjectEmail: "info@coloradio.org", StartDate: "2026-10-19", StartTime: "20:00", EndTime: "21:00"},
+	}}
+
+	err := reminderService.Remind()
+
+	require.NoError(t, err)
+	require.Len(t, srv.Messages(), 1)
+	assert.EqualValues(t, []string{"info@coloradio.org"}, srv.Messages()[0].To)
+}
+
+func TestRemindFailedDeliveryIsRetried(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	reminderService, _, srv := setupReminderTest(t)
+	srv.Reject("second@example.org")
+
+	err := reminderService.Remind()
+
+	require.Error(t, err)
+	assert.Empty(t, reminderService.Sent.GetAll())
+}
+
+func TestReminderLeadPicksShortestReachedLeadTime(t *testing.T) {
+	leadHours := []int{48, 24, 3}
+
+	lead1, due1 := reminderLead(leadHours, 30*time.Hour)
+	lead2, due2 := reminderLead(leadHours, 2*time.Hour)
+	_, due3 := reminderLead(leadHours, 49*time.Hour)
+	_, due4 := reminderLead(leadHours, -time.Minute)
+
+	assert.True(t, due1)
+	assert.EqualValues(t, 48, lead1)
+	assert.True(t, due2)
+	assert.EqualValues(t, 3, lead2)
+	assert.False(t, due3)
+	assert.False(t, due4)
+}
+
+func TestLoadReminderAddressesInvalidLineReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addresses.txt")
+	require.NoError(t, os.WriteFile(path, []byte("1=a@example.org\nno address\n"), 0644))
+
+	addresses, err := loadReminderAddresses(path)
+
+	require.Error(t, err)
+	assert.Nil(t, addresses)
+	assert.EqualValues(t, "line 2 of reminder addresses must have the form key=address", err.Error())
+}
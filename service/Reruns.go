@@ -0,0 +1,213 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// rerunLookupInterval is the time the result of looking up an original file in the history and the archive is reused
+const rerunLookupInterval = 15 * time.Minute
+
+const (
+	rerunSourceFileList = "file list"
+	rerunSourceHistory  = "history"
+	rerunSourceArchive  = "archive"
+)
+
+// safeRerunLookups caches the original files found in the history or the archive by original event id
+type safeRerunLookups struct {
+	sync.Mutex
+	lookups map[int]rerunLookup
+}
+
+type rerunLookup struct {
+	file   *domain.FileInfo // nil if no file was found
+	source string
+	looked time.Time
+}
+
+// rememberEventFile records the audio file of an event in the event file history for later reruns
+func (s DefaultCalCmsService) rememberEventFile(file domain.FileInfo) {
+	if s.EventFiles == nil || file.FileType != domain.FileTypeAudio || file.EventId == 0 || file.Duration == 0 {
+		return
+	}
+	now := s.Now()
+	if err := s.EventFiles.Store(domain.EventFile{
+		EventId:  file.EventId,
+		Path:     file.Path,
+		Duration: file.Duration,
+		Date:     domain.FormatFolderDate(file.FolderDate),
+		Title:    file.CalCmsTitle,
+		Recorded: now,
+	}); err != nil {
+		logger.Error("Error recording event file", err)
+	}
+	if err := s.EventFiles.Prune(now.AddDate(0, 0, -s.Cfg.Rerun.HistoryDays)); err != nil {
+		logger.Error("Error pruning event files", err)
+	}
+}
+
+// RerunFilesForHour returns the files of the original broadcasts for the reruns starting in the given date and hour.
+// Reruns with an own file in the file list are left out, the producer's upload takes precedence
+func (s DefaultCalCmsService) RerunFilesForHour(folderDate time.Time, hour string) domain.FileList {
+	var files domain.FileList
+	if !s.Cfg.Rerun.ScheduleReruns {
+		return nil
+	}
+	s.calCmsPgm.RLock()
+	events := append([]domain.CalCmsEvent(nil), s.calCmsPgm.data.Events...)
+	s.calCmsPgm.RUnlock()
+	day := domain.FormatFolderDate(folderDate)
+	for _, event := range events {
+		if event.StartDate != day || event.Live != 0 || !strings.HasPrefix(event.StartTime, hour) || s.excluded(event) {
+			continue
+		}
+		if file, _, ok := s.rerunFile(event); ok {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// HasRerunFile returns true, if the event is a rerun scheduled with the file of its original broadcast
+func (s DefaultCalCmsService) HasRerunFile(event domain.CalCmsEvent) bool {
+	if !s.Cfg.Rerun.ScheduleReruns {
+		return false
+	}
+	_, _, ok := s.rerunFile(event)
+	return ok
+}
+
+// rerunFile returns the file of the original broadcast scheduled to the rerun event and where it was found.
+// Returns false, if the event is not a rerun, has an own file or no original file was found
+func (s DefaultCalCmsService) rerunFile(event domain.CalCmsEvent) (domain.FileInfo, string, bool) {
+	originalId, isRerun := event.RerunOf()
+	if !isRerun {
+		return domain.FileInfo{}, "", false
+	}
+	eventDate, err := domain.ParseFolderDate(event.StartDate)
+	if err != nil || len(s.Repo.GetByEventIdAndDate(event.EventID, eventDate)) > 0 {
+		return domain.FileInfo{}, "", false
+	}
+	original, source, found := s.originalFile(originalId)
+	if !found {
+		return domain.FileInfo{}, "", false
+	}
+	entry, err := s.convertEventToEntry(event)
+	if err != nil {
+		return domain.FileInfo{}, "", false
+	}
+	rerun := original
+	rerun.FolderDate = eventDate
+	rerun.StartTime = entry.StartTime
+	rerun.EndTime = entry.EndTime
+	rerun.EventId = event.EventID
+	rerun.CalCmsTitle = event.FullTitle
+	rerun.FromCalCMS = true
+	rerun.CalCmsInfoExtracted = true
+	rerun.EventIsLive = false
+	rerun.MatchConfidence = 0
+	rerun.RuleMatched = fmt.Sprintf("rerun of event %v", originalId)
+	return rerun, source, true
+}
+
+// originalFile looks up the file of the original broadcast of a rerun, first in the file list, then in the event file history
+// and finally in the archive folder
+func (s DefaultCalCmsService) originalFile(originalId int) (domain.FileInfo, string, bool) {
+	var newest *domain.FileInfo
+	for _, file := range s.Repo.GetByEventId(originalId) {
+		if file.FileType == domain.FileTypeAudio && file.Duration > 0 && (newest == nil || file.ModTime.After(newest.ModTime)) {
+			newest = &file
+		}
+	}
+	if newest != nil {
+		return *newest, rerunSourceFileList, true
+	}
+	now := s.Now()
+	s.rerunLookups.Lock()
+	lookup, cached := s.rerunLookups.lookups[originalId]
+	s.rerunLookups.Unlock()
+	if !cached || now.Sub(lookup.looked) > rerunLookupInterval {
+		lookup = rerunLookup{looked: now}
+		lookup.file, lookup.source = s.lookupOriginalFile(originalId)
+		s.rerunLookups.Lock()
+		s.rerunLookups.lookups[originalId] = lookup
+		s.rerunLookups.Unlock()
+	}
+	if lookup.file == nil {
+		return domain.FileInfo{}, "", false
+	}
+	return *lookup.file, lookup.source, true
+}
+
+// lookupOriginalFile searches the event file history and the archive folder for the file of an event
+func (s DefaultCalCmsService) lookupOriginalFile(originalId int) (*domain.FileInfo, string) {
+	if s.EventFiles != nil {
+		if eventFile := s.EventFiles.Get(originalId); eventFile != nil {
+			if info, err := os.Stat(eventFile.Path); err == nil {
+				return &domain.FileInfo{
+					Path:        eventFile.Path,
+					ModTime:     info.ModTime(),
+					Duration:    eventFile.Duration,
+					FileType:    domain.FileTypeAudio,
+					CalCmsTitle: eventFile.Title,
+				}, rerunSourceHistory
+			}
+			logger.Warnf("File %v of event %v from the history no longer exists.", eventFile.Path, originalId)
+		}
+	}
+	if s.Cfg.Rerun.ArchiveFolder == "" {
+		return nil, ""
+	}
+	path, modTime := s.findInArchive(originalId)
+	if path == "" {
+		return nil, ""
+	}
+	techMd, err := analyzeTechMdWithRunner(path, s.Cfg.Crawl.FFprobeTimeout, s.Cfg.Crawl.FFprobePath, s.RunCmd)
+	if err != nil {
+		logger.Errorf("Could not analyze archived file %v of event %v: %v", path, originalId, err)
+		return nil, ""
+	}
+	file := domain.FileInfo{
+		Path:       path,
+		ModTime:    modTime,
+		Duration:   techMd.Duration,
+		FileType:   domain.FileTypeAudio,
+		BitRate:    techMd.BitRate,
+		FormatName: techMd.FormatName,
+	}
+	logger.Infof("Found file %v of event %v in the archive", path, originalId)
+	return &file, rerunSourceArchive
+}
+
+// findInArchive returns the newest audio file in the archive folder named with the event id ("-idNNN-")
+func (s DefaultCalCmsService) findInArchive(eventId int) (path string, modTime time.Time) {
+	marker := fmt.Sprintf("-id%d-", eventId)
+	err := filepath.WalkDir(s.Cfg.Rerun.ArchiveFolder, func(candidate string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if !strings.Contains(entry.Name(), marker) || !slices.Contains(s.Cfg.Crawl.AudioFileExtensions, strings.ToLower(filepath.Ext(candidate))) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err == nil && info.ModTime().After(modTime) {
+			path, modTime = candidate, info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error searching the rerun archive", err)
+	}
+	return path, modTime
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/johannes-kuhfuss/mairlist-feeder/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	originalDate = domain.MustParseFolderDate("2024-04-01")
+	rerunDate    = domain.MustParseFolderDate("2024-04-02")
+)
+
+type fakeReruns domain.FileList
+
+func (f fakeReruns) RerunFilesForHour(time.Time, string) domain.FileList {
+	return domain.FileList(f)
+}
+
+func setupReruns(rerun any) {
+	start := time.Date(2024, 4, 2, 10, 0, 0, 0, time.Local)
+	event := programEvent(domain.ScheduleEvent{Id: 20, Title: "Show (Wdh.)", Start: start, End: start.Add(time.Hour)})
+	event.Rerun = rerun
+	event.Recurrence = 10
+	calCmsService.insertData(domain.CalCmsPgmData{Events: []domain.CalCmsEvent{event}})
+	calCmsService.Cfg.Rerun.ScheduleReruns = true
+	calCmsService.Cfg.Rerun.ArchiveFolder = ""
+}
+
+func storeOriginalFile(path string) {
+	fileRepoCal.Store(domain.FileInfo{
+		Path:       path,
+		Duration:   59 * time.Minute,
+		StartTime:  helper.TimeFromHourAndMinuteAndDate(20, 0, originalDate),
+		FolderDate: originalDate,
+		EventId:    10,
+		FromCalCMS: true,
+		FileType:   domain.FileTypeAudio,
+	})
+}
+
+func TestRerunFilesForHourSchedulesOriginalFile(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupReruns(float64(1))
+	storeOriginalFile("/root/2024/04/01/20-00/show-id10-.mp3")
+
+	files := calCmsService.RerunFilesForHour(rerunDate, "10")
+
+	require.Len(t, files, 1)
+	assert.EqualValues(t, "/root/2024/04/01/20-00/show-id10-.mp3", files[0].Path)
+	assert.EqualValues(t, 20, files[0].EventId)
+	assert.EqualValues(t, time.Date(2024, 4, 2, 10, 0, 0, 0, time.Local), files[0].StartTime)
+	assert.EqualValues(t, rerunDate, files[0].FolderDate)
+	assert.EqualValues(t, 59*time.Minute, files[0].Duration)
+	assert.EqualValues(t, "rerun of event 10", files[0].RuleMatched)
+	assert.Empty(t, calCmsService.RerunFilesForHour(rerunDate, "11"))
+}
+
+func TestRerunFilesForHourWithOwnFileReturnsNothing(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupReruns(float64(1))
+	storeOriginalFile("/root/2024/04/01/20-00/show-id10-.mp3")
+	fileRepoCal.Store(domain.FileInfo{Path: "/root/2024/04/02/10-00/show-id20-.mp3", FolderDate: rerunDate, EventId: 20, FileType: domain.FileTypeAudio})
+
+	files := calCmsService.RerunFilesForHour(rerunDate, "10")
+
+	assert.Empty(t, files)
+}
+
+func TestRerunFilesForHourNoRerunReturnsNothing(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupReruns("")
+	storeOriginalFile("/root/2024/04/01/20-00/show-id10-.mp3")
+
+	files := calCmsService.RerunFilesForHour(rerunDate, "10")
+
+	assert.Empty(t, files)
+}
+
+func TestRerunFilesForHourUsesEventFileHistory(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupReruns(float64(1))
+	path := filepath.Join(t.TempDir(), "show-id10-.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("audio"), 0644))
+	cfgCal.Misc.EventFileSaveFile = filepath.Join(t.TempDir(), "eventfiles.dta")
+	eventFileRepo := repositories.NewEventFileRepository(&cfgCal)
+	calCmsService.EventFiles = &eventFileRepo
+	storeOriginalFile(path)
+	calCmsService.rememberEventFile(*fileRepoCal.GetByPath(path))
+	fileRepoCal.DeleteAllData()
+
+	files := calCmsService.RerunFilesForHour(rerunDate, "10")
+	_, source, _ := calCmsService.rerunFile(calCmsService.calCmsPgm.data.Events[0])
+
+	require.Len(t, files, 1)
+	assert.EqualValues(t, path, files[0].Path)
+	assert.EqualValues(t, 59*time.Minute, files[0].Duration)
+	assert.EqualValues(t, rerunSourceHistory, source)
+}
+
+func TestRerunFilesForHourSearchesArchiveFolder(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupReruns(float64(1))
+	archive := t.TempDir()
+	path := filepath.Join(archive, "2024", "show-id10-.mp3")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("audio"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(archive, "show-id11-.mp3"), []byte("audio"), 0644))
+	calCmsService.Cfg.Rerun.ArchiveFolder = archive
+	probe, err := os.ReadFile("../samples/ffprobe_allok.json")
+	require.NoError(t, err)
+	calls := 0
+	calCmsService.RunCmd = func(context.Context, string, ...string) ([]byte, error) {
+		calls++
+		return probe, nil
+	}
+
+	files := calCmsService.RerunFilesForHour(rerunDate, "10")
+	calCmsService.RerunFilesForHour(rerunDate, "10")
+
+	require.Len(t, files, 1)
+	assert.EqualValues(t, path, files[0].Path)
+	assert.NotZero(t, files[0].Duration)
+	assert.EqualValues(t, 1, calls)
+}
+
+func TestConvertEventMarksRerunWithOriginalFile(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupReruns(float64(1))
+	storeOriginalFile("/root/2024/04/01/20-00/show-id10-.mp3")
+
+	events := calCmsService.convertEvent(calCmsService.calCmsPgm.data)
+
+	require.Len(t, events, 1)
+	assert.EqualValues(t, "Rerun of event 10", events[0].Rerun)
+	assert.EqualValues(t, "Present", events[0].FileStatus)
+	assert.EqualValues(t, "Rerun (file list)", events[0].FileSource)
+}
+
+func TestPlanContainsRerunFile(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	exportService.Reruns = fakeReruns{{
+		Path:       "show-id10-.mp3",
+		Duration:   59 * time.Minute,
+		StartTime:  helper.TimeFromHourAndMinuteAndDate(20, 0, dayDate),
+		EndTime:    helper.TimeFromHourAndMinuteAndDate(21, 0, dayDate),
+		FolderDate: dayDate,
+		EventId:    20,
+		FromCalCMS: true,
+		FileType:   domain.FileTypeAudio,
+	}}
+
+	plan, _, found := exportService.planForDateAndHour(dayDate, "20")
+
+	assert.True(t, found)
+	require.Contains(t, plan, "20:00")
+	assert.EqualValues(t, "show-id10-.mp3", plan["20:00"].Path)
+}
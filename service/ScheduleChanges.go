@@ -0,0 +1,234 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const maxScheduleChanges = 200
+
+// safeScheduleChanges keeps the most recent schedule changes, oldest first
+type safeScheduleChanges struct {
+	sync.RWMutex
+	changes []domain.ScheduleChange
+	nextId  int
+}
+
+type hourExports interface {
+	IsExported(time.Time, string) bool
+	IsFileExported(time.Time, string, domain.FileInfo) bool
+}
+
+// updateProgram replaces the program in use. A program received from calCms is compared to the previous one
+// for the dates both cover, the differences are added to the change feed
+func (s DefaultCalCmsService) updateProgram(program loadedProgram, dates []time.Time) {
+	var days []string
+	for _, date := range dates {
+		days = append(days, domain.FormatFolderDate(date))
+	}
+	s.calCmsPgm.Lock()
+	previous, previousDays := s.calCmsPgm.data, s.calCmsPgm.days
+	s.calCmsPgm.data = program.data
+	s.calCmsPgm.days = days
+	s.calCmsPgm.Unlock()
+	if program.cached || len(previousDays) == 0 {
+		return
+	}
+	common := slices.DeleteFunc(slices.Clone(days), func(day string) bool { return !slices.Contains(previousDays, day) })
+	s.recordScheduleChanges(diffSchedules(s.scheduledEvents(previous, common), s.scheduledEvents(program.data, common)))
+}
+
+// scheduledEvents returns the events of the program starting on one of the days, leaving out excluded events
+func (s DefaultCalCmsService) scheduledEvents(data domain.CalCmsPgmData, days []string) []domain.CalCmsEvent {
+	var events []domain.CalCmsEvent
+	for _, event := range data.Events {
+		if slices.Contains(days, event.StartDate) && !s.excluded(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// diffSchedules compares two schedules. Events are matched by id, ids occurring more than once are matched by id and date
+func diffSchedules(previous []domain.CalCmsEvent, current []domain.CalCmsEvent) []domain.ScheduleChange {
+	var (
+		changes []domain.ScheduleChange
+		ids     []int
+	)
+	previousById := make(map[int][]domain.CalCmsEvent)
+	currentById := make(map[int][]domain.CalCmsEvent)
+	for _, event := range previous {
+		if _, found := previousById[event.EventID]; !found {
+			ids = append(ids, event.EventID)
+		}
+		previousById[event.EventID] = append(previousById[event.EventID], event)
+	}
+	for _, event := range current {
+		if _, found := previousById[event.EventID]; !found {
+			if _, found := currentById[event.EventID]; !found {
+				ids = append(ids, event.EventID)
+			}
+		}
+		currentById[event.EventID] = append(currentById[event.EventID], event)
+	}
+	for _, id := range ids {
+		oldEvents, newEvents := previousById[id], currentById[id]
+		if len(oldEvents) == 1 && len(newEvents) == 1 {
+			changes = append(changes, compareEvents(oldEvents[0], newEvents[0])...)
+			continue
+		}
+		matched := make([]bool, len(newEvents))
+		for _, oldEvent := range oldEvents {
+			index := -1
+			for i, newEvent := range newEvents {
+				if !matched[i] && newEvent.StartDate == oldEvent.StartDate {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				changes = append(changes, domain.ScheduleChange{Type: domain.ScheduleChangeRemoved, EventId: id, Title: oldEvent.FullTitle, Old: eventPeriodText(oldEvent), Slots: eventSlots(oldEvent)})
+				continue
+			}
+			matched[index] = true
+			changes = append(changes, compareEvents(oldEvent, newEvents[index])...)
+		}
+		for index, newEvent := range newEvents {
+			if !matched[index] {
+				changes = append(changes, domain.ScheduleChange{Type: domain.ScheduleChangeAdded, EventId: id, Title: newEvent.FullTitle, New: eventPeriodText(newEvent), Slots: eventSlots(newEvent)})
+			}
+		}
+	}
+	return changes
+}
+
+// compareEvents returns the changes between two versions of an event
+func compareEvents(oldEvent domain.CalCmsEvent, newEvent domain.CalCmsEvent) []domain.ScheduleChange {
+	var changes []domain.ScheduleChange
+	if oldEvent.StartDatetime != newEvent.StartDatetime || oldEvent.EndDatetime != newEvent.EndDatetime {
+		slots := eventSlots(oldEvent)
+		for _, slot := range eventSlots(newEvent) {
+			if !slices.Contains(slots, slot) {
+				slots = append(slots, slot)
+			}
+		}
+		changes = append(changes, domain.ScheduleChange{Type: domain.ScheduleChangeMoved, EventId: newEvent.EventID, Title: newEvent.FullTitle, Old: eventPeriodText(oldEvent), New: eventPeriodText(newEvent), Slots: slots})
+	}
+	if oldEvent.FullTitle != newEvent.FullTitle {
+		changes = append(changes, domain.ScheduleChange{Type: domain.ScheduleChangeRetitled, EventId: newEvent.EventID, Title: newEvent.FullTitle, Old: oldEvent.FullTitle, New: newEvent.FullTitle, Slots: eventSlots(newEvent)})
+	}
+	if (oldEvent.Live == 0) != (newEvent.Live == 0) {
+		changes = append(changes, domain.ScheduleChange{Type: domain.ScheduleChangeLiveChanged, EventId: newEvent.EventID, Title: newEvent.FullTitle, Old: liveText(oldEvent), New: liveText(newEvent), Slots: eventSlots(newEvent)})
+	}
+	return changes
+}
+
+// eventSlots returns every hour an event covers, from the hour it starts in to the hour it ends in
+func eventSlots(event domain.CalCmsEvent) []domain.ScheduleSlot {
+	start, end, err := eventPeriod(event.StartDate, event.StartTime, event.EndTime)
+	if err != nil {
+		return nil
+	}
+	return periodSlots(start, end)
+}
+
+// periodSlots returns every hour of a period, from the hour it starts in to the hour it ends in
+func periodSlots(start time.Time, end time.Time) (slots []domain.ScheduleSlot) {
+	for hour := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, start.Location()); hour.Before(end) || len(slots) == 0; hour = hour.Add(time.Hour) {
+		slots = append(slots, domain.ScheduleSlot{Date: domain.FormatFolderDate(hour), Hour: fmt.Sprintf("%02d", hour.Hour())})
+	}
+	return slots
+}
+
+func eventPeriodText(event domain.CalCmsEvent) string {
+	return event.StartDate + " " + event.StartTime + "-" + event.EndTime
+}
+
+func liveText(event domain.CalCmsEvent) string {
+	if event.Live == 0 {
+		return "preproduced"
+	}
+	return "live"
+}
+
+// recordScheduleChanges adds changes to the feed. Changes affecting an hour that was already exported are notified
+func (s DefaultCalCmsService) recordScheduleChanges(changes []domain.ScheduleChange) {
+	if len(changes) == 0 {
+		return
+	}
+	now := s.Now()
+	for i := range changes {
+		changes[i].Detected = now
+		if s.Exports == nil {
+			continue
+		}
+		for j, slot := range changes[i].Slots {
+			if date, err := domain.ParseFolderDate(slot.Date); err == nil {
+				changes[i].Slots[j].Exported = s.Exports.IsExported(date, slot.Hour)
+			}
+		}
+	}
+	s.changes.Lock()
+	for i := range changes {
+		s.changes.nextId++
+		changes[i].Id = s.changes.nextId
+	}
+	s.changes.changes = append(s.changes.changes, changes...)
+	if len(s.changes.changes) > maxScheduleChanges {
+		s.changes.changes = slices.Delete(s.changes.changes, 0, len(s.changes.changes)-maxScheduleChanges)
+	}
+	s.changes.Unlock()
+	for _, change := range changes {
+		message := scheduleChangeText(change)
+		logger.Info(message)
+		if !change.Exported() || s.Notifier == nil {
+			continue
+		}
+		var exported []string
+		for _, slot := range change.Slots {
+			if slot.Exported {
+				exported = append(exported, slot.Date+" "+slot.Hour)
+			}
+		}
+		s.Notifier.Notify(domain.NotificationScheduleChanged, fmt.Sprintf("%v, already exported: %v", message, strings.Join(exported, ", ")), map[string]string{
+			"type":     string(change.Type),
+			"event_id": fmt.Sprint(change.EventId),
+			"title":    change.Title,
+			"old":      change.Old,
+			"new":      change.New,
+			"exported": strings.Join(exported, ","),
+		})
+	}
+}
+
+// scheduleChangeText describes a schedule change for logs and notifications
+func scheduleChangeText(change domain.ScheduleChange) string {
+	switch change.Type {
+	case domain.ScheduleChangeAdded:
+		return fmt.Sprintf("Schedule change: %v (%v) was added at %v", change.Title, change.EventId, change.New)
+	case domain.ScheduleChangeRemoved:
+		return fmt.Sprintf("Schedule change: %v (%v) at %v was removed", change.Title, change.EventId, change.Old)
+	case domain.ScheduleChangeMoved:
+		return fmt.Sprintf("Schedule change: %v (%v) was moved from %v to %v", change.Title, change.EventId, change.Old, change.New)
+	case domain.ScheduleChangeRetitled:
+		return fmt.Sprintf("Schedule change: %v (%v) was renamed from %v", change.Title, change.EventId, change.Old)
+	default:
+		return fmt.Sprintf("Schedule change: %v (%v) changed from %v to %v", change.Title, change.EventId, change.Old, change.New)
+	}
+}
+
+// GetScheduleChanges returns the recent schedule changes, newest first
+func (s DefaultCalCmsService) GetScheduleChanges() []domain.ScheduleChange {
+	s.changes.RLock()
+	defer s.changes.RUnlock()
+	changes := slices.Clone(s.changes.changes)
+	slices.Reverse(changes)
+	return changes
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHourExports map[string]bool
+
+func (f fakeHourExports) IsExported(folderDate time.Time, hour string) bool {
+	return f[domain.FormatFolderDate(folderDate)+" "+hour]
+}
+
+func (f fakeHourExports) IsFileExported(folderDate time.Time, hour string, file domain.FileInfo) bool {
+	return f[domain.FormatFolderDate(folderDate)+" "+hour+" "+file.Path]
+}
+
+func scheduleEvent(id int, title string, hour int, live bool) domain.CalCmsEvent {
+	start := time.Date(2024, 9, 24, hour, 0, 0, 0, time.Local)
+	return programEvent(domain.ScheduleEvent{Id: id, Title: title, Start: start, End: start.Add(time.Hour), Live: live})
+}
+
+func TestDiffSchedulesReturnsAllChangeTypes(t *testing.T) {
+	previous := []domain.CalCmsEvent{
+		scheduleEvent(1, "Morning Show", 7, false),
+		scheduleEvent(2, "Evening Show", 20, false),
+		scheduleEvent(3, "Old Title", 21, false),
+		scheduleEvent(4, "Talk", 22, false),
+	}
+	current := []domain.CalCmsEvent{
+		scheduleEvent(2, "Evening Show", 21, false),
+		scheduleEvent(3, "New Title", 21, false),
+		scheduleEvent(4, "Talk", 22, true),
+		scheduleEvent(5, "Night Show", 23, false),
+	}
+
+	changes := diffSchedules(previous, current)
+
+	require.Len(t, changes, 5)
+	assert.EqualValues(t, domain.ScheduleChange{Type: domain.ScheduleChangeRemoved, EventId: 1, Title: "Morning Show", Old: "2024-09-24 07:00-08:00", Slots: []domain.ScheduleSlot{{Date: "2024-09-24", Hour: "07"}}}, changes[0])
+	assert.EqualValues(t, domain.ScheduleChangeMoved, changes[1].Type)
+	assert.EqualValues(t, "2024-09-24 20:00-21:00", changes[1].Old)
+	assert.EqualValues(t, "2024-09-24 21:00-22:00", changes[1].New)
+	assert.EqualValues(t, []domain.ScheduleSlot{{Date: "2024-09-24", Hour: "20"}, {Date: "2024-09-24", Hour: "21"}}, changes[1].Slots)
+	assert.EqualValues(t, domain.ScheduleChangeRetitled, changes[2].Type)
+	assert.EqualValues(t, "Old Title", changes[2].Old)
+	assert.EqualValues(t, domain.ScheduleChangeLiveChanged, changes[3].Type)
+	assert.EqualValues(t, "live", changes[3].New)
+	assert.EqualValues(t, domain.ScheduleChangeAdded, changes[4].Type)
+	assert.EqualValues(t, 5, changes[4].EventId)
+}
+
+func TestDiffSchedulesReturnsAllHoursOfLongEvents(t *testing.T) {
+	start := time.Date(2024, 9, 24, 20, 30, 0, 0, time.Local)
+	show := programEvent(domain.ScheduleEvent{Id: 1, Title: "Long Show", Start: start, End: start.Add(2 * time.Hour)})
+	moved := programEvent(domain.ScheduleEvent{Id: 1, Title: "Long Show", Start: start.Add(2 * time.Hour), End: start.Add(4 * time.Hour)})
+
+	changes := diffSchedules([]domain.CalCmsEvent{show}, []domain.CalCmsEvent{moved})
+
+	require.Len(t, changes, 1)
+	assert.EqualValues(t, []domain.ScheduleSlot{
+		{Date: "2024-09-24", Hour: "20"}, {Date: "2024-09-24", Hour: "21"}, {Date: "2024-09-24", Hour: "22"},
+		{Date: "2024-09-24", Hour: "23"}, {Date: "2024-09-25", Hour: "00"},
+	}, changes[0].Slots)
+}
+
+func TestDiffSchedulesMatchesRepeatedIdsByDate(t *testing.T) {
+	first := scheduleEvent(1, "Daily Show", 7, false)
+	second := programEvent(domain.ScheduleEvent{Id: 1, Title: "Daily Show", Start: time.Date(2024, 9, 25, 7, 0, 0, 0, time.Local), End: time.Date(2024, 9, 25, 8, 0, 0, 0, time.Local)})
+	moved := programEvent(domain.ScheduleEvent{Id: 1, Title: "Daily Show", Start: time.Date(2024, 9, 25, 8, 0, 0, 0, time.Local), End: time.Date(2024, 9, 25, 9, 0, 0, 0, time.Local)})
+
+	changes := diffSchedules([]domain.CalCmsEvent{first, second}, []domain.CalCmsEvent{first, moved})
+
+	require.Len(t, changes, 1)
+	assert.EqualValues(t, domain.ScheduleChangeMoved, changes[0].Type)
+	assert.EqualValues(t, "2024-09-25 08:00-09:00", changes[0].New)
+}
+
+func TestRefreshTodayEventsRecordsChangesAndNotifiesExportedHours(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	feed := func(hour string) string {
+		return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:4711\r\nSUMMARY:Evening Show\r\n" +
+			"DTSTART:20240924T" + hour + "0000\r\nDURATION:PT1H\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+	cfgCal.CalCms.ICalSource = filepath.Join(t.TempDir(), "schedule.ics")
+	cfgCal.Misc.TestCrawl = true
+	cfgCal.Misc.TestDate = "2024/09/24"
+	cfgCal.CalCms.QueryCalCms = true
+	calCmsService.Schedule = schedule.NewICalProvider(&cfgCal, InitHttpCalClient())
+	calCmsService.Exports = fakeHourExports{"2024-09-24 20": true}
+	notifications := &fakeNotifier{}
+	calCmsService.Notifier = notifications
+	require.NoError(t, os.WriteFile(cfgCal.CalCms.ICalSource, []byte(feed("20")), 0644))
+	_, err1 := calCmsService.RefreshTodayEvents()
+	_, err2 := calCmsService.RefreshTodayEvents()
+	require.NoError(t, os.WriteFile(cfgCal.CalCms.ICalSource, []byte(feed("21")), 0644))
+
+	_, err3 := calCmsService.RefreshTodayEvents()
+
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, err3)
+	changes := calCmsService.GetScheduleChanges()
+	require.Len(t, changes, 1)
+	assert.EqualValues(t, 1, changes[0].Id)
+	assert.EqualValues(t, domain.ScheduleChangeMoved, changes[0].Type)
+	assert.True(t, changes[0].Exported())
+	assert.EqualValues(t, []domain.ScheduleSlot{{Date: "2024-09-24", Hour: "20", Exported: true}, {Date: "2024-09-24", Hour: "21"}}, changes[0].Slots)
+	require.EqualValues(t, []domain.NotificationType{domain.NotificationScheduleChanged}, notifications.types())
+	assert.EqualValues(t, "2024-09-24 20", notifications.notifications[0].Data["exported"])
+}
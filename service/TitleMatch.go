@@ -0,0 +1,303 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	titleMatchTitleWeight = 0.7
+	titleMatchTimeWeight  = 0.3
+	titleMatchLead        = 0.1 // the best event must lead the runner-up by this much to be linked without confirmation
+)
+
+// safeTitleMatches keeps the title matches found automatically by file path. Matches decided by an operator are kept in the title match repository
+type safeTitleMatches struct {
+	sync.RWMutex
+	matches map[string]domain.TitleMatch
+}
+
+var titleUmlauts = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss")
+
+// matchByTitle links a file without event id to the event starting close to the file's start time with the most similar title.
+// Returns true, if the file was linked. Matches below the accept confidence are only kept as suggestions
+func (s DefaultCalCmsService) matchByTitle(file domain.FileInfo) (domain.FileInfo, bool) {
+	if s.Cfg.CalCms.TitleMatchAccept <= 0 || file.StartTime.IsZero() {
+		return file, false
+	}
+	if decided := s.TitleMatches.Get(file.Path); decided != nil && decided.Status == domain.TitleMatchConfirmed {
+		return linkFile(file, *decided), true
+	}
+	s.titleMatches.RLock()
+	previous, found := s.titleMatches.matches[file.Path]
+	s.titleMatches.RUnlock()
+	match, lead, ok := s.bestTitleMatch(file, s.TitleMatches.Rejected(file.Path))
+	if !ok || match.Confidence < s.Cfg.CalCms.TitleMatchSuggest {
+		if found {
+			s.titleMatches.Lock()
+			delete(s.titleMatches.matches, file.Path)
+			s.titleMatches.Unlock()
+		}
+		return file, false
+	}
+	match.Status = domain.TitleMatchSuggested
+	if match.Confidence >= s.Cfg.CalCms.TitleMatchAccept && lead >= titleMatchLead {
+		match.Status = domain.TitleMatchAccepted
+	}
+	match.Updated = s.Now()
+	s.titleMatches.Lock()
+	s.titleMatches.matches[file.Path] = match
+	s.titleMatches.Unlock()
+	if match.Status == domain.TitleMatchAccepted {
+		logger.Infof("Linked file %v to event %v (%v) by title. Confidence: %v%%", file.Path, match.EventTitle, match.EventId, match.ConfidencePercent())
+		return linkFile(file, match), true
+	}
+	if !found || previous.EventId != match.EventId || previous.Status != domain.TitleMatchSuggested {
+		logger.Infof("Suggesting event %v (%v) for file %v. Confidence: %v%%", match.EventTitle, match.EventId, file.Path, match.ConfidencePercent())
+	}
+	return file, false
+}
+
+// bestTitleMatch scores the events starting within the match window around the file's start time.
+// Returns the best match and its lead over the runner-up
+func (s DefaultCalCmsService) bestTitleMatch(file domain.FileInfo, rejected []int) (best domain.TitleMatch, lead float64, ok bool) {
+	s.calCmsPgm.RLock()
+	events := append([]domain.CalCmsEvent(nil), s.calCmsPgm.data.Events...)
+	s.calCmsPgm.RUnlock()
+	window := time.Duration(s.Cfg.CalCms.TitleMatchWindow) * time.Minute
+	fileTitle := titleTokens(fileTitleFromPath(file.Path))
+	runnerUp := 0.0
+	for _, event := range events {
+		if s.excluded(event) || slices.Contains(rejected, event.EventID) {
+			continue
+		}
+		start, err := time.ParseInLocation("2006-01-02T15:04:05", event.StartDatetime, time.Local)
+		if err != nil || !domain.NormalizeDate(start).Equal(domain.NormalizeDate(file.FolderDate)) {
+			continue
+		}
+		offset := start.Sub(file.StartTime).Abs()
+		if offset > window {
+			continue
+		}
+		timeScore := 1.0
+		if window > 0 {
+			timeScore = 1 - offset.Seconds()/window.Seconds()
+		}
+		titleScore := 0.0
+		for _, title := range []string{event.FullTitle, event.Title, event.SeriesName} {
+			titleScore = max(titleScore, titleSimilarity(fileTitle, titleTokens(title)))
+		}
+		confidence := math.Round((titleMatchTitleWeight*titleScore+titleMatchTimeWeight*timeScore)*100) / 100
+		switch {
+		case !ok || confidence > best.Confidence:
+			if ok {
+				runnerUp = best.Confidence
+			}
+			best = domain.TitleMatch{
+				Path:       file.Path,
+				FolderDate: file.FolderDate,
+				FileStart:  file.StartTime,
+				EventId:    event.EventID,
+				EventTitle: event.FullTitle,
+				EventStart: start,
+				Confidence: confidence,
+			}
+			ok = true
+		case confidence > runnerUp:
+			runnerUp = confidence
+		}
+	}
+	return best, best.Confidence - runnerUp, ok
+}
+
+// linkFile sets the event of a title match on the file
+func linkFile(file domain.FileInfo, match domain.TitleMatch) domain.FileInfo {
+	file.EventId = match.EventId
+	file.FromCalCMS = true
+	file.MatchConfidence = match.Confidence
+	return file
+}
+
+// unlinkFile removes the event of a title match and the information taken from it from the file
+func unlinkFile(file domain.FileInfo) domain.FileInfo {
+	file.EventId = 0
+	file.FromCalCMS = false
+	file.MatchConfidence = 0
+	file.CalCmsTitle = ""
+	file.CalCmsInfoExtracted = false
+	file.EventIsLive = false
+	return file
+}
+
+// fileTitleFromPath returns the file name without extension and the leading "HHMM-HHMM_" time information
+func fileTitleFromPath(path string) string {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if loc := file1Exp.FindStringIndex(name); loc != nil {
+		name = name[loc[1]:]
+	}
+	return name
+}
+
+// titleTokens normalizes a title into lower case words with umlauts spelled out. Numbers are left out, they
+// usually denote episodes
+func titleTokens(title string) []string {
+	title = titleUmlauts.Replace(strings.ToLower(title))
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return slices.DeleteFunc(words, func(word string) bool {
+		return strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+	})
+}
+
+// titleSimilarity compares the words of a file name to those of an event title. The result is the larger of the share
+// of title words found in the file name and the similarity of both texts written without spaces
+func titleSimilarity(fileWords []string, titleWords []string) float64 {
+	if len(fileWords) == 0 || len(titleWords) == 0 {
+		return 0
+	}
+	found := 0
+	for _, titleWord := range titleWords {
+		if slices.ContainsFunc(fileWords, func(fileWord string) bool {
+			return fileWord == titleWord || (len(titleWord) >= 4 && textSimilarity(fileWord, titleWord) >= 0.85)
+		}) {
+			found++
+		}
+	}
+	contained := float64(found) / float64(len(titleWords))
+	return max(contained, textSimilarity(strings.Join(fileWords, ""), strings.Join(titleWords, "")))
+}
+
+// textSimilarity returns 1 minus the edit distance of both texts relative to the length of the longer text
+func textSimilarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance calculates the Levenshtein distance of two texts
+func editDistance(a []rune, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			above := row[j]
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = above
+		}
+	}
+	return row[len(b)]
+}
+
+// pruneTitleMatches forgets the matches of files no longer in the file list
+func (s DefaultCalCmsService) pruneTitleMatches(paths []string) {
+	s.titleMatches.Lock()
+	for path := range s.titleMatches.matches {
+		if !slices.Contains(paths, path) {
+			delete(s.titleMatches.matches, path)
+		}
+	}
+	s.titleMatches.Unlock()
+	if err := s.TitleMatches.Prune(paths); err != nil {
+		logger.Error("Error pruning title matches", err)
+	}
+}
+
+// GetTitleMatches returns the current title matches ordered by date and start time of the file. A match found
+// automatically takes the place of an earlier rejection for the same file
+func (s DefaultCalCmsService) GetTitleMatches() []domain.TitleMatch {
+	s.titleMatches.RLock()
+	matches := slices.DeleteFunc(s.TitleMatches.GetAll(), func(match domain.TitleMatch) bool {
+		_, found := s.titleMatches.matches[match.Path]
+		return found
+	})
+	for _, match := range s.titleMatches.matches {
+		matches = append(matches, match)
+	}
+	s.titleMatches.RUnlock()
+	slices.SortFunc(matches, func(a, b domain.TitleMatch) int {
+		if c := a.FileStart.Compare(b.FileStart); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return matches
+}
+
+// ConfirmTitleMatch links the file of a suggested match to its event
+func (s DefaultCalCmsService) ConfirmTitleMatch(path string) error {
+	s.titleMatches.Lock()
+	match, found := s.titleMatches.matches[path]
+	if !found || match.Status != domain.TitleMatchSuggested {
+		s.titleMatches.Unlock()
+		return errors.New("no suggested match for this file")
+	}
+	match.Status = domain.TitleMatchConfirmed
+	match.Updated = s.Now()
+	delete(s.titleMatches.matches, path)
+	s.titleMatches.Unlock()
+	if err := s.TitleMatches.Store(match); err != nil {
+		return err
+	}
+	file := s.Repo.GetByPath(path)
+	if file == nil {
+		return errors.New("file not in file list")
+	}
+	logger.Infof("Linked file %v to event %v (%v) as confirmed by user", path, match.EventTitle, match.EventId)
+	linked := linkFile(*file, match)
+	if _, err := s.enrichFile(linked); err != nil {
+		return err
+	}
+	s.reportReceived([]domain.FileInfo{linked})
+	return nil
+}
+
+// RejectTitleMatch rejects a suggested, accepted or confirmed match. The event is not suggested for the file again. A file
+// already linked to the event is unlinked
+func (s DefaultCalCmsService) RejectTitleMatch(path string) error {
+	s.titleMatches.Lock()
+	match, found := s.titleMatches.matches[path]
+	if !found {
+		if decided := s.TitleMatches.Get(path); decided != nil && decided.Status == domain.TitleMatchConfirmed {
+			match, found = *decided, true
+		}
+	}
+	if !found {
+		s.titleMatches.Unlock()
+		return errors.New("no match for this file")
+	}
+	match.Status = domain.TitleMatchRejected
+	match.Updated = s.Now()
+	delete(s.titleMatches.matches, path)
+	s.titleMatches.Unlock()
+	if err := s.TitleMatches.Store(match); err != nil {
+		return err
+	}
+	logger.Infof("Match of file %v to event %v (%v) rejected by user", path, match.EventTitle, match.EventId)
+	if file := s.Repo.GetByPath(path); file != nil && file.MatchConfidence > 0 && file.EventId == match.EventId {
+		return s.Repo.Store(unlinkFile(*file))
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const titleMatchPath = "/root/2024/04/01/2000-2100_quatschbrötchen 121.mp3"
+
+func setupTitleMatch(t *testing.T, events ...domain.CalCmsEvent) {
+	calCmsService.insertData(domain.CalCmsPgmData{Events: events})
+	calCmsService.Cfg.Misc.TestCrawl = true
+	calCmsService.Cfg.Misc.TestDate = "2024/04/01"
+	calCmsService.Cfg.Misc.TitleMatchSaveFile = filepath.Join(t.TempDir(), "titlematches.dta")
+}
+
+func storeTitleMatchFile(path string) {
+	folderDate := domain.MustParseFolderDate("2024-04-01")
+	fileRepoCal.Store(domain.FileInfo{
+		Path:        path,
+		FolderDate:  folderDate,
+		StartTime:   helper.TimeFromHourAndMinuteAndDate(20, 0, folderDate),
+		EndTime:     helper.TimeFromHourAndMinuteAndDate(21, 0, folderDate),
+		RuleMatched: "file HHMM-HHMM",
+		FileType:    domain.FileTypeAudio,
+	})
+}
+
+func titleMatchEvent(id int, title string, hour int, minute int) domain.CalCmsEvent {
+	start := time.Date(2024, 4, 1, hour, minute, 0, 0, time.Local)
+	return programEvent(domain.ScheduleEvent{Id: id, Title: title, Start: start, End: start.Add(time.Hour)})
+}
+
+func TestTitleSimilarityIgnoresCaseUmlautsAndEpisodeNumbers(t *testing.T) {
+	fileWords := titleTokens(fileTitleFromPath("/root/2024/04/01/2000-2100_QuatschBroetchen 121.mp3"))
+
+	assert.EqualValues(t, []string{"quatschbroetchen"}, fileWords)
+	assert.EqualValues(t, 1, titleSimilarity(fileWords, titleTokens("Quatschbrötchen")))
+	assert.Less(t, titleSimilarity(fileWords, titleTokens("Nachtmusik")), 0.3)
+}
+
+func TestEnrichFileInformationLinksFileWithoutIdByTitle(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupTitleMatch(t, titleMatchEvent(4711, "Quatschbrötchen", 20, 0), titleMatchEvent(4712, "Nachtmusik", 20, 15))
+	storeTitleMatchFile(titleMatchPath)
+
+	n := calCmsService.EnrichFileInformation()
+
+	assert.EqualValues(t, 1, n.TotalCount)
+	file := fileRepoCal.GetByPath(titleMatchPath)
+	require.NotNil(t, file)
+	assert.EqualValues(t, 4711, file.EventId)
+	assert.EqualValues(t, "Quatschbrötchen", file.CalCmsTitle)
+	assert.True(t, file.FromCalCMS)
+	assert.EqualValues(t, 1, file.MatchConfidence)
+	matches := calCmsService.GetTitleMatches()
+	require.Len(t, matches, 1)
+	assert.EqualValues(t, domain.TitleMatchAccepted, matches[0].Status)
+}
+
+func TestEnrichFileInformationOnlySuggestsLowConfidenceMatch(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	path := "/root/2024/04/01/2000-2100_quatsch.mp3"
+	setupTitleMatch(t, titleMatchEvent(4711, "Quatschbrötchen", 20, 0))
+	storeTitleMatchFile(path)
+
+	n := calCmsService.EnrichFileInformation()
+	err := calCmsService.ConfirmTitleMatch(path)
+
+	assert.EqualValues(t, 0, n.TotalCount)
+	assert.Nil(t, err)
+	file := fileRepoCal.GetByPath(path)
+	require.NotNil(t, file)
+	assert.EqualValues(t, 4711, file.EventId)
+	assert.True(t, file.CalCmsInfoExtracted)
+	matches := calCmsService.GetTitleMatches()
+	require.Len(t, matches, 1)
+	assert.EqualValues(t, domain.TitleMatchConfirmed, matches[0].Status)
+	assert.EqualValues(t, 61, matches[0].ConfidencePercent())
+}
+
+func TestEnrichFileInformationAmbiguousMatchIsOnlySuggested(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupTitleMatch(t, titleMatchEvent(4711, "Quatschbrötchen", 20, 0), titleMatchEvent(4712, "Quatschbrötchen", 20, 5))
+	storeTitleMatchFile(titleMatchPath)
+
+	n := calCmsService.EnrichFileInformation()
+
+	assert.EqualValues(t, 0, n.TotalCount)
+	matches := calCmsService.GetTitleMatches()
+	require.Len(t, matches, 1)
+	assert.EqualValues(t, 4711, matches[0].EventId)
+	assert.EqualValues(t, domain.TitleMatchSuggested, matches[0].Status)
+}
+
+func TestRejectTitleMatchUnlinksFileAndDoesNotSuggestEventAgain(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupTitleMatch(t, titleMatchEvent(4711, "Quatschbrötchen", 20, 0))
+	storeTitleMatchFile(titleMatchPath)
+	calCmsService.EnrichFileInformation()
+
+	err := calCmsService.RejectTitleMatch(titleMatchPath)
+	n := calCmsService.EnrichFileInformation()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, n.TotalCount)
+	file := fileRepoCal.GetByPath(titleMatchPath)
+	require.NotNil(t, file)
+	assert.EqualValues(t, 0, file.EventId)
+	assert.False(t, file.FromCalCMS)
+	assert.EqualValues(t, 0, file.MatchConfidence)
+	assert.Empty(t, file.CalCmsTitle)
+	matches := calCmsService.GetTitleMatches()
+	require.Len(t, matches, 1)
+	assert.EqualValues(t, domain.TitleMatchRejected, matches[0].Status)
+}
+
+func TestConfirmTitleMatchWithoutSuggestionReturnsError(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+
+	err := calCmsService.ConfirmTitleMatch(titleMatchPath)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "no suggested match for this file", err.Error())
+}
+
+func TestTitleMatchDecisionsSurviveRestart(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	confirmedPath := "/root/2024/04/01/2000-2100_quatsch.mp3"
+	setupTitleMatch(t, titleMatchEvent(4711, "Quatschbrötchen", 20, 0))
+	storeTitleMatchFile(confirmedPath)
+	storeTitleMatchFile(titleMatchPath)
+	calCmsService.EnrichFileInformation()
+	calCmsService.ConfirmTitleMatch(confirmedPath)
+	calCmsService.RejectTitleMatch(titleMatchPath)
+
+	restarted := NewCalCmsServiceWithState(&cfgCal, stateCal, &fileRepoCal)
+	restarted.insertData(domain.CalCmsPgmData{Events: []domain.CalCmsEvent{titleMatchEvent(4711, "Quatschbrötchen", 20, 0)}})
+	loadErr := restarted.TitleMatches.LoadFromDisk()
+	storeTitleMatchFile(confirmedPath)
+	storeTitleMatchFile(titleMatchPath)
+	n := restarted.EnrichFileInformation()
+
+	require.NoError(t, loadErr)
+	assert.EqualValues(t, 1, n.TotalCount)
+	assert.EqualValues(t, 4711, fileRepoCal.GetByPath(confirmedPath).EventId)
+	assert.EqualValues(t, 0, fileRepoCal.GetByPath(titleMatchPath).EventId)
+	matches := restarted.GetTitleMatches()
+	require.Len(t, matches, 2)
+	assert.EqualValues(t, domain.TitleMatchConfirmed, matches[0].Status)
+	assert.EqualValues(t, domain.TitleMatchRejected, matches[1].Status)
+}
@@ -0,0 +1,210 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+const (
+	uploadStatusQueueSize     = 500
+	uploadStatusTimeout       = 10 * time.Second
+	uploadStatusRetryDelay    = time.Minute
+	uploadStatusMaxRetryDelay = time.Hour
+)
+
+type uploadStatusWriter interface {
+	SetUploadStatus(context.Context, int, string) error
+}
+
+// safeUploadStatus holds the upload statuses waiting to be written into calCms. It remembers the status last written,
+// queued or failed per event and date, so calCms is only updated on changes and failed updates are retried with backoff
+type safeUploadStatus struct {
+	sync.Mutex
+	queue   chan uploadStatusUpdate
+	written map[string]string
+	queued  map[string]string
+	failed  map[string]uploadStatusFailure
+}
+
+// uploadStatusUpdate is an upload status waiting to be written into calCms
+type uploadStatusUpdate struct {
+	eventId int
+	date    string
+	status  string
+}
+
+// uploadStatusFailure is an update calCms didn't accept and when it is tried again
+type uploadStatusFailure struct {
+	status  string
+	retryAt time.Time
+	delay   time.Duration
+}
+
+func newSafeUploadStatus() *safeUploadStatus {
+	return &safeUploadStatus{
+		queue:   make(chan uploadStatusUpdate, uploadStatusQueueSize),
+		written: make(map[string]string),
+		queued:  make(map[string]string),
+		failed:  make(map[string]uploadStatusFailure),
+	}
+}
+
+func (u uploadStatusUpdate) key() string {
+	return fmt.Sprintf("%v@%v", u.eventId, u.date)
+}
+
+// ReportUploadStatus queues the upload status of the event on the given date (YYYY-MM-DD) for writing back into calCms,
+// unless it was already written or queued. A status calCms didn't accept is queued again once its retry delay passed.
+// It never blocks, the status is written in the background by RunUploadStatus
+func (s DefaultCalCmsService) ReportUploadStatus(eventId int, date string, status string) {
+	if s.UploadStatus == nil || eventId == 0 {
+		return
+	}
+	update := uploadStatusUpdate{eventId: eventId, date: date, status: status}
+	key := update.key()
+	s.uploadStatus.Lock()
+	defer s.uploadStatus.Unlock()
+	if s.uploadStatus.written[key] == status || s.uploadStatus.queued[key] == status {
+		return
+	}
+	if failure, found := s.uploadStatus.failed[key]; found && failure.status == status && s.Now().Before(failure.retryAt) {
+		return
+	}
+	select {
+	case s.uploadStatus.queue <- update:
+		s.uploadStatus.queued[key] = status
+	default:
+		logger.Warnf("Upload status queue is full, dropping status of event %v on %v", eventId, date)
+	}
+}
+
+// RunUploadStatus writes the queued upload statuses into calCms until the context is cancelled
+func (s DefaultCalCmsService) RunUploadStatus(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-s.uploadStatus.queue:
+			s.writeUploadStatus(ctx, update)
+		}
+	}
+}
+
+// writeUploadStatus writes a single upload status into calCms and remembers the result
+func (s DefaultCalCmsService) writeUploadStatus(ctx context.Context, update uploadStatusUpdate) {
+	writeCtx, cancel := context.WithTimeout(ctx, uploadStatusTimeout)
+	defer cancel()
+	err := s.UploadStatus.SetUploadStatus(writeCtx, update.eventId, update.status)
+	key := update.key()
+	s.uploadStatus.Lock()
+	defer s.uploadStatus.Unlock()
+	if s.uploadStatus.queued[key] == update.status {
+		delete(s.uploadStatus.queued, key)
+	}
+	defer s.forgetPastUploadStatus()
+	if err != nil {
+		delay := uploadStatusRetryDelay
+		if failure, found := s.uploadStatus.failed[key]; found && failure.status == update.status {
+			delay = min(2*failure.delay, uploadStatusMaxRetryDelay)
+		}
+		s.uploadStatus.failed[key] = uploadStatusFailure{status: update.status, retryAt: s.Now().Add(delay), delay: delay}
+		logger.Errorf("Could not write upload status of event %v back into calCMS, retrying in %v: %v", update.eventId, delay, err)
+		return
+	}
+	logger.Infof("Upload status of event %v on %v set to \"%v\" in calCMS", update.eventId, update.date, update.status)
+	s.uploadStatus.written[key] = update.status
+	delete(s.uploadStatus.failed, key)
+}
+
+// forgetPastUploadStatus drops the written and failed statuses of events before yesterday. The caller holds the lock
+func (s DefaultCalCmsService) forgetPastUploadStatus() {
+	yesterday := domain.FormatFolderDate(s.Now().AddDate(0, 0, -1))
+	for key := range s.uploadStatus.written {
+		if _, date, _ := strings.Cut(key, "@"); date < yesterday {
+			delete(s.uploadStatus.written, key)
+		}
+	}
+	for key := range s.uploadStatus.failed {
+		if _, date, _ := strings.Cut(key, "@"); date < yesterday {
+			delete(s.uploadStatus.failed, key)
+		}
+	}
+}
+
+// reportReceived writes the upload status of the events of the enriched files. Events with several files get the status
+// of the file the export would choose, so the status doesn't change between the files. Files of live events are left out
+func (s DefaultCalCmsService) reportReceived(enriched []domain.FileInfo) {
+	type eventDay struct {
+		id   int
+		date time.Time
+	}
+	reported := make(map[eventDay]bool)
+	for _, file := range enriched {
+		key := eventDay{id: file.EventId, date: file.FolderDate}
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		chosen, found := s.exportCandidate(s.Repo.GetByEventIdAndDate(file.EventId, file.FolderDate))
+		if !found || chosen.EventIsLive {
+			continue
+		}
+		s.ReportUploadStatus(chosen.EventId, domain.FormatFolderDate(chosen.FolderDate), uploadStatusReceived(chosen, s.Cfg.Export.ShortDeltaAllowance, s.Cfg.Export.LongDeltaAllowance))
+	}
+}
+
+// exportCandidate is a helper function returning the file of an event the export would choose: the most recently modified
+// file whose length is accepted, the most recently modified file if no length is accepted
+func (s DefaultCalCmsService) exportCandidate(files domain.FileList) (domain.FileInfo, bool) {
+	var (
+		chosen   domain.FileInfo
+		chosenOk bool
+		found    bool
+	)
+	for _, file := range files {
+		lengthOk, _, _ := checkTime(file, s.Cfg.Export.ShortDeltaAllowance, s.Cfg.Export.LongDeltaAllowance)
+		if !found || (lengthOk && !chosenOk) || (lengthOk == chosenOk && file.ModTime.After(chosen.ModTime)) {
+			chosen, chosenOk, found = file, lengthOk, true
+		}
+	}
+	return chosen, found
+}
+
+// reportMissingFiles writes the upload status of the preproduced events without file
+func (s DefaultCalCmsService) reportMissingFiles(events []dto.Event) {
+	for _, event := range events {
+		if event.EventType != "Preproduction" || event.FileStatus != "Missing" {
+			continue
+		}
+		if id, err := strconv.Atoi(event.EventId); err == nil {
+			s.ReportUploadStatus(id, event.StartDate, "No file received")
+		}
+	}
+}
+
+// uploadStatusReceived describes a received file for calCms, e.g. "File received, 58 min, OK"
+func uploadStatusReceived(file domain.FileInfo, shortDelta float64, longDelta float64) string {
+	if file.FileType == domain.FileTypeStream {
+		return "Stream received, OK"
+	}
+	minutes := math.Round(file.Duration.Minutes())
+	if lengthOk, _, _ := checkTime(file, shortDelta, longDelta); !lengthOk {
+		return fmt.Sprintf("File received, %v min, length not accepted", minutes)
+	}
+	return fmt.Sprintf("File received, %v min, OK", minutes)
+}
+
+// uploadStatusRejected describes a file rejected at export for calCms
+func uploadStatusRejected(file domain.FileInfo, reason string) string {
+	return fmt.Sprintf("File rejected, %v min: %v", math.Round(file.Duration.Minutes()), reason)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/calcms"
+	"github.com/johannes-kuhfuss/mairlist-feeder/calcms/calcmstest"
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUploadReporter struct {
+	statuses []string
+}
+
+func (f *fakeUploadReporter) ReportUploadStatus(eventId int, date string, status string) {
+	f.statuses = append(f.statuses, status)
+}
+
+func setupUploadStatus(t *testing.T) *calcmstest.Server {
+	t.Helper()
+	srv := calcmstest.NewServer()
+	t.Cleanup(srv.Close)
+	calCmsService.UploadStatus = calcms.Client{Url: srv.Url(), HttpClient: &http.Client{Timeout: 5 * time.Second}}
+	return srv
+}
+
+// writeQueuedUploadStatus writes the queued upload statuses like RunUploadStatus does, until the queue is empty
+func writeQueuedUploadStatus() {
+	for {
+		select {
+		case update := <-calCmsService.uploadStatus.queue:
+			calCmsService.writeUploadStatus(context.Background(), update)
+		default:
+			return
+		}
+	}
+}
+
+func TestEnrichFileInformationWritesUploadStatusOnce(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	srv := setupUploadStatus(t)
+	calCmsService.insertData(domain.CalCmsPgmData{Events: []domain.CalCmsEvent{{FullTitle: "Test", StartDatetime: startDate, EndDatetime: endDate, EventID: 1234}}})
+	fileRepoCal.Store(domain.FileInfo{Path: "A", FolderDate: domain.MustParseFolderDate("2024-04-01"), EventId: 1234, FileType: domain.FileTypeAudio, Duration: 58 * time.Minute})
+	calCmsService.Cfg.Misc.TestCrawl = true
+	calCmsService.Cfg.Misc.TestDate = "2024/04/01"
+	calCmsService.Now = func() time.Time { return time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local) }
+
+	calCmsService.EnrichFileInformation()
+	writeQueuedUploadStatus()
+	calCmsService.EnrichFileInformation()
+	writeQueuedUploadStatus()
+
+	assert.EqualValues(t, []calcmstest.Update{{EventId: 1234, Status: "File received, 58 min, OK"}}, srv.Updates())
+}
+
+func TestEnrichFileInformationWritesOneStatusForEventWithTwoFiles(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	srv := setupUploadStatus(t)
+	calCmsService.insertData(domain.CalCmsPgmData{Events: []domain.CalCmsEvent{{FullTitle: "Test", StartDatetime: startDate, EndDatetime: endDate, EventID: 1234}}})
+	folderDate := domain.MustParseFolderDate("2024-04-01")
+	modified := time.Date(2024, 3, 30, 10, 0, 0, 0, time.Local)
+	fileRepoCal.Store(domain.FileInfo{Path: "A", FolderDate: folderDate, EventId: 1234, FileType: domain.FileTypeAudio, Duration: 58 * time.Minute, ModTime: modified})
+	fileRepoCal.Store(domain.FileInfo{Path: "B", FolderDate: folderDate, EventId: 1234, FileType: domain.FileTypeAudio, Duration: 20 * time.Minute, ModTime: modified.Add(time.Hour)})
+	calCmsService.Cfg.Misc.TestCrawl = true
+	calCmsService.Cfg.Misc.TestDate = "2024/04/01"
+	calCmsService.Now = func() time.Time { return time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local) }
+
+	calCmsService.EnrichFileInformation()
+	writeQueuedUploadStatus()
+	calCmsService.EnrichFileInformation()
+	writeQueuedUploadStatus()
+
+	assert.EqualValues(t, []calcmstest.Update{{EventId: 1234, Status: "File received, 58 min, OK"}}, srv.Updates())
+}
+
+func TestReportMissingFilesWritesUploadStatus(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	srv := setupUploadStatus(t)
+	events := []dto.Event{
+		{EventId: "1", StartDate: "2024-04-01", EventType: "Preproduction", FileStatus: "Missing"},
+		{EventId: "2", StartDate: "2024-04-01", EventType: "Live", FileStatus: "Missing"},
+		{EventId: "3", StartDate: "2024-04-01", EventType: "Preproduction", FileStatus: "Present"},
+	}
+
+	calCmsService.reportMissingFiles(events)
+	writeQueuedUploadStatus()
+
+	assert.EqualValues(t, map[int]string{1: "No file received"}, srv.Status())
+}
+
+func TestReportUploadStatusFailureIsRetriedAfterDelay(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	srv := setupUploadStatus(t)
+	srv.FailWith(http.StatusBadGateway)
+	now := time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)
+	calCmsService.Now = func() time.Time { return now }
+	calCmsService.ReportUploadStatus(1, "2024-04-01", "No file received")
+	writeQueuedUploadStatus()
+	srv.FailWith(0)
+
+	calCmsService.ReportUploadStatus(1, "2024-04-01", "No file received")
+	writeQueuedUploadStatus()
+	assert.Empty(t, srv.Updates())
+	now = now.Add(uploadStatusRetryDelay)
+	calCmsService.ReportUploadStatus(1, "2024-04-01", "No file received")
+	writeQueuedUploadStatus()
+
+	assert.EqualValues(t, []calcmstest.Update{{EventId: 1, Status: "No file received"}}, srv.Updates())
+}
+
+func TestReportUploadStatusFailureDoublesDelay(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	srv := setupUploadStatus(t)
+	srv.FailWith(http.StatusBadGateway)
+	now := time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)
+	calCmsService.Now = func() time.Time { return now }
+
+	calCmsService.ReportUploadStatus(1, "2024-04-01", "No file received")
+	writeQueuedUploadStatus()
+	now = now.Add(uploadStatusRetryDelay)
+	calCmsService.ReportUploadStatus(1, "2024-04-01", "No file received")
+	writeQueuedUploadStatus()
+
+	assert.EqualValues(t, now.Add(2*uploadStatusRetryDelay), calCmsService.uploadStatus.failed["1@2024-04-01"].retryAt)
+}
+
+func TestReportUploadStatusNeverBlocks(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupUploadStatus(t)
+
+	for id := 1; id <= uploadStatusQueueSize+1; id++ {
+		calCmsService.ReportUploadStatus(id, "2024-04-01", "No file received")
+	}
+
+	assert.Len(t, calCmsService.uploadStatus.queue, uploadStatusQueueSize)
+}
+
+func TestRunUploadStatusWritesQueuedStatus(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	srv := setupUploadStatus(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		calCmsService.RunUploadStatus(ctx)
+		close(done)
+	}()
+
+	calCmsService.ReportUploadStatus(1, "2024-04-01", "No file received")
+
+	assert.Eventually(t, func() bool { return len(srv.Updates()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestUploadStatusReceivedWithWrongLength(t *testing.T) {
+	status := uploadStatusReceived(domain.FileInfo{FileType: domain.FileTypeAudio, Duration: 20 * time.Minute}, 2, 5)
+
+	assert.EqualValues(t, "File received, 20 min, length not accepted", status)
+}
+
+func TestExportWithRejectedLengthReportsUploadStatus(t *testing.T) {
+	tearDown := setupTestEx()
+	defer tearDown()
+	uploads := &fakeUploadReporter{}
+	exportService.Uploads = uploads
+	cfg.Export.ExportFolder = t.TempDir()
+	storeDayFile(t, dayDate, "show.mp3", 20, 0, 60*time.Minute)
+	storeDayFile(t, dayDate, "short.mp3", 20, 30, 5*time.Minute)
+	short := fileRepo.GetByPath("short.mp3")
+	short.EventId = 4711
+	require.NoError(t, fileRepo.Store(*short))
+
+	err := exportService.ExportForDateAndHour(dayDate, "20")
+
+	require.NoError(t, err)
+	require.Len(t, uploads.statuses, 1)
+	assert.Contains(t, uploads.statuses[0], "File rejected, 5 min: Length not accepted.")
+}
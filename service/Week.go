@@ -0,0 +1,80 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+)
+
+// GetWeekOverviewContext returns the events of the given number of days starting at from in a grid by day and hour.
+// If loading the events fails, the overview of the events found so far is returned with the error
+func (s DefaultCalCmsService) GetWeekOverviewContext(ctx context.Context, from time.Time, days int) (dto.WeekOverview, error) {
+	from = domain.NormalizeDate(from)
+	var dates []time.Time
+	for day := range days {
+		dates = append(dates, from.AddDate(0, 0, day))
+	}
+	events, err := s.GetEventsForDatesContext(ctx, dates)
+	return buildWeekOverview(from, days, events), err
+}
+
+// buildWeekOverview arranges the events in a grid of the days starting at from and the hours of the day. An event is
+// placed in every hour it covers, also in the next day's column if it runs past midnight
+func buildWeekOverview(from time.Time, days int, events []dto.Event) dto.WeekOverview {
+	overview := dto.WeekOverview{
+		From:     domain.FormatFolderDate(from),
+		Previous: domain.FormatFolderDate(from.AddDate(0, 0, -days)),
+		Next:     domain.FormatFolderDate(from.AddDate(0, 0, days)),
+	}
+	column := make(map[string]int)
+	for day := range days {
+		date := from.AddDate(0, 0, day)
+		column[domain.FormatFolderDate(date)] = day
+		overview.Days = append(overview.Days, dto.WeekDay{Date: domain.FormatFolderDate(date), Name: date.Format("Mon 02.01.")})
+	}
+	for hour := range 24 {
+		overview.Rows = append(overview.Rows, dto.WeekRow{Hour: fmt.Sprintf("%02d", hour), Cells: make([][]dto.WeekEvent, days)})
+	}
+	for _, event := range events {
+		start, end, err := eventPeriod(event.StartDate, event.StartTime, event.EndTime)
+		if err != nil {
+			continue
+		}
+		for i, slot := range periodSlots(start, end) {
+			day, found := column[slot.Date]
+			if !found {
+				continue
+			}
+			hour, _ := strconv.Atoi(slot.Hour)
+			overview.Rows[hour].Cells[day] = append(overview.Rows[hour].Cells[day], dto.WeekEvent{
+				EventId:    event.EventId,
+				Title:      event.Title,
+				StartTime:  event.StartTime,
+				EndTime:    event.EndTime,
+				FileStatus: event.FileStatus,
+				Status:     weekStatus(event),
+				Continued:  i > 0,
+			})
+		}
+	}
+	return overview
+}
+
+// weekStatus classifies the file status of an event for the colour in the week overview
+func weekStatus(event dto.Event) string {
+	switch {
+	case event.EventType == "Live":
+		return "live"
+	case event.FileStatus == "Missing":
+		return "missing"
+	case event.FileStatus == "Present" || event.FileStatus == "Multiple (identical)":
+		return "ok"
+	default:
+		return "warning"
+	}
+}
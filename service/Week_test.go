@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/johannes-kuhfuss/mairlist-feeder/domain"
+	"github.com/johannes-kuhfuss/mairlist-feeder/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildWeekOverviewArrangesEventsByDayAndHour(t *testing.T) {
+	events := []dto.Event{
+		{EventId: "1", Title: "Morning", StartDate: "2024-09-23", StartTime: "08:00", EndTime: "09:00", EventType: "Preproduction", FileStatus: "Present"},
+		{EventId: "2", Title: "Evening", StartDate: "2024-09-25", StartTime: "20:30", EndTime: "21:00", EventType: "Preproduction", FileStatus: "Missing"},
+		{EventId: "3", Title: "Talk", StartDate: "2024-09-25", StartTime: "20:00", EndTime: "20:30", EventType: "Live", FileStatus: "N/A"},
+		{EventId: "4", Title: "Twin", StartDate: "2024-09-29", StartTime: "10:00", EndTime: "11:00", EventType: "Preproduction", FileStatus: "Multiple"},
+		{EventId: "5", Title: "Next Week", StartDate: "2024-09-30", StartTime: "10:00", EndTime: "11:00", EventType: "Preproduction", FileStatus: "Present"},
+	}
+
+	week := buildWeekOverview(domain.MustParseFolderDate("2024-09-23"), 7, events)
+
+	assert.EqualValues(t, "2024-09-16", week.Previous)
+	assert.EqualValues(t, "2024-09-30", week.Next)
+	require.Len(t, week.Days, 7)
+	assert.EqualValues(t, "Mon 23.09.", week.Days[0].Name)
+	require.Len(t, week.Rows, 24)
+	assert.EqualValues(t, []dto.WeekEvent{{EventId: "1", Title: "Morning", StartTime: "08:00", EndTime: "09:00", FileStatus: "Present", Status: "ok"}}, week.Rows[8].Cells[0])
+	assert.Empty(t, week.Rows[9].Cells[0])
+	require.Len(t, week.Rows[20].Cells[2], 2)
+	assert.EqualValues(t, "missing", week.Rows[20].Cells[2][0].Status)
+	assert.EqualValues(t, "live", week.Rows[20].Cells[2][1].Status)
+	assert.EqualValues(t, "warning", week.Rows[10].Cells[6][0].Status)
+	for _, row := range week.Rows {
+		for _, cell := range row.Cells {
+			for _, event := range cell {
+				assert.NotEqualValues(t, "5", event.EventId)
+			}
+		}
+	}
+}
+
+func TestBuildWeekOverviewPlacesEventInEveryHourItCovers(t *testing.T) {
+	events := []dto.Event{
+		{EventId: "1", Title: "Long Show", StartDate: "2024-09-23", StartTime: "1030", EndTime: "1300", FileStatus: "Present"},
+		{EventId: "2", Title: "Night Show", StartDate: "2024-09-23", StartTime: "2300", EndTime: "0100", FileStatus: "Present"},
+		{EventId: "3", Title: "Last Night", StartDate: "2024-09-29", StartTime: "2330", EndTime: "0030", FileStatus: "Present"},
+	}
+
+	week := buildWeekOverview(domain.MustParseFolderDate("2024-09-23"), 7, events)
+
+	for hour, continued := range map[int]bool{10: false, 11: true, 12: true} {
+		require.Len(t, week.Rows[hour].Cells[0], 1)
+		assert.EqualValues(t, "1", week.Rows[hour].Cells[0][0].EventId)
+		assert.EqualValues(t, continued, week.Rows[hour].Cells[0][0].Continued)
+	}
+	assert.Empty(t, week.Rows[13].Cells[0])
+	require.Len(t, week.Rows[23].Cells[0], 1)
+	assert.False(t, week.Rows[23].Cells[0][0].Continued)
+	require.Len(t, week.Rows[0].Cells[1], 1)
+	assert.EqualValues(t, "2", week.Rows[0].Cells[1][0].EventId)
+	assert.True(t, week.Rows[0].Cells[1][0].Continued)
+	assert.Empty(t, week.Rows[1].Cells[1])
+	require.Len(t, week.Rows[23].Cells[6], 1)
+	assert.Empty(t, week.Rows[0].Cells[0])
+}
+
+func TestGetWeekOverviewReturnsEventsOfTheDays(t *testing.T) {
+	teardown := setupTestCal()
+	defer teardown()
+	setupEventBrowser(t, http.StatusOK)
+
+	week, err := calCmsService.GetWeekOverviewContext(t.Context(), domain.MustParseFolderDate("2024-09-24"), 2)
+
+	require.NoError(t, err)
+	require.Len(t, week.Days, 2)
+	require.Len(t, week.Rows[12].Cells[0], 1)
+	assert.EqualValues(t, "Program Event", week.Rows[12].Cells[0][0].Title)
+	assert.Empty(t, week.Rows[13].Cells[0])
+}